@@ -324,12 +324,16 @@ func getCurrentSchema() []string {
 			avatar_url TEXT,
 			is_guest BOOLEAN DEFAULT FALSE,
 			session_token TEXT UNIQUE,
+			session_expires_at DATETIME,
 			security_question TEXT NOT NULL,
 			security_answer_hash TEXT NOT NULL,
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			last_active DATETIME DEFAULT CURRENT_TIMESTAMP,
 			total_games_played INTEGER DEFAULT 0,
-			favorite_difficulty TEXT DEFAULT 'easy' CHECK (favorite_difficulty IN ('easy', 'hard'))
+			favorite_difficulty TEXT DEFAULT 'easy' CHECK (favorite_difficulty IN ('easy', 'hard')),
+			totp_secret TEXT,
+			totp_enabled BOOLEAN DEFAULT FALSE,
+			totp_last_step INTEGER DEFAULT 0
 		)`,
 
 		// User indexes
@@ -337,6 +341,42 @@ func getCurrentSchema() []string {
 		"CREATE INDEX IF NOT EXISTS idx_users_session_token ON users(session_token)",
 		"CREATE INDEX IF NOT EXISTS idx_users_display_name ON users(display_name)",
 
+		// Login sessions, one per device
+		`CREATE TABLE IF NOT EXISTS user_sessions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			token_hash TEXT UNIQUE NOT NULL,
+			device_label TEXT NOT NULL DEFAULT '',
+			ip_address TEXT NOT NULL DEFAULT '',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			last_seen_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			expires_at DATETIME NOT NULL
+		)`,
+		"CREATE INDEX IF NOT EXISTS idx_user_sessions_user ON user_sessions(user_id, last_seen_at)",
+		"CREATE INDEX IF NOT EXISTS idx_user_sessions_expiry ON user_sessions(expires_at)",
+
+		// Failed login and password reset attempts per account
+		`CREATE TABLE IF NOT EXISTS auth_throttles (
+			user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			kind TEXT NOT NULL CHECK (kind IN ('login', 'reset')),
+			failures INTEGER NOT NULL DEFAULT 0,
+			last_failure_at DATETIME NOT NULL,
+			locked_until DATETIME,
+			PRIMARY KEY (user_id, kind)
+		)`,
+
+		// One-time password reset codes
+		`CREATE TABLE IF NOT EXISTS password_reset_codes (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			kind TEXT NOT NULL CHECK (kind IN ('admin', 'recovery', 'totp')),
+			code_hash TEXT UNIQUE NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			expires_at DATETIME,
+			used_at DATETIME
+		)`,
+		"CREATE INDEX IF NOT EXISTS idx_password_reset_codes_user ON password_reset_codes(user_id, kind)",
+
 		// Challenge sessions table
 		`CREATE TABLE IF NOT EXISTS challenge_sessions (
 			session_id TEXT PRIMARY KEY,
@@ -348,12 +388,17 @@ func getCurrentSchema() []string {
 			is_complete BOOLEAN DEFAULT FALSE,
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			completed_at DATETIME,
-			expires_at DATETIME DEFAULT (datetime('now', '+24 hours'))
+			expires_at DATETIME DEFAULT (datetime('now', '+24 hours')),
+			scoring_policy TEXT NOT NULL DEFAULT 'exponential',
+			scoring_params TEXT NOT NULL DEFAULT '{}',
+			theme_slug TEXT,
+			expired_at DATETIME
 		)`,
 
 		// Challenge session indexes
 		"CREATE INDEX IF NOT EXISTS idx_challenge_sessions_user_id ON challenge_sessions(user_id)",
 		"CREATE INDEX IF NOT EXISTS idx_challenge_sessions_created_at ON challenge_sessions(created_at)",
+		"CREATE INDEX IF NOT EXISTS idx_challenge_sessions_expiry ON challenge_sessions(is_complete, expires_at)",
 
 		// Challenge guesses table
 		`CREATE TABLE IF NOT EXISTS challenge_guesses (
@@ -365,11 +410,46 @@ func getCurrentSchema() []string {
 			actual_price INTEGER NOT NULL,
 			points INTEGER NOT NULL,
 			accuracy_percentage REAL NOT NULL,
+			log_ratio REAL,
+			hints_used INTEGER DEFAULT 0,
+			hint_penalty INTEGER DEFAULT 0,
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
 		)`,
 
 		"CREATE INDEX IF NOT EXISTS idx_challenge_guesses_session_id ON challenge_guesses(session_id)",
 
+		// Challenge car snapshots (actual prices kept with the session)
+		`CREATE TABLE IF NOT EXISTS challenge_cars (
+			session_id TEXT NOT NULL REFERENCES challenge_sessions(session_id) ON DELETE CASCADE,
+			car_index INTEGER NOT NULL,
+			car_id TEXT NOT NULL,
+			actual_price REAL NOT NULL,
+			car_json TEXT NOT NULL,
+			PRIMARY KEY (session_id, car_index)
+		)`,
+
+		// Admin-defined challenge themes
+		`CREATE TABLE IF NOT EXISTS challenge_themes (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			slug TEXT UNIQUE NOT NULL,
+			name TEXT NOT NULL,
+			description TEXT DEFAULT '',
+			filter_json TEXT NOT NULL,
+			is_active BOOLEAN DEFAULT TRUE,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+
+		// Challenge hints unlocked per car
+		`CREATE TABLE IF NOT EXISTS challenge_hints (
+			session_id TEXT NOT NULL REFERENCES challenge_sessions(session_id) ON DELETE CASCADE,
+			car_index INTEGER NOT NULL,
+			hint TEXT NOT NULL,
+			value_json TEXT NOT NULL,
+			cost INTEGER NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (session_id, car_index, hint)
+		)`,
+
 		// Friend challenges table (updated to 2-day expiration)
 		`CREATE TABLE IF NOT EXISTS friend_challenges (
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -381,7 +461,8 @@ func getCurrentSchema() []string {
 			max_participants INTEGER DEFAULT 10,
 			is_active BOOLEAN DEFAULT TRUE,
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			expires_at DATETIME DEFAULT (datetime('now', '+2 days'))
+			expires_at DATETIME DEFAULT (datetime('now', '+2 days')),
+			invited_user_id INTEGER REFERENCES users(id) ON DELETE SET NULL
 		)`,
 
 		// Friend challenge indexes
@@ -407,18 +488,40 @@ func getCurrentSchema() []string {
 		"CREATE INDEX IF NOT EXISTS idx_challenge_participants_challenge ON challenge_participants(friend_challenge_id)",
 		"CREATE INDEX IF NOT EXISTS idx_challenge_participants_user ON challenge_participants(user_id)",
 
+		// Friends and blocks
+		`CREATE TABLE IF NOT EXISTS friendships (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			requester_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			addressee_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'accepted')),
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			responded_at DATETIME,
+			CHECK (requester_id != addressee_id)
+		)`,
+		"CREATE UNIQUE INDEX IF NOT EXISTS idx_friendships_pair ON friendships(MIN(requester_id, addressee_id), MAX(requester_id, addressee_id))",
+		"CREATE INDEX IF NOT EXISTS idx_friendships_addressee ON friendships(addressee_id, status)",
+		"CREATE INDEX IF NOT EXISTS idx_friendships_requester ON friendships(requester_id, status)",
+		`CREATE TABLE IF NOT EXISTS user_blocks (
+			blocker_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			blocked_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (blocker_id, blocked_id),
+			CHECK (blocker_id != blocked_id)
+		)`,
+
 		// Leaderboard entries table
 		`CREATE TABLE IF NOT EXISTS leaderboard_entries (
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
 			user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
 			username TEXT NOT NULL,
 			score INTEGER NOT NULL,
-			game_mode TEXT NOT NULL CHECK (game_mode IN ('streak', 'challenge', 'zero')),
+			game_mode TEXT NOT NULL CHECK (game_mode IN ('streak', 'challenge', 'zero', 'daily', 'higherlower')),
 			difficulty TEXT NOT NULL CHECK (difficulty IN ('easy', 'hard')),
 			session_id TEXT,
 			friend_challenge_id INTEGER REFERENCES friend_challenges(id) ON DELETE SET NULL,
 			legacy_id TEXT,
-			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			hints_used INTEGER DEFAULT 0
 		)`,
 
 		// Leaderboard indexes
@@ -430,24 +533,70 @@ func getCurrentSchema() []string {
 		// Composite index for common query pattern: filter by game_mode AND difficulty
 		"CREATE INDEX IF NOT EXISTS idx_leaderboard_mode_difficulty ON leaderboard_entries(game_mode, difficulty, score DESC)",
 
+		// Closed leaderboard period standings
+		`CREATE TABLE IF NOT EXISTS leaderboard_archive (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			period TEXT NOT NULL CHECK (period IN ('day', 'week', 'month', 'season')),
+			period_key TEXT NOT NULL,
+			game_mode TEXT NOT NULL,
+			difficulty TEXT NOT NULL CHECK (difficulty IN ('easy', 'hard')),
+			position INTEGER NOT NULL,
+			rank INTEGER NOT NULL,
+			entry_id INTEGER REFERENCES leaderboard_entries(id) ON DELETE SET NULL,
+			user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+			username TEXT NOT NULL,
+			score INTEGER NOT NULL,
+			hints_used INTEGER DEFAULT 0,
+			submitted_at DATETIME,
+			archived_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE (period, game_mode, difficulty, period_key, position)
+		)`,
+
+		// Daily challenge tables
+		`CREATE TABLE IF NOT EXISTS daily_challenges (
+			challenge_date TEXT NOT NULL,
+			difficulty TEXT NOT NULL CHECK (difficulty IN ('easy', 'hard')),
+			cars_json TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (challenge_date, difficulty)
+		)`,
+		`CREATE TABLE IF NOT EXISTS daily_attempts (
+			challenge_date TEXT NOT NULL,
+			difficulty TEXT NOT NULL CHECK (difficulty IN ('easy', 'hard')),
+			player_key TEXT NOT NULL,
+			user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+			session_id TEXT UNIQUE NOT NULL REFERENCES challenge_sessions(session_id) ON DELETE CASCADE,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (challenge_date, difficulty, player_key)
+		)`,
+
 		// Game sessions table
 		`CREATE TABLE IF NOT EXISTS game_sessions (
 			session_id TEXT PRIMARY KEY,
 			user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
-			game_mode TEXT NOT NULL CHECK (game_mode IN ('streak', 'zero')),
+			game_mode TEXT NOT NULL CHECK (game_mode IN ('streak', 'zero', 'higherlower')),
 			difficulty TEXT NOT NULL CHECK (difficulty IN ('easy', 'hard')),
 			current_score INTEGER DEFAULT 0,
 			current_difference REAL DEFAULT 0,
 			cars_shown TEXT DEFAULT '[]',
 			is_active BOOLEAN DEFAULT TRUE,
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			last_guess_at DATETIME DEFAULT CURRENT_TIMESTAMP
+			last_guess_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			accuracy_metric TEXT NOT NULL DEFAULT 'percentage' CHECK (accuracy_metric IN ('percentage', 'logratio')),
+			game_id TEXT
 		)`,
 
 		// Game session indexes
 		"CREATE INDEX IF NOT EXISTS idx_game_sessions_user_id ON game_sessions(user_id)",
 		"CREATE INDEX IF NOT EXISTS idx_game_sessions_active ON game_sessions(is_active)",
 
+		// Higher-or-lower pairs in play
+		`CREATE TABLE IF NOT EXISTS higher_lower_pairs (
+			session_id TEXT PRIMARY KEY REFERENCES game_sessions(session_id) ON DELETE CASCADE,
+			cars_json TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+
 		// Database metadata table
 		`CREATE TABLE IF NOT EXISTS database_metadata (
 			key TEXT PRIMARY KEY,
@@ -457,7 +606,7 @@ func getCurrentSchema() []string {
 
 		// Initial metadata
 		`INSERT OR REPLACE INTO database_metadata (key, value) VALUES
-			('schema_version', '2.2'),
+			('schema_version', '3.9'),
 			('created_at', datetime('now')),
 			('migration_status', 'completed')`,
 	}
@@ -540,6 +689,338 @@ func getMigrations() []Migration {
 				"CREATE INDEX IF NOT EXISTS idx_friend_challenges_code_active ON friend_challenges(challenge_code, is_active, expires_at)",
 			},
 		},
+		{
+			Version:     "2.3",
+			Description: "Snapshot challenge car prices into challenge_cars",
+			SQL: []string{
+				`CREATE TABLE IF NOT EXISTS challenge_cars (
+					session_id TEXT NOT NULL REFERENCES challenge_sessions(session_id) ON DELETE CASCADE,
+					car_index INTEGER NOT NULL,
+					car_id TEXT NOT NULL,
+					actual_price REAL NOT NULL,
+					car_json TEXT NOT NULL,
+					PRIMARY KEY (session_id, car_index)
+				)`,
+			},
+		},
+		{
+			Version:     "2.4",
+			Description: "Add daily challenge mode",
+			SQL: []string{
+				`CREATE TABLE IF NOT EXISTS daily_challenges (
+					challenge_date TEXT NOT NULL,
+					difficulty TEXT NOT NULL CHECK (difficulty IN ('easy', 'hard')),
+					cars_json TEXT NOT NULL,
+					created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+					PRIMARY KEY (challenge_date, difficulty)
+				)`,
+				`CREATE TABLE IF NOT EXISTS daily_attempts (
+					challenge_date TEXT NOT NULL,
+					difficulty TEXT NOT NULL CHECK (difficulty IN ('easy', 'hard')),
+					player_key TEXT NOT NULL,
+					user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+					session_id TEXT UNIQUE NOT NULL REFERENCES challenge_sessions(session_id) ON DELETE CASCADE,
+					created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+					PRIMARY KEY (challenge_date, difficulty, player_key)
+				)`,
+				// SQLite can't alter CHECK constraints, so rebuild leaderboard_entries to allow 'daily'
+				`CREATE TABLE leaderboard_entries_new (
+					id INTEGER PRIMARY KEY AUTOINCREMENT,
+					user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+					username TEXT NOT NULL,
+					score INTEGER NOT NULL,
+					game_mode TEXT NOT NULL CHECK (game_mode IN ('streak', 'challenge', 'zero', 'daily')),
+					difficulty TEXT NOT NULL CHECK (difficulty IN ('easy', 'hard')),
+					session_id TEXT,
+					friend_challenge_id INTEGER REFERENCES friend_challenges(id) ON DELETE SET NULL,
+					legacy_id TEXT,
+					created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+				)`,
+				`INSERT INTO leaderboard_entries_new
+					SELECT id, user_id, username, score, game_mode, difficulty, session_id,
+						   friend_challenge_id, legacy_id, created_at
+					FROM leaderboard_entries`,
+				"DROP TABLE leaderboard_entries",
+				"ALTER TABLE leaderboard_entries_new RENAME TO leaderboard_entries",
+				"CREATE INDEX IF NOT EXISTS idx_leaderboard_game_mode ON leaderboard_entries(game_mode)",
+				"CREATE INDEX IF NOT EXISTS idx_leaderboard_difficulty ON leaderboard_entries(difficulty)",
+				"CREATE INDEX IF NOT EXISTS idx_leaderboard_score ON leaderboard_entries(score)",
+				"CREATE INDEX IF NOT EXISTS idx_leaderboard_user_id ON leaderboard_entries(user_id)",
+				"CREATE INDEX IF NOT EXISTS idx_leaderboard_created_at ON leaderboard_entries(created_at)",
+				"CREATE INDEX IF NOT EXISTS idx_leaderboard_mode_difficulty ON leaderboard_entries(game_mode, difficulty, score DESC)",
+			},
+		},
+		{
+			Version:     "2.5",
+			Description: "Add higher-or-lower game mode",
+			SQL: []string{
+				// Rebuild leaderboard_entries to allow 'higherlower'
+				`CREATE TABLE leaderboard_entries_new (
+					id INTEGER PRIMARY KEY AUTOINCREMENT,
+					user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+					username TEXT NOT NULL,
+					score INTEGER NOT NULL,
+					game_mode TEXT NOT NULL CHECK (game_mode IN ('streak', 'challenge', 'zero', 'daily', 'higherlower')),
+					difficulty TEXT NOT NULL CHECK (difficulty IN ('easy', 'hard')),
+					session_id TEXT,
+					friend_challenge_id INTEGER REFERENCES friend_challenges(id) ON DELETE SET NULL,
+					legacy_id TEXT,
+					created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+				)`,
+				`INSERT INTO leaderboard_entries_new
+					SELECT id, user_id, username, score, game_mode, difficulty, session_id,
+						   friend_challenge_id, legacy_id, created_at
+					FROM leaderboard_entries`,
+				"DROP TABLE leaderboard_entries",
+				"ALTER TABLE leaderboard_entries_new RENAME TO leaderboard_entries",
+				"CREATE INDEX IF NOT EXISTS idx_leaderboard_game_mode ON leaderboard_entries(game_mode)",
+				"CREATE INDEX IF NOT EXISTS idx_leaderboard_difficulty ON leaderboard_entries(difficulty)",
+				"CREATE INDEX IF NOT EXISTS idx_leaderboard_score ON leaderboard_entries(score)",
+				"CREATE INDEX IF NOT EXISTS idx_leaderboard_user_id ON leaderboard_entries(user_id)",
+				"CREATE INDEX IF NOT EXISTS idx_leaderboard_created_at ON leaderboard_entries(created_at)",
+				"CREATE INDEX IF NOT EXISTS idx_leaderboard_mode_difficulty ON leaderboard_entries(game_mode, difficulty, score DESC)",
+				// Rebuild game_sessions to allow 'higherlower'
+				`CREATE TABLE game_sessions_new (
+					session_id TEXT PRIMARY KEY,
+					user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+					game_mode TEXT NOT NULL CHECK (game_mode IN ('streak', 'zero', 'higherlower')),
+					difficulty TEXT NOT NULL CHECK (difficulty IN ('easy', 'hard')),
+					current_score INTEGER DEFAULT 0,
+					current_difference REAL DEFAULT 0,
+					cars_shown TEXT DEFAULT '[]',
+					is_active BOOLEAN DEFAULT TRUE,
+					created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+					last_guess_at DATETIME DEFAULT CURRENT_TIMESTAMP
+				)`,
+				`INSERT INTO game_sessions_new
+					SELECT session_id, user_id, game_mode, difficulty, current_score, current_difference,
+						   cars_shown, is_active, created_at, last_guess_at
+					FROM game_sessions`,
+				"DROP TABLE game_sessions",
+				"ALTER TABLE game_sessions_new RENAME TO game_sessions",
+				"CREATE INDEX IF NOT EXISTS idx_game_sessions_user_id ON game_sessions(user_id)",
+				"CREATE INDEX IF NOT EXISTS idx_game_sessions_active ON game_sessions(is_active)",
+				`CREATE TABLE IF NOT EXISTS higher_lower_pairs (
+					session_id TEXT PRIMARY KEY REFERENCES game_sessions(session_id) ON DELETE CASCADE,
+					cars_json TEXT NOT NULL,
+					created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+				)`,
+			},
+		},
+		{
+			Version:     "2.6",
+			Description: "Store scoring policy on challenge sessions",
+			SQL: []string{
+				// Existing sessions were all scored with the standard exponential curve
+				"ALTER TABLE challenge_sessions ADD COLUMN scoring_policy TEXT NOT NULL DEFAULT 'exponential'",
+				"ALTER TABLE challenge_sessions ADD COLUMN scoring_params TEXT NOT NULL DEFAULT '{}'",
+			},
+		},
+		{
+			Version:     "2.7",
+			Description: "Store log-ratio accuracy alongside percentage",
+			SQL: []string{
+				// Left NULL for existing guesses - it is derived from the stored prices when read
+				"ALTER TABLE challenge_guesses ADD COLUMN log_ratio REAL",
+				"ALTER TABLE game_sessions ADD COLUMN accuracy_metric TEXT NOT NULL DEFAULT 'percentage' CHECK (accuracy_metric IN ('percentage', 'logratio'))",
+			},
+		},
+		{
+			Version:     "2.8",
+			Description: "Add challenge hints",
+			SQL: []string{
+				`CREATE TABLE IF NOT EXISTS challenge_hints (
+					session_id TEXT NOT NULL REFERENCES challenge_sessions(session_id) ON DELETE CASCADE,
+					car_index INTEGER NOT NULL,
+					hint TEXT NOT NULL,
+					value_json TEXT NOT NULL,
+					cost INTEGER NOT NULL,
+					created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+					PRIMARY KEY (session_id, car_index, hint)
+				)`,
+				"ALTER TABLE challenge_guesses ADD COLUMN hints_used INTEGER DEFAULT 0",
+				"ALTER TABLE challenge_guesses ADD COLUMN hint_penalty INTEGER DEFAULT 0",
+				"ALTER TABLE leaderboard_entries ADD COLUMN hints_used INTEGER DEFAULT 0",
+			},
+		},
+		{
+			Version:     "2.9",
+			Description: "Add themed challenges",
+			SQL: []string{
+				`CREATE TABLE IF NOT EXISTS challenge_themes (
+					id INTEGER PRIMARY KEY AUTOINCREMENT,
+					slug TEXT UNIQUE NOT NULL,
+					name TEXT NOT NULL,
+					description TEXT DEFAULT '',
+					filter_json TEXT NOT NULL,
+					is_active BOOLEAN DEFAULT TRUE,
+					created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+				)`,
+				"ALTER TABLE challenge_sessions ADD COLUMN theme_slug TEXT",
+			},
+		},
+		{
+			Version:     "3.0",
+			Description: "Track expired challenge sessions",
+			SQL: []string{
+				"ALTER TABLE challenge_sessions ADD COLUMN expired_at DATETIME",
+				"CREATE INDEX IF NOT EXISTS idx_challenge_sessions_expiry ON challenge_sessions(is_complete, expires_at)",
+			},
+		},
+		{
+			Version:     "3.1",
+			Description: "Archive closed leaderboard period standings",
+			SQL: []string{
+				`CREATE TABLE IF NOT EXISTS leaderboard_archive (
+					id INTEGER PRIMARY KEY AUTOINCREMENT,
+					period TEXT NOT NULL CHECK (period IN ('day', 'week', 'month', 'season')),
+					period_key TEXT NOT NULL,
+					game_mode TEXT NOT NULL,
+					difficulty TEXT NOT NULL CHECK (difficulty IN ('easy', 'hard')),
+					position INTEGER NOT NULL,
+					rank INTEGER NOT NULL,
+					entry_id INTEGER REFERENCES leaderboard_entries(id) ON DELETE SET NULL,
+					user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+					username TEXT NOT NULL,
+					score INTEGER NOT NULL,
+					hints_used INTEGER DEFAULT 0,
+					submitted_at DATETIME,
+					archived_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+					UNIQUE (period, game_mode, difficulty, period_key, position)
+				)`,
+			},
+		},
+		{
+			Version:     "3.2",
+			Description: "Add friends and blocking",
+			SQL: []string{
+				`CREATE TABLE IF NOT EXISTS friendships (
+					id INTEGER PRIMARY KEY AUTOINCREMENT,
+					requester_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+					addressee_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+					status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'accepted')),
+					created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+					responded_at DATETIME,
+					CHECK (requester_id != addressee_id)
+				)`,
+				"CREATE UNIQUE INDEX IF NOT EXISTS idx_friendships_pair ON friendships(MIN(requester_id, addressee_id), MAX(requester_id, addressee_id))",
+				"CREATE INDEX IF NOT EXISTS idx_friendships_addressee ON friendships(addressee_id, status)",
+				"CREATE INDEX IF NOT EXISTS idx_friendships_requester ON friendships(requester_id, status)",
+				`CREATE TABLE IF NOT EXISTS user_blocks (
+					blocker_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+					blocked_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+					created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+					PRIMARY KEY (blocker_id, blocked_id),
+					CHECK (blocker_id != blocked_id)
+				)`,
+				"ALTER TABLE friend_challenges ADD COLUMN invited_user_id INTEGER REFERENCES users(id) ON DELETE SET NULL",
+			},
+		},
+		{
+			Version:     "3.3",
+			Description: "Add per-device login sessions",
+			SQL: []string{
+				`CREATE TABLE IF NOT EXISTS user_sessions (
+					id INTEGER PRIMARY KEY AUTOINCREMENT,
+					user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+					token TEXT UNIQUE NOT NULL,
+					device_label TEXT NOT NULL DEFAULT '',
+					ip_address TEXT NOT NULL DEFAULT '',
+					created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+					last_seen_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+					expires_at DATETIME NOT NULL
+				)`,
+				"CREATE INDEX IF NOT EXISTS idx_user_sessions_user ON user_sessions(user_id, last_seen_at)",
+				"CREATE INDEX IF NOT EXISTS idx_user_sessions_expiry ON user_sessions(expires_at)",
+			},
+		},
+		{
+			Version:     "3.4",
+			Description: "Invalidate raw session tokens",
+			SQL: []string{
+				// Raw tokens can't be hashed in SQLite, so everyone logs in again once
+				"DELETE FROM user_sessions",
+				"ALTER TABLE user_sessions RENAME COLUMN token TO token_hash",
+				"UPDATE users SET session_token = NULL WHERE session_token IS NOT NULL",
+			},
+		},
+		{
+			Version:     "3.5",
+			Description: "Add per-account login throttling",
+			SQL: []string{
+				`CREATE TABLE IF NOT EXISTS auth_throttles (
+					user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+					kind TEXT NOT NULL CHECK (kind IN ('login', 'reset')),
+					failures INTEGER NOT NULL DEFAULT 0,
+					last_failure_at DATETIME NOT NULL,
+					locked_until DATETIME,
+					PRIMARY KEY (user_id, kind)
+				)`,
+			},
+		},
+		{
+			Version:     "3.6",
+			Description: "Add one-time password reset codes",
+			SQL: []string{
+				`CREATE TABLE IF NOT EXISTS password_reset_codes (
+					id INTEGER PRIMARY KEY AUTOINCREMENT,
+					user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+					kind TEXT NOT NULL CHECK (kind IN ('admin', 'recovery')),
+					code_hash TEXT UNIQUE NOT NULL,
+					created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+					expires_at DATETIME,
+					used_at DATETIME
+				)`,
+				"CREATE INDEX IF NOT EXISTS idx_password_reset_codes_user ON password_reset_codes(user_id, kind)",
+			},
+		},
+		{
+			Version:     "3.7",
+			Description: "Add TOTP two-factor authentication",
+			SQL: []string{
+				"ALTER TABLE users ADD COLUMN totp_secret TEXT",
+				"ALTER TABLE users ADD COLUMN totp_enabled BOOLEAN DEFAULT FALSE",
+				"ALTER TABLE users ADD COLUMN totp_last_step INTEGER DEFAULT 0",
+				"ALTER TABLE users ADD COLUMN totp_recovery_codes TEXT DEFAULT '[]'",
+			},
+		},
+		{
+			Version:     "3.8",
+			Description: "Add server-issued game IDs to game sessions",
+			SQL: []string{
+				"ALTER TABLE game_sessions ADD COLUMN game_id TEXT",
+				// Existing games keep their session ID so any already on the leaderboard stay submitted
+				"UPDATE game_sessions SET game_id = session_id WHERE game_id IS NULL",
+			},
+		},
+		{
+			Version:     "3.9",
+			Description: "Store two-factor recovery codes with the other one-time codes",
+			SQL: []string{
+				// Rebuild password_reset_codes to allow 'totp'
+				`CREATE TABLE password_reset_codes_new (
+					id INTEGER PRIMARY KEY AUTOINCREMENT,
+					user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+					kind TEXT NOT NULL CHECK (kind IN ('admin', 'recovery', 'totp')),
+					code_hash TEXT UNIQUE NOT NULL,
+					created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+					expires_at DATETIME,
+					used_at DATETIME
+				)`,
+				`INSERT INTO password_reset_codes_new
+					SELECT id, user_id, kind, code_hash, created_at, expires_at, used_at
+					FROM password_reset_codes`,
+				// Carry over the unused two-factor recovery codes, which are already hashed the same way
+				`INSERT OR IGNORE INTO password_reset_codes_new (user_id, kind, code_hash)
+					SELECT users.id, 'totp', codes.value
+					FROM users, json_each(users.totp_recovery_codes) AS codes
+					WHERE users.totp_enabled`,
+				"DROP TABLE password_reset_codes",
+				"ALTER TABLE password_reset_codes_new RENAME TO password_reset_codes",
+				"CREATE INDEX IF NOT EXISTS idx_password_reset_codes_user ON password_reset_codes(user_id, kind)",
+				"ALTER TABLE users DROP COLUMN totp_recovery_codes",
+			},
+		},
 	}
 }
 
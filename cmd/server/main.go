@@ -90,7 +90,7 @@ func main() {
 	config.AllowOrigins = allowedOrigins
 	config.AllowMethods = []string{"GET", "POST", "OPTIONS"} // Aligned with HTTPMethodFilter for consistency
 	config.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "X-Session-ID", "Authorization"}
-	config.ExposeHeaders = []string{"Content-Length", "X-Session-ID"}
+	config.ExposeHeaders = []string{"Content-Length", "X-Session-ID", "X-Next-Cursor"}
 	config.AllowCredentials = true
 	config.MaxAge = 12 * 3600
 	r.Use(cors.New(config))
@@ -203,11 +203,22 @@ func main() {
 		{
 			auth.POST("/register", authHandler.Register)
 			auth.POST("/login", authHandler.Login)
+			auth.POST("/login/2fa", authHandler.LoginTOTP)
 			auth.POST("/logout", authHandler.Logout)
 			auth.POST("/reset-password", authHandler.ResetPassword)
+			auth.POST("/redeem-reset-code", authHandler.RedeemResetCode)
 			auth.POST("/security-question", authHandler.GetSecurityQuestion)
 			auth.GET("/profile", authHandler.RequireAuth(), authHandler.GetProfile)
 			auth.PUT("/profile", authHandler.RequireAuth(), authHandler.UpdateProfile)
+			auth.GET("/profile/recovery-codes", authHandler.RequireAuth(), authHandler.GetRecoveryCodes)
+			auth.POST("/profile/recovery-codes", authHandler.RequireAuth(), authHandler.GenerateRecoveryCodes)
+			auth.GET("/2fa", authHandler.RequireAuth(), authHandler.GetTOTPStatus)
+			auth.POST("/2fa/enroll", authHandler.RequireAuth(), authHandler.EnrollTOTP)
+			auth.POST("/2fa/confirm", authHandler.RequireAuth(), authHandler.ConfirmTOTP)
+			auth.POST("/2fa/disable", authHandler.RequireAuth(), authHandler.DisableTOTP)
+			auth.GET("/sessions", authHandler.RequireAuth(), authHandler.ListSessions)
+			auth.POST("/sessions/revoke-all", authHandler.RequireAuth(), authHandler.RevokeAllSessions)
+			auth.POST("/sessions/:id/revoke", authHandler.RequireAuth(), authHandler.RevokeSession)
 		}
 
 		// Game endpoints
@@ -216,12 +227,31 @@ func main() {
 		api.POST("/check-guess", gameHandler.CheckGuess)
 		api.GET("/leaderboard", gameHandler.GetLeaderboard)
 		api.POST("/leaderboard/submit", gameHandler.SubmitScore)
+		api.GET("/leaderboard/history", gameHandler.GetLeaderboardHistory)
 		api.GET("/data-source", gameHandler.GetDataSource)
 
+		// Higher-or-Lower routes
+		api.GET("/higher-lower/pair", gameHandler.GetHigherLowerPair)
+		api.POST("/higher-lower/guess", gameHandler.SubmitHigherLowerGuess)
+
+		// Guess-the-Year routes
+		api.GET("/year/listing", gameHandler.GetYearListing)
+		api.POST("/year/guess", gameHandler.SubmitYearGuess)
+
 		// Challenge Mode routes
 		api.POST("/challenge/start", gameHandler.StartChallenge)
+		api.GET("/challenge/themes", gameHandler.GetChallengeThemes)
+		api.GET("/challenge/resume", gameHandler.ResumeChallenge)
 		api.GET("/challenge/:sessionId", gameHandler.GetChallengeSession)
 		api.POST("/challenge/:sessionId/guess", gameHandler.SubmitChallengeGuess)
+		api.POST("/challenge/:sessionId/hint", gameHandler.UnlockChallengeHint)
+		api.GET("/challenge/:sessionId/review", gameHandler.GetChallengeReview)
+
+		// Daily Challenge routes (guesses use the challenge guess endpoint)
+		api.GET("/daily", gameHandler.GetDailyChallenge)
+		api.POST("/daily/start", gameHandler.StartDailyChallenge)
+		api.GET("/daily/leaderboard", gameHandler.GetDailyLeaderboard)
+		api.GET("/daily/archive", gameHandler.GetDailyArchive)
 
 		// Friend Challenge routes (require authentication)
 		api.POST("/friends/challenges", friendsHandler.CreateFriendChallenge)
@@ -230,6 +260,17 @@ func main() {
 		api.GET("/friends/challenges/:code/leaderboard", friendsHandler.GetChallengeLeaderboard)
 		api.GET("/friends/challenges/:code/participation", friendsHandler.GetUserParticipation)
 		api.GET("/friends/challenges/my-challenges", friendsHandler.GetMyChallenges)
+		api.POST("/friends/challenges/direct", friendsHandler.ChallengeFriend)
+
+		// Friends list routes (require authentication)
+		api.GET("/friends", friendsHandler.GetFriends)
+		api.POST("/friends/requests", friendsHandler.SendFriendRequest)
+		api.POST("/friends/requests/:id/accept", friendsHandler.AcceptFriendRequest)
+		api.POST("/friends/requests/:id/decline", friendsHandler.DeclineFriendRequest)
+		api.POST("/friends/remove", friendsHandler.RemoveFriend)
+		api.GET("/friends/blocked", friendsHandler.GetBlockedUsers)
+		api.POST("/friends/block", friendsHandler.BlockUser)
+		api.POST("/friends/unblock", friendsHandler.UnblockUser)
 
 		// Health check (no additional rate limiting)
 		api.GET("/health", func(c *gin.Context) {
@@ -271,9 +312,16 @@ func main() {
 	{
 		admin.POST("/refresh-listings", middleware.RefreshProtectionMiddleware(), gameHandler.ManualRefresh)
 		admin.GET("/cache-status", gameHandler.GetCacheStatus)
+		admin.GET("/session-stats", gameHandler.GetSessionStats)
 		admin.GET("/leaderboard-status", gameHandler.GetLeaderboardStatus)
+		admin.POST("/leaderboard/archive", gameHandler.ArchiveLeaderboardPeriod)
 		admin.GET("/listings", gameHandler.GetAllListings)
 		admin.GET("/test-scraper", gameHandler.TestScraper)
+		admin.GET("/themes", gameHandler.ListChallengeThemes)
+		admin.POST("/themes", gameHandler.CreateChallengeTheme)
+		admin.POST("/themes/:slug/retire", gameHandler.RetireChallengeTheme)
+		admin.POST("/users/:username/unlock", authHandler.UnlockAccount)
+		admin.POST("/users/:username/reset-code", authHandler.CreateResetCode)
 	}
 
 	// Get port from environment or use default
@@ -9,6 +9,13 @@ import (
 	"autotraderguesser/internal/models"
 )
 
+// Snapshot is the on-disk format shared by every listing source cache.
+// Data is kept raw so each source can decode its own listing type.
+type Snapshot struct {
+	Data      json.RawMessage `json:"data"`
+	Timestamp time.Time       `json:"timestamp"`
+}
+
 type BonhamsCache struct {
 	Data      []*models.BonhamsCar `json:"data"`
 	Timestamp time.Time            `json:"timestamp"`
@@ -20,113 +27,195 @@ type LookersCache struct {
 }
 
 const (
+	DefaultExpiry        = 7 * 24 * time.Hour // 7 days - listing data doesn't need frequent updates
+	BonhamsCacheKey      = "bonhams"
+	LookersCacheKey      = "lookers"
 	BonhamsCacheFileName = "data/bonhams_cache.json"
 	LookersCacheFileName = "data/lookers_cache.json"
-	BonhamsCacheExpiry   = 7 * 24 * time.Hour // 7 days - auction data changes less frequently
-	LookersCacheExpiry   = 7 * 24 * time.Hour // 7 days - dealership inventory also doesn't need frequent updates
+	BonhamsCacheExpiry   = DefaultExpiry // 7 days - auction data changes less frequently
+	LookersCacheExpiry   = DefaultExpiry // 7 days - dealership inventory also doesn't need frequent updates
 )
 
-// LoadBonhamsFromCache loads cached Bonhams listings if they exist and are not expired
-func LoadBonhamsFromCache() ([]*models.BonhamsCar, bool) {
-	file, err := os.Open(BonhamsCacheFileName)
+// FileName returns the cache file path used for a listing source cache key
+func FileName(key string) string {
+	return fmt.Sprintf("data/%s_cache.json", key)
+}
+
+// readSnapshot opens and decodes the cache file for a key
+func readSnapshot(key string) (*Snapshot, error) {
+	file, err := os.Open(FileName(key))
 	if err != nil {
-		fmt.Println("No Bonhams cache file found, will scrape fresh data")
-		return nil, false
+		return nil, err
 	}
 	defer file.Close()
 
-	var cache BonhamsCache
-	if err := json.NewDecoder(file).Decode(&cache); err != nil {
-		fmt.Printf("ERROR: Error reading Bonhams cache file: %v\n", err)
-		return nil, false
+	var snapshot Snapshot
+	if err := json.NewDecoder(file).Decode(&snapshot); err != nil {
+		return nil, err
 	}
 
-	// Check if cache is expired
-	if time.Since(cache.Timestamp) > BonhamsCacheExpiry {
-		fmt.Printf("Bonhams cache expired (%.1f days old), will refresh\n", time.Since(cache.Timestamp).Hours()/24)
-		return nil, false
-	}
+	return &snapshot, nil
+}
 
-	daysRemaining := (BonhamsCacheExpiry - time.Since(cache.Timestamp)).Hours() / 24
-	fmt.Printf("Loaded %d Bonhams cars from cache (updated %.1f days ago, %.1f days until refresh)\n",
-		len(cache.Data), time.Since(cache.Timestamp).Hours()/24, daysRemaining)
-	return cache.Data, true
+// countItems returns the number of listings in a raw cache payload
+func countItems(data json.RawMessage) int {
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return 0
+	}
+	return len(items)
 }
 
-// LoadLookersFromCache loads cached Lookers listings if they exist and are not expired
-func LoadLookersFromCache() ([]*models.LookersCar, bool) {
-	file, err := os.Open(LookersCacheFileName)
-	if err != nil {
-		fmt.Println("No Lookers cache file found, will scrape fresh data")
+// Load returns the raw cached listings for a key if they exist and are not expired
+func Load(key string) (json.RawMessage, bool) {
+	if _, err := os.Stat(FileName(key)); err != nil {
+		fmt.Printf("No %s cache file found, will scrape fresh data\n", key)
 		return nil, false
 	}
-	defer file.Close()
 
-	var cache LookersCache
-	if err := json.NewDecoder(file).Decode(&cache); err != nil {
-		fmt.Printf("ERROR: Error reading Lookers cache file: %v\n", err)
+	snapshot, err := readSnapshot(key)
+	if err != nil {
+		fmt.Printf("ERROR: Error reading %s cache file: %v\n", key, err)
 		return nil, false
 	}
 
 	// Check if cache is expired
-	if time.Since(cache.Timestamp) > LookersCacheExpiry {
-		fmt.Printf("Lookers cache expired (%.1f days old), will refresh\n", time.Since(cache.Timestamp).Hours()/24)
+	if time.Since(snapshot.Timestamp) > DefaultExpiry {
+		fmt.Printf("%s cache expired (%.1f days old), will refresh\n", key, time.Since(snapshot.Timestamp).Hours()/24)
 		return nil, false
 	}
 
-	daysRemaining := (LookersCacheExpiry - time.Since(cache.Timestamp)).Hours() / 24
-	fmt.Printf("Loaded %d Lookers cars from cache (updated %.1f days ago, %.1f days until refresh)\n",
-		len(cache.Data), time.Since(cache.Timestamp).Hours()/24, daysRemaining)
-	return cache.Data, true
+	daysRemaining := (DefaultExpiry - time.Since(snapshot.Timestamp)).Hours() / 24
+	fmt.Printf("Loaded %d %s cars from cache (updated %.1f days ago, %.1f days until refresh)\n",
+		countItems(snapshot.Data), key, time.Since(snapshot.Timestamp).Hours()/24, daysRemaining)
+	return snapshot.Data, true
 }
 
-// LoadFromCache loads cached Bonhams listings (backward compatibility)
-func LoadFromCache() ([]*models.BonhamsCar, bool) {
-	return LoadBonhamsFromCache()
+// LoadIgnoreExpiry returns the raw cached listings for a key regardless of expiry (for fallback)
+func LoadIgnoreExpiry(key string) (json.RawMessage, error) {
+	if _, err := os.Stat(FileName(key)); err != nil {
+		return nil, fmt.Errorf("no cache file found: %v", err)
+	}
+
+	snapshot, err := readSnapshot(key)
+	if err != nil {
+		return nil, fmt.Errorf("error reading cache file: %v", err)
+	}
+
+	age := time.Since(snapshot.Timestamp)
+	fmt.Printf("Loaded %d %s cars from expired cache (%.1f days old)\n",
+		countItems(snapshot.Data), key, age.Hours()/24)
+	return snapshot.Data, nil
 }
 
-// SaveBonhamsToCache saves Bonhams listings to cache file
-func SaveBonhamsToCache(listings []*models.BonhamsCar) error {
-	cache := BonhamsCache{
-		Data:      listings,
+// Save writes listings to the cache file for a key with a fresh timestamp
+func Save(key string, listings interface{}) error {
+	data, err := json.Marshal(listings)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s cache: %v", key, err)
+	}
+
+	snapshot := Snapshot{
+		Data:      data,
 		Timestamp: time.Now(),
 	}
 
-	file, err := os.Create(BonhamsCacheFileName)
+	file, err := os.Create(FileName(key))
 	if err != nil {
-		return fmt.Errorf("failed to create Bonhams cache file: %v", err)
+		return fmt.Errorf("failed to create %s cache file: %v", key, err)
 	}
 	defer file.Close()
 
-	if err := json.NewEncoder(file).Encode(cache); err != nil {
-		return fmt.Errorf("failed to encode Bonhams cache: %v", err)
+	if err := json.NewEncoder(file).Encode(snapshot); err != nil {
+		return fmt.Errorf("failed to encode %s cache: %v", key, err)
 	}
 
-	fmt.Printf("Cached %d Bonhams listings to %s\n", len(listings), BonhamsCacheFileName)
+	fmt.Printf("Cached %d %s listings to %s\n", countItems(data), key, FileName(key))
 	return nil
 }
 
-// SaveLookersToCache saves Lookers listings to cache file
-func SaveLookersToCache(listings []*models.LookersCar) error {
-	cache := LookersCache{
-		Data:      listings,
-		Timestamp: time.Now(),
+// IsExpired checks if the cache for a key is expired without decoding its listings
+func IsExpired(key string) bool {
+	snapshot, err := readSnapshot(key)
+	if err != nil {
+		return true // Missing or corrupted cache means expired
 	}
 
-	file, err := os.Create(LookersCacheFileName)
+	return time.Since(snapshot.Timestamp) > DefaultExpiry
+}
+
+// Age returns the age of the cache for a key
+func Age(key string) (time.Duration, error) {
+	snapshot, err := readSnapshot(key)
 	if err != nil {
-		return fmt.Errorf("failed to create Lookers cache file: %v", err)
+		return 0, err
 	}
-	defer file.Close()
 
-	if err := json.NewEncoder(file).Encode(cache); err != nil {
-		return fmt.Errorf("failed to encode Lookers cache: %v", err)
+	return time.Since(snapshot.Timestamp), nil
+}
+
+// Bump updates the cache timestamp for a key to extend its life by 7 days
+func Bump(key string) error {
+	// Load existing cache data (ignoring expiry)
+	data, err := LoadIgnoreExpiry(key)
+	if err != nil {
+		return fmt.Errorf("cannot bump expiry: %v", err)
+	}
+
+	// Resave with new timestamp
+	if err := Save(key, data); err != nil {
+		return fmt.Errorf("failed to bump %s expiry: %v", key, err)
 	}
 
-	fmt.Printf("Cached %d Lookers listings to %s\n", len(listings), LookersCacheFileName)
+	fmt.Printf("Bumped %s cache expiry - valid for another 7 days\n", key)
 	return nil
 }
 
+// LoadBonhamsFromCache loads cached Bonhams listings if they exist and are not expired
+func LoadBonhamsFromCache() ([]*models.BonhamsCar, bool) {
+	data, found := Load(BonhamsCacheKey)
+	if !found {
+		return nil, false
+	}
+
+	var listings []*models.BonhamsCar
+	if err := json.Unmarshal(data, &listings); err != nil {
+		fmt.Printf("ERROR: Error reading Bonhams cache file: %v\n", err)
+		return nil, false
+	}
+	return listings, true
+}
+
+// LoadLookersFromCache loads cached Lookers listings if they exist and are not expired
+func LoadLookersFromCache() ([]*models.LookersCar, bool) {
+	data, found := Load(LookersCacheKey)
+	if !found {
+		return nil, false
+	}
+
+	var listings []*models.LookersCar
+	if err := json.Unmarshal(data, &listings); err != nil {
+		fmt.Printf("ERROR: Error reading Lookers cache file: %v\n", err)
+		return nil, false
+	}
+	return listings, true
+}
+
+// LoadFromCache loads cached Bonhams listings (backward compatibility)
+func LoadFromCache() ([]*models.BonhamsCar, bool) {
+	return LoadBonhamsFromCache()
+}
+
+// SaveBonhamsToCache saves Bonhams listings to cache file
+func SaveBonhamsToCache(listings []*models.BonhamsCar) error {
+	return Save(BonhamsCacheKey, listings)
+}
+
+// SaveLookersToCache saves Lookers listings to cache file
+func SaveLookersToCache(listings []*models.LookersCar) error {
+	return Save(LookersCacheKey, listings)
+}
+
 // SaveToCache saves Bonhams listings to cache (backward compatibility)
 func SaveToCache(listings []*models.BonhamsCar) error {
 	return SaveBonhamsToCache(listings)
@@ -134,34 +223,12 @@ func SaveToCache(listings []*models.BonhamsCar) error {
 
 // IsBonhamsCacheExpired checks if the Bonhams cache is expired without loading it
 func IsBonhamsCacheExpired() bool {
-	file, err := os.Open(BonhamsCacheFileName)
-	if err != nil {
-		return true // No cache file means expired
-	}
-	defer file.Close()
-
-	var cache BonhamsCache
-	if err := json.NewDecoder(file).Decode(&cache); err != nil {
-		return true // Corrupted cache means expired
-	}
-
-	return time.Since(cache.Timestamp) > BonhamsCacheExpiry
+	return IsExpired(BonhamsCacheKey)
 }
 
 // IsLookersCacheExpired checks if the Lookers cache is expired without loading it
 func IsLookersCacheExpired() bool {
-	file, err := os.Open(LookersCacheFileName)
-	if err != nil {
-		return true // No cache file means expired
-	}
-	defer file.Close()
-
-	var cache LookersCache
-	if err := json.NewDecoder(file).Decode(&cache); err != nil {
-		return true // Corrupted cache means expired
-	}
-
-	return time.Since(cache.Timestamp) > LookersCacheExpiry
+	return IsExpired(LookersCacheKey)
 }
 
 // IsCacheExpired checks if the Bonhams cache is expired (backward compatibility)
@@ -171,34 +238,12 @@ func IsCacheExpired() bool {
 
 // GetBonhamsCacheAge returns the age of the Bonhams cache
 func GetBonhamsCacheAge() (time.Duration, error) {
-	file, err := os.Open(BonhamsCacheFileName)
-	if err != nil {
-		return 0, err
-	}
-	defer file.Close()
-
-	var cache BonhamsCache
-	if err := json.NewDecoder(file).Decode(&cache); err != nil {
-		return 0, err
-	}
-
-	return time.Since(cache.Timestamp), nil
+	return Age(BonhamsCacheKey)
 }
 
 // GetLookersCacheAge returns the age of the Lookers cache
 func GetLookersCacheAge() (time.Duration, error) {
-	file, err := os.Open(LookersCacheFileName)
-	if err != nil {
-		return 0, err
-	}
-	defer file.Close()
-
-	var cache LookersCache
-	if err := json.NewDecoder(file).Decode(&cache); err != nil {
-		return 0, err
-	}
-
-	return time.Since(cache.Timestamp), nil
+	return Age(LookersCacheKey)
 }
 
 // GetCacheAge returns the age of the Bonhams cache (backward compatibility)
@@ -208,72 +253,38 @@ func GetCacheAge() (time.Duration, error) {
 
 // LoadBonhamsFromCacheIgnoreExpiry loads cached Bonhams listings regardless of expiry (for fallback)
 func LoadBonhamsFromCacheIgnoreExpiry() ([]*models.BonhamsCar, error) {
-	file, err := os.Open(BonhamsCacheFileName)
+	data, err := LoadIgnoreExpiry(BonhamsCacheKey)
 	if err != nil {
-		return nil, fmt.Errorf("no cache file found: %v", err)
+		return nil, err
 	}
-	defer file.Close()
 
-	var cache BonhamsCache
-	if err := json.NewDecoder(file).Decode(&cache); err != nil {
+	var listings []*models.BonhamsCar
+	if err := json.Unmarshal(data, &listings); err != nil {
 		return nil, fmt.Errorf("error reading cache file: %v", err)
 	}
-
-	age := time.Since(cache.Timestamp)
-	fmt.Printf("Loaded %d Bonhams cars from expired cache (%.1f days old)\n",
-		len(cache.Data), age.Hours()/24)
-	return cache.Data, nil
+	return listings, nil
 }
 
 // LoadLookersFromCacheIgnoreExpiry loads cached Lookers listings regardless of expiry (for fallback)
 func LoadLookersFromCacheIgnoreExpiry() ([]*models.LookersCar, error) {
-	file, err := os.Open(LookersCacheFileName)
+	data, err := LoadIgnoreExpiry(LookersCacheKey)
 	if err != nil {
-		return nil, fmt.Errorf("no cache file found: %v", err)
+		return nil, err
 	}
-	defer file.Close()
 
-	var cache LookersCache
-	if err := json.NewDecoder(file).Decode(&cache); err != nil {
+	var listings []*models.LookersCar
+	if err := json.Unmarshal(data, &listings); err != nil {
 		return nil, fmt.Errorf("error reading cache file: %v", err)
 	}
-
-	age := time.Since(cache.Timestamp)
-	fmt.Printf("Loaded %d Lookers cars from expired cache (%.1f days old)\n",
-		len(cache.Data), age.Hours()/24)
-	return cache.Data, nil
+	return listings, nil
 }
 
 // BumpBonhamsExpiry updates the cache timestamp to extend its life by 7 days
 func BumpBonhamsExpiry() error {
-	// Load existing cache data (ignoring expiry)
-	listings, err := LoadBonhamsFromCacheIgnoreExpiry()
-	if err != nil {
-		return fmt.Errorf("cannot bump expiry: %v", err)
-	}
-
-	// Resave with new timestamp
-	if err := SaveBonhamsToCache(listings); err != nil {
-		return fmt.Errorf("failed to bump Bonhams expiry: %v", err)
-	}
-
-	fmt.Printf("Bumped Bonhams cache expiry - valid for another 7 days\n")
-	return nil
+	return Bump(BonhamsCacheKey)
 }
 
 // BumpLookersExpiry updates the cache timestamp to extend its life by 7 days
 func BumpLookersExpiry() error {
-	// Load existing cache data (ignoring expiry)
-	listings, err := LoadLookersFromCacheIgnoreExpiry()
-	if err != nil {
-		return fmt.Errorf("cannot bump expiry: %v", err)
-	}
-
-	// Resave with new timestamp
-	if err := SaveLookersToCache(listings); err != nil {
-		return fmt.Errorf("failed to bump Lookers expiry: %v", err)
-	}
-
-	fmt.Printf("Bumped Lookers cache expiry - valid for another 7 days\n")
-	return nil
+	return Bump(LookersCacheKey)
 }
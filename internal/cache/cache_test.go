@@ -232,3 +232,53 @@ func TestBonhamsCacheExpiredOnCorruption(t *testing.T) {
 		t.Fatalf("expected corrupted cache to be treated as expired")
 	}
 }
+
+func TestGenericKeyCache(t *testing.T) {
+	const key = "testsource"
+	restore := backupFile(t, FileName(key))
+	defer restore()
+
+	if _, ok := Load(key); ok {
+		t.Fatalf("expected no cache data for missing file")
+	}
+	if !IsExpired(key) {
+		t.Fatalf("expected missing cache to be expired")
+	}
+
+	if err := os.MkdirAll("data", 0o755); err != nil {
+		t.Fatalf("failed to ensure data directory: %v", err)
+	}
+	if err := Save(key, []*models.LookersCar{{ID: "a"}, {ID: "b"}}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	data, ok := Load(key)
+	if !ok {
+		t.Fatalf("expected fresh cache to load")
+	}
+	var cars []*models.LookersCar
+	if err := json.Unmarshal(data, &cars); err != nil || len(cars) != 2 || cars[1].ID != "b" {
+		t.Fatalf("unexpected cached data %s (%v)", data, err)
+	}
+
+	// Expired caches are skipped by Load but still usable as a fallback, and Bump revives them
+	writeCacheFile(t, FileName(key), Snapshot{
+		Data:      json.RawMessage(`[{"id":"old"}]`),
+		Timestamp: time.Now().Add(-DefaultExpiry - time.Hour),
+	})
+	if _, ok := Load(key); ok {
+		t.Fatalf("expected expired cache to be ignored")
+	}
+	if _, err := LoadIgnoreExpiry(key); err != nil {
+		t.Fatalf("LoadIgnoreExpiry failed: %v", err)
+	}
+	if err := Bump(key); err != nil {
+		t.Fatalf("Bump failed: %v", err)
+	}
+	if IsExpired(key) {
+		t.Fatalf("expected bumped cache to be fresh")
+	}
+	if age, err := Age(key); err != nil || age > time.Minute {
+		t.Fatalf("expected bumped cache age near zero, got %v (%v)", age, err)
+	}
+}
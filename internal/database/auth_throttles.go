@@ -0,0 +1,133 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"autotraderguesser/internal/models"
+)
+
+func scanAuthThrottle(row rowScanner) (*models.AuthThrottle, error) {
+	var t models.AuthThrottle
+	var lockedUntil sql.NullTime
+	if err := row.Scan(&t.UserID, &t.Kind, &t.Failures, &t.LastFailureAt, &lockedUntil); err != nil {
+		return nil, err
+	}
+	if lockedUntil.Valid {
+		t.LockedUntil = &lockedUntil.Time
+	}
+	return &t, nil
+}
+
+// GetAuthThrottle returns the failed attempts recorded against an account for one kind of attempt.
+// Returns a throttle with no failures if there are none.
+func (d *Database) GetAuthThrottle(userID int, kind string) (*models.AuthThrottle, error) {
+	throttle, err := scanAuthThrottle(d.db.QueryRow(`
+		SELECT user_id, kind, failures, last_failure_at, locked_until
+		FROM auth_throttles
+		WHERE user_id = ? AND kind = ?
+	`, userID, kind))
+	if err == sql.ErrNoRows {
+		return &models.AuthThrottle{UserID: userID, Kind: kind}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get failed attempts: %w", err)
+	}
+	return throttle, nil
+}
+
+// ReserveAuthAttempt counts an attempt against an account as failed before it is checked, so concurrent
+// attempts can't all be checked against the same count. Returns the throttle and whether the attempt was
+// reserved; it isn't while the account must wait. Clear or release the attempt once it succeeds.
+func (d *Database) ReserveAuthAttempt(userID int, kind string, policy models.AuthThrottlePolicy, now time.Time) (*models.AuthThrottle, bool, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	throttle, err := scanAuthThrottle(tx.QueryRow(`
+		SELECT user_id, kind, failures, last_failure_at, locked_until
+		FROM auth_throttles
+		WHERE user_id = ? AND kind = ?
+	`, userID, kind))
+	if err == sql.ErrNoRows {
+		throttle, err = &models.AuthThrottle{UserID: userID, Kind: kind}, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get failed attempts: %w", err)
+	}
+	if throttle.RetryAfter(now) > 0 {
+		return throttle, false, nil
+	}
+
+	seen := throttle.Failures
+	throttle.Fail(policy, now.UTC())
+
+	var lockedUntil interface{}
+	if throttle.LockedUntil != nil {
+		lockedUntil = *throttle.LockedUntil
+	}
+	// Only take the attempt if nobody else has since the count was read
+	result, err := tx.Exec(`
+		INSERT INTO auth_throttles (user_id, kind, failures, last_failure_at, locked_until)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (user_id, kind) DO UPDATE SET
+			failures = excluded.failures,
+			last_failure_at = excluded.last_failure_at,
+			locked_until = excluded.locked_until
+		WHERE auth_throttles.failures = ?
+	`, userID, kind, throttle.Failures, throttle.LastFailureAt, lockedUntil, seen)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to reserve attempt: %w", err)
+	}
+	if reserved, err := result.RowsAffected(); err != nil || reserved == 0 {
+		return throttle, false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, false, fmt.Errorf("failed to commit attempt: %w", err)
+	}
+	return throttle, true, nil
+}
+
+// ReleaseAuthAttempt hands back a reserved attempt that succeeded without ending the failed attempts,
+// such as a password check before a sensitive change. Lifts the wait the attempt added: no attempt is
+// reserved while the account must wait, so any wait before it was already over.
+func (d *Database) ReleaseAuthAttempt(userID int, kind string) error {
+	_, err := d.db.Exec(`
+		UPDATE auth_throttles
+		SET failures = failures - 1, locked_until = NULL
+		WHERE user_id = ? AND kind = ? AND failures > 0
+	`, userID, kind)
+	if err != nil {
+		return fmt.Errorf("failed to release attempt: %w", err)
+	}
+	return nil
+}
+
+// ClearAuthFailures forgets the failed attempts of the given kinds against an account
+func (d *Database) ClearAuthFailures(userID int, kinds ...string) error {
+	for _, kind := range kinds {
+		if _, err := d.db.Exec(`DELETE FROM auth_throttles WHERE user_id = ? AND kind = ?`, userID, kind); err != nil {
+			return fmt.Errorf("failed to clear failed attempts: %w", err)
+		}
+	}
+	return nil
+}
+
+// UnlockAccount forgets every failed attempt against an account, lifting any backoff or lockout.
+// Returns false if there was nothing to clear.
+func (d *Database) UnlockAccount(userID int) (bool, error) {
+	result, err := d.db.Exec(`DELETE FROM auth_throttles WHERE user_id = ?`, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to unlock account: %w", err)
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to unlock account: %w", err)
+	}
+	return count > 0, nil
+}
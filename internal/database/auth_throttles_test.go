@@ -0,0 +1,120 @@
+package database
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"autotraderguesser/internal/models"
+)
+
+func TestAuthThrottles(t *testing.T) {
+	db := newTestDatabase(t)
+	defer db.Close()
+
+	users := createFriendshipTestUsers(t, db, "alice", "bob")
+	alice, bob := users[0], users[1]
+	policy := models.DefaultAuthThrottle
+	now := time.Date(2025, 6, 4, 12, 0, 0, 0, time.UTC)
+
+	throttle, err := db.GetAuthThrottle(alice.ID, models.AuthAttemptLogin)
+	if err != nil || throttle.Failures != 0 || throttle.RetryAfter(now) != 0 {
+		t.Fatalf("expected no failures yet, got %+v err=%v", throttle, err)
+	}
+
+	for i := 1; i <= policy.LockoutAfter; i++ {
+		var reserved bool
+		if throttle, reserved, err = db.ReserveAuthAttempt(alice.ID, models.AuthAttemptLogin, policy, now); err != nil || !reserved {
+			t.Fatalf("expected attempt %d to be reserved, got %v err=%v", i, reserved, err)
+		}
+		if i < policy.LockoutAfter {
+			// Attempts during the wait are refused without counting
+			if wait := policy.Wait(i); wait > 0 {
+				if _, reserved, err := db.ReserveAuthAttempt(alice.ID, models.AuthAttemptLogin, policy, now); err != nil || reserved {
+					t.Fatalf("expected attempt during the wait to be refused, got %v err=%v", reserved, err)
+				}
+				now = now.Add(wait)
+			}
+		}
+	}
+	if _, reserved, err := db.ReserveAuthAttempt(alice.ID, models.AuthAttemptReset, policy, now); err != nil || !reserved {
+		t.Fatalf("expected a reset attempt to be reserved, got %v err=%v", reserved, err)
+	}
+
+	stored, err := db.GetAuthThrottle(alice.ID, models.AuthAttemptLogin)
+	if err != nil {
+		t.Fatalf("GetAuthThrottle failed: %v", err)
+	}
+	if stored.Failures != policy.LockoutAfter || !stored.LockedOut(policy) || stored.RetryAfter(now) != policy.LockoutDuration {
+		t.Fatalf("expected alice's logins to be locked, got %+v", stored)
+	}
+	if !stored.LastFailureAt.Equal(now) || !stored.LockedUntil.Equal(*throttle.LockedUntil) {
+		t.Fatalf("expected the stored times to round trip, got %+v want %+v", stored, throttle)
+	}
+
+	// Kinds and accounts are counted separately
+	if reset, err := db.GetAuthThrottle(alice.ID, models.AuthAttemptReset); err != nil || reset.Failures != 1 {
+		t.Fatalf("expected one failed reset, got %+v err=%v", reset, err)
+	}
+	if other, err := db.GetAuthThrottle(bob.ID, models.AuthAttemptLogin); err != nil || other.Failures != 0 {
+		t.Fatalf("expected bob to have no failures, got %+v err=%v", other, err)
+	}
+
+	if err := db.ClearAuthFailures(alice.ID, models.AuthAttemptReset); err != nil {
+		t.Fatalf("ClearAuthFailures failed: %v", err)
+	}
+	if reset, err := db.GetAuthThrottle(alice.ID, models.AuthAttemptReset); err != nil || reset.Failures != 0 {
+		t.Fatalf("expected failed resets to be cleared, got %+v err=%v", reset, err)
+	}
+
+	if unlocked, err := db.UnlockAccount(alice.ID); err != nil || !unlocked {
+		t.Fatalf("expected alice to be unlocked, got %v err=%v", unlocked, err)
+	}
+	if login, err := db.GetAuthThrottle(alice.ID, models.AuthAttemptLogin); err != nil || login.RetryAfter(now) != 0 {
+		t.Fatalf("expected no lockout after unlocking, got %+v err=%v", login, err)
+	}
+	if unlocked, err := db.UnlockAccount(alice.ID); err != nil || unlocked {
+		t.Fatalf("expected nothing left to unlock, got %v err=%v", unlocked, err)
+	}
+}
+
+func TestReserveAuthAttemptConcurrently(t *testing.T) {
+	db := newTestDatabase(t)
+	defer db.Close()
+
+	users := createFriendshipTestUsers(t, db, "alice")
+	policy := models.DefaultAuthThrottle
+	now := time.Date(2025, 6, 4, 12, 0, 0, 0, time.UTC)
+
+	// Parallel guesses at one moment only get the free attempts and the one that starts the wait
+	var reservedCount atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, reserved, err := db.ReserveAuthAttempt(users[0].ID, models.AuthAttemptLogin, policy, now)
+			if err != nil {
+				t.Errorf("ReserveAuthAttempt failed: %v", err)
+			}
+			if reserved {
+				reservedCount.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := int(reservedCount.Load()); got != policy.FreeAttempts+1 {
+		t.Fatalf("expected %d attempts to be reserved, got %d", policy.FreeAttempts+1, got)
+	}
+
+	// Releasing the last one hands it back and lifts the wait it started
+	if err := db.ReleaseAuthAttempt(users[0].ID, models.AuthAttemptLogin); err != nil {
+		t.Fatalf("ReleaseAuthAttempt failed: %v", err)
+	}
+	throttle, err := db.GetAuthThrottle(users[0].ID, models.AuthAttemptLogin)
+	if err != nil || throttle.Failures != policy.FreeAttempts || throttle.RetryAfter(now) != 0 {
+		t.Fatalf("expected %d failures and no wait, got %+v err=%v", policy.FreeAttempts, throttle, err)
+	}
+}
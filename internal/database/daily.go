@@ -0,0 +1,246 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"autotraderguesser/internal/models"
+)
+
+// Daily challenge methods
+
+// CreateDailyChallenge stores the car set for a date and difficulty.
+// If a set already exists for that day it is kept, so the first stored set always wins.
+func (d *Database) CreateDailyChallenge(daily *models.DailyChallenge) error {
+	carsJSON, err := json.Marshal(daily.Cars)
+	if err != nil {
+		return fmt.Errorf("failed to marshal daily cars: %w", err)
+	}
+
+	query := `
+		INSERT OR IGNORE INTO daily_challenges (challenge_date, difficulty, cars_json)
+		VALUES (?, ?, ?)
+	`
+
+	if _, err := d.db.Exec(query, daily.Date, daily.Difficulty, string(carsJSON)); err != nil {
+		return fmt.Errorf("failed to create daily challenge: %w", err)
+	}
+
+	return nil
+}
+
+// GetDailyChallenge retrieves the car set for a date and difficulty
+func (d *Database) GetDailyChallenge(date, difficulty string) (*models.DailyChallenge, error) {
+	query := `
+		SELECT challenge_date, difficulty, cars_json
+		FROM daily_challenges
+		WHERE challenge_date = ? AND difficulty = ?
+	`
+
+	daily, err := scanDailyChallenge(d.db.QueryRow(query, date, difficulty))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // No set generated for this day yet
+		}
+		return nil, fmt.Errorf("failed to get daily challenge: %w", err)
+	}
+
+	return daily, nil
+}
+
+// GetDailyArchive retrieves past daily car sets before the given date, newest first
+func (d *Database) GetDailyArchive(difficulty, beforeDate string, limit int) ([]models.DailyChallenge, error) {
+	query := `
+		SELECT challenge_date, difficulty, cars_json
+		FROM daily_challenges
+		WHERE challenge_date < ?
+	`
+	args := []interface{}{beforeDate}
+
+	if difficulty != "" {
+		query += " AND difficulty = ?"
+		args = append(args, difficulty)
+	}
+
+	query += " ORDER BY challenge_date DESC, difficulty"
+
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	rows, err := d.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query daily archive: %w", err)
+	}
+	defer rows.Close()
+
+	var archive []models.DailyChallenge
+	for rows.Next() {
+		daily, err := scanDailyChallenge(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan daily challenge: %w", err)
+		}
+		archive = append(archive, *daily)
+	}
+
+	return archive, rows.Err()
+}
+
+// scanDailyChallenge reads a daily challenge row and decodes its cars
+func scanDailyChallenge(row interface{ Scan(...interface{}) error }) (*models.DailyChallenge, error) {
+	var daily models.DailyChallenge
+	var carsJSON string
+
+	if err := row.Scan(&daily.Date, &daily.Difficulty, &carsJSON); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(carsJSON), &daily.Cars); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal daily cars: %w", err)
+	}
+
+	return &daily, nil
+}
+
+// CreateDailyAttempt creates the challenge session for a player's daily attempt.
+// Returns false without creating anything if the player already has an attempt for that day.
+func (d *Database) CreateDailyAttempt(attempt *models.DailyAttempt, session *models.ChallengeSession) (bool, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	err = tx.QueryRow(`
+		SELECT EXISTS(SELECT 1 FROM daily_attempts WHERE challenge_date = ? AND difficulty = ? AND player_key = ?)
+	`, attempt.Date, attempt.Difficulty, attempt.PlayerKey).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check daily attempt: %w", err)
+	}
+	if exists {
+		return false, nil
+	}
+
+	if err := insertChallengeSession(tx, session); err != nil {
+		return false, err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO daily_attempts (challenge_date, difficulty, player_key, user_id, session_id)
+		VALUES (?, ?, ?, ?, ?)
+	`, attempt.Date, attempt.Difficulty, attempt.PlayerKey, attempt.UserID, session.SessionID)
+	if err != nil {
+		return false, fmt.Errorf("failed to create daily attempt: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit daily attempt: %w", err)
+	}
+
+	attempt.SessionID = session.SessionID
+	return true, nil
+}
+
+// GetDailyAttempt retrieves a player's attempt for a date and difficulty
+func (d *Database) GetDailyAttempt(date, difficulty, playerKey string) (*models.DailyAttempt, error) {
+	query := `
+		SELECT challenge_date, difficulty, player_key, user_id, session_id
+		FROM daily_attempts
+		WHERE challenge_date = ? AND difficulty = ? AND player_key = ?
+	`
+
+	return d.getDailyAttempt(query, date, difficulty, playerKey)
+}
+
+// GetDailyAttemptBySession retrieves the daily attempt a challenge session belongs to
+func (d *Database) GetDailyAttemptBySession(sessionID string) (*models.DailyAttempt, error) {
+	query := `
+		SELECT challenge_date, difficulty, player_key, user_id, session_id
+		FROM daily_attempts
+		WHERE session_id = ?
+	`
+
+	return d.getDailyAttempt(query, sessionID)
+}
+
+// getDailyAttempt runs a single-row daily attempt query
+func (d *Database) getDailyAttempt(query string, args ...interface{}) (*models.DailyAttempt, error) {
+	var attempt models.DailyAttempt
+	var userID sql.NullInt64
+
+	err := d.db.QueryRow(query, args...).Scan(
+		&attempt.Date, &attempt.Difficulty, &attempt.PlayerKey, &userID, &attempt.SessionID,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // No attempt found
+		}
+		return nil, fmt.Errorf("failed to get daily attempt: %w", err)
+	}
+
+	if userID.Valid {
+		id := int(userID.Int64)
+		attempt.UserID = &id
+	}
+
+	return &attempt, nil
+}
+
+// GetDailyLeaderboard retrieves daily leaderboard entries for a single date and difficulty
+func (d *Database) GetDailyLeaderboard(date, difficulty string, limit int) ([]models.LeaderboardEntry, error) {
+	query := `
+		SELECT le.username, le.score, le.game_mode, le.difficulty, le.hints_used, le.created_at
+		FROM leaderboard_entries le
+		JOIN daily_attempts da ON da.session_id = le.session_id
+		WHERE le.game_mode = 'daily' AND da.challenge_date = ? AND le.difficulty = ?
+		ORDER BY ` + rankingFor("daily").orderBy("le.") + `
+	`
+	args := []interface{}{date, difficulty}
+
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	rows, err := d.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query daily leaderboard: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []models.LeaderboardEntry
+	for rows.Next() {
+		var entry models.LeaderboardEntry
+		var createdAt time.Time
+
+		if err := rows.Scan(&entry.Name, &entry.Score, &entry.GameMode, &entry.Difficulty, &entry.HintsUsed, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		entry.Date = createdAt.Format("2006-01-02 15:04:05")
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// GetDailyRank returns the 1-based position a score holds on a day's daily leaderboard
+func (d *Database) GetDailyRank(date, difficulty string, score int) (int, error) {
+	var ahead int
+	err := d.db.QueryRow(`
+		SELECT COUNT(*)
+		FROM leaderboard_entries le
+		JOIN daily_attempts da ON da.session_id = le.session_id
+		WHERE le.game_mode = 'daily' AND da.challenge_date = ? AND le.difficulty = ?
+		AND le.score `+rankingFor("daily").better()+` ?
+	`, date, difficulty, score).Scan(&ahead)
+	if err != nil {
+		return 0, fmt.Errorf("failed to calculate daily leaderboard rank: %w", err)
+	}
+
+	return ahead + 1, nil
+}
@@ -0,0 +1,139 @@
+package database
+
+import (
+	"testing"
+
+	"autotraderguesser/internal/models"
+)
+
+func TestDailyChallengeSetsAndArchive(t *testing.T) {
+	db := newTestDatabase(t)
+	defer db.Close()
+
+	if daily, err := db.GetDailyChallenge("2024-05-01", "easy"); err != nil || daily != nil {
+		t.Fatalf("expected no daily challenge, got %v err=%v", daily, err)
+	}
+
+	first := &models.DailyChallenge{Date: "2024-05-01", Difficulty: "easy", Cars: []*models.EnhancedCar{{ID: "a", Price: 1000}}}
+	if err := db.CreateDailyChallenge(first); err != nil {
+		t.Fatalf("CreateDailyChallenge failed: %v", err)
+	}
+
+	// The first stored set for a day wins
+	second := &models.DailyChallenge{Date: "2024-05-01", Difficulty: "easy", Cars: []*models.EnhancedCar{{ID: "b", Price: 2000}}}
+	if err := db.CreateDailyChallenge(second); err != nil {
+		t.Fatalf("CreateDailyChallenge duplicate failed: %v", err)
+	}
+
+	daily, err := db.GetDailyChallenge("2024-05-01", "easy")
+	if err != nil || daily == nil {
+		t.Fatalf("GetDailyChallenge failed: %v", err)
+	}
+	if len(daily.Cars) != 1 || daily.Cars[0].ID != "a" || daily.Cars[0].Price != 1000 {
+		t.Fatalf("unexpected daily cars: %+v", daily.Cars)
+	}
+
+	for _, d := range []*models.DailyChallenge{
+		{Date: "2024-05-02", Difficulty: "easy", Cars: []*models.EnhancedCar{{ID: "c"}}},
+		{Date: "2024-05-02", Difficulty: "hard", Cars: []*models.EnhancedCar{{ID: "d"}}},
+		{Date: "2024-05-03", Difficulty: "easy", Cars: []*models.EnhancedCar{{ID: "e"}}},
+	} {
+		if err := db.CreateDailyChallenge(d); err != nil {
+			t.Fatalf("CreateDailyChallenge failed: %v", err)
+		}
+	}
+
+	archive, err := db.GetDailyArchive("easy", "2024-05-03", 0)
+	if err != nil {
+		t.Fatalf("GetDailyArchive failed: %v", err)
+	}
+	if len(archive) != 2 || archive[0].Date != "2024-05-02" || archive[1].Date != "2024-05-01" {
+		t.Fatalf("unexpected archive: %+v", archive)
+	}
+
+	all, err := db.GetDailyArchive("", "2024-05-03", 2)
+	if err != nil || len(all) != 2 {
+		t.Fatalf("expected limited archive across difficulties, got %d err=%v", len(all), err)
+	}
+}
+
+func TestDailyAttemptsAndLeaderboard(t *testing.T) {
+	db := newTestDatabase(t)
+	defer db.Close()
+
+	newSession := func(id string) *models.ChallengeSession {
+		return &models.ChallengeSession{
+			SessionID:  id,
+			Difficulty: "hard",
+			Cars:       []*models.EnhancedCar{{ID: "car1"}},
+			PricedCars: []*models.EnhancedCar{{ID: "car1", Price: 5000}},
+		}
+	}
+
+	attempt := &models.DailyAttempt{Date: "2024-05-01", Difficulty: "hard", PlayerKey: "anon:player-one"}
+	created, err := db.CreateDailyAttempt(attempt, newSession("daily-session-1"))
+	if err != nil || !created {
+		t.Fatalf("expected attempt to be created, got %v err=%v", created, err)
+	}
+	if attempt.SessionID != "daily-session-1" {
+		t.Fatalf("expected attempt session ID to be set, got %q", attempt.SessionID)
+	}
+
+	// A second attempt for the same player and day is refused and creates no session
+	retry := &models.DailyAttempt{Date: "2024-05-01", Difficulty: "hard", PlayerKey: "anon:player-one"}
+	created, err = db.CreateDailyAttempt(retry, newSession("daily-session-2"))
+	if err != nil || created {
+		t.Fatalf("expected duplicate attempt to be refused, got %v err=%v", created, err)
+	}
+	if s, err := db.GetChallengeSession("daily-session-2"); err != nil || s != nil {
+		t.Fatalf("expected refused attempt to leave no session, got %v err=%v", s, err)
+	}
+
+	// Another day is a fresh attempt
+	nextDay := &models.DailyAttempt{Date: "2024-05-02", Difficulty: "hard", PlayerKey: "anon:player-one"}
+	if created, err := db.CreateDailyAttempt(nextDay, newSession("daily-session-3")); err != nil || !created {
+		t.Fatalf("expected next day attempt to be created, got %v err=%v", created, err)
+	}
+
+	found, err := db.GetDailyAttempt("2024-05-01", "hard", "anon:player-one")
+	if err != nil || found == nil || found.SessionID != "daily-session-1" {
+		t.Fatalf("GetDailyAttempt mismatch: %+v err=%v", found, err)
+	}
+	bySession, err := db.GetDailyAttemptBySession("daily-session-3")
+	if err != nil || bySession == nil || bySession.Date != "2024-05-02" {
+		t.Fatalf("GetDailyAttemptBySession mismatch: %+v err=%v", bySession, err)
+	}
+	if missing, err := db.GetDailyAttemptBySession("not-daily"); err != nil || missing != nil {
+		t.Fatalf("expected nil for non-daily session, got %+v err=%v", missing, err)
+	}
+
+	// Daily leaderboard is partitioned by date
+	for _, entry := range []models.LeaderboardEntry{
+		{Name: "Day1", Score: 700, GameMode: "daily", Difficulty: "hard", SessionID: "daily-session-1"},
+		{Name: "Day2", Score: 900, GameMode: "daily", Difficulty: "hard", SessionID: "daily-session-3"},
+	} {
+		entry := entry
+		if err := db.AddLeaderboardEntry(&entry); err != nil {
+			t.Fatalf("AddLeaderboardEntry failed: %v", err)
+		}
+	}
+
+	entries, err := db.GetDailyLeaderboard("2024-05-01", "hard", 10)
+	if err != nil {
+		t.Fatalf("GetDailyLeaderboard failed: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Name != "Day1" {
+		t.Fatalf("unexpected daily leaderboard: %+v", entries)
+	}
+
+	// Ranks only count the same day's entries
+	if rank, err := db.GetDailyRank("2024-05-01", "hard", 800); err != nil || rank != 1 {
+		t.Fatalf("expected rank 1 above the day's best, got %d err=%v", rank, err)
+	}
+	if rank, err := db.GetDailyRank("2024-05-01", "hard", 700); err != nil || rank != 1 {
+		t.Fatalf("expected a tied score to share rank 1, got %d err=%v", rank, err)
+	}
+	if rank, err := db.GetDailyRank("2024-05-02", "hard", 700); err != nil || rank != 2 {
+		t.Fatalf("expected rank 2 below the next day's best, got %d err=%v", rank, err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -87,15 +88,22 @@ func (d *Database) initializeSchema() error {
 
 // User management methods
 
-// CreateUser creates a new user in the database
+// CreateUser creates a new user in the database. If the user has a session token, they start
+// out logged in with it.
 func (d *Database) CreateUser(user *models.User) error {
 	query := `
-		INSERT INTO users (username, password_hash, display_name, is_guest, session_token, avatar_url, security_question, security_answer_hash)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO users (username, password_hash, display_name, is_guest, avatar_url, security_question, security_answer_hash)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
 	`
 
-	result, err := d.db.Exec(query, user.Username, user.PasswordHash,
-		user.DisplayName, user.IsGuest, user.SessionToken, user.AvatarURL,
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(query, user.Username, user.PasswordHash,
+		user.DisplayName, user.IsGuest, user.AvatarURL,
 		user.SecurityQuestion, user.SecurityAnswerHash)
 	if err != nil {
 		return fmt.Errorf("failed to create user: %w", err)
@@ -107,6 +115,16 @@ func (d *Database) CreateUser(user *models.User) error {
 		return fmt.Errorf("failed to get user ID: %w", err)
 	}
 
+	if user.SessionToken != "" {
+		if _, err := insertUserSession(tx, int(id), user.SessionToken, "", ""); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit user: %w", err)
+	}
+
 	user.ID = int(id)
 	user.CreatedAt = time.Now()
 	user.LastActive = time.Now()
@@ -114,50 +132,16 @@ func (d *Database) CreateUser(user *models.User) error {
 	return nil
 }
 
-// GetUserBySessionToken retrieves a user by their session token
+// GetUserBySessionToken retrieves the user logged in with a session token, comparing by digest
 func (d *Database) GetUserBySessionToken(token string) (*models.User, error) {
-	query := `
-		SELECT id, username, display_name, password_hash, avatar_url, session_token, session_expires_at, is_guest,
-		       security_question, security_answer_hash, created_at, last_active, total_games_played, favorite_difficulty
-		FROM users
-		WHERE session_token = ?
-	`
-
-	var user models.User
-	var passwordHash, avatarURL, securityQuestion, securityAnswerHash sql.NullString
-	var sessionExpiresAt sql.NullTime
-
-	err := d.db.QueryRow(query, token).Scan(
-		&user.ID, &user.Username, &user.DisplayName, &passwordHash,
-		&avatarURL, &user.SessionToken, &sessionExpiresAt, &user.IsGuest, &securityQuestion, &securityAnswerHash,
-		&user.CreatedAt, &user.LastActive, &user.TotalGamesPlayed, &user.FavoriteDifficulty,
-	)
-
+	user, _, err := d.GetUserSession(token)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, models.ErrUserSessionNotFound) {
 			return nil, fmt.Errorf("user not found")
 		}
-		return nil, fmt.Errorf("failed to get user: %w", err)
-	}
-
-	// Handle nullable fields
-	if passwordHash.Valid {
-		user.PasswordHash = passwordHash.String
-	}
-	if avatarURL.Valid {
-		user.AvatarURL = avatarURL.String
-	}
-	if securityQuestion.Valid {
-		user.SecurityQuestion = securityQuestion.String
-	}
-	if securityAnswerHash.Valid {
-		user.SecurityAnswerHash = securityAnswerHash.String
-	}
-	if sessionExpiresAt.Valid {
-		user.SessionExpiresAt = &sessionExpiresAt.Time
+		return nil, err
 	}
-
-	return &user, nil
+	return user, nil
 }
 
 // GetUserByUsername retrieves a user by username
@@ -257,17 +241,27 @@ func (d *Database) GetUserByDisplayName(displayName string) (*models.User, error
 	return &user, nil
 }
 
-// UpdateUserSession updates a user's session token
+// UpdateUserSession logs a user out on every device and, unless the token is empty, starts a single
+// session with it
 func (d *Database) UpdateUserSession(userID int, sessionToken string) error {
-	// Sessions expire after 7 days
-	expiresAt := time.Now().Add(7 * 24 * time.Hour)
-	query := `UPDATE users SET session_token = ?, session_expires_at = ?, last_active = ? WHERE id = ?`
-
-	_, err := d.db.Exec(query, sessionToken, expiresAt, time.Now(), userID)
+	tx, err := d.db.Begin()
 	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM user_sessions WHERE user_id = ?`, userID); err != nil {
 		return fmt.Errorf("failed to update user session: %w", err)
 	}
+	if sessionToken != "" {
+		if _, err := insertUserSession(tx, userID, sessionToken, "", ""); err != nil {
+			return err
+		}
+	}
 
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to update user session: %w", err)
+	}
 	return nil
 }
 
@@ -392,16 +386,60 @@ func (d *Database) IncrementUserGamesPlayed(userID int) error {
 
 // Challenge session methods
 
-// CreateChallengeSession creates a new challenge session
+// CreateChallengeSession creates a new challenge session along with its car price snapshot
 func (d *Database) CreateChallengeSession(session *models.ChallengeSession) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := insertChallengeSession(tx, session); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit challenge session: %w", err)
+	}
+
+	return nil
+}
+
+// scoringParams is the stored form of a session's scoring parameters
+type scoringParams struct {
+	Scale    float64 `json:"scale,omitempty"`
+	Cutoff   float64 `json:"cutoff,omitempty"`
+	CarCount int     `json:"carCount,omitempty"`
+}
+
+// insertChallengeSession writes a challenge session and its car price snapshot within a transaction
+func insertChallengeSession(tx *sql.Tx, session *models.ChallengeSession) error {
 	carsJSON, err := json.Marshal(session.Cars)
 	if err != nil {
 		return fmt.Errorf("failed to marshal cars: %w", err)
 	}
 
+	policy := session.Scoring.WithDefaults()
+	paramsJSON, err := json.Marshal(scoringParams{Scale: policy.Scale, Cutoff: policy.Cutoff, CarCount: policy.CarCount})
+	if err != nil {
+		return fmt.Errorf("failed to marshal scoring params: %w", err)
+	}
+
+	// Stored in SQLite's own datetime format so it compares correctly with CURRENT_TIMESTAMP.
+	// Callers can set an earlier expiry, such as a daily challenge closing at the end of its day.
+	expiresAt := time.Now().UTC().Add(models.ChallengeSessionTTL).Truncate(time.Second)
+	if session.ExpiresAt != "" {
+		deadline, err := time.Parse(time.RFC3339, session.ExpiresAt)
+		if err != nil {
+			return fmt.Errorf("invalid session expiry: %w", err)
+		}
+		expiresAt = deadline.UTC().Truncate(time.Second)
+	}
+
 	query := `
-		INSERT INTO challenge_sessions (session_id, user_id, difficulty, cars_json, current_car, total_score)
-		VALUES (?, ?, ?, ?, ?, ?)
+		INSERT INTO challenge_sessions (session_id, user_id, difficulty, cars_json, current_car, total_score,
+		                                scoring_policy, scoring_params, theme_slug, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	var userID *int
@@ -409,34 +447,64 @@ func (d *Database) CreateChallengeSession(session *models.ChallengeSession) erro
 		userID = &session.UserID
 	}
 
-	_, err = d.db.Exec(query, session.SessionID, userID, session.Difficulty,
-		string(carsJSON), session.CurrentCar, session.TotalScore)
+	var themeSlug *string
+	if session.Theme != "" {
+		themeSlug = &session.Theme
+	}
+
+	_, err = tx.Exec(query, session.SessionID, userID, session.Difficulty,
+		string(carsJSON), session.CurrentCar, session.TotalScore, policy.Curve, string(paramsJSON), themeSlug,
+		expiresAt.Format("2006-01-02 15:04:05"))
 
 	if err != nil {
 		return fmt.Errorf("failed to create challenge session: %w", err)
 	}
+	session.ExpiresAt = expiresAt.Format(time.RFC3339)
+
+	// Snapshot each car with its actual price so scoring doesn't depend on live listings
+	for i, car := range session.PricedCars {
+		carJSON, err := json.Marshal(car)
+		if err != nil {
+			return fmt.Errorf("failed to marshal challenge car: %w", err)
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO challenge_cars (session_id, car_index, car_id, actual_price, car_json)
+			VALUES (?, ?, ?, ?, ?)
+		`, session.SessionID, i, car.ID, car.Price, string(carJSON))
+		if err != nil {
+			return fmt.Errorf("failed to save challenge car: %w", err)
+		}
+	}
 
 	return nil
 }
 
-// GetChallengeSession retrieves a challenge session by ID
+// GetChallengeSession retrieves a challenge session by ID.
+// Unfinished sessions past their expiry are treated as missing; completed sessions are kept for review.
 func (d *Database) GetChallengeSession(sessionID string) (*models.ChallengeSession, error) {
 	query := `
 		SELECT session_id, user_id, difficulty, cars_json, current_car, total_score, 
-		       is_complete, created_at, completed_at
+		       is_complete, created_at, completed_at, scoring_policy, scoring_params, theme_slug, expires_at
 		FROM challenge_sessions 
-		WHERE session_id = ? AND expires_at > CURRENT_TIMESTAMP
+		WHERE session_id = ? AND (is_complete = TRUE OR (expires_at > CURRENT_TIMESTAMP AND expired_at IS NULL))
 	`
 
+	return d.scanChallengeSession(d.db.QueryRow(query, sessionID))
+}
+
+// scanChallengeSession reads a challenge session row and loads its guesses, car snapshot and hints
+func (d *Database) scanChallengeSession(row *sql.Row) (*models.ChallengeSession, error) {
 	var session models.ChallengeSession
 	var userID sql.NullInt64
-	var carsJSON string
-	var completedAt sql.NullTime
+	var carsJSON, paramsJSON string
+	var completedAt, expiresAt sql.NullTime
+	var themeSlug sql.NullString
 
-	err := d.db.QueryRow(query, sessionID).Scan(
+	err := row.Scan(
 		&session.SessionID, &userID, &session.Difficulty, &carsJSON,
 		&session.CurrentCar, &session.TotalScore, &session.IsComplete,
-		&session.StartTime, &completedAt,
+		&session.StartTime, &completedAt, &session.Scoring.Curve, &paramsJSON, &themeSlug, &expiresAt,
 	)
 
 	if err != nil {
@@ -446,6 +514,8 @@ func (d *Database) GetChallengeSession(sessionID string) (*models.ChallengeSessi
 		return nil, fmt.Errorf("failed to get challenge session: %w", err)
 	}
 
+	sessionID := session.SessionID
+
 	// Parse user ID
 	if userID.Valid {
 		session.UserID = int(userID.Int64)
@@ -456,11 +526,27 @@ func (d *Database) GetChallengeSession(sessionID string) (*models.ChallengeSessi
 		session.CompletedTime = completedAt.Time.Format(time.RFC3339)
 	}
 
+	if expiresAt.Valid {
+		session.ExpiresAt = expiresAt.Time.UTC().Format(time.RFC3339)
+	}
+
+	session.Theme = themeSlug.String
+
 	// Parse cars JSON
 	if err := json.Unmarshal([]byte(carsJSON), &session.Cars); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal cars: %w", err)
 	}
 
+	// Parse scoring params - sessions from before scoring policies have none and get the defaults
+	var params scoringParams
+	if err := json.Unmarshal([]byte(paramsJSON), &params); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal scoring params: %w", err)
+	}
+	session.Scoring.Scale = params.Scale
+	session.Scoring.Cutoff = params.Cutoff
+	session.Scoring.CarCount = params.CarCount
+	session.Scoring = session.Scoring.WithDefaults()
+
 	// Load guesses
 	guesses, err := d.getChallengeGuesses(sessionID)
 	if err != nil {
@@ -468,9 +554,102 @@ func (d *Database) GetChallengeSession(sessionID string) (*models.ChallengeSessi
 	}
 	session.Guesses = guesses
 
+	// Load the car price snapshot
+	pricedCars, err := d.getChallengeCars(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load challenge cars: %w", err)
+	}
+	session.PricedCars = pricedCars
+
+	// Load unlocked hints
+	hints, err := d.getChallengeHints(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load challenge hints: %w", err)
+	}
+	session.Hints = hints
+
 	return &session, nil
 }
 
+// GetResumableChallengeSession retrieves a user's most recent unfinished, unexpired challenge session.
+// Friend challenge templates are skipped as they are never played directly. Returns nil if there is none.
+func (d *Database) GetResumableChallengeSession(userID int) (*models.ChallengeSession, error) {
+	query := `
+		SELECT session_id, user_id, difficulty, cars_json, current_car, total_score, 
+		       is_complete, created_at, completed_at, scoring_policy, scoring_params, theme_slug, expires_at
+		FROM challenge_sessions 
+		WHERE user_id = ? AND is_complete = FALSE AND expires_at > CURRENT_TIMESTAMP AND expired_at IS NULL
+		  AND session_id NOT IN (SELECT template_session_id FROM friend_challenges)
+		ORDER BY created_at DESC, rowid DESC
+		LIMIT 1
+	`
+
+	return d.scanChallengeSession(d.db.QueryRow(query, userID))
+}
+
+// IsChallengeSessionExpired reports whether a session exists but was abandoned past its expiry
+func (d *Database) IsChallengeSessionExpired(sessionID string) (bool, error) {
+	query := `
+		SELECT COUNT(*) FROM challenge_sessions
+		WHERE session_id = ? AND is_complete = FALSE
+		  AND (expired_at IS NOT NULL OR expires_at <= CURRENT_TIMESTAMP)
+	`
+
+	var count int
+	if err := d.db.QueryRow(query, sessionID).Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to check challenge session expiry: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+// ExpireAbandonedChallengeSessions marks unfinished challenge sessions past their expiry as expired
+func (d *Database) ExpireAbandonedChallengeSessions() (int64, error) {
+	query := `
+		UPDATE challenge_sessions SET expired_at = CURRENT_TIMESTAMP
+		WHERE is_complete = FALSE AND expired_at IS NULL AND expires_at <= CURRENT_TIMESTAMP
+	`
+
+	result, err := d.db.Exec(query)
+	if err != nil {
+		return 0, fmt.Errorf("failed to expire challenge sessions: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// getChallengeCars retrieves the priced car snapshot for a challenge session in car order
+func (d *Database) getChallengeCars(sessionID string) ([]*models.EnhancedCar, error) {
+	query := `
+		SELECT car_json
+		FROM challenge_cars
+		WHERE session_id = ?
+		ORDER BY car_index
+	`
+
+	rows, err := d.db.Query(query, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cars []*models.EnhancedCar
+	for rows.Next() {
+		var carJSON string
+		if err := rows.Scan(&carJSON); err != nil {
+			return nil, err
+		}
+
+		var car models.EnhancedCar
+		if err := json.Unmarshal([]byte(carJSON), &car); err != nil {
+			return nil, err
+		}
+		cars = append(cars, &car)
+	}
+
+	return cars, rows.Err()
+}
+
 // UpdateChallengeSession updates an existing challenge session
 func (d *Database) UpdateChallengeSession(session *models.ChallengeSession) error {
 	query := `
@@ -499,12 +678,14 @@ func (d *Database) UpdateChallengeSession(session *models.ChallengeSession) erro
 func (d *Database) AddChallengeGuess(sessionID string, guess *models.ChallengeGuess) error {
 	query := `
 		INSERT INTO challenge_guesses 
-		(session_id, car_index, car_id, guessed_price, actual_price, points, accuracy_percentage)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
+		(session_id, car_index, car_id, guessed_price, actual_price, points, accuracy_percentage, log_ratio,
+		 hints_used, hint_penalty)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	_, err := d.db.Exec(query, sessionID, guess.CarIndex, guess.CarID,
-		guess.GuessedPrice, guess.ActualPrice, guess.Points, guess.Percentage)
+		guess.GuessedPrice, guess.ActualPrice, guess.Points, guess.Percentage, guess.LogRatio,
+		guess.HintsUsed, guess.HintPenalty)
 
 	if err != nil {
 		return fmt.Errorf("failed to add challenge guess: %w", err)
@@ -516,7 +697,8 @@ func (d *Database) AddChallengeGuess(sessionID string, guess *models.ChallengeGu
 // getChallengeGuesses retrieves all guesses for a challenge session
 func (d *Database) getChallengeGuesses(sessionID string) ([]models.ChallengeGuess, error) {
 	query := `
-		SELECT car_index, car_id, guessed_price, actual_price, points, accuracy_percentage, created_at
+		SELECT car_index, car_id, guessed_price, actual_price, points, accuracy_percentage, log_ratio,
+		       hints_used, hint_penalty, created_at
 		FROM challenge_guesses 
 		WHERE session_id = ?
 		ORDER BY car_index
@@ -531,33 +713,218 @@ func (d *Database) getChallengeGuesses(sessionID string) ([]models.ChallengeGues
 	var guesses []models.ChallengeGuess
 	for rows.Next() {
 		var guess models.ChallengeGuess
+		var logRatio sql.NullFloat64
 		var createdAt time.Time
 
 		err := rows.Scan(&guess.CarIndex, &guess.CarID, &guess.GuessedPrice,
-			&guess.ActualPrice, &guess.Points, &guess.Percentage, &createdAt)
+			&guess.ActualPrice, &guess.Points, &guess.Percentage, &logRatio,
+			&guess.HintsUsed, &guess.HintPenalty, &createdAt)
 		if err != nil {
 			return nil, err
 		}
 
+		// Guesses stored before the log-ratio metric have none, so derive it from the prices
+		if logRatio.Valid {
+			guess.LogRatio = logRatio.Float64
+		} else {
+			guess.LogRatio = models.LogRatioError(guess.GuessedPrice, guess.ActualPrice)
+		}
+
 		guesses = append(guesses, guess)
 	}
 
 	return guesses, nil
 }
 
+// Game session methods
+
+// StartGameSession creates a streak or zero mode session, replacing any previous game with the same ID.
+// Each game has its own GameID, so a replaced game's leaderboard entry doesn't block the new one.
+func (d *Database) StartGameSession(session *models.GameSession) error {
+	carsJSON, err := json.Marshal(session.CarsShown)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cars shown: %w", err)
+	}
+	session.CarsShownJSON = string(carsJSON)
+
+	if session.AccuracyMetric == "" {
+		session.AccuracyMetric = models.MetricPercentage
+	}
+	if session.GameID == "" {
+		session.GameID = session.SessionID
+	}
+
+	query := `
+		INSERT OR REPLACE INTO game_sessions
+		(session_id, user_id, game_mode, difficulty, current_score, current_difference, cars_shown, is_active, accuracy_metric, game_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err = d.db.Exec(query, session.SessionID, session.UserID, session.GameMode, session.Difficulty,
+		session.CurrentScore, session.CurrentDifference, session.CarsShownJSON, session.IsActive, session.AccuracyMetric, session.GameID)
+
+	if err != nil {
+		return fmt.Errorf("failed to start game session: %w", err)
+	}
+
+	return nil
+}
+
+// GetGameSession retrieves a streak or zero mode session by ID
+func (d *Database) GetGameSession(sessionID string) (*models.GameSession, error) {
+	query := `
+		SELECT session_id, user_id, game_mode, difficulty, current_score, current_difference,
+		       cars_shown, is_active, accuracy_metric, COALESCE(game_id, session_id), created_at, last_guess_at
+		FROM game_sessions
+		WHERE session_id = ?
+	`
+
+	var session models.GameSession
+	var userID sql.NullInt64
+
+	err := d.db.QueryRow(query, sessionID).Scan(
+		&session.SessionID, &userID, &session.GameMode, &session.Difficulty,
+		&session.CurrentScore, &session.CurrentDifference, &session.CarsShownJSON,
+		&session.IsActive, &session.AccuracyMetric, &session.GameID, &session.CreatedAt, &session.LastGuessAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Session not found
+		}
+		return nil, fmt.Errorf("failed to get game session: %w", err)
+	}
+
+	// Parse user ID
+	if userID.Valid {
+		id := int(userID.Int64)
+		session.UserID = &id
+	}
+
+	// Parse cars shown JSON
+	if err := json.Unmarshal([]byte(session.CarsShownJSON), &session.CarsShown); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal cars shown: %w", err)
+	}
+
+	return &session, nil
+}
+
+// UpdateGameSession saves the score and progress of a streak or zero mode session
+func (d *Database) UpdateGameSession(session *models.GameSession) error {
+	carsJSON, err := json.Marshal(session.CarsShown)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cars shown: %w", err)
+	}
+	session.CarsShownJSON = string(carsJSON)
+
+	query := `
+		UPDATE game_sessions
+		SET current_score = ?, current_difference = ?, cars_shown = ?, is_active = ?,
+		    last_guess_at = CURRENT_TIMESTAMP
+		WHERE session_id = ?
+	`
+
+	_, err = d.db.Exec(query, session.CurrentScore, session.CurrentDifference,
+		session.CarsShownJSON, session.IsActive, session.SessionID)
+
+	if err != nil {
+		return fmt.Errorf("failed to update game session: %w", err)
+	}
+
+	return nil
+}
+
+// CountActiveGameSessions counts active streak and zero mode sessions with a guess within the given window
+func (d *Database) CountActiveGameSessions(within time.Duration) (int, error) {
+	query := `
+		SELECT COUNT(*) FROM game_sessions
+		WHERE is_active = TRUE AND last_guess_at > datetime('now', ?)
+	`
+
+	var count int
+	if err := d.db.QueryRow(query, sqliteOffset(-within)).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count active game sessions: %w", err)
+	}
+
+	return count, nil
+}
+
+// DeleteStaleGameSessions removes streak and zero mode sessions with no guesses for longer than maxAge
+func (d *Database) DeleteStaleGameSessions(maxAge time.Duration) (int64, error) {
+	query := `DELETE FROM game_sessions WHERE last_guess_at < datetime('now', ?)`
+
+	result, err := d.db.Exec(query, sqliteOffset(-maxAge))
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete stale game sessions: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// SetHigherLowerPair stores the pair in play for a higher-or-lower session, replacing any previous pair
+func (d *Database) SetHigherLowerPair(pair *models.HigherLowerPair) error {
+	carsJSON, err := json.Marshal([]*models.EnhancedCar{pair.Left, pair.Right})
+	if err != nil {
+		return fmt.Errorf("failed to marshal pair: %w", err)
+	}
+
+	query := `INSERT OR REPLACE INTO higher_lower_pairs (session_id, cars_json) VALUES (?, ?)`
+
+	if _, err := d.db.Exec(query, pair.SessionID, string(carsJSON)); err != nil {
+		return fmt.Errorf("failed to set higher-or-lower pair: %w", err)
+	}
+
+	return nil
+}
+
+// GetHigherLowerPair retrieves the pair in play for a higher-or-lower session
+func (d *Database) GetHigherLowerPair(sessionID string) (*models.HigherLowerPair, error) {
+	var carsJSON string
+	err := d.db.QueryRow(`SELECT cars_json FROM higher_lower_pairs WHERE session_id = ?`, sessionID).Scan(&carsJSON)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // No pair in play
+		}
+		return nil, fmt.Errorf("failed to get higher-or-lower pair: %w", err)
+	}
+
+	var cars []*models.EnhancedCar
+	if err := json.Unmarshal([]byte(carsJSON), &cars); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal pair: %w", err)
+	}
+	if len(cars) != 2 {
+		return nil, fmt.Errorf("invalid higher-or-lower pair for session %s", sessionID)
+	}
+
+	return &models.HigherLowerPair{SessionID: sessionID, Left: cars[0], Right: cars[1]}, nil
+}
+
+// ClearHigherLowerPair removes the pair in play once it has been answered
+func (d *Database) ClearHigherLowerPair(sessionID string) error {
+	if _, err := d.db.Exec(`DELETE FROM higher_lower_pairs WHERE session_id = ?`, sessionID); err != nil {
+		return fmt.Errorf("failed to clear higher-or-lower pair: %w", err)
+	}
+	return nil
+}
+
+// sqliteOffset formats a duration as a datetime() modifier such as "-3600 seconds"
+func sqliteOffset(d time.Duration) string {
+	return fmt.Sprintf("%+d seconds", int64(d.Seconds()))
+}
+
 // Friend challenge methods
 
 // CreateFriendChallenge creates a new friend challenge
 func (d *Database) CreateFriendChallenge(challenge *models.FriendChallenge) error {
 	query := `
 		INSERT INTO friend_challenges 
-		(challenge_code, title, creator_user_id, template_session_id, difficulty, max_participants, is_active, created_at, expires_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		(challenge_code, title, creator_user_id, template_session_id, difficulty, max_participants, is_active, created_at, expires_at, invited_user_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	result, err := d.db.Exec(query, challenge.ChallengeCode, challenge.Title, challenge.CreatorUserID,
 		challenge.TemplateSessionID, challenge.Difficulty, challenge.MaxParticipants, challenge.IsActive,
-		challenge.CreatedAt, challenge.ExpiresAt)
+		challenge.CreatedAt, challenge.ExpiresAt, challenge.InvitedUserID)
 	if err != nil {
 		return fmt.Errorf("failed to create friend challenge: %w", err)
 	}
@@ -589,17 +956,19 @@ func (d *Database) GetFriendChallengeByCode(code string) (*models.FriendChalleng
 	query := `
 		SELECT fc.id, fc.challenge_code, fc.title, fc.creator_user_id, fc.template_session_id,
 		       fc.difficulty, fc.max_participants, fc.is_active, fc.created_at, fc.expires_at,
-		       u.display_name as creator_display_name
+		       u.display_name as creator_display_name, fc.invited_user_id
 		FROM friend_challenges fc
 		JOIN users u ON fc.creator_user_id = u.id
 		WHERE fc.challenge_code = ? AND fc.is_active = TRUE AND fc.expires_at > ?
 	`
 
 	var challenge models.FriendChallenge
+	var invitedUserID sql.NullInt64
 	err := d.db.QueryRow(query, code, time.Now()).Scan(
 		&challenge.ID, &challenge.ChallengeCode, &challenge.Title, &challenge.CreatorUserID,
 		&challenge.TemplateSessionID, &challenge.Difficulty, &challenge.MaxParticipants,
 		&challenge.IsActive, &challenge.CreatedAt, &challenge.ExpiresAt, &challenge.CreatorDisplayName,
+		&invitedUserID,
 	)
 
 	if err != nil {
@@ -609,6 +978,11 @@ func (d *Database) GetFriendChallengeByCode(code string) (*models.FriendChalleng
 		return nil, fmt.Errorf("failed to get challenge: %w", err)
 	}
 
+	if invitedUserID.Valid {
+		id := int(invitedUserID.Int64)
+		challenge.InvitedUserID = &id
+	}
+
 	return &challenge, nil
 }
 
@@ -726,6 +1100,49 @@ func (d *Database) GetUserChallengeParticipation(challengeID, userID int) (*mode
 	return &p, nil
 }
 
+// GetChallengeParticipantBySession finds the friend challenge participant playing a session.
+// Returns nil if the session isn't part of a friend challenge.
+func (d *Database) GetChallengeParticipantBySession(sessionID string) (*models.ChallengeParticipant, error) {
+	query := `
+		SELECT cp.id, cp.friend_challenge_id, cp.user_id, cp.session_id,
+		       cp.final_score, cp.rank_position, cp.completed_at, cp.joined_at,
+		       u.display_name as user_display_name
+		FROM challenge_participants cp
+		JOIN users u ON cp.user_id = u.id
+		WHERE cp.session_id = ?
+	`
+
+	var p models.ChallengeParticipant
+	var finalScore sql.NullInt64
+	var rankPosition sql.NullInt64
+	var completedAt sql.NullTime
+
+	err := d.db.QueryRow(query, sessionID).Scan(
+		&p.ID, &p.FriendChallengeID, &p.UserID, &p.SessionID,
+		&finalScore, &rankPosition, &completedAt, &p.JoinedAt, &p.UserDisplayName)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not a friend challenge session
+		}
+		return nil, fmt.Errorf("failed to get participation: %w", err)
+	}
+
+	if finalScore.Valid {
+		score := int(finalScore.Int64)
+		p.FinalScore = &score
+	}
+	if rankPosition.Valid {
+		rank := int(rankPosition.Int64)
+		p.RankPosition = &rank
+	}
+	if completedAt.Valid {
+		p.CompletedAt = &completedAt.Time
+	}
+
+	return &p, nil
+}
+
 // GetUserCreatedChallenges gets challenges created by a user
 func (d *Database) GetUserCreatedChallenges(userID int) ([]models.FriendChallenge, error) {
 	query := `
@@ -832,6 +1249,52 @@ func (d *Database) GetUserParticipatingChallenges(userID int) ([]models.FriendCh
 	return challenges, nil
 }
 
+// GetUserChallengeInvites gets open challenges sent directly to a user that they haven't joined yet,
+// leaving out any from a creator either side has since blocked
+func (d *Database) GetUserChallengeInvites(userID int) ([]models.FriendChallenge, error) {
+	query := `
+		SELECT fc.id, fc.challenge_code, fc.title, fc.creator_user_id, fc.template_session_id,
+		       fc.difficulty, fc.max_participants, fc.is_active, fc.created_at, fc.expires_at,
+		       u.display_name as creator_display_name,
+		       (SELECT COUNT(*) FROM challenge_participants cp WHERE cp.friend_challenge_id = fc.id) as participant_count
+		FROM friend_challenges fc
+		JOIN users u ON fc.creator_user_id = u.id
+		WHERE fc.invited_user_id = ? AND fc.is_active = TRUE AND fc.expires_at > ?
+		AND NOT EXISTS (
+			SELECT 1 FROM challenge_participants cp WHERE cp.friend_challenge_id = fc.id AND cp.user_id = ?
+		)
+		AND NOT EXISTS (
+			SELECT 1 FROM user_blocks b
+			WHERE (b.blocker_id = fc.creator_user_id AND b.blocked_id = ?)
+			   OR (b.blocker_id = ? AND b.blocked_id = fc.creator_user_id)
+		)
+		ORDER BY fc.created_at DESC
+	`
+
+	rows, err := d.db.Query(query, userID, time.Now(), userID, userID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get challenge invites: %w", err)
+	}
+	defer rows.Close()
+
+	var challenges []models.FriendChallenge
+	for rows.Next() {
+		var c models.FriendChallenge
+		err := rows.Scan(&c.ID, &c.ChallengeCode, &c.Title, &c.CreatorUserID,
+			&c.TemplateSessionID, &c.Difficulty, &c.MaxParticipants, &c.IsActive,
+			&c.CreatedAt, &c.ExpiresAt, &c.CreatorDisplayName, &c.ParticipantCount)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan challenge: %w", err)
+		}
+
+		invitedUserID := userID
+		c.InvitedUserID = &invitedUserID
+		challenges = append(challenges, c)
+	}
+
+	return challenges, nil
+}
+
 // CalculateChallengeRankings calculates and updates rankings for a challenge
 func (d *Database) CalculateChallengeRankings(challengeID int, participants []models.ChallengeParticipant) error {
 	// Sort participants by score (descending), then by completion time (ascending)
@@ -895,9 +1358,11 @@ func (d *Database) CalculateChallengeRankings(challengeID int, participants []mo
 
 // GetUserLeaderboardRank gets a user's rank on the leaderboard for a specific game mode and difficulty
 func (d *Database) GetUserLeaderboardRank(userID int, gameMode string, difficulty string) (*int, error) {
+	r := rankingFor(gameMode)
+
 	// First get the user's best score for this game mode and difficulty
 	bestScoreQuery := `
-		SELECT MAX(score) as best_score
+		SELECT ` + r.bestScoreAggregate() + `(score) as best_score
 		FROM leaderboard_entries 
 		WHERE user_id = ? AND game_mode = ? AND difficulty = ?
 	`
@@ -920,11 +1385,11 @@ func (d *Database) GetUserLeaderboardRank(userID int, gameMode string, difficult
 		WHERE game_mode = ? AND difficulty = ? 
 		AND user_id IS NOT NULL AND user_id > 0
 		AND (
-			SELECT MAX(score) 
+			SELECT ` + r.bestScoreAggregate() + `(score) 
 			FROM leaderboard_entries le2 
 			WHERE le2.user_id = leaderboard_entries.user_id 
 			AND le2.game_mode = ? AND le2.difficulty = ?
-		) > ?
+		) ` + r.better() + ` ?
 	`
 
 	var rank int
@@ -938,9 +1403,11 @@ func (d *Database) GetUserLeaderboardRank(userID int, gameMode string, difficult
 
 // GetUserOverallLeaderboardRank gets a user's rank against ALL entries (including legacy guest entries)
 func (d *Database) GetUserOverallLeaderboardRank(userID int, gameMode string, difficulty string) (*int, error) {
+	r := rankingFor(gameMode)
+
 	// First get the user's best score for this game mode and difficulty
 	bestScoreQuery := `
-		SELECT MAX(score) as best_score
+		SELECT ` + r.bestScoreAggregate() + `(score) as best_score
 		FROM leaderboard_entries 
 		WHERE user_id = ? AND game_mode = ? AND difficulty = ?
 	`
@@ -965,14 +1432,14 @@ func (d *Database) GetUserOverallLeaderboardRank(userID int, gameMode string, di
 					WHEN user_id IS NULL OR user_id = 0 THEN 'guest_' || id 
 					ELSE CAST(user_id AS TEXT) 
 				END as unique_user,
-				MAX(score) as best_score
+				` + r.bestScoreAggregate() + `(score) as best_score
 			FROM leaderboard_entries 
 			WHERE game_mode = ? AND difficulty = ?
 			GROUP BY unique_user
 		)
 		SELECT COUNT(*) + 1 as rank
 		FROM user_best_scores 
-		WHERE best_score > ?
+		WHERE best_score ` + r.better() + ` ?
 	`
 
 	var rank int
@@ -988,11 +1455,12 @@ func (d *Database) GetUserOverallLeaderboardRank(userID int, gameMode string, di
 func (d *Database) GetUserLeaderboardStats(userID int) (map[string]interface{}, error) {
 	stats := make(map[string]interface{})
 
-	difficulties := []string{"easy", "hard"}
-	gameModes := []string{"challenge", "streak"}
+	// Ranked all-time modes; daily scores only compare within a day
+	gameModes := []string{"challenge", "streak", "zero", "higherlower"}
 
-	for _, difficulty := range difficulties {
-		for _, gameMode := range gameModes {
+	for _, gameMode := range gameModes {
+		mode, _ := models.LookupGameMode(gameMode)
+		for _, difficulty := range mode.Difficulties {
 			// Get registered users rank
 			registeredRank, err := d.GetUserLeaderboardRank(userID, gameMode, difficulty)
 			if err != nil {
@@ -1034,12 +1502,12 @@ func (d *Database) GetUserLeaderboardStats(userID int) (map[string]interface{},
 	return stats, nil
 }
 
-// generateSessionToken generates a cryptographically secure random session token
-func generateSessionToken() string {
+// generateSessionToken generates a cryptographically secure random session token.
+// Fails rather than fall back to a guessable token if the random source does.
+func generateSessionToken() (string, error) {
 	bytes := make([]byte, 32)
-	if _, err := randomReader.Read(bytes); err != nil {
-		// Fallback to time-based if crypto/rand fails
-		return fmt.Sprintf("%d%d", time.Now().UnixNano(), time.Now().Unix()%10000)
+	if _, err := io.ReadFull(randomReader, bytes); err != nil {
+		return "", fmt.Errorf("failed to generate session token: %w", err)
 	}
-	return hex.EncodeToString(bytes)
+	return hex.EncodeToString(bytes), nil
 }
@@ -2,6 +2,7 @@ package database
 
 import (
 	"errors"
+	"math"
 	"os"
 	"path/filepath"
 	"testing"
@@ -25,7 +26,9 @@ func TestMain(m *testing.M) {
 	if err := os.Chdir(root); err != nil {
 		panic(err)
 	}
-	os.Exit(m.Run())
+	code := m.Run()
+	closeLeaderboardBenchDatabase()
+	os.Exit(code)
 }
 
 func newTestDatabase(t *testing.T) *Database {
@@ -38,21 +41,18 @@ func newTestDatabase(t *testing.T) *Database {
 	return db
 }
 
-func TestGenerateSessionTokenFallback(t *testing.T) {
-	token := generateSessionToken()
-	if len(token) == 0 {
-		t.Fatalf("expected token, got empty string")
+func TestGenerateSessionTokenFailsClosed(t *testing.T) {
+	token, err := generateSessionToken()
+	if err != nil || len(token) != 64 {
+		t.Fatalf("expected a 64 character token, got %q err=%v", token, err)
 	}
 
 	original := randomReader
 	randomReader = failingReader{}
 	defer func() { randomReader = original }()
-	fallback := generateSessionToken()
-	if len(fallback) == 0 {
-		t.Fatalf("expected fallback token")
-	}
-	if fallback == token {
-		t.Fatalf("expected fallback token to differ")
+	fallback, err := generateSessionToken()
+	if err == nil || fallback != "" {
+		t.Fatalf("expected no token when the random source fails, got %q", fallback)
 	}
 }
 
@@ -397,3 +397,397 @@ func TestCreateUserErrorPaths(t *testing.T) {
 		t.Fatalf("expected duplicate user creation to fail")
 	}
 }
+
+func TestGameSessionLifecycle(t *testing.T) {
+	db := newTestDatabase(t)
+	defer db.Close()
+
+	if session, err := db.GetGameSession("missing"); err != nil || session != nil {
+		t.Fatalf("expected missing session to return nil, got %v err=%v", session, err)
+	}
+
+	session := &models.GameSession{
+		SessionID:  "streak-session",
+		GameMode:   "streak",
+		Difficulty: "hard",
+		IsActive:   true,
+	}
+	if err := db.StartGameSession(session); err != nil {
+		t.Fatalf("StartGameSession failed: %v", err)
+	}
+
+	session.CurrentScore = 2
+	session.CarsShown = []string{"car1", "car2"}
+	if err := db.UpdateGameSession(session); err != nil {
+		t.Fatalf("UpdateGameSession failed: %v", err)
+	}
+
+	loaded, err := db.GetGameSession("streak-session")
+	if err != nil || loaded == nil {
+		t.Fatalf("GetGameSession failed: %v", err)
+	}
+	if loaded.CurrentScore != 2 || len(loaded.CarsShown) != 2 || loaded.CarsShown[1] != "car2" || !loaded.IsActive {
+		t.Fatalf("unexpected session state: %+v", loaded)
+	}
+	if loaded.UserID != nil {
+		t.Fatalf("expected anonymous session, got user %v", *loaded.UserID)
+	}
+	if loaded.AccuracyMetric != models.MetricPercentage {
+		t.Fatalf("expected percentage metric by default, got %q", loaded.AccuracyMetric)
+	}
+
+	if count, err := db.CountActiveGameSessions(time.Hour); err != nil || count != 1 {
+		t.Fatalf("expected one active session, got %d err=%v", count, err)
+	}
+
+	// Ending the game keeps the row but no longer counts it as active
+	loaded.IsActive = false
+	if err := db.UpdateGameSession(loaded); err != nil {
+		t.Fatalf("UpdateGameSession end failed: %v", err)
+	}
+	if count, err := db.CountActiveGameSessions(time.Hour); err != nil || count != 0 {
+		t.Fatalf("expected no active sessions, got %d err=%v", count, err)
+	}
+
+	// Starting again with the same ID replaces the finished game
+	restart := &models.GameSession{SessionID: "streak-session", GameMode: "zero", Difficulty: "easy", IsActive: true}
+	if err := db.StartGameSession(restart); err != nil {
+		t.Fatalf("StartGameSession restart failed: %v", err)
+	}
+	if loaded, err := db.GetGameSession("streak-session"); err != nil || loaded.GameMode != "zero" || loaded.CurrentScore != 0 {
+		t.Fatalf("expected restarted zero session, got %+v err=%v", loaded, err)
+	}
+
+	// Stale sessions are removed
+	if _, err := db.db.Exec(`UPDATE game_sessions SET last_guess_at = datetime('now', '-2 days')`); err != nil {
+		t.Fatalf("failed to age session: %v", err)
+	}
+	if deleted, err := db.DeleteStaleGameSessions(24 * time.Hour); err != nil || deleted != 1 {
+		t.Fatalf("expected one stale session deleted, got %d err=%v", deleted, err)
+	}
+	if session, err := db.GetGameSession("streak-session"); err != nil || session != nil {
+		t.Fatalf("expected session to be deleted, got %v err=%v", session, err)
+	}
+}
+
+func TestChallengeCarSnapshot(t *testing.T) {
+	db := newTestDatabase(t)
+	defer db.Close()
+
+	session := &models.ChallengeSession{
+		SessionID:  "snapshot-session",
+		Difficulty: "hard",
+		Cars:       []*models.EnhancedCar{{ID: "car1"}, {ID: "car2"}},
+		PricedCars: []*models.EnhancedCar{
+			{ID: "car1", Price: 15000, OriginalURL: "https://example.com/car1"},
+			{ID: "car2", Price: 42000, OriginalURL: "https://example.com/car2"},
+		},
+	}
+	if err := db.CreateChallengeSession(session); err != nil {
+		t.Fatalf("CreateChallengeSession failed: %v", err)
+	}
+
+	loaded, err := db.GetChallengeSession("snapshot-session")
+	if err != nil || loaded == nil {
+		t.Fatalf("GetChallengeSession failed: %v", err)
+	}
+	if len(loaded.PricedCars) != 2 {
+		t.Fatalf("expected two priced cars, got %d", len(loaded.PricedCars))
+	}
+	if loaded.PricedCars[1].ID != "car2" || loaded.PricedCars[1].Price != 42000 || loaded.PricedCars[1].OriginalURL != "https://example.com/car2" {
+		t.Fatalf("unexpected priced car snapshot: %+v", loaded.PricedCars[1])
+	}
+	for _, car := range loaded.Cars {
+		if car.Price != 0 {
+			t.Fatalf("expected player-facing cars to keep prices hidden, got %+v", car)
+		}
+	}
+
+	// A failed snapshot insert rolls back the whole session
+	if _, err := db.db.Exec(`DROP TABLE challenge_cars`); err != nil {
+		t.Fatalf("failed to drop table: %v", err)
+	}
+	rollback := &models.ChallengeSession{
+		SessionID:  "rollback-session",
+		Difficulty: "hard",
+		PricedCars: []*models.EnhancedCar{{ID: "car1", Price: 1}},
+	}
+	if err := db.CreateChallengeSession(rollback); err == nil {
+		t.Fatalf("expected snapshot failure to be reported")
+	}
+	var count int
+	if err := db.db.QueryRow(`SELECT COUNT(*) FROM challenge_sessions WHERE session_id = 'rollback-session'`).Scan(&count); err != nil || count != 0 {
+		t.Fatalf("expected session insert to be rolled back, count=%d err=%v", count, err)
+	}
+}
+
+func TestHigherLowerPairs(t *testing.T) {
+	db := newTestDatabase(t)
+	defer db.Close()
+
+	session := &models.GameSession{SessionID: "hl-session", GameMode: "higherlower", Difficulty: "easy", IsActive: true}
+	if err := db.StartGameSession(session); err != nil {
+		t.Fatalf("StartGameSession failed: %v", err)
+	}
+
+	if pair, err := db.GetHigherLowerPair("hl-session"); err != nil || pair != nil {
+		t.Fatalf("expected no pair, got %v err=%v", pair, err)
+	}
+
+	pair := &models.HigherLowerPair{
+		SessionID: "hl-session",
+		Left:      &models.EnhancedCar{ID: "left", Price: 10000},
+		Right:     &models.EnhancedCar{ID: "right", Price: 25000},
+	}
+	if err := db.SetHigherLowerPair(pair); err != nil {
+		t.Fatalf("SetHigherLowerPair failed: %v", err)
+	}
+
+	loaded, err := db.GetHigherLowerPair("hl-session")
+	if err != nil || loaded == nil {
+		t.Fatalf("GetHigherLowerPair failed: %v", err)
+	}
+	if loaded.Left.ID != "left" || loaded.Right.Price != 25000 {
+		t.Fatalf("unexpected pair: %+v %+v", loaded.Left, loaded.Right)
+	}
+
+	if err := db.ClearHigherLowerPair("hl-session"); err != nil {
+		t.Fatalf("ClearHigherLowerPair failed: %v", err)
+	}
+	if pair, err := db.GetHigherLowerPair("hl-session"); err != nil || pair != nil {
+		t.Fatalf("expected pair to be cleared, got %v err=%v", pair, err)
+	}
+
+	// Pairs go with their session when it is cleaned up
+	if err := db.SetHigherLowerPair(pair); err != nil {
+		t.Fatalf("SetHigherLowerPair failed: %v", err)
+	}
+	if _, err := db.db.Exec(`UPDATE game_sessions SET last_guess_at = datetime('now', '-2 days')`); err != nil {
+		t.Fatalf("failed to age session: %v", err)
+	}
+	if _, err := db.DeleteStaleGameSessions(24 * time.Hour); err != nil {
+		t.Fatalf("DeleteStaleGameSessions failed: %v", err)
+	}
+	if pair, err := db.GetHigherLowerPair("hl-session"); err != nil || pair != nil {
+		t.Fatalf("expected pair to be removed with its session, got %v err=%v", pair, err)
+	}
+}
+
+func TestChallengeScoringPolicyPersisted(t *testing.T) {
+	db := newTestDatabase(t)
+	defer db.Close()
+
+	custom := models.ScoringPolicy{Curve: models.CurveLinear, Scale: 50, CarCount: 20}
+	for id, policy := range map[string]models.ScoringPolicy{
+		"custom-policy":  custom,
+		"default-policy": {},
+	} {
+		session := &models.ChallengeSession{SessionID: id, Difficulty: "easy", Scoring: policy}
+		if err := db.CreateChallengeSession(session); err != nil {
+			t.Fatalf("CreateChallengeSession failed: %v", err)
+		}
+	}
+
+	loaded, err := db.GetChallengeSession("custom-policy")
+	if err != nil || loaded == nil {
+		t.Fatalf("GetChallengeSession failed: %v", err)
+	}
+	if loaded.Scoring != custom.WithDefaults() {
+		t.Fatalf("expected stored policy %+v, got %+v", custom.WithDefaults(), loaded.Scoring)
+	}
+
+	// Sessions created without a policy read back as the standard one
+	loaded, err = db.GetChallengeSession("default-policy")
+	if err != nil || loaded == nil {
+		t.Fatalf("GetChallengeSession failed: %v", err)
+	}
+	if loaded.Scoring != models.DefaultScoringPolicy() {
+		t.Fatalf("expected default policy, got %+v", loaded.Scoring)
+	}
+}
+
+func TestChallengeGuessMetrics(t *testing.T) {
+	db := newTestDatabase(t)
+	defer db.Close()
+
+	session := &models.ChallengeSession{SessionID: "metric-session", Difficulty: "hard"}
+	if err := db.CreateChallengeSession(session); err != nil {
+		t.Fatalf("CreateChallengeSession failed: %v", err)
+	}
+
+	guess := &models.ChallengeGuess{
+		CarIndex:     0,
+		CarID:        "car1",
+		GuessedPrice: 20000,
+		ActualPrice:  10000,
+		Percentage:   100,
+		LogRatio:     models.LogRatioError(20000, 10000),
+	}
+	if err := db.AddChallengeGuess("metric-session", guess); err != nil {
+		t.Fatalf("AddChallengeGuess failed: %v", err)
+	}
+
+	// A guess stored before the log-ratio column existed
+	if _, err := db.db.Exec(`
+		INSERT INTO challenge_guesses (session_id, car_index, car_id, guessed_price, actual_price, points, accuracy_percentage)
+		VALUES ('metric-session', 1, 'car2', 5000, 10000, 0, 50)
+	`); err != nil {
+		t.Fatalf("failed to insert legacy guess: %v", err)
+	}
+
+	loaded, err := db.GetChallengeSession("metric-session")
+	if err != nil || loaded == nil || len(loaded.Guesses) != 2 {
+		t.Fatalf("GetChallengeSession failed: %+v err=%v", loaded, err)
+	}
+	for _, g := range loaded.Guesses {
+		if math.Abs(g.LogRatio-math.Ln2) > 1e-9 {
+			t.Fatalf("expected log-ratio of ln 2 for car %s, got %f", g.CarID, g.LogRatio)
+		}
+	}
+	if loaded.Guesses[0].Percentage != 100 || loaded.Guesses[1].Percentage != 50 {
+		t.Fatalf("expected percentages to be kept, got %+v", loaded.Guesses)
+	}
+}
+
+func TestGetChallengeParticipantBySession(t *testing.T) {
+	db := newTestDatabase(t)
+	defer db.Close()
+
+	user := &models.User{
+		Username:           "reviewer",
+		PasswordHash:       "hash",
+		DisplayName:        "Reviewer",
+		SessionToken:       "session",
+		SecurityQuestion:   "Q?",
+		SecurityAnswerHash: "answer",
+	}
+	if err := db.CreateUser(user); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+
+	for _, id := range []string{"review-template", "review-player", "review-solo"} {
+		if err := db.CreateChallengeSession(&models.ChallengeSession{SessionID: id, Difficulty: "easy"}); err != nil {
+			t.Fatalf("CreateChallengeSession failed: %v", err)
+		}
+	}
+
+	challenge := &models.FriendChallenge{
+		ChallengeCode:     "REV123",
+		Title:             "Review",
+		CreatorUserID:     user.ID,
+		TemplateSessionID: "review-template",
+		Difficulty:        "easy",
+		MaxParticipants:   5,
+		IsActive:          true,
+		CreatedAt:         time.Now(),
+		ExpiresAt:         time.Now().Add(time.Hour),
+	}
+	if err := db.CreateFriendChallenge(challenge); err != nil {
+		t.Fatalf("CreateFriendChallenge failed: %v", err)
+	}
+	if err := db.AddChallengeParticipant(&models.ChallengeParticipant{
+		FriendChallengeID: challenge.ID,
+		UserID:            user.ID,
+		SessionID:         "review-player",
+		JoinedAt:          time.Now(),
+	}); err != nil {
+		t.Fatalf("AddChallengeParticipant failed: %v", err)
+	}
+
+	participant, err := db.GetChallengeParticipantBySession("review-player")
+	if err != nil || participant == nil {
+		t.Fatalf("GetChallengeParticipantBySession failed: %v", err)
+	}
+	if participant.FriendChallengeID != challenge.ID || participant.UserDisplayName != "Reviewer" {
+		t.Fatalf("unexpected participant: %+v", participant)
+	}
+
+	if solo, err := db.GetChallengeParticipantBySession("review-solo"); err != nil || solo != nil {
+		t.Fatalf("expected nil for a session outside friend challenges, got %+v err=%v", solo, err)
+	}
+}
+
+func TestChallengeSessionExpiry(t *testing.T) {
+	db := newTestDatabase(t)
+	defer db.Close()
+
+	user := &models.User{
+		Username:           "resumer",
+		PasswordHash:       "hash",
+		DisplayName:        "Resumer",
+		SessionToken:       "session",
+		SecurityQuestion:   "Q?",
+		SecurityAnswerHash: "answer",
+	}
+	if err := db.CreateUser(user); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+
+	for _, id := range []string{"expiry-old", "expiry-done", "expiry-live", "expiry-template"} {
+		session := &models.ChallengeSession{SessionID: id, UserID: user.ID, Difficulty: "easy"}
+		if err := db.CreateChallengeSession(session); err != nil {
+			t.Fatalf("CreateChallengeSession failed: %v", err)
+		}
+		expiresAt, err := time.Parse(time.RFC3339, session.ExpiresAt)
+		if err != nil || time.Until(expiresAt) < models.ChallengeSessionTTL-time.Minute {
+			t.Fatalf("expected expiry about %v away, got %q err=%v", models.ChallengeSessionTTL, session.ExpiresAt, err)
+		}
+	}
+
+	// Friend challenge templates are never resumed
+	if err := db.CreateFriendChallenge(&models.FriendChallenge{
+		ChallengeCode:     "EXP123",
+		Title:             "Expiry",
+		CreatorUserID:     user.ID,
+		TemplateSessionID: "expiry-template",
+		Difficulty:        "easy",
+		MaxParticipants:   5,
+		IsActive:          true,
+		CreatedAt:         time.Now(),
+		ExpiresAt:         time.Now().Add(time.Hour),
+	}); err != nil {
+		t.Fatalf("CreateFriendChallenge failed: %v", err)
+	}
+
+	if _, err := db.db.Exec(`UPDATE challenge_sessions SET created_at = datetime('now', '-2 hours') WHERE session_id = 'expiry-live'`); err != nil {
+		t.Fatalf("failed to age live session: %v", err)
+	}
+	if _, err := db.db.Exec(`UPDATE challenge_sessions SET expires_at = datetime('now', '-1 minute'), created_at = datetime('now', '-25 hours')
+		WHERE session_id IN ('expiry-old', 'expiry-done')`); err != nil {
+		t.Fatalf("failed to expire sessions: %v", err)
+	}
+	if _, err := db.db.Exec(`UPDATE challenge_sessions SET is_complete = TRUE WHERE session_id = 'expiry-done'`); err != nil {
+		t.Fatalf("failed to complete session: %v", err)
+	}
+
+	if session, err := db.GetChallengeSession("expiry-old"); err != nil || session != nil {
+		t.Fatalf("expected expired session to be hidden, got %+v err=%v", session, err)
+	}
+	if expired, err := db.IsChallengeSessionExpired("expiry-old"); err != nil || !expired {
+		t.Fatalf("expected session to be reported expired, got %v err=%v", expired, err)
+	}
+	if expired, err := db.IsChallengeSessionExpired("missing"); err != nil || expired {
+		t.Fatalf("expected missing session not to be reported expired, got %v err=%v", expired, err)
+	}
+
+	// Completed sessions stay available for review
+	if session, err := db.GetChallengeSession("expiry-done"); err != nil || session == nil {
+		t.Fatalf("expected completed session to outlive its expiry, got err=%v", err)
+	}
+
+	resumable, err := db.GetResumableChallengeSession(user.ID)
+	if err != nil || resumable == nil || resumable.SessionID != "expiry-live" {
+		t.Fatalf("expected the live session to be resumable, got %+v err=%v", resumable, err)
+	}
+	if none, err := db.GetResumableChallengeSession(user.ID + 1); err != nil || none != nil {
+		t.Fatalf("expected nothing to resume for another user, got %+v err=%v", none, err)
+	}
+
+	expired, err := db.ExpireAbandonedChallengeSessions()
+	if err != nil || expired != 1 {
+		t.Fatalf("expected one abandoned session to be expired, got %d err=%v", expired, err)
+	}
+	if expired, err := db.ExpireAbandonedChallengeSessions(); err != nil || expired != 0 {
+		t.Fatalf("expected sweeping twice to be a no-op, got %d err=%v", expired, err)
+	}
+}
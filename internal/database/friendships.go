@@ -0,0 +1,312 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"autotraderguesser/internal/models"
+)
+
+// friendIDsQuery selects the IDs of a user's accepted friends, taking the user's ID three times
+const friendIDsQuery = `
+	SELECT CASE WHEN requester_id = ? THEN addressee_id ELSE requester_id END
+	FROM friendships
+	WHERE status = 'accepted' AND (requester_id = ? OR addressee_id = ?)
+`
+
+// pairCondition matches the friendship between two users, whichever of them asked, using idx_friendships_pair
+const pairCondition = "MIN(requester_id, addressee_id) = MIN(?, ?) AND MAX(requester_id, addressee_id) = MAX(?, ?)"
+
+// blockCondition matches a block between two users in either direction. Pass the users as (a, b, b, a).
+const blockCondition = "(blocker_id = ? AND blocked_id = ?) OR (blocker_id = ? AND blocked_id = ?)"
+
+// friendshipColumns are the columns read by scanFriendship
+const friendshipColumns = "id, requester_id, addressee_id, status, created_at, responded_at"
+
+// rowScanner is a *sql.Row or *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanFriendship(row rowScanner) (*models.Friendship, error) {
+	var f models.Friendship
+	var respondedAt sql.NullTime
+	if err := row.Scan(&f.ID, &f.RequesterID, &f.AddresseeID, &f.Status, &f.CreatedAt, &respondedAt); err != nil {
+		return nil, err
+	}
+	if respondedAt.Valid {
+		f.RespondedAt = &respondedAt.Time
+	}
+	return &f, nil
+}
+
+// SendFriendRequest asks another user to be friends. If they had already asked, their request is
+// accepted instead. Returns ErrUserBlocked if either user has blocked the other, ErrAlreadyFriends,
+// or ErrFriendRequestExists if the request was already sent.
+func (d *Database) SendFriendRequest(requesterID, addresseeID int) (*models.Friendship, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var blocked bool
+	err = tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM user_blocks WHERE `+blockCondition+`)`,
+		requesterID, addresseeID, addresseeID, requesterID).Scan(&blocked)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check blocks: %w", err)
+	}
+	if blocked {
+		return nil, models.ErrUserBlocked
+	}
+
+	existing, err := scanFriendship(tx.QueryRow(`SELECT `+friendshipColumns+` FROM friendships WHERE `+pairCondition,
+		requesterID, addresseeID, requesterID, addresseeID))
+	switch {
+	case err == sql.ErrNoRows:
+		result, err := tx.Exec(`INSERT INTO friendships (requester_id, addressee_id) VALUES (?, ?)`, requesterID, addresseeID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create friend request: %w", err)
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get friend request ID: %w", err)
+		}
+		existing, err = scanFriendship(tx.QueryRow(`SELECT `+friendshipColumns+` FROM friendships WHERE id = ?`, id))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get friend request: %w", err)
+		}
+	case err != nil:
+		return nil, fmt.Errorf("failed to get friendship: %w", err)
+	case existing.Status == models.FriendshipAccepted:
+		return nil, models.ErrAlreadyFriends
+	case existing.RequesterID == requesterID:
+		return nil, models.ErrFriendRequestExists
+	default:
+		// They asked first, so asking back accepts
+		if existing, err = acceptFriendRequest(tx, existing.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit friend request: %w", err)
+	}
+	return existing, nil
+}
+
+// acceptFriendRequest marks a pending request accepted and returns the friendship
+func acceptFriendRequest(tx *sql.Tx, requestID int) (*models.Friendship, error) {
+	_, err := tx.Exec(`
+		UPDATE friendships SET status = 'accepted', responded_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND status = 'pending'
+	`, requestID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to accept friend request: %w", err)
+	}
+
+	friendship, err := scanFriendship(tx.QueryRow(`SELECT `+friendshipColumns+` FROM friendships WHERE id = ?`, requestID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get friendship: %w", err)
+	}
+	return friendship, nil
+}
+
+// AcceptFriendRequest accepts a pending request sent to a user.
+// Returns ErrFriendRequestNotFound unless the request is pending and addressed to them.
+func (d *Database) AcceptFriendRequest(requestID, userID int) (*models.Friendship, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var pending bool
+	err = tx.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM friendships WHERE id = ? AND addressee_id = ? AND status = 'pending')
+	`, requestID, userID).Scan(&pending)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get friend request: %w", err)
+	}
+	if !pending {
+		return nil, models.ErrFriendRequestNotFound
+	}
+
+	friendship, err := acceptFriendRequest(tx, requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit friend request: %w", err)
+	}
+	return friendship, nil
+}
+
+// DeclineFriendRequest deletes a pending request sent to a user, so it can be sent again later.
+// Returns ErrFriendRequestNotFound unless the request is pending and addressed to them.
+func (d *Database) DeclineFriendRequest(requestID, userID int) error {
+	result, err := d.db.Exec(`
+		DELETE FROM friendships WHERE id = ? AND addressee_id = ? AND status = 'pending'
+	`, requestID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to decline friend request: %w", err)
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to decline friend request: %w", err)
+	}
+	if count == 0 {
+		return models.ErrFriendRequestNotFound
+	}
+	return nil
+}
+
+// RemoveFriend ends a friendship, or withdraws or declines a pending request, between two users.
+// Returns false if there was nothing between them.
+func (d *Database) RemoveFriend(userID, otherID int) (bool, error) {
+	result, err := d.db.Exec(`DELETE FROM friendships WHERE `+pairCondition, userID, otherID, userID, otherID)
+	if err != nil {
+		return false, fmt.Errorf("failed to remove friend: %w", err)
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to remove friend: %w", err)
+	}
+	return count > 0, nil
+}
+
+// AreFriends reports whether two users have an accepted friendship
+func (d *Database) AreFriends(userID, otherID int) (bool, error) {
+	var friends bool
+	err := d.db.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM friendships WHERE `+pairCondition+` AND status = 'accepted')
+	`, userID, otherID, userID, otherID).Scan(&friends)
+	if err != nil {
+		return false, fmt.Errorf("failed to check friendship: %w", err)
+	}
+	return friends, nil
+}
+
+// IsBlocked reports whether either user has blocked the other
+func (d *Database) IsBlocked(userID, otherID int) (bool, error) {
+	var blocked bool
+	err := d.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM user_blocks WHERE `+blockCondition+`)`,
+		userID, otherID, otherID, userID).Scan(&blocked)
+	if err != nil {
+		return false, fmt.Errorf("failed to check blocks: %w", err)
+	}
+	return blocked, nil
+}
+
+// GetFriendList returns a user's friends and their pending requests either way, ordered by display name
+func (d *Database) GetFriendList(userID int) (*models.FriendList, error) {
+	rows, err := d.db.Query(`
+		SELECT f.id, f.requester_id, f.status, f.created_at, f.responded_at,
+		       u.id, u.username, u.display_name, u.avatar_url
+		FROM friendships f
+		JOIN users u ON u.id = CASE WHEN f.requester_id = ? THEN f.addressee_id ELSE f.requester_id END
+		WHERE f.requester_id = ? OR f.addressee_id = ?
+		ORDER BY u.display_name COLLATE NOCASE
+	`, userID, userID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get friends: %w", err)
+	}
+	defer rows.Close()
+
+	list := &models.FriendList{Friends: []models.Friend{}, Incoming: []models.Friend{}, Outgoing: []models.Friend{}}
+	for rows.Next() {
+		var friend models.Friend
+		var requesterID int
+		var status string
+		var respondedAt sql.NullTime
+		var avatarURL sql.NullString
+
+		if err := rows.Scan(&friend.FriendshipID, &requesterID, &status, &friend.Since, &respondedAt,
+			&friend.UserID, &friend.Username, &friend.DisplayName, &avatarURL); err != nil {
+			return nil, fmt.Errorf("failed to scan friend: %w", err)
+		}
+		friend.AvatarURL = avatarURL.String
+
+		switch {
+		case status == models.FriendshipAccepted:
+			friend.FriendshipID = 0
+			if respondedAt.Valid {
+				friend.Since = respondedAt.Time
+			}
+			list.Friends = append(list.Friends, friend)
+		case requesterID == userID:
+			list.Outgoing = append(list.Outgoing, friend)
+		default:
+			list.Incoming = append(list.Incoming, friend)
+		}
+	}
+
+	return list, rows.Err()
+}
+
+// BlockUser stops another user sending friend requests and challenges to a user, and ends any
+// friendship or pending request between them. Blocking someone already blocked does nothing.
+func (d *Database) BlockUser(blockerID, blockedID int) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`INSERT OR IGNORE INTO user_blocks (blocker_id, blocked_id) VALUES (?, ?)`, blockerID, blockedID); err != nil {
+		return fmt.Errorf("failed to block user: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM friendships WHERE `+pairCondition, blockerID, blockedID, blockerID, blockedID); err != nil {
+		return fmt.Errorf("failed to remove friendship: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit block: %w", err)
+	}
+	return nil
+}
+
+// UnblockUser lifts a block. Returns false if the user wasn't blocked.
+func (d *Database) UnblockUser(blockerID, blockedID int) (bool, error) {
+	result, err := d.db.Exec(`DELETE FROM user_blocks WHERE blocker_id = ? AND blocked_id = ?`, blockerID, blockedID)
+	if err != nil {
+		return false, fmt.Errorf("failed to unblock user: %w", err)
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to unblock user: %w", err)
+	}
+	return count > 0, nil
+}
+
+// GetBlockedUsers returns the users a user has blocked, most recent first
+func (d *Database) GetBlockedUsers(userID int) ([]models.Friend, error) {
+	rows, err := d.db.Query(`
+		SELECT u.id, u.username, u.display_name, u.avatar_url, b.created_at
+		FROM user_blocks b
+		JOIN users u ON u.id = b.blocked_id
+		WHERE b.blocker_id = ?
+		ORDER BY b.created_at DESC, u.id DESC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blocked users: %w", err)
+	}
+	defer rows.Close()
+
+	blocked := []models.Friend{}
+	for rows.Next() {
+		var user models.Friend
+		var avatarURL sql.NullString
+		if err := rows.Scan(&user.UserID, &user.Username, &user.DisplayName, &avatarURL, &user.Since); err != nil {
+			return nil, fmt.Errorf("failed to scan blocked user: %w", err)
+		}
+		user.AvatarURL = avatarURL.String
+		blocked = append(blocked, user)
+	}
+
+	return blocked, rows.Err()
+}
@@ -0,0 +1,213 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"autotraderguesser/internal/models"
+)
+
+func createFriendshipTestUsers(t *testing.T, db *Database, names ...string) []*models.User {
+	t.Helper()
+	var users []*models.User
+	for _, name := range names {
+		user := &models.User{Username: name, PasswordHash: "hash", DisplayName: name}
+		if err := db.CreateUser(user); err != nil {
+			t.Fatalf("CreateUser failed: %v", err)
+		}
+		users = append(users, user)
+	}
+	return users
+}
+
+func TestFriendRequests(t *testing.T) {
+	db := newTestDatabase(t)
+	defer db.Close()
+
+	users := createFriendshipTestUsers(t, db, "alice", "bob", "carol")
+	alice, bob, carol := users[0], users[1], users[2]
+
+	request, err := db.SendFriendRequest(alice.ID, bob.ID)
+	if err != nil || request.Status != models.FriendshipPending {
+		t.Fatalf("expected a pending request, got %+v err=%v", request, err)
+	}
+	if _, err := db.SendFriendRequest(alice.ID, bob.ID); !errors.Is(err, models.ErrFriendRequestExists) {
+		t.Fatalf("expected ErrFriendRequestExists, got %v", err)
+	}
+
+	list, err := db.GetFriendList(bob.ID)
+	if err != nil {
+		t.Fatalf("GetFriendList failed: %v", err)
+	}
+	if len(list.Incoming) != 1 || list.Incoming[0].UserID != alice.ID || list.Incoming[0].FriendshipID != request.ID {
+		t.Fatalf("expected alice's request incoming for bob, got %+v", list)
+	}
+
+	// Only the addressee can accept
+	if _, err := db.AcceptFriendRequest(request.ID, alice.ID); !errors.Is(err, models.ErrFriendRequestNotFound) {
+		t.Fatalf("expected the sender to be unable to accept, got %v", err)
+	}
+	if friendship, err := db.AcceptFriendRequest(request.ID, bob.ID); err != nil || friendship.Status != models.FriendshipAccepted {
+		t.Fatalf("expected an accepted friendship, got %+v err=%v", friendship, err)
+	}
+	if _, err := db.SendFriendRequest(bob.ID, alice.ID); !errors.Is(err, models.ErrAlreadyFriends) {
+		t.Fatalf("expected ErrAlreadyFriends, got %v", err)
+	}
+	if friends, err := db.AreFriends(bob.ID, alice.ID); err != nil || !friends {
+		t.Fatalf("expected alice and bob to be friends, got %v err=%v", friends, err)
+	}
+
+	// Asking someone who already asked you accepts their request
+	if _, err := db.SendFriendRequest(carol.ID, alice.ID); err != nil {
+		t.Fatalf("SendFriendRequest failed: %v", err)
+	}
+	if friendship, err := db.SendFriendRequest(alice.ID, carol.ID); err != nil || friendship.Status != models.FriendshipAccepted {
+		t.Fatalf("expected the crossed request to be accepted, got %+v err=%v", friendship, err)
+	}
+
+	list, err = db.GetFriendList(alice.ID)
+	if err != nil {
+		t.Fatalf("GetFriendList failed: %v", err)
+	}
+	var names []string
+	for _, f := range list.Friends {
+		names = append(names, f.Username)
+	}
+	if fmt.Sprint(names) != "[bob carol]" || len(list.Incoming) != 0 || len(list.Outgoing) != 0 {
+		t.Fatalf("expected alice to have friends bob and carol and no requests, got %+v", list)
+	}
+
+	// Declined requests can be sent again
+	request, err = db.SendFriendRequest(bob.ID, carol.ID)
+	if err != nil {
+		t.Fatalf("SendFriendRequest failed: %v", err)
+	}
+	if err := db.DeclineFriendRequest(request.ID, carol.ID); err != nil {
+		t.Fatalf("DeclineFriendRequest failed: %v", err)
+	}
+	if err := db.DeclineFriendRequest(request.ID, carol.ID); !errors.Is(err, models.ErrFriendRequestNotFound) {
+		t.Fatalf("expected a declined request to be gone, got %v", err)
+	}
+	if _, err := db.SendFriendRequest(bob.ID, carol.ID); err != nil {
+		t.Fatalf("expected to be able to ask again after a decline, got %v", err)
+	}
+
+	if removed, err := db.RemoveFriend(bob.ID, alice.ID); err != nil || !removed {
+		t.Fatalf("expected the friendship to be removed, got %v err=%v", removed, err)
+	}
+	if removed, err := db.RemoveFriend(bob.ID, alice.ID); err != nil || removed {
+		t.Fatalf("expected nothing left to remove, got %v err=%v", removed, err)
+	}
+}
+
+func TestBlockUser(t *testing.T) {
+	db := newTestDatabase(t)
+	defer db.Close()
+
+	users := createFriendshipTestUsers(t, db, "alice", "bob")
+	alice, bob := users[0], users[1]
+
+	request, err := db.SendFriendRequest(alice.ID, bob.ID)
+	if err != nil {
+		t.Fatalf("SendFriendRequest failed: %v", err)
+	}
+	if _, err := db.AcceptFriendRequest(request.ID, bob.ID); err != nil {
+		t.Fatalf("AcceptFriendRequest failed: %v", err)
+	}
+
+	// Blocking ends the friendship and stops requests either way
+	if err := db.BlockUser(bob.ID, alice.ID); err != nil {
+		t.Fatalf("BlockUser failed: %v", err)
+	}
+	if err := db.BlockUser(bob.ID, alice.ID); err != nil {
+		t.Fatalf("expected blocking twice to be harmless, got %v", err)
+	}
+	if friends, err := db.AreFriends(alice.ID, bob.ID); err != nil || friends {
+		t.Fatalf("expected the block to end the friendship, got %v err=%v", friends, err)
+	}
+	for _, pair := range [][2]int{{alice.ID, bob.ID}, {bob.ID, alice.ID}} {
+		if blocked, err := db.IsBlocked(pair[0], pair[1]); err != nil || !blocked {
+			t.Fatalf("expected a block between %v, got %v err=%v", pair, blocked, err)
+		}
+	}
+	for _, pair := range [][2]int{{alice.ID, bob.ID}, {bob.ID, alice.ID}} {
+		if _, err := db.SendFriendRequest(pair[0], pair[1]); !errors.Is(err, models.ErrUserBlocked) {
+			t.Fatalf("expected ErrUserBlocked for %v, got %v", pair, err)
+		}
+	}
+
+	blocked, err := db.GetBlockedUsers(bob.ID)
+	if err != nil || len(blocked) != 1 || blocked[0].UserID != alice.ID {
+		t.Fatalf("expected bob to have blocked alice, got %+v err=%v", blocked, err)
+	}
+
+	if unblocked, err := db.UnblockUser(bob.ID, alice.ID); err != nil || !unblocked {
+		t.Fatalf("expected alice to be unblocked, got %v err=%v", unblocked, err)
+	}
+	if unblocked, err := db.UnblockUser(bob.ID, alice.ID); err != nil || unblocked {
+		t.Fatalf("expected nothing left to unblock, got %v err=%v", unblocked, err)
+	}
+	if blocked, err := db.IsBlocked(alice.ID, bob.ID); err != nil || blocked {
+		t.Fatalf("expected no block after unblocking, got %v err=%v", blocked, err)
+	}
+	if _, err := db.SendFriendRequest(alice.ID, bob.ID); err != nil {
+		t.Fatalf("expected requests to work after unblocking, got %v", err)
+	}
+}
+
+func TestFriendsLeaderboard(t *testing.T) {
+	db := newTestDatabase(t)
+	defer db.Close()
+
+	users := createFriendshipTestUsers(t, db, "alice", "bob", "carol")
+	alice, bob, carol := users[0], users[1], users[2]
+
+	request, err := db.SendFriendRequest(alice.ID, bob.ID)
+	if err != nil {
+		t.Fatalf("SendFriendRequest failed: %v", err)
+	}
+	if _, err := db.AcceptFriendRequest(request.ID, bob.ID); err != nil {
+		t.Fatalf("AcceptFriendRequest failed: %v", err)
+	}
+	// A pending request doesn't count
+	if _, err := db.SendFriendRequest(alice.ID, carol.ID); err != nil {
+		t.Fatalf("SendFriendRequest failed: %v", err)
+	}
+
+	for i, user := range users {
+		entry := &models.LeaderboardEntry{
+			UserID:     &user.ID,
+			Name:       user.Username,
+			Score:      100 * (i + 1),
+			GameMode:   "streak",
+			Difficulty: "easy",
+			SessionID:  "friends-" + user.Username,
+		}
+		if _, err := db.AddSessionLeaderboardEntry(entry); err != nil {
+			t.Fatalf("AddSessionLeaderboardEntry failed: %v", err)
+		}
+	}
+	addRankedTestEntry(t, db, "streak", "easy", 1000) // A guest
+
+	entries, err := db.QueryLeaderboard(models.LeaderboardQuery{GameMode: "streak", Difficulty: "easy", FriendsOf: alice.ID})
+	if err != nil {
+		t.Fatalf("QueryLeaderboard failed: %v", err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name)
+	}
+	if fmt.Sprint(names) != "[bob alice]" {
+		t.Fatalf("expected only alice and her friends, got %v", names)
+	}
+
+	entryID, err := db.GetUserBestEntryID(alice.ID, models.LeaderboardQuery{GameMode: "streak", Difficulty: "easy"})
+	if err != nil {
+		t.Fatalf("GetUserBestEntryID failed: %v", err)
+	}
+	neighbours, err := db.GetBoardNeighbours(models.LeaderboardQuery{GameMode: "streak", Difficulty: "easy", FriendsOf: alice.ID}, entryID, 5)
+	if err != nil || len(neighbours) != 2 || neighbours[1].Rank != 2 {
+		t.Fatalf("expected alice to rank 2nd among friends, got %+v err=%v", neighbours, err)
+	}
+}
@@ -0,0 +1,61 @@
+package database
+
+import (
+	"fmt"
+
+	"autotraderguesser/internal/models"
+)
+
+// Challenge hint methods
+
+// UnlockChallengeHint records a hint unlocked for a car in a challenge session.
+// Returns false if that hint was already unlocked for the car.
+func (d *Database) UnlockChallengeHint(sessionID string, hint *models.ChallengeHint) (bool, error) {
+	query := `
+		INSERT OR IGNORE INTO challenge_hints (session_id, car_index, hint, value_json, cost)
+		VALUES (?, ?, ?, ?, ?)
+	`
+
+	result, err := d.db.Exec(query, sessionID, hint.CarIndex, hint.Hint, string(hint.Value), hint.Cost)
+	if err != nil {
+		return false, fmt.Errorf("failed to unlock challenge hint: %w", err)
+	}
+
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to unlock challenge hint: %w", err)
+	}
+
+	return inserted > 0, nil
+}
+
+// getChallengeHints retrieves the hints unlocked in a challenge session, in car and unlock order
+func (d *Database) getChallengeHints(sessionID string) ([]models.ChallengeHint, error) {
+	query := `
+		SELECT car_index, hint, value_json, cost
+		FROM challenge_hints
+		WHERE session_id = ?
+		ORDER BY car_index, created_at, rowid
+	`
+
+	rows, err := d.db.Query(query, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hints := []models.ChallengeHint{}
+	for rows.Next() {
+		var hint models.ChallengeHint
+		var valueJSON string
+
+		if err := rows.Scan(&hint.CarIndex, &hint.Hint, &valueJSON, &hint.Cost); err != nil {
+			return nil, err
+		}
+
+		hint.Value = []byte(valueJSON)
+		hints = append(hints, hint)
+	}
+
+	return hints, rows.Err()
+}
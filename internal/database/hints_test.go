@@ -0,0 +1,65 @@
+package database
+
+import (
+	"testing"
+
+	"autotraderguesser/internal/models"
+)
+
+func TestChallengeHints(t *testing.T) {
+	db := newTestDatabase(t)
+	defer db.Close()
+
+	session := &models.ChallengeSession{SessionID: "hint-session", Difficulty: "hard"}
+	if err := db.CreateChallengeSession(session); err != nil {
+		t.Fatalf("CreateChallengeSession failed: %v", err)
+	}
+
+	hint := &models.ChallengeHint{CarIndex: 0, Hint: models.HintMileage, Value: []byte(`"41,565 Miles"`), Cost: 250}
+	if unlocked, err := db.UnlockChallengeHint("hint-session", hint); err != nil || !unlocked {
+		t.Fatalf("expected hint to unlock, got %v err=%v", unlocked, err)
+	}
+
+	// The same hint for the same car can only be bought once
+	if unlocked, err := db.UnlockChallengeHint("hint-session", hint); err != nil || unlocked {
+		t.Fatalf("expected duplicate hint to be ignored, got %v err=%v", unlocked, err)
+	}
+
+	second := &models.ChallengeHint{CarIndex: 1, Hint: models.HintPriceBand, Value: []byte(`{"min":40000,"max":50000}`), Cost: 1500}
+	if _, err := db.UnlockChallengeHint("hint-session", second); err != nil {
+		t.Fatalf("UnlockChallengeHint failed: %v", err)
+	}
+
+	loaded, err := db.GetChallengeSession("hint-session")
+	if err != nil || loaded == nil {
+		t.Fatalf("GetChallengeSession failed: %v", err)
+	}
+	if len(loaded.Hints) != 2 {
+		t.Fatalf("expected two hints, got %+v", loaded.Hints)
+	}
+	if loaded.Hints[0].Hint != models.HintMileage || string(loaded.Hints[0].Value) != `"41,565 Miles"` || loaded.Hints[0].Cost != 250 {
+		t.Fatalf("unexpected first hint: %+v", loaded.Hints[0])
+	}
+	if loaded.Hints[1].CarIndex != 1 || loaded.Hints[1].Hint != models.HintPriceBand {
+		t.Fatalf("unexpected second hint: %+v", loaded.Hints[1])
+	}
+
+	// Hint usage is kept per guess and on the leaderboard
+	guess := &models.ChallengeGuess{CarIndex: 0, CarID: "car1", GuessedPrice: 100, ActualPrice: 100, Points: 4750, HintsUsed: 1, HintPenalty: 250}
+	if err := db.AddChallengeGuess("hint-session", guess); err != nil {
+		t.Fatalf("AddChallengeGuess failed: %v", err)
+	}
+	loaded, err = db.GetChallengeSession("hint-session")
+	if err != nil || len(loaded.Guesses) != 1 || loaded.Guesses[0].HintsUsed != 1 || loaded.Guesses[0].HintPenalty != 250 {
+		t.Fatalf("expected hint usage on guess, got %+v err=%v", loaded.Guesses, err)
+	}
+
+	entry := &models.LeaderboardEntry{Name: "Hinter", Score: 4750, GameMode: "challenge", Difficulty: "hard", SessionID: "hint-session", HintsUsed: 2}
+	if _, err := db.AddSessionLeaderboardEntry(entry); err != nil {
+		t.Fatalf("AddSessionLeaderboardEntry failed: %v", err)
+	}
+	entries, err := db.GetLeaderboard("challenge", "hard", 10)
+	if err != nil || len(entries) != 1 || entries[0].HintsUsed != 2 {
+		t.Fatalf("expected hint count on leaderboard, got %+v err=%v", entries, err)
+	}
+}
@@ -0,0 +1,490 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"autotraderguesser/internal/models"
+)
+
+// ranking is how a game mode's leaderboard is ordered, from the game mode registry
+type ranking struct {
+	higherIsBetter bool
+	tieBreakers    []string // Columns ordering equal scores, smallest first, ending with id
+}
+
+// rankingFor returns the ranking for a game mode. An empty or unknown mode gets the default ranking.
+func rankingFor(gameMode string) ranking {
+	mode, ok := models.LookupGameMode(gameMode)
+	if !ok {
+		mode = models.DefaultGameMode
+	}
+
+	tieBreakers := append(append([]string{}, mode.TieBreakers...), "id")
+	return ranking{higherIsBetter: mode.HigherIsBetter, tieBreakers: tieBreakers}
+}
+
+// better returns the comparison operator meaning "scored better than"
+func (r ranking) better() string {
+	if r.higherIsBetter {
+		return ">"
+	}
+	return "<"
+}
+
+// worse returns the comparison operator meaning "scored worse than"
+func (r ranking) worse() string {
+	if r.higherIsBetter {
+		return "<"
+	}
+	return ">"
+}
+
+// scoreOrder returns the direction that puts the best scores first
+func (r ranking) scoreOrder() string {
+	if r.higherIsBetter {
+		return "DESC"
+	}
+	return "ASC"
+}
+
+// bestScoreAggregate returns the SQL aggregate that picks a player's best score under a ranking
+func (r ranking) bestScoreAggregate() string {
+	if r.higherIsBetter {
+		return "MAX"
+	}
+	return "MIN"
+}
+
+// orderBy returns the ORDER BY list that puts the best entries first
+func (r ranking) orderBy(prefix string) string {
+	return r.orderList(prefix, false)
+}
+
+// reverseOrderBy returns the ORDER BY list that puts the worst entries first
+func (r ranking) reverseOrderBy(prefix string) string {
+	return r.orderList(prefix, true)
+}
+
+// orderList builds an ORDER BY list for the ranking or its reverse
+func (r ranking) orderList(prefix string, reverse bool) string {
+	scoreOrder, tieOrder := r.scoreOrder(), "ASC"
+	if reverse {
+		scoreOrder, tieOrder = flipOrder(scoreOrder), flipOrder(tieOrder)
+	}
+
+	clause := prefix + "score " + scoreOrder
+	for _, column := range r.tieBreakers {
+		clause += ", " + prefix + column + " " + tieOrder
+	}
+	return clause
+}
+
+// tieOrderBy returns the ORDER BY list for entries with equal scores
+func (r ranking) tieOrderBy(reverse bool) string {
+	order := " ASC"
+	if reverse {
+		order = " DESC"
+	}
+	return strings.Join(r.tieBreakers, order+", ") + order
+}
+
+// tieColumns returns the tie-breaker columns as a row value, for comparing positions within a tie
+func (r ranking) tieColumns(prefix string) string {
+	columns := make([]string, len(r.tieBreakers))
+	for i, column := range r.tieBreakers {
+		columns[i] = prefix + column
+	}
+	return "(" + strings.Join(columns, ", ") + ")"
+}
+
+// flipOrder swaps ASC and DESC
+func flipOrder(order string) string {
+	if order == "ASC" {
+		return "DESC"
+	}
+	return "ASC"
+}
+
+// CountEntries returns how many leaderboard entries a game mode and difficulty has
+func (d *Database) CountEntries(gameMode, difficulty string) (int, error) {
+	var count int
+	err := d.db.QueryRow(`
+		SELECT COUNT(*) FROM leaderboard_entries
+		WHERE game_mode = ? AND difficulty = ?
+	`, gameMode, difficulty).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count leaderboard entries: %w", err)
+	}
+
+	return count, nil
+}
+
+// GetRank returns the 1-based position a score holds on a leaderboard.
+// Equal scores share a position, so this is one more than the number of strictly better entries.
+func (d *Database) GetRank(gameMode, difficulty string, score int) (int, error) {
+	r := rankingFor(gameMode)
+
+	// Counting a range of idx_leaderboard_mode_difficulty only touches the entries ranked above
+	var ahead int
+	err := d.db.QueryRow(`
+		SELECT COUNT(*) FROM leaderboard_entries
+		WHERE game_mode = ? AND difficulty = ? AND score `+r.better()+` ?
+	`, gameMode, difficulty, score).Scan(&ahead)
+	if err != nil {
+		return 0, fmt.Errorf("failed to calculate leaderboard rank: %w", err)
+	}
+
+	return ahead + 1, nil
+}
+
+// GetNeighbours returns up to radius entries either side of a leaderboard entry, plus the entry itself,
+// in leaderboard order with their ranks. Entries with equal scores are ordered by the game mode's tie-breakers.
+// Returns nil without error if the entry doesn't exist.
+func (d *Database) GetNeighbours(entryID, radius int) ([]models.RankedLeaderboardEntry, error) {
+	var gameMode, difficulty string
+	err := d.db.QueryRow(`SELECT game_mode, difficulty FROM leaderboard_entries WHERE id = ?`, entryID).
+		Scan(&gameMode, &difficulty)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get leaderboard entry: %w", err)
+	}
+
+	if radius < 0 {
+		radius = 0
+	}
+
+	r := rankingFor(gameMode)
+	ties := r.tieColumns("")
+
+	// The slice is read with index seeks either side of the entry: ties with the entry are walked
+	// in tie-breaker order and everything else by score, so SQLite never sorts more than a tie group
+	// plus the rows it returns. Only the slice is ranked with RANK(). Its top score gets its rank
+	// from a count, and everything below that is offset by the rows above the slice.
+	query := `
+		WITH target AS MATERIALIZED (
+			SELECT ` + leaderboardColumns + ` FROM leaderboard_entries WHERE id = ?
+		),
+		before_entry AS (
+			SELECT * FROM (
+				SELECT * FROM (
+					SELECT ` + leaderboardColumns + ` FROM leaderboard_entries
+					WHERE game_mode = ? AND difficulty = ? AND score = (SELECT score FROM target)
+					AND ` + ties + ` < (SELECT ` + strings.Join(r.tieBreakers, ", ") + ` FROM target)
+					ORDER BY ` + r.tieOrderBy(true) + ` LIMIT ?
+				)
+				UNION ALL
+				SELECT * FROM (
+					SELECT ` + leaderboardColumns + ` FROM leaderboard_entries
+					WHERE game_mode = ? AND difficulty = ? AND score ` + r.better() + ` (SELECT score FROM target)
+					ORDER BY ` + r.reverseOrderBy("") + ` LIMIT ?
+				)
+			)
+			ORDER BY ` + r.reverseOrderBy("") + ` LIMIT ?
+		),
+		from_entry AS (
+			SELECT * FROM (
+				SELECT * FROM (
+					SELECT ` + leaderboardColumns + ` FROM leaderboard_entries
+					WHERE game_mode = ? AND difficulty = ? AND score = (SELECT score FROM target)
+					AND ` + ties + ` >= (SELECT ` + strings.Join(r.tieBreakers, ", ") + ` FROM target)
+					ORDER BY ` + r.tieOrderBy(false) + ` LIMIT ?
+				)
+				UNION ALL
+				SELECT * FROM (
+					SELECT ` + leaderboardColumns + ` FROM leaderboard_entries
+					WHERE game_mode = ? AND difficulty = ? AND score ` + r.worse() + ` (SELECT score FROM target)
+					ORDER BY ` + r.orderBy("") + ` LIMIT ?
+				)
+			)
+			ORDER BY ` + r.orderBy("") + ` LIMIT ?
+		),
+		slice AS (
+			SELECT * FROM before_entry UNION ALL SELECT * FROM from_entry
+		),
+		top AS MATERIALIZED (
+			SELECT * FROM slice ORDER BY ` + r.orderBy("") + ` LIMIT 1
+		),
+		above AS MATERIALIZED (
+			SELECT
+				(SELECT COUNT(*) FROM leaderboard_entries
+				 WHERE game_mode = ? AND difficulty = ? AND score ` + r.better() + ` top.score) AS better_scores,
+				(SELECT COUNT(*) FROM leaderboard_entries
+				 WHERE game_mode = ? AND difficulty = ? AND score = top.score
+				 AND ` + ties + ` < ` + r.tieColumns("top.") + `) AS tied_before
+			FROM top
+		)
+		SELECT slice.id, slice.user_id, slice.username, slice.score, slice.game_mode, slice.difficulty,
+			slice.hints_used, slice.created_at,
+			CASE WHEN slice.score = top.score
+				THEN above.better_scores + 1
+				ELSE above.better_scores + above.tied_before + RANK() OVER (ORDER BY slice.score ` + r.scoreOrder() + `)
+			END AS rank
+		FROM slice, top, above
+		ORDER BY ` + r.orderBy("slice.") + `
+	`
+
+	rows, err := d.db.Query(query, entryID,
+		gameMode, difficulty, radius,
+		gameMode, difficulty, radius, radius,
+		gameMode, difficulty, radius+1,
+		gameMode, difficulty, radius+1, radius+1,
+		gameMode, difficulty, gameMode, difficulty)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query leaderboard neighbours: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []models.RankedLeaderboardEntry
+	for rows.Next() {
+		var entry models.RankedLeaderboardEntry
+		if err := scanLeaderboardEntry(rows, &entry.LeaderboardEntry, &entry.Rank); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// leaderboardColumns are the columns read for a leaderboard entry, in the order scanLeaderboardEntry expects
+const leaderboardColumns = "id, user_id, username, score, game_mode, difficulty, hints_used, created_at"
+
+// playerKey identifies the player behind an entry. Registered users are grouped by ID and, as in
+// GetUserOverallLeaderboardRank, every guest entry counts as a player of its own.
+const playerKey = "CASE WHEN user_id IS NULL OR user_id = 0 THEN 'guest_' || id ELSE CAST(user_id AS TEXT) END"
+
+// leaderboardFilter returns the WHERE clause and arguments selecting a query's game mode, difficulty,
+// window and players
+func leaderboardFilter(q models.LeaderboardQuery) (string, []interface{}) {
+	where := "WHERE 1=1"
+	args := []interface{}{}
+
+	if q.Window != nil {
+		where += " AND created_at >= ? AND created_at < ?"
+		args = append(args, formatDBTime(q.Window.Start), formatDBTime(q.Window.End))
+	}
+	if q.GameMode != "" {
+		where += " AND game_mode = ?"
+		args = append(args, q.GameMode)
+	}
+	if q.Difficulty != "" {
+		where += " AND difficulty = ?"
+		args = append(args, q.Difficulty)
+	}
+	if q.FriendsOf != 0 {
+		where += " AND user_id IN (SELECT ? UNION ALL " + friendIDsQuery + ")"
+		args = append(args, q.FriendsOf, q.FriendsOf, q.FriendsOf, q.FriendsOf)
+	}
+	return where, args
+}
+
+// leaderboardBoard returns a SELECT of the entries on a query's board. The best view keeps each
+// player's top entry by the full ranking, so a player's tied scores keep only the one ranked first.
+func leaderboardBoard(q models.LeaderboardQuery, r ranking) (string, []interface{}) {
+	where, args := leaderboardFilter(q)
+	if !q.BestOnly {
+		return "SELECT " + leaderboardColumns + " FROM leaderboard_entries " + where, args
+	}
+
+	return `
+		SELECT ` + leaderboardColumns + ` FROM (
+			SELECT ` + leaderboardColumns + `,
+				ROW_NUMBER() OVER (PARTITION BY ` + playerKey + ` ORDER BY ` + r.orderBy("") + `) AS player_entry
+			FROM leaderboard_entries ` + where + `
+		)
+		WHERE player_entry = 1
+	`, args
+}
+
+// scanLeaderboardEntry scans the leaderboardColumns of a row, followed by any extra columns
+func scanLeaderboardEntry(rows *sql.Rows, entry *models.LeaderboardEntry, extra ...interface{}) error {
+	var userID sql.NullInt64
+	var createdAt time.Time
+
+	dest := append([]interface{}{&entry.ID, &userID, &entry.Name, &entry.Score, &entry.GameMode, &entry.Difficulty,
+		&entry.HintsUsed, &createdAt}, extra...)
+	if err := rows.Scan(dest...); err != nil {
+		return fmt.Errorf("failed to scan row: %w", err)
+	}
+
+	if userID.Valid {
+		id := int(userID.Int64)
+		entry.UserID = &id
+	}
+	entry.Date = createdAt.Format("2006-01-02 15:04:05")
+	return nil
+}
+
+// QueryLeaderboard returns a page of a leaderboard, best first. Pages after the first start from the
+// entry named by q.After, which must be on the query's game mode and difficulty, or ErrInvalidCursor is
+// returned. Paging is keyed on the cursor entry's place in the ranking rather than an offset, so new
+// submissions never repeat or skip entries between pages.
+func (d *Database) QueryLeaderboard(q models.LeaderboardQuery) ([]models.LeaderboardEntry, error) {
+	r := rankingFor(q.GameMode)
+	board, args := leaderboardBoard(q, r)
+
+	// SQLite treats a negative limit as no limit
+	limit := q.Limit
+	if limit <= 0 {
+		limit = -1
+	}
+
+	var query string
+	if q.After == 0 {
+		query = board + " ORDER BY " + r.orderBy("") + " LIMIT ?"
+		args = append(args, limit)
+	} else {
+		if err := d.checkLeaderboardCursor(q); err != nil {
+			return nil, err
+		}
+
+		// As in GetNeighbours, the rest of the cursor's tie group and the lower scores are read as
+		// separate seeks, since an OR across them stops SQLite walking the index in order
+		query = `
+			WITH board AS ` + materializedIf(q.BestOnly) + ` (` + board + `),
+			cursor AS MATERIALIZED (
+				SELECT score, ` + strings.Join(r.tieBreakers, ", ") + ` FROM leaderboard_entries WHERE id = ?
+			)
+			SELECT * FROM (
+				SELECT * FROM (
+					SELECT * FROM board
+					WHERE score = (SELECT score FROM cursor)
+					AND ` + r.tieColumns("") + ` > (SELECT ` + strings.Join(r.tieBreakers, ", ") + ` FROM cursor)
+					ORDER BY ` + r.tieOrderBy(false) + ` LIMIT ?
+				)
+				UNION ALL
+				SELECT * FROM (
+					SELECT * FROM board
+					WHERE score ` + r.worse() + ` (SELECT score FROM cursor)
+					ORDER BY ` + r.orderBy("") + ` LIMIT ?
+				)
+			)
+			ORDER BY ` + r.orderBy("") + ` LIMIT ?
+		`
+		args = append(args, q.After, limit, limit, limit)
+	}
+
+	rows, err := d.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query leaderboard: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []models.LeaderboardEntry
+	for rows.Next() {
+		var entry models.LeaderboardEntry
+		if err := scanLeaderboardEntry(rows, &entry); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// checkLeaderboardCursor returns ErrInvalidCursor unless the query's cursor entry is on its game mode and difficulty
+func (d *Database) checkLeaderboardCursor(q models.LeaderboardQuery) error {
+	var gameMode, difficulty string
+	err := d.db.QueryRow(`SELECT game_mode, difficulty FROM leaderboard_entries WHERE id = ?`, q.After).
+		Scan(&gameMode, &difficulty)
+	if err == sql.ErrNoRows {
+		return models.ErrInvalidCursor
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get leaderboard cursor: %w", err)
+	}
+
+	if (q.GameMode != "" && gameMode != q.GameMode) || (q.Difficulty != "" && difficulty != q.Difficulty) {
+		return models.ErrInvalidCursor
+	}
+	return nil
+}
+
+// materializedIf returns the CTE hint that makes SQLite compute a board once, for boards that are
+// expensive to build and read more than once
+func materializedIf(expensive bool) string {
+	if expensive {
+		return "MATERIALIZED"
+	}
+	return "NOT MATERIALIZED"
+}
+
+// GetUserBestEntryID returns the ID of a user's highest ranked entry on a query's board, or 0 if they have none
+func (d *Database) GetUserBestEntryID(userID int, q models.LeaderboardQuery) (int, error) {
+	r := rankingFor(q.GameMode)
+	where, args := leaderboardFilter(q)
+
+	var entryID int
+	err := d.db.QueryRow(`
+		SELECT id FROM leaderboard_entries `+where+` AND user_id = ?
+		ORDER BY `+r.orderBy("")+` LIMIT 1
+	`, append(args, userID)...).Scan(&entryID)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get user's best leaderboard entry: %w", err)
+	}
+
+	return entryID, nil
+}
+
+// GetBoardNeighbours returns up to radius entries either side of an entry on a query's board, with
+// their ranks on that board. Returns nil without error if the entry isn't on the board.
+func (d *Database) GetBoardNeighbours(q models.LeaderboardQuery, entryID, radius int) ([]models.RankedLeaderboardEntry, error) {
+	// The full all-time board of one mode and difficulty is served from index seeks
+	if !q.BestOnly && q.Window == nil && q.FriendsOf == 0 && q.GameMode != "" && q.Difficulty != "" {
+		if err := d.checkLeaderboardCursor(models.LeaderboardQuery{GameMode: q.GameMode, Difficulty: q.Difficulty, After: entryID}); err != nil {
+			if errors.Is(err, models.ErrInvalidCursor) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		return d.GetNeighbours(entryID, radius)
+	}
+
+	if radius < 0 {
+		radius = 0
+	}
+
+	// Other boards have to be built to be ranked, so they're ranked whole and sliced by position
+	r := rankingFor(q.GameMode)
+	board, args := leaderboardBoard(q, r)
+	query := `
+		WITH ranked AS MATERIALIZED (
+			SELECT ` + leaderboardColumns + `,
+				RANK() OVER (ORDER BY score ` + r.scoreOrder() + `) AS rank,
+				ROW_NUMBER() OVER (ORDER BY ` + r.orderBy("") + `) AS position
+			FROM (` + board + `)
+		),
+		target AS (
+			SELECT position FROM ranked WHERE id = ?
+		)
+		SELECT ` + leaderboardColumns + `, rank FROM ranked
+		WHERE position BETWEEN (SELECT position FROM target) - ? AND (SELECT position FROM target) + ?
+		ORDER BY position
+	`
+
+	rows, err := d.db.Query(query, append(args, entryID, radius, radius)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query leaderboard neighbours: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []models.RankedLeaderboardEntry
+	for rows.Next() {
+		var entry models.RankedLeaderboardEntry
+		if err := scanLeaderboardEntry(rows, &entry.LeaderboardEntry, &entry.Rank); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
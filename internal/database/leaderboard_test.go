@@ -0,0 +1,436 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	mathrand "math/rand"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"autotraderguesser/internal/models"
+)
+
+func addRankedTestEntry(t *testing.T, db *Database, mode, difficulty string, score int) int {
+	t.Helper()
+	return addRankedTestEntryWithHints(t, db, mode, difficulty, score, 0)
+}
+
+func addRankedTestEntryWithHints(t *testing.T, db *Database, mode, difficulty string, score, hints int) int {
+	t.Helper()
+	entry := &models.LeaderboardEntry{
+		Name:       fmt.Sprintf("player-%d", score),
+		Score:      score,
+		GameMode:   mode,
+		Difficulty: difficulty,
+		SessionID:  fmt.Sprintf("%s-%s-%d-%d", mode, difficulty, score, mathrand.Int()),
+		HintsUsed:  hints,
+	}
+	if _, err := db.AddSessionLeaderboardEntry(entry); err != nil {
+		t.Fatalf("AddSessionLeaderboardEntry failed: %v", err)
+	}
+	return entry.ID
+}
+
+func TestLeaderboardRankAndCount(t *testing.T) {
+	db := newTestDatabase(t)
+	defer db.Close()
+
+	for _, score := range []int{500, 400, 400, 300} {
+		addRankedTestEntry(t, db, "challenge", "hard", score)
+	}
+	addRankedTestEntry(t, db, "challenge", "easy", 9000)
+	for _, score := range []int{2, 5, 5} {
+		addRankedTestEntry(t, db, "zero", "hard", score)
+	}
+
+	if count, err := db.CountEntries("challenge", "hard"); err != nil || count != 4 {
+		t.Fatalf("expected 4 challenge hard entries, got %d err=%v", count, err)
+	}
+	if count, err := db.CountEntries("streak", "easy"); err != nil || count != 0 {
+		t.Fatalf("expected no streak easy entries, got %d err=%v", count, err)
+	}
+
+	ranks := []struct {
+		mode  string
+		score int
+		want  int
+	}{
+		{"challenge", 600, 1},
+		{"challenge", 500, 1},
+		{"challenge", 400, 2},
+		{"challenge", 350, 4},
+		{"challenge", 300, 4},
+		{"challenge", 100, 5},
+		{"zero", 1, 1},
+		{"zero", 5, 2},
+		{"zero", 8, 4},
+	}
+	for _, tc := range ranks {
+		rank, err := db.GetRank(tc.mode, "hard", tc.score)
+		if err != nil {
+			t.Fatalf("GetRank failed: %v", err)
+		}
+		if rank != tc.want {
+			t.Errorf("%s score %d: expected rank %d, got %d", tc.mode, tc.score, tc.want, rank)
+		}
+	}
+}
+
+func TestLeaderboardNeighbours(t *testing.T) {
+	db := newTestDatabase(t)
+	defer db.Close()
+
+	var ids []int
+	for _, score := range []int{500, 400, 400, 400, 300, 200} {
+		ids = append(ids, addRankedTestEntry(t, db, "challenge", "hard", score))
+	}
+	addRankedTestEntry(t, db, "challenge", "easy", 450)
+
+	type neighbour struct{ id, rank int }
+	check := func(entryID, radius int, want []neighbour) {
+		t.Helper()
+		entries, err := db.GetNeighbours(entryID, radius)
+		if err != nil {
+			t.Fatalf("GetNeighbours failed: %v", err)
+		}
+		got := make([]neighbour, len(entries))
+		for i, e := range entries {
+			got[i] = neighbour{e.ID, e.Rank}
+		}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Fatalf("neighbours of %d within %d: expected %v, got %v", entryID, radius, want, got)
+		}
+	}
+
+	// Ties are ordered oldest first and share a rank
+	check(ids[3], 2, []neighbour{{ids[1], 2}, {ids[2], 2}, {ids[3], 2}, {ids[4], 5}, {ids[5], 6}})
+	// The slice starts part way through a tie
+	check(ids[4], 1, []neighbour{{ids[3], 2}, {ids[4], 5}, {ids[5], 6}})
+	check(ids[2], 0, []neighbour{{ids[2], 2}})
+	// Edges of the board
+	check(ids[0], 2, []neighbour{{ids[0], 1}, {ids[1], 2}, {ids[2], 2}})
+	check(ids[5], 1, []neighbour{{ids[4], 5}, {ids[5], 6}})
+
+	entries, err := db.GetNeighbours(9999, 2)
+	if err != nil || entries != nil {
+		t.Fatalf("expected nothing for a missing entry, got %v err=%v", entries, err)
+	}
+}
+
+func TestLeaderboardModeOrdering(t *testing.T) {
+	db := newTestDatabase(t)
+	defer db.Close()
+
+	// Streaks rank highest first, zero mode lowest first
+	for _, score := range []int{3, 12, 7} {
+		addRankedTestEntry(t, db, "streak", "easy", score)
+		addRankedTestEntry(t, db, "zero", "easy", score)
+	}
+
+	scores := func(mode string) []int {
+		t.Helper()
+		entries, err := db.GetLeaderboard(mode, "easy", 0)
+		if err != nil {
+			t.Fatalf("GetLeaderboard failed: %v", err)
+		}
+		var result []int
+		for _, e := range entries {
+			result = append(result, e.Score)
+		}
+		return result
+	}
+	if got := fmt.Sprint(scores("streak")); got != "[12 7 3]" {
+		t.Fatalf("expected streak leaderboard highest first, got %s", got)
+	}
+	if got := fmt.Sprint(scores("zero")); got != "[3 7 12]" {
+		t.Fatalf("expected zero leaderboard lowest first, got %s", got)
+	}
+	if rank, err := db.GetRank("streak", "easy", 10); err != nil || rank != 2 {
+		t.Fatalf("expected streak of 10 to rank 2nd, got %d err=%v", rank, err)
+	}
+
+	// Equal challenge scores go to whoever used fewer hints, then whoever submitted first
+	manyHints := addRankedTestEntryWithHints(t, db, "challenge", "easy", 400, 2)
+	firstNoHints := addRankedTestEntryWithHints(t, db, "challenge", "easy", 400, 0)
+	secondNoHints := addRankedTestEntryWithHints(t, db, "challenge", "easy", 400, 0)
+	best := addRankedTestEntryWithHints(t, db, "challenge", "easy", 500, 3)
+
+	neighbours, err := db.GetNeighbours(secondNoHints, 5)
+	if err != nil {
+		t.Fatalf("GetNeighbours failed: %v", err)
+	}
+	var got []string
+	for _, e := range neighbours {
+		got = append(got, fmt.Sprintf("%d@%d", e.ID, e.Rank))
+	}
+	want := []string{fmt.Sprintf("%d@1", best), fmt.Sprintf("%d@2", firstNoHints), fmt.Sprintf("%d@2", secondNoHints), fmt.Sprintf("%d@2", manyHints)}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("expected tie-broken order %v, got %v", want, got)
+	}
+}
+
+func TestLeaderboardBestViewAndPaging(t *testing.T) {
+	db := newTestDatabase(t)
+	defer db.Close()
+
+	userIDs := map[string]*int{}
+	for _, name := range []string{"alice", "bob"} {
+		user := &models.User{Username: name, PasswordHash: "hash", DisplayName: name, SessionToken: "token-" + name}
+		if err := db.CreateUser(user); err != nil {
+			t.Fatalf("CreateUser failed: %v", err)
+		}
+		userIDs[name] = &user.ID
+	}
+
+	ids := map[string]int{}
+	add := func(label, name string, score int) {
+		t.Helper()
+		entry := &models.LeaderboardEntry{
+			UserID:     userIDs[name],
+			Name:       name,
+			Score:      score,
+			GameMode:   "challenge",
+			Difficulty: "hard",
+			SessionID:  "session-" + label,
+		}
+		if _, err := db.AddSessionLeaderboardEntry(entry); err != nil {
+			t.Fatalf("AddSessionLeaderboardEntry failed: %v", err)
+		}
+		ids[label] = entry.ID
+	}
+	add("alice500", "alice", 500)
+	add("alice300", "alice", 300)
+	add("bob400", "bob", 400)
+	add("bob400again", "bob", 400)
+	add("guest450", "guest", 450)
+	add("guest200", "guest", 200) // Guests share a name but each entry is its own player
+	easy := addRankedTestEntry(t, db, "challenge", "easy", 100)
+
+	labels := make(map[int]string, len(ids))
+	for label, id := range ids {
+		labels[id] = label
+	}
+	page := func(q models.LeaderboardQuery) string {
+		t.Helper()
+		q.GameMode, q.Difficulty = "challenge", "hard"
+		entries, err := db.QueryLeaderboard(q)
+		if err != nil {
+			t.Fatalf("QueryLeaderboard failed: %v", err)
+		}
+		var got []string
+		for _, e := range entries {
+			got = append(got, labels[e.ID])
+		}
+		return fmt.Sprint(got)
+	}
+
+	if got := page(models.LeaderboardQuery{}); got != "[alice500 guest450 bob400 bob400again alice300 guest200]" {
+		t.Fatalf("unexpected full leaderboard %s", got)
+	}
+	if got := page(models.LeaderboardQuery{BestOnly: true}); got != "[alice500 guest450 bob400 guest200]" {
+		t.Fatalf("unexpected best-per-player leaderboard %s", got)
+	}
+
+	// Pages pick up after the cursor, including part way through a tie
+	if got := page(models.LeaderboardQuery{After: ids["bob400"], Limit: 2}); got != "[bob400again alice300]" {
+		t.Fatalf("unexpected page after a tied entry %s", got)
+	}
+	if got := page(models.LeaderboardQuery{BestOnly: true, After: ids["guest450"], Limit: 2}); got != "[bob400 guest200]" {
+		t.Fatalf("unexpected best-per-player page %s", got)
+	}
+	if got := page(models.LeaderboardQuery{BestOnly: true, After: ids["guest200"]}); got != "[]" {
+		t.Fatalf("expected nothing after the last entry, got %s", got)
+	}
+
+	for _, after := range []int{9999, easy} {
+		_, err := db.QueryLeaderboard(models.LeaderboardQuery{GameMode: "challenge", Difficulty: "hard", After: after})
+		if !errors.Is(err, models.ErrInvalidCursor) {
+			t.Fatalf("expected ErrInvalidCursor for cursor %d, got %v", after, err)
+		}
+	}
+
+	// Neighbours on the best-per-player board are ranked by players, not entries
+	if id, err := db.GetUserBestEntryID(*userIDs["bob"], models.LeaderboardQuery{GameMode: "challenge", Difficulty: "hard"}); err != nil || id != ids["bob400"] {
+		t.Fatalf("expected bob's best entry %d, got %d err=%v", ids["bob400"], id, err)
+	}
+	around := func(q models.LeaderboardQuery, label string, radius int) string {
+		t.Helper()
+		q.GameMode, q.Difficulty = "challenge", "hard"
+		entries, err := db.GetBoardNeighbours(q, ids[label], radius)
+		if err != nil {
+			t.Fatalf("GetBoardNeighbours failed: %v", err)
+		}
+		var got []string
+		for _, e := range entries {
+			got = append(got, fmt.Sprintf("%s@%d", labels[e.ID], e.Rank))
+		}
+		return fmt.Sprint(got)
+	}
+	if got := around(models.LeaderboardQuery{BestOnly: true}, "bob400", 1); got != "[guest450@2 bob400@3 guest200@4]" {
+		t.Fatalf("unexpected best-per-player neighbours %s", got)
+	}
+	if got := around(models.LeaderboardQuery{BestOnly: true}, "alice300", 1); got != "[]" {
+		t.Fatalf("expected no neighbours for an entry off the best-per-player board, got %s", got)
+	}
+	if got := around(models.LeaderboardQuery{}, "alice300", 1); got != "[bob400again@3 alice300@5 guest200@6]" {
+		t.Fatalf("unexpected neighbours %s", got)
+	}
+	window, _ := models.PeriodContaining(models.PeriodWeek, time.Now())
+	if got := around(models.LeaderboardQuery{Window: &window}, "alice300", 1); got != "[bob400again@3 alice300@5 guest200@6]" {
+		t.Fatalf("unexpected neighbours this week %s", got)
+	}
+	if id, err := db.GetUserBestEntryID(9999, models.LeaderboardQuery{GameMode: "challenge", Difficulty: "hard"}); err != nil || id != 0 {
+		t.Fatalf("expected no entry for an unknown user, got %d err=%v", id, err)
+	}
+
+	// A new leader doesn't shift the next page
+	add("guest600", "guest", 600)
+	labels[ids["guest600"]] = "guest600"
+	if got := page(models.LeaderboardQuery{After: ids["guest450"], Limit: 2}); got != "[bob400 bob400again]" {
+		t.Fatalf("expected the next page to be unaffected by a new entry, got %s", got)
+	}
+}
+
+// The benchmarks share one seeded database because filling it takes several seconds:
+//
+//	go test ./internal/database -run '^$' -bench Leaderboard
+const benchLeaderboardRows = 1_000_000
+
+var (
+	benchLeaderboardOnce sync.Once
+	benchLeaderboardDB   *Database
+	benchLeaderboardDir  string
+	benchLeaderboardErr  error
+)
+
+// leaderboardBenchDatabase returns a database with benchLeaderboardRows entries, nine in ten of
+// them on the hard challenge leaderboard
+func leaderboardBenchDatabase(b *testing.B) *Database {
+	b.Helper()
+	benchLeaderboardOnce.Do(func() {
+		benchLeaderboardDir, benchLeaderboardErr = os.MkdirTemp("", "leaderboard-bench")
+		if benchLeaderboardErr != nil {
+			return
+		}
+		benchLeaderboardDB, benchLeaderboardErr = NewDatabase(filepath.Join(benchLeaderboardDir, "bench.db"))
+		if benchLeaderboardErr != nil {
+			return
+		}
+		benchLeaderboardErr = seedLeaderboard(benchLeaderboardDB, benchLeaderboardRows)
+	})
+	if benchLeaderboardErr != nil {
+		b.Fatalf("failed to set up benchmark database: %v", benchLeaderboardErr)
+	}
+	return benchLeaderboardDB
+}
+
+func seedLeaderboard(d *Database, rows int) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`INSERT INTO leaderboard_entries (username, score, game_mode, difficulty) VALUES (?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	rng := mathrand.New(mathrand.NewSource(1))
+	for i := 0; i < rows; i++ {
+		mode, difficulty := "challenge", "hard"
+		if i%10 == 0 {
+			mode, difficulty = "streak", "easy"
+		}
+		if _, err := stmt.Exec(fmt.Sprintf("player-%d", i), rng.Intn(50000), mode, difficulty); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// closeLeaderboardBenchDatabase removes the shared benchmark database, if one was created
+func closeLeaderboardBenchDatabase() {
+	if benchLeaderboardDB != nil {
+		benchLeaderboardDB.Close()
+	}
+	if benchLeaderboardDir != "" {
+		os.RemoveAll(benchLeaderboardDir)
+	}
+}
+
+func BenchmarkLeaderboardGetRank(b *testing.B) {
+	db := leaderboardBenchDatabase(b)
+	rng := mathrand.New(mathrand.NewSource(2))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := db.GetRank("challenge", "hard", rng.Intn(50000)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkLeaderboardRankByLoadingAll is the previous approach for comparison: load the whole
+// leaderboard and walk it in Go
+func BenchmarkLeaderboardRankByLoadingAll(b *testing.B) {
+	db := leaderboardBenchDatabase(b)
+	rng := mathrand.New(mathrand.NewSource(2))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		score := rng.Intn(50000)
+		entries, err := db.GetLeaderboard("challenge", "hard", 0)
+		if err != nil {
+			b.Fatal(err)
+		}
+		for position, e := range entries {
+			if e.Score <= score {
+				_ = position + 1
+				break
+			}
+		}
+	}
+}
+
+func BenchmarkLeaderboardCountEntries(b *testing.B) {
+	db := leaderboardBenchDatabase(b)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := db.CountEntries("challenge", "hard"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkLeaderboardQueryPage(b *testing.B) {
+	db := leaderboardBenchDatabase(b)
+	rng := mathrand.New(mathrand.NewSource(4))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// Every tenth row is on the streak board, which would be an invalid cursor
+		after := 10*rng.Intn(benchLeaderboardRows/10) + 2
+		if _, err := db.QueryLeaderboard(models.LeaderboardQuery{GameMode: "challenge", Difficulty: "hard", After: after, Limit: 10}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkLeaderboardGetNeighbours(b *testing.B) {
+	db := leaderboardBenchDatabase(b)
+	rng := mathrand.New(mathrand.NewSource(3))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := db.GetNeighbours(1+rng.Intn(benchLeaderboardRows), 5); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
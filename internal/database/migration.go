@@ -103,68 +103,30 @@ func (d *Database) MigrateLeaderboardFromJSON(jsonPath string) error {
 
 // GetLeaderboard retrieves leaderboard entries with filtering
 func (d *Database) GetLeaderboard(gameMode, difficulty string, limit int) ([]models.LeaderboardEntry, error) {
-	query := `
-		SELECT username, score, game_mode, difficulty, created_at
-		FROM leaderboard_entries
-		WHERE 1=1
-	`
-	args := []interface{}{}
-
-	if gameMode != "" {
-		query += " AND game_mode = ?"
-		args = append(args, gameMode)
-	}
-
-	if difficulty != "" {
-		query += " AND difficulty = ?"
-		args = append(args, difficulty)
-	}
-
-	// Sort by score (descending for challenge, ascending for streak/zero)
-	if gameMode == "challenge" {
-		query += " ORDER BY score DESC"
-	} else {
-		query += " ORDER BY score ASC"
-	}
-
-	if limit > 0 {
-		query += " LIMIT ?"
-		args = append(args, limit)
-	}
-
-	rows, err := d.db.Query(query, args...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query leaderboard: %w", err)
-	}
-	defer rows.Close()
-
-	var entries []models.LeaderboardEntry
-	for rows.Next() {
-		var entry models.LeaderboardEntry
-		var createdAt time.Time
-
-		err := rows.Scan(&entry.Name, &entry.Score, &entry.GameMode, &entry.Difficulty, &createdAt)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan row: %w", err)
-		}
-
-		entry.Date = createdAt.Format("2006-01-02 15:04:05")
-		entries = append(entries, entry)
-	}
+	return d.GetPeriodLeaderboard(gameMode, difficulty, nil, limit)
+}
 
-	return entries, nil
+// GetPeriodLeaderboard retrieves leaderboard entries submitted within a period window.
+// A nil window covers all time.
+func (d *Database) GetPeriodLeaderboard(gameMode, difficulty string, window *models.PeriodWindow, limit int) ([]models.LeaderboardEntry, error) {
+	return d.QueryLeaderboard(models.LeaderboardQuery{
+		GameMode:   gameMode,
+		Difficulty: difficulty,
+		Window:     window,
+		Limit:      limit,
+	})
 }
 
 // AddLeaderboardEntry adds a new leaderboard entry
 func (d *Database) AddLeaderboardEntry(entry *models.LeaderboardEntry) error {
 	query := `
 		INSERT INTO leaderboard_entries 
-		(user_id, username, score, game_mode, difficulty, session_id, friend_challenge_id)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
+		(user_id, username, score, game_mode, difficulty, session_id, friend_challenge_id, hints_used)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	_, err := d.db.Exec(query, entry.UserID, entry.Name, entry.Score,
-		entry.GameMode, entry.Difficulty, entry.SessionID, entry.FriendChallengeID)
+		entry.GameMode, entry.Difficulty, entry.SessionID, entry.FriendChallengeID, entry.HintsUsed)
 
 	if err != nil {
 		return fmt.Errorf("failed to add leaderboard entry: %w", err)
@@ -173,6 +135,36 @@ func (d *Database) AddLeaderboardEntry(entry *models.LeaderboardEntry) error {
 	return nil
 }
 
+// AddSessionLeaderboardEntry adds a leaderboard entry for a game session unless that session
+// already has one. Returns false if the session was already submitted.
+func (d *Database) AddSessionLeaderboardEntry(entry *models.LeaderboardEntry) (bool, error) {
+	query := `
+		INSERT INTO leaderboard_entries
+		(user_id, username, score, game_mode, difficulty, session_id, friend_challenge_id, hints_used)
+		SELECT ?, ?, ?, ?, ?, ?, ?, ?
+		WHERE NOT EXISTS (SELECT 1 FROM leaderboard_entries WHERE session_id = ?)
+	`
+
+	result, err := d.db.Exec(query, entry.UserID, entry.Name, entry.Score,
+		entry.GameMode, entry.Difficulty, entry.SessionID, entry.FriendChallengeID, entry.HintsUsed, entry.SessionID)
+	if err != nil {
+		return false, fmt.Errorf("failed to add leaderboard entry: %w", err)
+	}
+
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to add leaderboard entry: %w", err)
+	}
+
+	if inserted > 0 {
+		if id, err := result.LastInsertId(); err == nil {
+			entry.ID = int(id)
+		}
+	}
+
+	return inserted > 0, nil
+}
+
 // BackupCurrentData creates a backup of current JSON files before migration
 func (d *Database) BackupCurrentData(dataDir string) error {
 	backupDir := fmt.Sprintf("%s/backup_%d", dataDir, time.Now().Unix())
@@ -138,3 +138,36 @@ func TestLeaderboardQueriesAndBackup(t *testing.T) {
 		}
 	}
 }
+
+func TestAddSessionLeaderboardEntryOncePerSession(t *testing.T) {
+	db := newTestDatabase(t)
+	defer db.Close()
+
+	first := models.LeaderboardEntry{Name: "Alice", Score: 5, GameMode: "streak", Difficulty: "hard", SessionID: "session-one"}
+	inserted, err := db.AddSessionLeaderboardEntry(&first)
+	if err != nil || !inserted {
+		t.Fatalf("expected first submission to be inserted, got %v err=%v", inserted, err)
+	}
+	if first.ID == 0 {
+		t.Fatalf("expected entry ID to be set")
+	}
+
+	dup := models.LeaderboardEntry{Name: "Alice", Score: 9, GameMode: "streak", Difficulty: "hard", SessionID: "session-one"}
+	inserted, err = db.AddSessionLeaderboardEntry(&dup)
+	if err != nil || inserted {
+		t.Fatalf("expected duplicate submission to be skipped, got %v err=%v", inserted, err)
+	}
+
+	other := models.LeaderboardEntry{Name: "Bob", Score: 2, GameMode: "streak", Difficulty: "hard", SessionID: "session-two"}
+	if inserted, err := db.AddSessionLeaderboardEntry(&other); err != nil || !inserted {
+		t.Fatalf("expected other session to be inserted, got %v err=%v", inserted, err)
+	}
+
+	entries, err := db.GetLeaderboard("streak", "hard", 0)
+	if err != nil {
+		t.Fatalf("GetLeaderboard failed: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected two entries, got %v", entries)
+	}
+}
@@ -0,0 +1,151 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"autotraderguesser/internal/models"
+)
+
+// formatDBTime formats a time the way SQLite's CURRENT_TIMESTAMP stores it, so it compares as text
+func formatDBTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04:05")
+}
+
+// ArchivePeriodStandings stores the top entries of a closed period's leaderboard.
+// Returns how many standings were archived; a board that was already archived is left as it is.
+func (d *Database) ArchivePeriodStandings(window models.PeriodWindow, gameMode, difficulty string, limit int) (int, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var archived bool
+	err = tx.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM leaderboard_archive
+			WHERE period = ? AND game_mode = ? AND difficulty = ? AND period_key = ?
+		)
+	`, window.Period, gameMode, difficulty, window.Key).Scan(&archived)
+	if err != nil {
+		return 0, fmt.Errorf("failed to check leaderboard archive: %w", err)
+	}
+	if archived {
+		return 0, nil
+	}
+
+	// Ranks are taken across the whole period before the standings are cut to the limit
+	r := rankingFor(gameMode)
+	result, err := tx.Exec(`
+		INSERT INTO leaderboard_archive
+		(period, period_key, game_mode, difficulty, position, rank, entry_id, user_id, username, score, hints_used, submitted_at)
+		SELECT ?, ?, game_mode, difficulty,
+			ROW_NUMBER() OVER (ORDER BY `+r.orderBy("")+`),
+			RANK() OVER (ORDER BY score `+r.scoreOrder()+`),
+			id, user_id, username, score, hints_used, created_at
+		FROM leaderboard_entries
+		WHERE game_mode = ? AND difficulty = ? AND created_at >= ? AND created_at < ?
+		ORDER BY `+r.orderBy("")+`
+		LIMIT ?
+	`, window.Period, window.Key, gameMode, difficulty, formatDBTime(window.Start), formatDBTime(window.End), limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to archive leaderboard standings: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit leaderboard archive: %w", err)
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to archive leaderboard standings: %w", err)
+	}
+	return int(count), nil
+}
+
+// LastArchivedPeriodKey returns the newest key archived for a period on any leaderboard, or "" if none has been
+func (d *Database) LastArchivedPeriodKey(period string) (string, error) {
+	var key string
+	err := d.db.QueryRow(`SELECT COALESCE(MAX(period_key), '') FROM leaderboard_archive WHERE period = ?`, period).Scan(&key)
+	if err != nil {
+		return "", fmt.Errorf("failed to get last archived period: %w", err)
+	}
+	return key, nil
+}
+
+// EarliestLeaderboardEntry returns when the first leaderboard entry was submitted, or nil if there are none
+func (d *Database) EarliestLeaderboardEntry() (*time.Time, error) {
+	var createdAt time.Time
+	err := d.db.QueryRow(`SELECT created_at FROM leaderboard_entries ORDER BY created_at ASC LIMIT 1`).Scan(&createdAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get earliest leaderboard entry: %w", err)
+	}
+	return &createdAt, nil
+}
+
+// GetArchivedPeriods returns the most recent archived periods of a leaderboard, newest first,
+// with each period's top standings. An empty key returns up to limit periods; otherwise only that period.
+func (d *Database) GetArchivedPeriods(period, gameMode, difficulty, key string, limit, top int) ([]models.ArchivedPeriod, error) {
+	query := `
+		WITH periods AS (
+			SELECT DISTINCT period_key FROM leaderboard_archive
+			WHERE period = ? AND game_mode = ? AND difficulty = ? AND (? = '' OR period_key = ?)
+			ORDER BY period_key DESC
+			LIMIT ?
+		)
+		SELECT period_key, rank, entry_id, user_id, username, score, hints_used, submitted_at
+		FROM leaderboard_archive
+		WHERE period = ? AND game_mode = ? AND difficulty = ?
+		AND period_key IN (SELECT period_key FROM periods) AND position <= ?
+		ORDER BY period_key DESC, position ASC
+	`
+
+	rows, err := d.db.Query(query, period, gameMode, difficulty, key, key, limit, period, gameMode, difficulty, top)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query leaderboard archive: %w", err)
+	}
+	defer rows.Close()
+
+	var periods []models.ArchivedPeriod
+	for rows.Next() {
+		var periodKey string
+		var entry models.RankedLeaderboardEntry
+		var entryID, userID sql.NullInt64
+		var submittedAt sql.NullTime
+
+		if err := rows.Scan(&periodKey, &entry.Rank, &entryID, &userID, &entry.Name, &entry.Score,
+			&entry.HintsUsed, &submittedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		if entryID.Valid {
+			entry.ID = int(entryID.Int64)
+		}
+		if userID.Valid {
+			id := int(userID.Int64)
+			entry.UserID = &id
+		}
+		if submittedAt.Valid {
+			entry.Date = submittedAt.Time.Format("2006-01-02 15:04:05")
+		}
+		entry.GameMode = gameMode
+		entry.Difficulty = difficulty
+
+		if len(periods) == 0 || periods[len(periods)-1].Key != periodKey {
+			window, ok := models.ParsePeriodKey(period, periodKey)
+			if !ok {
+				window = models.PeriodWindow{Period: period, Key: periodKey}
+			}
+			periods = append(periods, models.ArchivedPeriod{PeriodWindow: window, GameMode: gameMode, Difficulty: difficulty})
+		}
+		current := &periods[len(periods)-1]
+		current.Standings = append(current.Standings, entry)
+	}
+
+	return periods, rows.Err()
+}
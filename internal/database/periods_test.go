@@ -0,0 +1,105 @@
+package database
+
+import (
+	"testing"
+
+	"autotraderguesser/internal/models"
+)
+
+func TestPeriodLeaderboardsAndArchive(t *testing.T) {
+	db := newTestDatabase(t)
+	defer db.Close()
+
+	add := func(name string, score int, createdAt string) {
+		t.Helper()
+		_, err := db.db.Exec(`
+			INSERT INTO leaderboard_entries (username, score, game_mode, difficulty, created_at)
+			VALUES (?, ?, 'challenge', 'hard', ?)
+		`, name, score, createdAt)
+		if err != nil {
+			t.Fatalf("failed to insert entry: %v", err)
+		}
+	}
+	add("Early", 500, "2025-05-26 09:00:00")
+	add("Late", 500, "2025-06-01 23:59:59")
+	add("Third", 300, "2025-05-28 12:00:00")
+	add("NextWeek", 400, "2025-06-02 00:00:00")
+
+	lastWeek, _ := models.ParsePeriodKey(models.PeriodWeek, "2025-W22")
+	thisWeek, _ := models.ParsePeriodKey(models.PeriodWeek, "2025-W23")
+
+	names := func(window *models.PeriodWindow) []string {
+		t.Helper()
+		entries, err := db.GetPeriodLeaderboard("challenge", "hard", window, 0)
+		if err != nil {
+			t.Fatalf("GetPeriodLeaderboard failed: %v", err)
+		}
+		var result []string
+		for _, e := range entries {
+			result = append(result, e.Name)
+		}
+		return result
+	}
+	if got := names(&lastWeek); len(got) != 3 || got[0] != "Early" || got[1] != "Late" || got[2] != "Third" {
+		t.Fatalf("unexpected last week leaderboard: %v", got)
+	}
+	if got := names(&thisWeek); len(got) != 1 || got[0] != "NextWeek" {
+		t.Fatalf("unexpected this week leaderboard: %v", got)
+	}
+	if got := names(nil); len(got) != 4 {
+		t.Fatalf("expected all-time leaderboard to have every entry, got %v", got)
+	}
+
+	// Standings are cut to the limit, but ranks count the whole period
+	if archived, err := db.ArchivePeriodStandings(lastWeek, "challenge", "hard", 2); err != nil || archived != 2 {
+		t.Fatalf("expected 2 standings archived, got %d err=%v", archived, err)
+	}
+	if archived, err := db.ArchivePeriodStandings(lastWeek, "challenge", "hard", 2); err != nil || archived != 0 {
+		t.Fatalf("expected an archived board to be left alone, got %d err=%v", archived, err)
+	}
+	if archived, err := db.ArchivePeriodStandings(thisWeek, "challenge", "hard", 2); err != nil || archived != 1 {
+		t.Fatalf("expected 1 standing archived, got %d err=%v", archived, err)
+	}
+
+	history, err := db.GetArchivedPeriods(models.PeriodWeek, "challenge", "hard", "", 10, 3)
+	if err != nil {
+		t.Fatalf("GetArchivedPeriods failed: %v", err)
+	}
+	if len(history) != 2 || history[0].Key != "2025-W23" || history[1].Key != "2025-W22" {
+		t.Fatalf("expected newest period first, got %+v", history)
+	}
+	winners := history[1].Standings
+	if len(winners) != 2 || winners[0].Name != "Early" || winners[0].Rank != 1 || winners[1].Name != "Late" || winners[1].Rank != 1 {
+		t.Fatalf("unexpected archived standings: %+v", winners)
+	}
+	if !history[1].Start.Equal(lastWeek.Start) || history[1].GameMode != "challenge" {
+		t.Fatalf("expected archived period window and board, got %+v", history[1].PeriodWindow)
+	}
+
+	single, err := db.GetArchivedPeriods(models.PeriodWeek, "challenge", "hard", "2025-W22", 1, 1)
+	if err != nil || len(single) != 1 || single[0].Key != "2025-W22" || len(single[0].Standings) != 1 {
+		t.Fatalf("unexpected single period history: %+v err=%v", single, err)
+	}
+
+	if none, err := db.GetArchivedPeriods(models.PeriodMonth, "challenge", "hard", "", 10, 3); err != nil || len(none) != 0 {
+		t.Fatalf("expected no monthly history, got %+v err=%v", none, err)
+	}
+	if key, err := db.LastArchivedPeriodKey(models.PeriodWeek); err != nil || key != "2025-W23" {
+		t.Fatalf("expected 2025-W23 to be the last archived week, got %q err=%v", key, err)
+	}
+	if key, err := db.LastArchivedPeriodKey(models.PeriodMonth); err != nil || key != "" {
+		t.Fatalf("expected no archived month, got %q err=%v", key, err)
+	}
+	if earliest, err := db.EarliestLeaderboardEntry(); err != nil || earliest == nil || earliest.Format("2006-01-02 15:04:05") != "2025-05-26 09:00:00" {
+		t.Fatalf("expected the earliest entry on 2025-05-26, got %v err=%v", earliest, err)
+	}
+}
+
+func TestEarliestLeaderboardEntryEmpty(t *testing.T) {
+	db := newTestDatabase(t)
+	defer db.Close()
+
+	if earliest, err := db.EarliestLeaderboardEntry(); err != nil || earliest != nil {
+		t.Fatalf("expected no earliest entry, got %v err=%v", earliest, err)
+	}
+}
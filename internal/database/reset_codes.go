@@ -0,0 +1,150 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"autotraderguesser/internal/models"
+)
+
+// hashResetCode returns the digest stored in place of a reset code.
+// Codes are matched ignoring case, spaces and dashes so they can be typed back as displayed.
+func hashResetCode(code string) string {
+	normalized := strings.NewReplacer("-", "", " ", "").Replace(strings.ToUpper(strings.TrimSpace(code)))
+	return hashSessionToken(normalized)
+}
+
+func insertResetCode(tx *sql.Tx, userID int, kind, code string, expiresAt interface{}) error {
+	if _, err := tx.Exec(`
+		INSERT INTO password_reset_codes (user_id, kind, code_hash, expires_at)
+		VALUES (?, ?, ?, ?)
+	`, userID, kind, hashResetCode(code), expiresAt); err != nil {
+		return fmt.Errorf("failed to store reset code: %w", err)
+	}
+	return nil
+}
+
+// replaceResetCodes replaces a user's codes of one kind with a new set that never expires
+func replaceResetCodes(tx *sql.Tx, userID int, kind string, codes []string) error {
+	if _, err := tx.Exec(`DELETE FROM password_reset_codes WHERE user_id = ? AND kind = ?`, userID, kind); err != nil {
+		return fmt.Errorf("failed to clear old %s codes: %w", kind, err)
+	}
+	for _, code := range codes {
+		if err := insertResetCode(tx, userID, kind, code, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// useResetCode marks one of a user's unused, unexpired codes of the given kinds as used at now and returns its kind.
+// Returns ErrResetCodeInvalid if there is no such code.
+func useResetCode(tx *sql.Tx, userID int, code string, now time.Time, kinds ...string) (string, error) {
+	args := []interface{}{userID, hashResetCode(code)}
+	for _, kind := range kinds {
+		args = append(args, kind)
+	}
+
+	var id int
+	var kind string
+	var expiresAt, usedAt sql.NullTime
+	err := tx.QueryRow(`
+		SELECT id, kind, expires_at, used_at FROM password_reset_codes
+		WHERE user_id = ? AND code_hash = ? AND kind IN (?`+strings.Repeat(", ?", len(kinds)-1)+`)
+	`, args...).Scan(&id, &kind, &expiresAt, &usedAt)
+	if err == sql.ErrNoRows {
+		return "", models.ErrResetCodeInvalid
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get reset code: %w", err)
+	}
+	if usedAt.Valid || (expiresAt.Valid && !now.Before(expiresAt.Time)) {
+		return "", models.ErrResetCodeInvalid
+	}
+
+	if _, err := tx.Exec(`UPDATE password_reset_codes SET used_at = ? WHERE id = ?`, now.UTC(), id); err != nil {
+		return "", fmt.Errorf("failed to use reset code: %w", err)
+	}
+	return kind, nil
+}
+
+// CreateAdminResetCode stores a single-use reset code for a user that expires at expiresAt.
+// Any earlier admin code the user hasn't redeemed stops working.
+func (d *Database) CreateAdminResetCode(userID int, code string, expiresAt time.Time) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM password_reset_codes WHERE user_id = ? AND kind = ?`, userID, models.ResetCodeAdmin); err != nil {
+		return fmt.Errorf("failed to clear old reset codes: %w", err)
+	}
+	if err := insertResetCode(tx, userID, models.ResetCodeAdmin, code, expiresAt.UTC().Truncate(time.Second)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit reset code: %w", err)
+	}
+	return nil
+}
+
+// ReplaceRecoveryCodes stores a new set of recovery codes for a user, replacing the old set
+func (d *Database) ReplaceRecoveryCodes(userID int, codes []string) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := replaceResetCodes(tx, userID, models.ResetCodeRecovery, codes); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit recovery codes: %w", err)
+	}
+	return nil
+}
+
+// CountRecoveryCodes returns how many of a user's recovery codes haven't been used
+func (d *Database) CountRecoveryCodes(userID int) (int, error) {
+	var count int
+	err := d.db.QueryRow(`
+		SELECT COUNT(*) FROM password_reset_codes
+		WHERE user_id = ? AND kind = ? AND used_at IS NULL
+	`, userID, models.ResetCodeRecovery).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count recovery codes: %w", err)
+	}
+	return count, nil
+}
+
+// RedeemResetCode uses up one of a user's admin or recovery codes at now, sets their new password and logs them out everywhere.
+// Returns the kind of code redeemed, or ErrResetCodeInvalid if the code is unknown, used or expired.
+func (d *Database) RedeemResetCode(userID int, code, passwordHash string, now time.Time) (string, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	kind, err := useResetCode(tx, userID, code, now, models.ResetCodeAdmin, models.ResetCodeRecovery)
+	if err != nil {
+		return "", err
+	}
+	if _, err := tx.Exec(`UPDATE users SET password_hash = ?, last_active = ? WHERE id = ?`, passwordHash, now, userID); err != nil {
+		return "", fmt.Errorf("failed to update user password: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM user_sessions WHERE user_id = ?`, userID); err != nil {
+		return "", fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("failed to commit password reset: %w", err)
+	}
+	return kind, nil
+}
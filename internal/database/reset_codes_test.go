@@ -0,0 +1,90 @@
+package database
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"autotraderguesser/internal/models"
+)
+
+func TestResetCodes(t *testing.T) {
+	db := newTestDatabase(t)
+	defer db.Close()
+
+	users := createFriendshipTestUsers(t, db, "alice", "bob")
+	alice, bob := users[0], users[1]
+	now := time.Date(2025, 6, 4, 12, 0, 0, 0, time.UTC)
+
+	if _, err := db.CreateUserSession(alice.ID, "alice-token", "", ""); err != nil {
+		t.Fatalf("CreateUserSession failed: %v", err)
+	}
+	if err := db.CreateAdminResetCode(alice.ID, "OLDC-ODEO-LDCO", now.Add(models.ResetCodeTTL)); err != nil {
+		t.Fatalf("CreateAdminResetCode failed: %v", err)
+	}
+	if err := db.CreateAdminResetCode(alice.ID, "ABCD-EFGH-JKLM", now.Add(models.ResetCodeTTL)); err != nil {
+		t.Fatalf("CreateAdminResetCode failed: %v", err)
+	}
+
+	// Only the digest is stored
+	var stored int
+	if err := db.db.QueryRow(`SELECT COUNT(*) FROM password_reset_codes WHERE code_hash LIKE '%ABCD%'`).Scan(&stored); err != nil || stored != 0 {
+		t.Fatalf("expected no raw codes to be stored, got %d err=%v", stored, err)
+	}
+
+	invalid := map[string]struct {
+		userID int
+		code   string
+		at     time.Time
+	}{
+		"replaced code":   {alice.ID, "OLDC-ODEO-LDCO", now},
+		"another user":    {bob.ID, "ABCD-EFGH-JKLM", now},
+		"expired":         {alice.ID, "ABCD-EFGH-JKLM", now.Add(models.ResetCodeTTL)},
+		"unknown code":    {alice.ID, "ZZZZ-ZZZZ-ZZZZ", now},
+		"empty code":      {alice.ID, "", now},
+		"typo in code":    {alice.ID, "ABCD-EFGH-JKLN", now},
+		"partial code":    {alice.ID, "ABCD-EFGH", now},
+		"code plus extra": {alice.ID, "ABCD-EFGH-JKLMX", now},
+	}
+	for name, tc := range invalid {
+		if _, err := db.RedeemResetCode(tc.userID, tc.code, "new-hash", tc.at); !errors.Is(err, models.ErrResetCodeInvalid) {
+			t.Errorf("%s: expected ErrResetCodeInvalid, got %v", name, err)
+		}
+	}
+
+	// Codes match ignoring case, spaces and dashes
+	kind, err := db.RedeemResetCode(alice.ID, " abcd efgh-jklm ", "new-hash", now)
+	if err != nil || kind != models.ResetCodeAdmin {
+		t.Fatalf("expected the admin code to be redeemed, got %q err=%v", kind, err)
+	}
+	fresh, err := db.GetUserByUsername("alice")
+	if err != nil || fresh.PasswordHash != "new-hash" {
+		t.Fatalf("expected the password to change, got %+v err=%v", fresh, err)
+	}
+	if sessions, err := db.ListUserSessions(alice.ID); err != nil || len(sessions) != 0 {
+		t.Fatalf("expected alice to be logged out everywhere, got %+v err=%v", sessions, err)
+	}
+	if _, err := db.RedeemResetCode(alice.ID, "ABCD-EFGH-JKLM", "other-hash", now); !errors.Is(err, models.ErrResetCodeInvalid) {
+		t.Fatalf("expected a used code to be rejected, got %v", err)
+	}
+
+	// Recovery codes don't expire and are replaced as a set
+	if err := db.ReplaceRecoveryCodes(alice.ID, []string{"AAAAA-AAAAA", "BBBBB-BBBBB"}); err != nil {
+		t.Fatalf("ReplaceRecoveryCodes failed: %v", err)
+	}
+	if _, err := db.RedeemResetCode(alice.ID, "AAAAA-AAAAA", "recovered-hash", now.AddDate(1, 0, 0)); err != nil {
+		t.Fatalf("expected the recovery code to be redeemed, got %v", err)
+	}
+	if count, err := db.CountRecoveryCodes(alice.ID); err != nil || count != 1 {
+		t.Fatalf("expected 1 recovery code left, got %d err=%v", count, err)
+	}
+	if err := db.ReplaceRecoveryCodes(alice.ID, []string{"CCCCC-CCCCC"}); err != nil {
+		t.Fatalf("ReplaceRecoveryCodes failed: %v", err)
+	}
+	if _, err := db.RedeemResetCode(alice.ID, "BBBBB-BBBBB", "recovered-hash", now); !errors.Is(err, models.ErrResetCodeInvalid) {
+		t.Fatalf("expected replaced recovery codes to be rejected, got %v", err)
+	}
+	if count, err := db.CountRecoveryCodes(alice.ID); err != nil || count != 1 {
+		t.Fatalf("expected only the new recovery code, got %d err=%v", count, err)
+	}
+}
@@ -12,19 +12,61 @@ CREATE TABLE IF NOT EXISTS users (
     display_name TEXT UNIQUE NOT NULL COLLATE NOCASE, -- Made unique and required
     avatar_url TEXT,
     is_guest BOOLEAN DEFAULT FALSE, -- Changed default to FALSE since we removed guest accounts
-    session_token TEXT UNIQUE, -- Simple session management
+    session_token TEXT UNIQUE, -- Unused: sessions live in user_sessions
+    session_expires_at DATETIME, -- Unused
     security_question TEXT NOT NULL, -- Security question for password reset
     security_answer_hash TEXT NOT NULL, -- bcrypt hashed security answer
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     last_active DATETIME DEFAULT CURRENT_TIMESTAMP,
     total_games_played INTEGER DEFAULT 0,
-    favorite_difficulty TEXT DEFAULT 'easy' CHECK (favorite_difficulty IN ('easy', 'hard'))
+    favorite_difficulty TEXT DEFAULT 'easy' CHECK (favorite_difficulty IN ('easy', 'hard')),
+    totp_secret TEXT, -- Base32 TOTP secret, set from enrolment onwards
+    totp_enabled BOOLEAN DEFAULT FALSE, -- Set once a code has been confirmed
+    totp_last_step INTEGER DEFAULT 0 -- Time step of the last accepted code, against replays
 );
 
 -- Create index for fast username lookups
 CREATE INDEX IF NOT EXISTS idx_users_username ON users(username);
 CREATE INDEX IF NOT EXISTS idx_users_session_token ON users(session_token);
 
+-- Login sessions, one per device (only a SHA-256 digest of each token is kept)
+CREATE TABLE IF NOT EXISTS user_sessions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash TEXT UNIQUE NOT NULL, -- Hex SHA-256 of the session token
+    device_label TEXT NOT NULL DEFAULT '',
+    ip_address TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    last_seen_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    expires_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_sessions_user ON user_sessions(user_id, last_seen_at);
+CREATE INDEX IF NOT EXISTS idx_user_sessions_expiry ON user_sessions(expires_at);
+
+-- Failed login and password reset attempts per account, for backoff and lockout
+CREATE TABLE IF NOT EXISTS auth_throttles (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL CHECK (kind IN ('login', 'reset')),
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_failure_at DATETIME NOT NULL,
+    locked_until DATETIME, -- No attempts are checked before this
+    PRIMARY KEY (user_id, kind)
+);
+
+-- One-time password reset codes (only a SHA-256 digest of each code is kept)
+CREATE TABLE IF NOT EXISTS password_reset_codes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL CHECK (kind IN ('admin', 'recovery', 'totp')), -- totp codes stand in for a two-factor code, not a password
+    code_hash TEXT UNIQUE NOT NULL, -- Hex SHA-256 of the normalized code
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    expires_at DATETIME, -- NULL for recovery and totp codes, which don't expire
+    used_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_password_reset_codes_user ON password_reset_codes(user_id, kind);
+
 -- Challenge sessions table (persistent storage)
 CREATE TABLE IF NOT EXISTS challenge_sessions (
     session_id TEXT PRIMARY KEY, -- 16 character session ID
@@ -36,12 +78,17 @@ CREATE TABLE IF NOT EXISTS challenge_sessions (
     is_complete BOOLEAN DEFAULT FALSE,
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     completed_at DATETIME,
-    expires_at DATETIME DEFAULT (datetime('now', '+24 hours')) -- Sessions expire in 24 hours
+    expires_at DATETIME DEFAULT (datetime('now', '+24 hours')), -- Sessions expire in 24 hours
+    scoring_policy TEXT NOT NULL DEFAULT 'exponential', -- Scoring curve name
+    scoring_params TEXT NOT NULL DEFAULT '{}', -- JSON scoring parameters and car count
+    theme_slug TEXT, -- Challenge theme the cars were picked from, if any
+    expired_at DATETIME -- Set by the sweeper when an unfinished session passes expires_at
 );
 
 -- Create index for session lookups
 CREATE INDEX IF NOT EXISTS idx_challenge_sessions_user_id ON challenge_sessions(user_id);
 CREATE INDEX IF NOT EXISTS idx_challenge_sessions_created_at ON challenge_sessions(created_at);
+CREATE INDEX IF NOT EXISTS idx_challenge_sessions_expiry ON challenge_sessions(is_complete, expires_at);
 
 -- Individual guesses within challenge sessions
 CREATE TABLE IF NOT EXISTS challenge_guesses (
@@ -53,12 +100,47 @@ CREATE TABLE IF NOT EXISTS challenge_guesses (
     actual_price INTEGER NOT NULL,
     points INTEGER NOT NULL,
     accuracy_percentage REAL NOT NULL,
+    log_ratio REAL, -- |ln(guess/actual)|, symmetric for over- and under-guesses
+    hints_used INTEGER DEFAULT 0, -- Hints unlocked for this car
+    hint_penalty INTEGER DEFAULT 0, -- Points taken off for hints (already deducted from points)
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP
 );
 
 -- Create index for guess lookups
 CREATE INDEX IF NOT EXISTS idx_challenge_guesses_session_id ON challenge_guesses(session_id);
 
+-- Snapshot of each challenge car with its actual price, so scoring survives listing refreshes
+CREATE TABLE IF NOT EXISTS challenge_cars (
+    session_id TEXT NOT NULL REFERENCES challenge_sessions(session_id) ON DELETE CASCADE,
+    car_index INTEGER NOT NULL, -- Which car in the challenge (0-9)
+    car_id TEXT NOT NULL,
+    actual_price REAL NOT NULL,
+    car_json TEXT NOT NULL, -- Full car record including price
+    PRIMARY KEY (session_id, car_index)
+);
+
+-- Admin-defined challenge themes
+CREATE TABLE IF NOT EXISTS challenge_themes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    slug TEXT UNIQUE NOT NULL,
+    name TEXT NOT NULL,
+    description TEXT DEFAULT '',
+    filter_json TEXT NOT NULL, -- JSON car filter (makes, years, body/fuel types, price band)
+    is_active BOOLEAN DEFAULT TRUE,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Hints unlocked per car within challenge sessions
+CREATE TABLE IF NOT EXISTS challenge_hints (
+    session_id TEXT NOT NULL REFERENCES challenge_sessions(session_id) ON DELETE CASCADE,
+    car_index INTEGER NOT NULL,
+    hint TEXT NOT NULL,
+    value_json TEXT NOT NULL, -- Revealed value
+    cost INTEGER NOT NULL, -- Points taken off the car's score
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (session_id, car_index, hint)
+);
+
 -- Friend challenges table for multiplayer
 CREATE TABLE IF NOT EXISTS friend_challenges (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -70,7 +152,8 @@ CREATE TABLE IF NOT EXISTS friend_challenges (
     max_participants INTEGER DEFAULT 10,
     is_active BOOLEAN DEFAULT TRUE,
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-    expires_at DATETIME DEFAULT (datetime('now', '+7 days')) -- Challenges expire in 7 days
+    expires_at DATETIME DEFAULT (datetime('now', '+7 days')), -- Challenges expire in 7 days
+    invited_user_id INTEGER REFERENCES users(id) ON DELETE SET NULL -- Set for a challenge sent directly to a friend
 );
 
 -- Create index for challenge code lookups
@@ -96,18 +179,44 @@ CREATE TABLE IF NOT EXISTS challenge_participants (
 CREATE INDEX IF NOT EXISTS idx_challenge_participants_challenge ON challenge_participants(friend_challenge_id);
 CREATE INDEX IF NOT EXISTS idx_challenge_participants_user ON challenge_participants(user_id);
 
+-- Friend requests between users, which become friendships once accepted (one row per pair)
+CREATE TABLE IF NOT EXISTS friendships (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    requester_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    addressee_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'accepted')),
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    responded_at DATETIME,
+    CHECK (requester_id != addressee_id)
+);
+
+-- A pair of users can only have one friendship, whoever asked first
+CREATE UNIQUE INDEX IF NOT EXISTS idx_friendships_pair ON friendships(MIN(requester_id, addressee_id), MAX(requester_id, addressee_id));
+CREATE INDEX IF NOT EXISTS idx_friendships_addressee ON friendships(addressee_id, status);
+CREATE INDEX IF NOT EXISTS idx_friendships_requester ON friendships(requester_id, status);
+
+-- Users a user has blocked from sending them friend requests and challenges
+CREATE TABLE IF NOT EXISTS user_blocks (
+    blocker_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    blocked_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (blocker_id, blocked_id),
+    CHECK (blocker_id != blocked_id)
+);
+
 -- Enhanced leaderboard entries with user references
 CREATE TABLE IF NOT EXISTS leaderboard_entries (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
     user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
     username TEXT NOT NULL, -- Denormalized for performance and guest support
     score INTEGER NOT NULL,
-    game_mode TEXT NOT NULL CHECK (game_mode IN ('streak', 'challenge', 'zero')),
+    game_mode TEXT NOT NULL CHECK (game_mode IN ('streak', 'challenge', 'zero', 'daily', 'higherlower')),
     difficulty TEXT NOT NULL CHECK (difficulty IN ('easy', 'hard')),
     session_id TEXT, -- Link to challenge session if applicable
     friend_challenge_id INTEGER REFERENCES friend_challenges(id) ON DELETE SET NULL, -- If part of friend challenge
     legacy_id TEXT, -- For migrating existing JSON data
-    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    hints_used INTEGER DEFAULT 0 -- Challenge hints unlocked during the session
 );
 
 -- Create indexes for leaderboard performance
@@ -119,24 +228,72 @@ CREATE INDEX IF NOT EXISTS idx_leaderboard_created_at ON leaderboard_entries(cre
 -- Composite index for common query pattern: filter by game_mode AND difficulty
 CREATE INDEX IF NOT EXISTS idx_leaderboard_mode_difficulty ON leaderboard_entries(game_mode, difficulty, score DESC);
 
+-- Final standings of closed leaderboard periods (day, week, month, season)
+CREATE TABLE IF NOT EXISTS leaderboard_archive (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    period TEXT NOT NULL CHECK (period IN ('day', 'week', 'month', 'season')),
+    period_key TEXT NOT NULL, -- 2025-06-02, 2025-W23, 2025-06 or 2025-Q2
+    game_mode TEXT NOT NULL,
+    difficulty TEXT NOT NULL CHECK (difficulty IN ('easy', 'hard')),
+    position INTEGER NOT NULL, -- Order within the standings, ties broken by the mode's tie-breakers
+    rank INTEGER NOT NULL, -- Shared by equal scores
+    entry_id INTEGER REFERENCES leaderboard_entries(id) ON DELETE SET NULL,
+    user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    username TEXT NOT NULL,
+    score INTEGER NOT NULL,
+    hints_used INTEGER DEFAULT 0,
+    submitted_at DATETIME,
+    archived_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (period, game_mode, difficulty, period_key, position)
+);
+
+-- Daily challenge car sets (one shared set per UTC date and difficulty, kept as an archive)
+CREATE TABLE IF NOT EXISTS daily_challenges (
+    challenge_date TEXT NOT NULL, -- UTC date (YYYY-MM-DD)
+    difficulty TEXT NOT NULL CHECK (difficulty IN ('easy', 'hard')),
+    cars_json TEXT NOT NULL, -- Full car records including prices
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (challenge_date, difficulty)
+);
+
+-- One daily challenge attempt per player per day and difficulty
+CREATE TABLE IF NOT EXISTS daily_attempts (
+    challenge_date TEXT NOT NULL,
+    difficulty TEXT NOT NULL CHECK (difficulty IN ('easy', 'hard')),
+    player_key TEXT NOT NULL, -- "user:<id>" or "anon:<session id>"
+    user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    session_id TEXT UNIQUE NOT NULL REFERENCES challenge_sessions(session_id) ON DELETE CASCADE,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (challenge_date, difficulty, player_key)
+);
+
 -- Game sessions for tracking streaks and zero mode
 CREATE TABLE IF NOT EXISTS game_sessions (
     session_id TEXT PRIMARY KEY,
     user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
-    game_mode TEXT NOT NULL CHECK (game_mode IN ('streak', 'zero')),
+    game_mode TEXT NOT NULL CHECK (game_mode IN ('streak', 'zero', 'higherlower')),
     difficulty TEXT NOT NULL CHECK (difficulty IN ('easy', 'hard')),
     current_score INTEGER DEFAULT 0,
     current_difference REAL DEFAULT 0, -- For zero mode
     cars_shown TEXT DEFAULT '[]', -- JSON array of car IDs shown to prevent repetition
     is_active BOOLEAN DEFAULT TRUE,
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-    last_guess_at DATETIME DEFAULT CURRENT_TIMESTAMP
+    last_guess_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    accuracy_metric TEXT NOT NULL DEFAULT 'percentage' CHECK (accuracy_metric IN ('percentage', 'logratio')), -- Streak tolerance metric
+    game_id TEXT -- Server-issued per game, so a reused session ID can't resubmit to the leaderboard
 );
 
 -- Create index for active session lookups
 CREATE INDEX IF NOT EXISTS idx_game_sessions_user_id ON game_sessions(user_id);
 CREATE INDEX IF NOT EXISTS idx_game_sessions_active ON game_sessions(is_active);
 
+-- Pair currently in play for a higher-or-lower session (prices stay server-side)
+CREATE TABLE IF NOT EXISTS higher_lower_pairs (
+    session_id TEXT PRIMARY KEY REFERENCES game_sessions(session_id) ON DELETE CASCADE,
+    cars_json TEXT NOT NULL, -- Both car records including prices
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
 -- Database metadata table
 CREATE TABLE IF NOT EXISTS database_metadata (
     key TEXT PRIMARY KEY,
@@ -0,0 +1,127 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"autotraderguesser/internal/models"
+)
+
+// Challenge theme methods
+
+// CreateChallengeTheme stores a new challenge theme.
+// Returns false without changing anything if the slug is already taken.
+func (d *Database) CreateChallengeTheme(theme *models.ChallengeTheme) (bool, error) {
+	filterJSON, err := json.Marshal(theme.Filter)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal theme filter: %w", err)
+	}
+
+	query := `
+		INSERT OR IGNORE INTO challenge_themes (slug, name, description, filter_json, is_active)
+		VALUES (?, ?, ?, ?, ?)
+	`
+
+	result, err := d.db.Exec(query, theme.Slug, theme.Name, theme.Description, string(filterJSON), theme.IsActive)
+	if err != nil {
+		return false, fmt.Errorf("failed to create challenge theme: %w", err)
+	}
+
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to create challenge theme: %w", err)
+	}
+
+	if inserted > 0 {
+		if id, err := result.LastInsertId(); err == nil {
+			theme.ID = int(id)
+		}
+	}
+
+	return inserted > 0, nil
+}
+
+// GetChallengeTheme retrieves a challenge theme by slug, active or not
+func (d *Database) GetChallengeTheme(slug string) (*models.ChallengeTheme, error) {
+	query := `
+		SELECT id, slug, name, description, filter_json, is_active, created_at
+		FROM challenge_themes
+		WHERE slug = ?
+	`
+
+	theme, err := scanChallengeTheme(d.db.QueryRow(query, slug))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Theme not found
+		}
+		return nil, fmt.Errorf("failed to get challenge theme: %w", err)
+	}
+
+	return theme, nil
+}
+
+// ListChallengeThemes retrieves challenge themes by name, optionally only the active ones
+func (d *Database) ListChallengeThemes(activeOnly bool) ([]models.ChallengeTheme, error) {
+	query := `
+		SELECT id, slug, name, description, filter_json, is_active, created_at
+		FROM challenge_themes
+	`
+
+	if activeOnly {
+		query += " WHERE is_active = TRUE"
+	}
+
+	query += " ORDER BY name"
+
+	rows, err := d.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query challenge themes: %w", err)
+	}
+	defer rows.Close()
+
+	themes := []models.ChallengeTheme{}
+	for rows.Next() {
+		theme, err := scanChallengeTheme(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan challenge theme: %w", err)
+		}
+		themes = append(themes, *theme)
+	}
+
+	return themes, rows.Err()
+}
+
+// SetChallengeThemeActive enables or retires a challenge theme.
+// Returns false if no theme has that slug.
+func (d *Database) SetChallengeThemeActive(slug string, active bool) (bool, error) {
+	result, err := d.db.Exec(`UPDATE challenge_themes SET is_active = ? WHERE slug = ?`, active, slug)
+	if err != nil {
+		return false, fmt.Errorf("failed to update challenge theme: %w", err)
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to update challenge theme: %w", err)
+	}
+
+	return updated > 0, nil
+}
+
+// scanChallengeTheme reads a challenge theme row and decodes its filter
+func scanChallengeTheme(row interface{ Scan(...interface{}) error }) (*models.ChallengeTheme, error) {
+	var theme models.ChallengeTheme
+	var filterJSON string
+
+	err := row.Scan(&theme.ID, &theme.Slug, &theme.Name, &theme.Description, &filterJSON,
+		&theme.IsActive, &theme.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(filterJSON), &theme.Filter); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal theme filter: %w", err)
+	}
+
+	return &theme, nil
+}
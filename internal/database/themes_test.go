@@ -0,0 +1,78 @@
+package database
+
+import (
+	"testing"
+
+	"autotraderguesser/internal/models"
+)
+
+func TestChallengeThemes(t *testing.T) {
+	db := newTestDatabase(t)
+	defer db.Close()
+
+	theme := &models.ChallengeTheme{
+		Slug:     "classic-porsches",
+		Name:     "Classic Porsches",
+		Filter:   models.ThemeFilter{Makes: []string{"Porsche"}, YearTo: 1990},
+		IsActive: true,
+	}
+	if created, err := db.CreateChallengeTheme(theme); err != nil || !created {
+		t.Fatalf("expected theme to be created, got %v err=%v", created, err)
+	}
+	if theme.ID == 0 {
+		t.Fatalf("expected theme ID to be set")
+	}
+
+	// Slugs are unique
+	duplicate := &models.ChallengeTheme{Slug: "classic-porsches", Name: "Other", Filter: models.ThemeFilter{YearFrom: 2000}, IsActive: true}
+	if created, err := db.CreateChallengeTheme(duplicate); err != nil || created {
+		t.Fatalf("expected duplicate slug to be ignored, got %v err=%v", created, err)
+	}
+
+	loaded, err := db.GetChallengeTheme("classic-porsches")
+	if err != nil || loaded == nil {
+		t.Fatalf("GetChallengeTheme failed: %v", err)
+	}
+	if loaded.Name != "Classic Porsches" || len(loaded.Filter.Makes) != 1 || loaded.Filter.Makes[0] != "Porsche" || loaded.Filter.YearTo != 1990 {
+		t.Fatalf("unexpected theme: %+v", loaded)
+	}
+
+	if missing, err := db.GetChallengeTheme("missing"); err != nil || missing != nil {
+		t.Fatalf("expected nil for missing theme, got %+v err=%v", missing, err)
+	}
+
+	if _, err := db.CreateChallengeTheme(&models.ChallengeTheme{Slug: "evs-under-20k", Name: "EVs under £20k",
+		Filter: models.ThemeFilter{FuelTypes: []string{"Electric"}, MaxPrice: 20000}, IsActive: true}); err != nil {
+		t.Fatalf("CreateChallengeTheme failed: %v", err)
+	}
+
+	// Retired themes drop out of the active list but can still be loaded
+	if updated, err := db.SetChallengeThemeActive("classic-porsches", false); err != nil || !updated {
+		t.Fatalf("expected theme to be retired, got %v err=%v", updated, err)
+	}
+	if updated, err := db.SetChallengeThemeActive("missing", false); err != nil || updated {
+		t.Fatalf("expected no update for missing theme, got %v err=%v", updated, err)
+	}
+
+	active, err := db.ListChallengeThemes(true)
+	if err != nil || len(active) != 1 || active[0].Slug != "evs-under-20k" {
+		t.Fatalf("expected only the EV theme to be active, got %+v err=%v", active, err)
+	}
+	all, err := db.ListChallengeThemes(false)
+	if err != nil || len(all) != 2 {
+		t.Fatalf("expected both themes, got %+v err=%v", all, err)
+	}
+	if retired, _ := db.GetChallengeTheme("classic-porsches"); retired == nil || retired.IsActive {
+		t.Fatalf("expected retired theme to load as inactive, got %+v", retired)
+	}
+
+	// Sessions remember the theme they were built from
+	session := &models.ChallengeSession{SessionID: "themed-session", Difficulty: "hard", Theme: "evs-under-20k"}
+	if err := db.CreateChallengeSession(session); err != nil {
+		t.Fatalf("CreateChallengeSession failed: %v", err)
+	}
+	stored, err := db.GetChallengeSession("themed-session")
+	if err != nil || stored == nil || stored.Theme != "evs-under-20k" {
+		t.Fatalf("expected session theme to round-trip, got %+v err=%v", stored, err)
+	}
+}
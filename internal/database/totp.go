@@ -0,0 +1,153 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"autotraderguesser/internal/models"
+)
+
+// GetUserTOTP returns a user's two-factor authentication state
+func (d *Database) GetUserTOTP(userID int) (*models.UserTOTP, error) {
+	var totp models.UserTOTP
+	var secret sql.NullString
+	var enabled sql.NullBool
+	var lastStep sql.NullInt64
+	err := d.db.QueryRow(`
+		SELECT totp_secret, totp_enabled, totp_last_step FROM users WHERE id = ?
+	`, userID).Scan(&secret, &enabled, &lastStep)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("user not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get two-factor settings: %w", err)
+	}
+
+	totp.Secret = secret.String
+	totp.Enabled = enabled.Bool
+	totp.LastStep = lastStep.Int64
+	return &totp, nil
+}
+
+// StartTOTPEnrolment stores a new secret for a user to confirm, replacing any unconfirmed one.
+// Returns ErrTOTPAlreadyEnabled if two-factor authentication is already on.
+func (d *Database) StartTOTPEnrolment(userID int, secret string) error {
+	result, err := d.db.Exec(`
+		UPDATE users SET totp_secret = ?, totp_last_step = 0
+		WHERE id = ? AND NOT COALESCE(totp_enabled, FALSE)
+	`, secret, userID)
+	if err != nil {
+		return fmt.Errorf("failed to start two-factor enrolment: %w", err)
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to start two-factor enrolment: %w", err)
+	}
+	if count == 0 {
+		return models.ErrTOTPAlreadyEnabled
+	}
+	return nil
+}
+
+// EnableTOTP turns on two-factor authentication once a code from the pending secret has been confirmed.
+// step is the confirmed code's time step and recoveryCodes replace any earlier set.
+// Returns ErrTOTPNotEnrolled if there is no pending secret.
+func (d *Database) EnableTOTP(userID int, step int64, recoveryCodes []string) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE users SET totp_enabled = TRUE, totp_last_step = ?
+		WHERE id = ? AND totp_secret IS NOT NULL AND NOT COALESCE(totp_enabled, FALSE)
+	`, step, userID)
+	if err != nil {
+		return fmt.Errorf("failed to enable two-factor authentication: %w", err)
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to enable two-factor authentication: %w", err)
+	}
+	if count == 0 {
+		return models.ErrTOTPNotEnrolled
+	}
+
+	if err := replaceResetCodes(tx, userID, models.ResetCodeTOTP, recoveryCodes); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit two-factor authentication: %w", err)
+	}
+	return nil
+}
+
+// DisableTOTP turns off two-factor authentication and forgets the secret and recovery codes
+func (d *Database) DisableTOTP(userID int) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		UPDATE users SET totp_secret = NULL, totp_enabled = FALSE, totp_last_step = 0
+		WHERE id = ?
+	`, userID); err != nil {
+		return fmt.Errorf("failed to disable two-factor authentication: %w", err)
+	}
+	if err := replaceResetCodes(tx, userID, models.ResetCodeTOTP, nil); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit two-factor authentication: %w", err)
+	}
+	return nil
+}
+
+// UseTOTPStep records that a code from a time step was accepted.
+// Returns false if a code from that step or a later one was already used, so it must be rejected as a replay.
+func (d *Database) UseTOTPStep(userID int, step int64) (bool, error) {
+	result, err := d.db.Exec(`
+		UPDATE users SET totp_last_step = ?
+		WHERE id = ? AND COALESCE(totp_last_step, 0) < ?
+	`, step, userID, step)
+	if err != nil {
+		return false, fmt.Errorf("failed to record two-factor code: %w", err)
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to record two-factor code: %w", err)
+	}
+	return count > 0, nil
+}
+
+// UseTOTPRecoveryCode uses up one of a user's two-factor recovery codes at now.
+// Returns false if the code is unknown or already used.
+func (d *Database) UseTOTPRecoveryCode(userID int, code string, now time.Time) (bool, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := useResetCode(tx, userID, code, now, models.ResetCodeTOTP); err != nil {
+		if errors.Is(err, models.ErrResetCodeInvalid) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit recovery code: %w", err)
+	}
+	return true, nil
+}
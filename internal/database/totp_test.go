@@ -0,0 +1,88 @@
+package database
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"autotraderguesser/internal/models"
+)
+
+func TestUserTOTP(t *testing.T) {
+	db := newTestDatabase(t)
+	defer db.Close()
+
+	user := createFriendshipTestUsers(t, db, "alice")[0]
+	now := time.Date(2025, 6, 4, 12, 0, 0, 0, time.UTC)
+
+	totp, err := db.GetUserTOTP(user.ID)
+	if err != nil || totp.Enabled || totp.Secret != "" {
+		t.Fatalf("expected two-factor authentication to start off, got %+v err=%v", totp, err)
+	}
+	if err := db.EnableTOTP(user.ID, 1, nil); !errors.Is(err, models.ErrTOTPNotEnrolled) {
+		t.Fatalf("expected ErrTOTPNotEnrolled before enrolment, got %v", err)
+	}
+
+	if err := db.StartTOTPEnrolment(user.ID, "FIRSTSECRET"); err != nil {
+		t.Fatalf("StartTOTPEnrolment failed: %v", err)
+	}
+	if err := db.StartTOTPEnrolment(user.ID, "SECONDSECRET"); err != nil {
+		t.Fatalf("expected enrolment to be restartable, got %v", err)
+	}
+	if err := db.EnableTOTP(user.ID, 100, []string{"AAAAA-AAAAA", "BBBBB-BBBBB"}); err != nil {
+		t.Fatalf("EnableTOTP failed: %v", err)
+	}
+	if totp, err = db.GetUserTOTP(user.ID); err != nil || !totp.Enabled || totp.Secret != "SECONDSECRET" || totp.LastStep != 100 {
+		t.Fatalf("expected two-factor authentication on with the second secret, got %+v err=%v", totp, err)
+	}
+	if err := db.StartTOTPEnrolment(user.ID, "THIRDSECRET"); !errors.Is(err, models.ErrTOTPAlreadyEnabled) {
+		t.Fatalf("expected ErrTOTPAlreadyEnabled, got %v", err)
+	}
+
+	// Steps can only be used once, in order
+	for step, want := range map[int64]bool{100: false, 99: false} {
+		if ok, err := db.UseTOTPStep(user.ID, step); err != nil || ok != want {
+			t.Fatalf("UseTOTPStep(%d) = %v err=%v, want %v", step, ok, err, want)
+		}
+	}
+	if ok, err := db.UseTOTPStep(user.ID, 101); err != nil || !ok {
+		t.Fatalf("expected a later step to be accepted, got %v err=%v", ok, err)
+	}
+
+	// Recovery codes work once each, typed in any case
+	if ok, err := db.UseTOTPRecoveryCode(user.ID, "aaaaa aaaaa", now); err != nil || !ok {
+		t.Fatalf("expected the recovery code to be accepted, got %v err=%v", ok, err)
+	}
+	for _, code := range []string{"AAAAA-AAAAA", "CCCCC-CCCCC", ""} {
+		if ok, err := db.UseTOTPRecoveryCode(user.ID, code, now); err != nil || ok {
+			t.Fatalf("expected %q to be rejected, got %v err=%v", code, ok, err)
+		}
+	}
+
+	// They sit alongside reset codes but can't reset a password, nor reset codes pass for them
+	if count, err := db.CountRecoveryCodes(user.ID); err != nil || count != 0 {
+		t.Fatalf("expected two-factor codes not to count as password recovery codes, got %d err=%v", count, err)
+	}
+	if _, err := db.RedeemResetCode(user.ID, "BBBBB-BBBBB", "newhash", now); !errors.Is(err, models.ErrResetCodeInvalid) {
+		t.Fatalf("expected a two-factor code not to reset the password, got %v", err)
+	}
+	if err := db.ReplaceRecoveryCodes(user.ID, []string{"DDDDD-DDDDD"}); err != nil {
+		t.Fatalf("ReplaceRecoveryCodes failed: %v", err)
+	}
+	if ok, err := db.UseTOTPRecoveryCode(user.ID, "DDDDD-DDDDD", now); err != nil || ok {
+		t.Fatalf("expected a password recovery code not to pass as a two-factor code, got %v err=%v", ok, err)
+	}
+
+	if err := db.DisableTOTP(user.ID); err != nil {
+		t.Fatalf("DisableTOTP failed: %v", err)
+	}
+	if totp, err = db.GetUserTOTP(user.ID); err != nil || totp.Enabled || totp.Secret != "" {
+		t.Fatalf("expected two-factor authentication to be off, got %+v err=%v", totp, err)
+	}
+	if ok, err := db.UseTOTPRecoveryCode(user.ID, "BBBBB-BBBBB", now); err != nil || ok {
+		t.Fatalf("expected recovery codes to be forgotten, got %v err=%v", ok, err)
+	}
+	if count, err := db.CountRecoveryCodes(user.ID); err != nil || count != 1 {
+		t.Fatalf("expected password recovery codes to be kept, got %d err=%v", count, err)
+	}
+}
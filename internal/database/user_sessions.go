@@ -0,0 +1,185 @@
+package database
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"autotraderguesser/internal/models"
+)
+
+// userSessionColumns are the columns read by scanUserSession
+const userSessionColumns = "s.id, s.user_id, s.device_label, s.ip_address, s.created_at, s.last_seen_at, s.expires_at"
+
+// hashSessionToken returns the hex SHA-256 digest stored in place of a session token
+func hashSessionToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func scanUserSession(row rowScanner, extra ...interface{}) (*models.UserSession, error) {
+	var s models.UserSession
+	dest := append([]interface{}{&s.ID, &s.UserID, &s.DeviceLabel, &s.IPAddress, &s.CreatedAt, &s.LastSeenAt, &s.ExpiresAt}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// insertUserSession stores a new login session within a transaction and returns its ID
+func insertUserSession(tx *sql.Tx, userID int, token, deviceLabel, ipAddress string) (int64, error) {
+	expiresAt := time.Now().UTC().Add(models.UserSessionTTL).Truncate(time.Second)
+	result, err := tx.Exec(`
+		INSERT INTO user_sessions (user_id, token_hash, device_label, ip_address, expires_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, userID, hashSessionToken(token), deviceLabel, ipAddress, expiresAt)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get session ID: %w", err)
+	}
+	return id, nil
+}
+
+// CreateUserSession starts a login session for a user on a device, keeping only a digest of the token.
+// The user's expired sessions are cleared out at the same time.
+func (d *Database) CreateUserSession(userID int, token, deviceLabel, ipAddress string) (*models.UserSession, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM user_sessions WHERE user_id = ? AND expires_at <= CURRENT_TIMESTAMP`, userID); err != nil {
+		return nil, fmt.Errorf("failed to clear expired sessions: %w", err)
+	}
+
+	id, err := insertUserSession(tx, userID, token, deviceLabel, ipAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec(`UPDATE users SET last_active = CURRENT_TIMESTAMP WHERE id = ?`, userID); err != nil {
+		return nil, fmt.Errorf("failed to update last active: %w", err)
+	}
+
+	session, err := scanUserSession(tx.QueryRow(`SELECT `+userSessionColumns+` FROM user_sessions s WHERE s.id = ?`, id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit session: %w", err)
+	}
+	return session, nil
+}
+
+// GetUserSession returns the user and unexpired login session a session token belongs to.
+// Returns ErrUserSessionNotFound if there is none.
+func (d *Database) GetUserSession(token string) (*models.User, *models.UserSession, error) {
+	query := `
+		SELECT ` + userSessionColumns + `,
+		       u.username, u.display_name, u.password_hash, u.avatar_url, u.is_guest,
+		       u.security_question, u.security_answer_hash, u.created_at, u.last_active,
+		       u.total_games_played, u.favorite_difficulty
+		FROM user_sessions s
+		JOIN users u ON u.id = s.user_id
+		WHERE s.token_hash = ? AND s.expires_at > CURRENT_TIMESTAMP
+	`
+
+	var user models.User
+	var passwordHash, avatarURL, securityQuestion, securityAnswerHash sql.NullString
+
+	session, err := scanUserSession(d.db.QueryRow(query, hashSessionToken(token)),
+		&user.Username, &user.DisplayName, &passwordHash, &avatarURL, &user.IsGuest,
+		&securityQuestion, &securityAnswerHash, &user.CreatedAt, &user.LastActive,
+		&user.TotalGamesPlayed, &user.FavoriteDifficulty,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, models.ErrUserSessionNotFound
+		}
+		return nil, nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	user.ID = session.UserID
+	user.PasswordHash = passwordHash.String
+	user.AvatarURL = avatarURL.String
+	user.SecurityQuestion = securityQuestion.String
+	user.SecurityAnswerHash = securityAnswerHash.String
+	user.SessionExpiresAt = &session.ExpiresAt
+
+	return &user, session, nil
+}
+
+// TouchUserSession records that a login session was just used
+func (d *Database) TouchUserSession(session *models.UserSession) error {
+	if _, err := d.db.Exec(`UPDATE user_sessions SET last_seen_at = CURRENT_TIMESTAMP WHERE id = ?`, session.ID); err != nil {
+		return fmt.Errorf("failed to update session: %w", err)
+	}
+	if _, err := d.db.Exec(`UPDATE users SET last_active = CURRENT_TIMESTAMP WHERE id = ?`, session.UserID); err != nil {
+		return fmt.Errorf("failed to update last active: %w", err)
+	}
+
+	session.LastSeenAt = time.Now().UTC()
+	return nil
+}
+
+// ListUserSessions returns a user's unexpired login sessions, most recently used first
+func (d *Database) ListUserSessions(userID int) ([]models.UserSession, error) {
+	rows, err := d.db.Query(`
+		SELECT `+userSessionColumns+`
+		FROM user_sessions s
+		WHERE s.user_id = ? AND s.expires_at > CURRENT_TIMESTAMP
+		ORDER BY s.last_seen_at DESC, s.id DESC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []models.UserSession{}
+	for rows.Next() {
+		session, err := scanUserSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessions = append(sessions, *session)
+	}
+
+	return sessions, rows.Err()
+}
+
+// RevokeUserSession ends one of a user's login sessions. Returns false if the user has no such session.
+func (d *Database) RevokeUserSession(userID, sessionID int) (bool, error) {
+	result, err := d.db.Exec(`DELETE FROM user_sessions WHERE id = ? AND user_id = ?`, sessionID, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke session: %w", err)
+	}
+	return count > 0, nil
+}
+
+// RevokeUserSessions ends all of a user's login sessions apart from keepSessionID (0 keeps none).
+// Returns how many sessions were ended.
+func (d *Database) RevokeUserSessions(userID, keepSessionID int) (int64, error) {
+	result, err := d.db.Exec(`DELETE FROM user_sessions WHERE user_id = ? AND id != ?`, userID, keepSessionID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	return count, nil
+}
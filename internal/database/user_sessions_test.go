@@ -0,0 +1,165 @@
+package database
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"testing"
+	"time"
+
+	"autotraderguesser/internal/models"
+)
+
+func TestUserSessions(t *testing.T) {
+	db := newTestDatabase(t)
+	defer db.Close()
+
+	users := createFriendshipTestUsers(t, db, "alice", "bob")
+	alice, bob := users[0], users[1]
+
+	laptop, err := db.CreateUserSession(alice.ID, "laptop-token", "Firefox on Windows", "10.0.0.1")
+	if err != nil {
+		t.Fatalf("CreateUserSession failed: %v", err)
+	}
+	phone, err := db.CreateUserSession(alice.ID, "phone-token", "Safari on iPhone", "10.0.0.2")
+	if err != nil {
+		t.Fatalf("CreateUserSession failed: %v", err)
+	}
+	if _, err := db.CreateUserSession(bob.ID, "bob-token", "Chrome on Linux", "10.0.0.3"); err != nil {
+		t.Fatalf("CreateUserSession failed: %v", err)
+	}
+
+	// Only the digest is stored
+	var stored int
+	if err := db.db.QueryRow(`SELECT COUNT(*) FROM user_sessions WHERE token_hash LIKE '%token%'`).Scan(&stored); err != nil || stored != 0 {
+		t.Fatalf("expected no raw tokens in user_sessions, got %d err=%v", stored, err)
+	}
+
+	// Both devices stay logged in
+	for _, token := range []string{"laptop-token", "phone-token"} {
+		user, session, err := db.GetUserSession(token)
+		if err != nil || user.ID != alice.ID || user.Username != "alice" {
+			t.Fatalf("expected %s to log alice in, got %+v err=%v", token, user, err)
+		}
+		if err := db.TouchUserSession(session); err != nil {
+			t.Fatalf("TouchUserSession failed: %v", err)
+		}
+	}
+	if _, _, err := db.GetUserSession("unknown"); !errors.Is(err, models.ErrUserSessionNotFound) {
+		t.Fatalf("expected ErrUserSessionNotFound, got %v", err)
+	}
+
+	sessions, err := db.ListUserSessions(alice.ID)
+	if err != nil || len(sessions) != 2 {
+		t.Fatalf("expected alice to have 2 sessions, got %+v err=%v", sessions, err)
+	}
+	if sessions[0].DeviceLabel != "Safari on iPhone" || sessions[1].IPAddress != "10.0.0.1" {
+		t.Fatalf("expected the phone first and the laptop's details kept, got %+v", sessions)
+	}
+
+	// Users can only revoke their own sessions
+	if revoked, err := db.RevokeUserSession(bob.ID, laptop.ID); err != nil || revoked {
+		t.Fatalf("expected bob to be unable to revoke alice's session, got %v err=%v", revoked, err)
+	}
+	if revoked, err := db.RevokeUserSession(alice.ID, laptop.ID); err != nil || !revoked {
+		t.Fatalf("expected the laptop session to be revoked, got %v err=%v", revoked, err)
+	}
+	if _, _, err := db.GetUserSession("laptop-token"); !errors.Is(err, models.ErrUserSessionNotFound) {
+		t.Fatalf("expected the revoked session to be gone, got %v", err)
+	}
+	if _, _, err := db.GetUserSession("phone-token"); err != nil {
+		t.Fatalf("expected the phone to stay logged in, got %v", err)
+	}
+
+	if _, err := db.CreateUserSession(alice.ID, "tablet-token", "Chrome on Android", "10.0.0.4"); err != nil {
+		t.Fatalf("CreateUserSession failed: %v", err)
+	}
+	if revoked, err := db.RevokeUserSessions(alice.ID, phone.ID); err != nil || revoked != 1 {
+		t.Fatalf("expected the tablet to be logged out, got %d err=%v", revoked, err)
+	}
+	if revoked, err := db.RevokeUserSessions(alice.ID, 0); err != nil || revoked != 1 {
+		t.Fatalf("expected the phone to be logged out, got %d err=%v", revoked, err)
+	}
+	if sessions, err := db.ListUserSessions(alice.ID); err != nil || len(sessions) != 0 {
+		t.Fatalf("expected no sessions left, got %+v err=%v", sessions, err)
+	}
+	if _, _, err := db.GetUserSession("bob-token"); err != nil {
+		t.Fatalf("expected bob to stay logged in, got %v", err)
+	}
+}
+
+func TestUserSessionExpiry(t *testing.T) {
+	db := newTestDatabase(t)
+	defer db.Close()
+
+	user := createFriendshipTestUsers(t, db, "alice")[0]
+	session, err := db.CreateUserSession(user.ID, "old-token", "", "")
+	if err != nil {
+		t.Fatalf("CreateUserSession failed: %v", err)
+	}
+	if until := time.Until(session.ExpiresAt); until < models.UserSessionTTL-time.Minute || until > models.UserSessionTTL {
+		t.Fatalf("expected the session to last %v, expires in %v", models.UserSessionTTL, until)
+	}
+
+	if _, err := db.db.Exec(`UPDATE user_sessions SET expires_at = datetime('now', '-1 minute') WHERE id = ?`, session.ID); err != nil {
+		t.Fatalf("failed to expire session: %v", err)
+	}
+	if _, _, err := db.GetUserSession("old-token"); !errors.Is(err, models.ErrUserSessionNotFound) {
+		t.Fatalf("expected an expired session to be rejected, got %v", err)
+	}
+	if sessions, err := db.ListUserSessions(user.ID); err != nil || len(sessions) != 0 {
+		t.Fatalf("expected expired sessions to be hidden, got %+v err=%v", sessions, err)
+	}
+
+	// Logging in again clears out expired sessions
+	if _, err := db.CreateUserSession(user.ID, "new-token", "", ""); err != nil {
+		t.Fatalf("CreateUserSession failed: %v", err)
+	}
+	var count int
+	if err := db.db.QueryRow(`SELECT COUNT(*) FROM user_sessions WHERE user_id = ?`, user.ID).Scan(&count); err != nil || count != 1 {
+		t.Fatalf("expected only the new session to be kept, got %d err=%v", count, err)
+	}
+}
+
+func TestSessionTokensStoredAsDigests(t *testing.T) {
+	db := newTestDatabase(t)
+	defer db.Close()
+
+	user := &models.User{Username: "alice", PasswordHash: "hash", DisplayName: "alice", SessionToken: "created-token"}
+	if err := db.CreateUser(user); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	if err := db.UpdateUserSession(user.ID, "updated-token"); err != nil {
+		t.Fatalf("UpdateUserSession failed: %v", err)
+	}
+
+	// Only a digest of the current token is kept, and nothing in users
+	var tokenHash string
+	var raw int
+	if err := db.db.QueryRow(`SELECT token_hash FROM user_sessions WHERE user_id = ?`, user.ID).Scan(&tokenHash); err != nil {
+		t.Fatalf("expected one session: %v", err)
+	}
+	sum := sha256.Sum256([]byte("updated-token"))
+	if tokenHash != hex.EncodeToString(sum[:]) {
+		t.Fatalf("expected the SHA-256 digest of the token, got %q", tokenHash)
+	}
+	if err := db.db.QueryRow(`SELECT COUNT(*) FROM users WHERE session_token IS NOT NULL`).Scan(&raw); err != nil || raw != 0 {
+		t.Fatalf("expected no tokens in users, got %d err=%v", raw, err)
+	}
+
+	if fetched, err := db.GetUserBySessionToken("updated-token"); err != nil || fetched.ID != user.ID {
+		t.Fatalf("expected the token to find alice, got %+v err=%v", fetched, err)
+	}
+	for _, token := range []string{"created-token", tokenHash} {
+		if _, err := db.GetUserBySessionToken(token); err == nil {
+			t.Fatalf("expected %q not to log in", token)
+		}
+	}
+
+	if err := db.UpdateUserSession(user.ID, ""); err != nil {
+		t.Fatalf("UpdateUserSession failed: %v", err)
+	}
+	if sessions, err := db.ListUserSessions(user.ID); err != nil || len(sessions) != 0 {
+		t.Fatalf("expected an empty token to log alice out, got %+v err=%v", sessions, err)
+	}
+}
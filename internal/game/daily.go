@@ -0,0 +1,331 @@
+package game
+
+import (
+	"fmt"
+	"hash/fnv"
+	"log"
+	mathrand "math/rand"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"autotraderguesser/internal/models"
+	"autotraderguesser/internal/validation"
+)
+
+// dailyDateFormat is the UTC date layout identifying a daily challenge
+const dailyDateFormat = "2006-01-02"
+
+const (
+	defaultDailyArchiveLimit = 30
+	maxDailyArchiveLimit     = 100
+)
+
+// todayUTC returns the date of the current daily challenge
+func todayUTC() string {
+	return time.Now().UTC().Format(dailyDateFormat)
+}
+
+// dailyDeadline returns when a day's daily challenge closes: the next UTC midnight, when the archive publishes its prices
+func dailyDeadline(date string) time.Time {
+	day, _ := time.Parse(dailyDateFormat, date)
+	return day.AddDate(0, 0, 1)
+}
+
+// dailySeed derives the selection seed for a date and difficulty so every server picks the same cars
+func dailySeed(date, difficulty string) int64 {
+	hash := fnv.New64a()
+	hash.Write([]byte("daily:" + date + ":" + difficulty))
+	return int64(hash.Sum64())
+}
+
+// ensureDailyChallenge returns the car set for a date, generating it from the current pool on first use.
+// The set is stored so it stays fixed for the whole day even if listings are refreshed.
+func (h *Handler) ensureDailyChallenge(date, difficulty string) (*models.DailyChallenge, error) {
+	daily, err := h.db.GetDailyChallenge(date, difficulty)
+	if err != nil || daily != nil {
+		return daily, err
+	}
+
+	rng := mathrand.New(mathrand.NewSource(dailySeed(date, difficulty)))
+	_, pricedCars, err := h.selectChallengeCars(difficulty, models.DefaultChallengeCarCount, rng, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.db.CreateDailyChallenge(&models.DailyChallenge{
+		Date:       date,
+		Difficulty: difficulty,
+		Cars:       pricedCars,
+	}); err != nil {
+		return nil, err
+	}
+
+	// Re-read in case another request stored the set first
+	return h.db.GetDailyChallenge(date, difficulty)
+}
+
+// dailyPlayerKey identifies the player for the one-attempt rule: the logged-in user,
+// or the anonymous session from the X-Session-ID header
+func dailyPlayerKey(c *gin.Context) (key string, userID *int, ok bool) {
+	if user, exists := c.Get("user"); exists {
+		if u, ok := user.(*models.User); ok {
+			return fmt.Sprintf("user:%d", u.ID), &u.ID, true
+		}
+	}
+
+	sessionID := c.GetHeader("X-Session-ID")
+	if sessionID == "" || validation.ValidateSessionID(sessionID) != nil {
+		return "", nil, false
+	}
+	return "anon:" + sessionID, nil, true
+}
+
+// GetDailyChallenge godoc
+// @Summary Get today's daily challenge
+// @Description Returns today's daily challenge (UTC) for a difficulty and whether the player has already attempted it. Players are identified by login or the X-Session-ID header.
+// @Tags daily
+// @Produce json
+// @Param difficulty query string false "Difficulty (easy or hard)" default(hard)
+// @Param X-Session-ID header string false "Anonymous session ID"
+// @Success 200 {object} map[string]interface{} "date, difficulty, carCount, nextResetAt, attempt"
+// @Failure 404 {object} map[string]string "error: Not enough cars available"
+// @Router /api/daily [get]
+func (h *Handler) GetDailyChallenge(c *gin.Context) {
+	difficulty := h.resolveDifficulty(c.DefaultQuery("difficulty", "hard"))
+	date := todayUTC()
+
+	daily, err := h.ensureDailyChallenge(date, difficulty)
+	if err != nil || daily == nil {
+		log.Printf("Failed to load daily challenge for %s %s: %v", date, difficulty, err)
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Daily challenge unavailable for %s mode", difficulty)})
+		return
+	}
+
+	response := gin.H{
+		"date":        daily.Date,
+		"difficulty":  daily.Difficulty,
+		"carCount":    len(daily.Cars),
+		"nextResetAt": time.Now().UTC().Truncate(24 * time.Hour).Add(24 * time.Hour).Format(time.RFC3339),
+		"attempt":     nil,
+	}
+
+	if playerKey, _, ok := dailyPlayerKey(c); ok {
+		attempt, err := h.db.GetDailyAttempt(date, difficulty, playerKey)
+		if err != nil {
+			log.Printf("Failed to load daily attempt: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load daily challenge"})
+			return
+		}
+		if attempt != nil {
+			attemptInfo := gin.H{"sessionId": attempt.SessionID}
+			if session, err := h.db.GetChallengeSession(attempt.SessionID); err == nil && session != nil {
+				attemptInfo["isComplete"] = session.IsComplete
+				attemptInfo["totalScore"] = session.TotalScore
+			}
+			response["attempt"] = attemptInfo
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// StartDailyChallenge godoc
+// @Summary Start today's daily challenge
+// @Description Starts the player's single attempt at today's daily challenge. Every player gets the same 10 cars for a UTC date and difficulty. An unfinished attempt is resumed until it expires at the end of its UTC day; a finished one cannot be replayed. Guesses are submitted via /api/challenge/{sessionId}/guess.
+// @Tags daily
+// @Produce json
+// @Param difficulty query string false "Difficulty (easy or hard)" default(hard)
+// @Param X-Session-ID header string false "Anonymous session ID (required when not logged in)"
+// @Success 200 {object} models.ChallengeSession
+// @Failure 400 {object} map[string]string "error: Login or X-Session-ID required"
+// @Failure 404 {object} map[string]string "error: Not enough cars available"
+// @Failure 409 {object} map[string]string "error, code and sessionId: Already played today"
+// @Router /api/daily/start [post]
+func (h *Handler) StartDailyChallenge(c *gin.Context) {
+	difficulty := h.resolveDifficulty(c.DefaultQuery("difficulty", "hard"))
+	date := todayUTC()
+
+	playerKey, userID, ok := dailyPlayerKey(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Log in or provide a valid X-Session-ID header to play the daily challenge"})
+		return
+	}
+
+	// One attempt per player - resume it if it is still in progress
+	attempt, err := h.db.GetDailyAttempt(date, difficulty, playerKey)
+	if err != nil {
+		log.Printf("Failed to load daily attempt: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start daily challenge"})
+		return
+	}
+	if attempt != nil {
+		h.resumeDailyAttempt(c, attempt)
+		return
+	}
+
+	daily, err := h.ensureDailyChallenge(date, difficulty)
+	if err != nil || daily == nil {
+		log.Printf("Failed to load daily challenge for %s %s: %v", date, difficulty, err)
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Daily challenge unavailable for %s mode", difficulty)})
+		return
+	}
+
+	// Hide prices and hint attributes from the player-facing copy of the cars
+	hiddenCars := make([]*models.EnhancedCar, len(daily.Cars))
+	for i, car := range daily.Cars {
+		hiddenCars[i] = car.WithoutHints()
+	}
+
+	session := &models.ChallengeSession{
+		SessionID:  generateSessionID(),
+		Difficulty: difficulty,
+		Cars:       hiddenCars,
+		PricedCars: daily.Cars,
+		Scoring:    models.DefaultScoringPolicy(), // Everyone plays the daily under the same rules
+		CurrentCar: 0,
+		Guesses:    make([]models.ChallengeGuess, 0),
+		TotalScore: 0,
+		IsComplete: false,
+		StartTime:  time.Now().Format(time.RFC3339),
+		ExpiresAt:  dailyDeadline(date).Format(time.RFC3339), // Can't be finished once the prices are in the archive
+	}
+	if userID != nil {
+		session.UserID = *userID
+	}
+
+	attempt = &models.DailyAttempt{
+		Date:       date,
+		Difficulty: difficulty,
+		PlayerKey:  playerKey,
+		UserID:     userID,
+	}
+
+	created, err := h.db.CreateDailyAttempt(attempt, session)
+	if err != nil {
+		log.Printf("Failed to create daily attempt: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start daily challenge"})
+		return
+	}
+	if !created {
+		// Lost a race with a concurrent start for the same player
+		c.JSON(http.StatusConflict, gin.H{"error": "You have already started today's daily challenge", "code": "DAILY_ALREADY_PLAYED"})
+		return
+	}
+
+	c.JSON(http.StatusOK, session)
+}
+
+// resumeDailyAttempt returns an unfinished daily attempt, or rejects a finished one
+func (h *Handler) resumeDailyAttempt(c *gin.Context, attempt *models.DailyAttempt) {
+	session, err := h.db.GetChallengeSession(attempt.SessionID)
+	if err != nil {
+		log.Printf("Failed to load daily session %s: %v", attempt.SessionID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start daily challenge"})
+		return
+	}
+
+	if session == nil || session.IsComplete {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":     "You have already played today's daily challenge",
+			"code":      "DAILY_ALREADY_PLAYED",
+			"sessionId": attempt.SessionID,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, session)
+}
+
+// GetDailyLeaderboard godoc
+// @Summary Get the daily challenge leaderboard
+// @Description Returns daily challenge scores for a single UTC date and difficulty, highest first
+// @Tags daily
+// @Produce json
+// @Param date query string false "UTC date (YYYY-MM-DD), defaults to today"
+// @Param difficulty query string false "Difficulty (easy or hard)" default(hard)
+// @Param limit query int false "Maximum number of entries to return (default: 10)"
+// @Success 200 {object} map[string]interface{} "date, difficulty, entries"
+// @Failure 400 {object} map[string]string "error: Invalid date"
+// @Router /api/daily/leaderboard [get]
+func (h *Handler) GetDailyLeaderboard(c *gin.Context) {
+	difficulty := h.resolveDifficulty(c.DefaultQuery("difficulty", "hard"))
+	date := c.DefaultQuery("date", todayUTC())
+	if _, err := time.Parse(dailyDateFormat, date); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date, expected YYYY-MM-DD"})
+		return
+	}
+
+	limit := 10 // Default limit
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if l := parseInt(limitStr); l > 0 && l <= 100 {
+			limit = l
+		}
+	}
+
+	entries, err := h.db.GetDailyLeaderboard(date, difficulty, limit)
+	if err != nil {
+		log.Printf("Failed to get daily leaderboard: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get leaderboard"})
+		return
+	}
+	if entries == nil {
+		entries = []models.LeaderboardEntry{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"date":       date,
+		"difficulty": difficulty,
+		"entries":    entries,
+	})
+}
+
+// GetDailyArchive godoc
+// @Summary Get past daily challenges
+// @Description Returns previous days' daily challenge car sets, newest first, with prices revealed. Today's set is never included.
+// @Tags daily
+// @Produce json
+// @Param difficulty query string false "Difficulty filter (easy or hard)"
+// @Param limit query int false "Maximum number of days to return (default: 30, max: 100)"
+// @Success 200 {object} map[string]interface{} "count, challenges"
+// @Router /api/daily/archive [get]
+func (h *Handler) GetDailyArchive(c *gin.Context) {
+	difficulty := c.Query("difficulty")
+	if difficulty != "" {
+		difficulty = h.resolveDifficulty(difficulty)
+	}
+
+	limit := defaultDailyArchiveLimit
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if l := parseInt(limitStr); l > 0 && l <= maxDailyArchiveLimit {
+			limit = l
+		}
+	}
+
+	archive, err := h.db.GetDailyArchive(difficulty, todayUTC(), limit)
+	if err != nil {
+		log.Printf("Failed to get daily archive: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get daily archive"})
+		return
+	}
+	if archive == nil {
+		archive = []models.DailyChallenge{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count":      len(archive),
+		"challenges": archive,
+	})
+}
+
+// findDailyPositionFromDB finds an entry's position on its day's daily leaderboard
+func (h *Handler) findDailyPositionFromDB(date string, entry models.LeaderboardEntry) int {
+	position, err := h.db.GetDailyRank(date, entry.Difficulty, entry.Score)
+	if err != nil {
+		log.Printf("Failed to get daily leaderboard position: %v", err)
+		return -1
+	}
+
+	return position
+}
@@ -0,0 +1,63 @@
+package game
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"autotraderguesser/internal/models"
+)
+
+func TestDailyAttemptClosesWhenArchived(t *testing.T) {
+	h, r := newTestHandler(t)
+	r.POST("/api/daily/start", h.StartDailyChallenge)
+	r.GET("/api/daily/archive", h.GetDailyArchive)
+	r.POST("/api/challenge/:sessionId/guess", h.SubmitChallengeGuess)
+
+	rec := performRequest(r, http.MethodPost, "/api/daily/start?difficulty=hard", nil, "dailyplayer00001")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected today's daily to start, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var today models.ChallengeSession
+	if err := json.Unmarshal(rec.Body.Bytes(), &today); err != nil {
+		t.Fatalf("failed to decode session: %v", err)
+	}
+	if want := dailyDeadline(todayUTC()).Format(time.RFC3339); today.ExpiresAt != want {
+		t.Fatalf("expected today's attempt to expire at %s, got %s", want, today.ExpiresAt)
+	}
+
+	// An attempt started just before midnight is still unfinished once yesterday's prices are archived
+	yesterday := time.Now().UTC().AddDate(0, 0, -1).Format(dailyDateFormat)
+	listing, _ := h.findListing("hard", "car-0001")
+	car := listing.ToEnhancedCar()
+	if err := h.db.CreateDailyChallenge(&models.DailyChallenge{Date: yesterday, Difficulty: "hard", Cars: []*models.EnhancedCar{car}}); err != nil {
+		t.Fatalf("CreateDailyChallenge failed: %v", err)
+	}
+	session := &models.ChallengeSession{
+		SessionID:  "latedailysession",
+		Difficulty: "hard",
+		Cars:       []*models.EnhancedCar{car.WithoutHints()},
+		PricedCars: []*models.EnhancedCar{car},
+		Scoring:    models.DefaultScoringPolicy(),
+		ExpiresAt:  dailyDeadline(yesterday).Format(time.RFC3339),
+	}
+	attempt := &models.DailyAttempt{Date: yesterday, Difficulty: "hard", PlayerKey: "session:latedailysession"}
+	if created, err := h.db.CreateDailyAttempt(attempt, session); err != nil || !created {
+		t.Fatalf("CreateDailyAttempt failed: created=%v err=%v", created, err)
+	}
+
+	rec = performRequest(r, http.MethodGet, "/api/daily/archive?difficulty=hard", nil, "")
+	var archive struct {
+		Challenges []models.DailyChallenge `json:"challenges"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &archive); err != nil || len(archive.Challenges) != 1 || archive.Challenges[0].Date != yesterday {
+		t.Fatalf("expected yesterday in the archive, got %s err=%v", rec.Body.String(), err)
+	}
+	actual := archive.Challenges[0].Cars[0].Price
+
+	rec = performRequest(r, http.MethodPost, "/api/challenge/latedailysession/guess", models.ChallengeGuessRequest{GuessedPrice: actual}, "")
+	if rec.Code != http.StatusGone {
+		t.Fatalf("expected the attempt to have closed with its day, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
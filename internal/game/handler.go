@@ -4,18 +4,17 @@ import (
 	"bytes"
 	"crypto/rand"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"math"
 	mathrand "math/rand"
 	"net/http"
-	"os"
 	"regexp"
 	"strconv"
 	"strings"
 	"sync"
-	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -24,58 +23,74 @@ import (
 	"autotraderguesser/internal/database"
 	"autotraderguesser/internal/models"
 	"autotraderguesser/internal/scraper"
+	"autotraderguesser/internal/sessions"
 	"autotraderguesser/internal/validation"
 )
 
 const ListingAmount int = 250
 
+const (
+	activeSessionWindow    = 30 * time.Minute // Sessions guessed within this window count as in progress
+	staleSessionAge        = 24 * time.Hour   // Streak/zero sessions idle for longer are deleted
+	sessionCleanupInterval = time.Hour
+
+	recentlyShownTTL         = 2 * time.Hour // Repeat avoidance only matters within a sitting
+	recentlyShownShards      = 32            // Spreads listing requests across locks
+	maxRecentlyShownSessions = 10000
+	maxChallengeSessions     = 5000 // In-memory fallback only, the database keeps every session
+)
+
 type Handler struct {
-	db                   *database.Database
-	scraper              *scraper.Scraper
-	bonhamsListings      map[string]*models.BonhamsCar // Hard mode data
-	lookersListings      map[string]*models.LookersCar // Easy mode data
-	mu                   sync.RWMutex
-	zeroScores           map[string]float64
-	streakScores         map[string]int
-	challengeSessions    map[string]*models.ChallengeSession
-	recentlyShown        map[string][]string // Track recently shown car IDs per session
-	bonhamsRefreshTicker *time.Ticker
-	lookersRefreshTicker *time.Ticker
-	isRefreshingBonhams  atomic.Bool // Prevents concurrent refreshes
-	isRefreshingLookers  atomic.Bool // Prevents concurrent refreshes
+	db                *database.Database
+	scraper           *scraper.Scraper
+	pools             map[string]*listingPool // Live listings per source, keyed by difficulty
+	mu                sync.RWMutex            // Serialises game session read-modify-writes; listings are lock-free snapshots
+	challengeSessions *sessions.Store[*models.ChallengeSession]
+	recentlyShown     *sessions.Sharded[[]string] // Track recently shown car IDs per session
+	currentListings   *sessions.Sharded[string]   // Listing each session was last served, awaiting its streak or zero mode guess
 }
 
-// NewHandler creates a game handler, primes both data sources, and starts refresh schedulers.
+// NewHandler creates a game handler backed by the default listing sources.
 func NewHandler(db *database.Database) *Handler {
+	return NewHandlerWithSources(db, DefaultSources())
+}
+
+// NewHandlerWithSources creates a game handler, primes every registered source, and starts refresh schedulers.
+func NewHandlerWithSources(db *database.Database, sources *scraper.Registry) *Handler {
 	h := &Handler{
 		db:                db,
-		scraper:           scraper.New(),
-		bonhamsListings:   make(map[string]*models.BonhamsCar),
-		lookersListings:   make(map[string]*models.LookersCar),
-		zeroScores:        make(map[string]float64),
-		streakScores:      make(map[string]int),
-		challengeSessions: make(map[string]*models.ChallengeSession),
-		recentlyShown:     make(map[string][]string),
+		scraper:           scraper.New(sources),
+		pools:             newListingPools(sources),
+		challengeSessions: sessions.New[*models.ChallengeSession]("challengeSessions", models.ChallengeSessionTTL, maxChallengeSessions),
+		recentlyShown:     sessions.NewSharded[[]string]("recentlyShown", recentlyShownShards, recentlyShownTTL, maxRecentlyShownSessions),
+		currentListings:   sessions.NewSharded[string]("currentListings", recentlyShownShards, recentlyShownTTL, maxRecentlyShownSessions),
 	}
 
-	// Initialize both scrapers before starting (both modes must be ready)
+	// Initialize every source before starting (all modes must be ready)
 	fmt.Println("Initializing CarGuessr data sources...")
-	fmt.Println("   Both Hard Mode (Bonhams) and Easy Mode (Lookers) must be ready before startup")
+	fmt.Println("   Every registered game mode must be ready before startup")
 
-	h.initializeBonhamsListings()
-	h.initializeLookersListings()
+	for _, pool := range h.orderedPools() {
+		h.initializeSource(pool)
+	}
 
-	// Verify both data sources are ready
+	// Verify all data sources are ready
 	h.verifyDataSourcesReady()
 	fmt.Println("All game modes ready for play!")
 
+	// Clean up abandoned streak/zero sessions periodically
+	go h.cleanupGameSessions()
+
+	// Archive leaderboard standings as periods close
+	go h.archiveLeaderboards()
+
 	// Start automatic refresh timers
 	h.startAutoRefresh()
 	fmt.Printf("Auto-refresh scheduled:\n")
-	fmt.Printf("  Bonhams (Hard): every 7 days (next: %s)\n",
-		time.Now().Add(7*24*time.Hour).Format("Mon, 02 Jan 2006 15:04"))
-	fmt.Printf("  Lookers (Easy): every 7 days (next: %s)\n",
-		time.Now().Add(7*24*time.Hour).Format("Mon, 02 Jan 2006 15:04"))
+	for _, pool := range h.orderedPools() {
+		fmt.Printf("  %s (%s): every 7 days (next: %s)\n", pool.source.Name(), pool.source.Difficulty(),
+			time.Now().Add(refreshInterval).Format("Mon, 02 Jan 2006 15:04"))
+	}
 
 	return h
 }
@@ -90,40 +105,16 @@ func NewHandler(db *database.Database) *Handler {
 // @Failure 429 {object} map[string]string "error: Too Many Requests - Rate limited"
 // @Router /api/random-listing [get]
 func (h *Handler) GetRandomListing(c *gin.Context) {
-	// Get session ID from header for history tracking
+	// Get session ID from header for history tracking (anonymous requests get no history)
 	sessionID := c.GetHeader("X-Session-ID")
-	if sessionID == "" {
-		sessionID = generateSessionID()
-	} else if err := validation.ValidateSessionID(sessionID); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID format"})
-		return
-	}
-
-	h.mu.Lock() // Using Lock instead of RLock because we modify recentlyShown
-	defer h.mu.Unlock()
-
-	// Get all Bonhams listing IDs
-	ids := make([]string, 0, len(h.bonhamsListings))
-	for id := range h.bonhamsListings {
-		ids = append(ids, id)
-	}
-
-	if len(ids) == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "No listings available"})
-		return
+	if sessionID != "" {
+		if err := validation.ValidateSessionID(sessionID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID format"})
+			return
+		}
 	}
 
-	// Select random listing avoiding recently shown cars
-	randomID := h.selectRandomCarWithHistory(sessionID, ids)
-	h.addToRecentlyShown(sessionID, randomID)
-
-	bonhamsListing := h.bonhamsListings[randomID]
-
-	// Convert to enhanced format and hide price
-	enhancedListing := bonhamsListing.ToEnhancedCar()
-	enhancedListing.Price = 0
-
-	c.JSON(http.StatusOK, enhancedListing)
+	h.serveRandomListing(c, "hard", sessionID)
 }
 
 // GetRandomEnhancedListing godoc
@@ -138,67 +129,44 @@ func (h *Handler) GetRandomListing(c *gin.Context) {
 func (h *Handler) GetRandomEnhancedListing(c *gin.Context) {
 	difficulty := c.DefaultQuery("difficulty", "hard") // Default to hard mode for backward compatibility
 
-	// Get session ID from header (frontend should send this)
+	// Get session ID from header (frontend should send this, anonymous requests get no history)
 	sessionID := c.GetHeader("X-Session-ID")
-	if sessionID == "" {
-		sessionID = generateSessionID() // Generate one if not provided
-	} else if err := validation.ValidateSessionID(sessionID); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID format"})
-		return
-	}
-
-	h.mu.Lock() // Using Lock instead of RLock because we modify recentlyShown
-	defer h.mu.Unlock()
-
-	if difficulty == "easy" {
-		// Easy mode - use Lookers listings
-		if len(h.lookersListings) == 0 {
-			c.JSON(http.StatusNotFound, gin.H{"error": "No easy mode listings available"})
+	if sessionID != "" {
+		if err := validation.ValidateSessionID(sessionID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID format"})
 			return
 		}
+	}
 
-		// Get all Lookers listing IDs
-		ids := make([]string, 0, len(h.lookersListings))
-		for id := range h.lookersListings {
-			ids = append(ids, id)
-		}
-
-		// Select random listing avoiding recently shown cars
-		randomID := h.selectRandomCarWithHistory(sessionID, ids)
-		h.addToRecentlyShown(sessionID, randomID)
-
-		lookersListing := h.lookersListings[randomID]
-
-		// Convert to enhanced format and hide price
-		enhancedListing := lookersListing.ToEnhancedCar()
-		enhancedListing.Price = 0
-
-		c.JSON(http.StatusOK, enhancedListing)
-	} else {
-		// Hard mode - use Bonhams listings (default)
-		if len(h.bonhamsListings) == 0 {
-			c.JSON(http.StatusNotFound, gin.H{"error": "No hard mode listings available"})
-			return
-		}
+	h.serveRandomListing(c, h.resolveDifficulty(difficulty), sessionID)
+}
 
-		// Get all Bonhams listing IDs
-		ids := make([]string, 0, len(h.bonhamsListings))
-		for id := range h.bonhamsListings {
-			ids = append(ids, id)
-		}
+// serveRandomListing responds with a random listing from a difficulty pool with its price hidden
+func (h *Handler) serveRandomListing(c *gin.Context, difficulty, sessionID string) {
+	pool, ok := h.pools[difficulty]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No listings available"})
+		return
+	}
 
-		// Select random listing avoiding recently shown cars
-		randomID := h.selectRandomCarWithHistory(sessionID, ids)
-		h.addToRecentlyShown(sessionID, randomID)
+	snap := pool.listings()
+	if len(snap.ids) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("No %s mode listings available", difficulty)})
+		return
+	}
 
-		bonhamsListing := h.bonhamsListings[randomID]
+	// Select random listing avoiding recently shown cars
+	randomID := h.selectRandomCarWithHistory(sessionID, snap.ids)
+	h.addToRecentlyShown(sessionID, randomID)
+	if sessionID != "" {
+		h.currentListings.Put(sessionID, randomID)
+	}
 
-		// Convert to enhanced format and hide price
-		enhancedListing := bonhamsListing.ToEnhancedCar()
-		enhancedListing.Price = 0
+	// Convert to enhanced format and hide price
+	enhancedListing := snap.byID[randomID].ToEnhancedCar()
+	enhancedListing.Price = 0
 
-		c.JSON(http.StatusOK, enhancedListing)
-	}
+	c.JSON(http.StatusOK, enhancedListing)
 }
 
 // CheckGuess godoc
@@ -209,8 +177,9 @@ func (h *Handler) GetRandomEnhancedListing(c *gin.Context) {
 // @Produce json
 // @Param guess body models.GuessRequest true "Price guess data (max price: £10,000,000)"
 // @Success 200 {object} models.GuessResponse
-// @Failure 400 {object} map[string]string "error: Invalid request or price exceeds maximum"
+// @Failure 400 {object} map[string]string "error: Invalid request, price exceeds maximum, or listing not served to this X-Session-ID"
 // @Failure 404 {object} map[string]string "error: Listing not found"
+// @Failure 409 {object} map[string]string "error: Listing already guessed in this game"
 // @Failure 429 {object} map[string]string "error: Too Many Requests - Rate limited"
 // @Router /api/check-guess [post]
 func (h *Handler) CheckGuess(c *gin.Context) {
@@ -245,52 +214,28 @@ func (h *Handler) CheckGuess(c *gin.Context) {
 		return
 	}
 
-	h.mu.RLock()
-
 	// Try to find the listing in the appropriate difficulty mode
-	var actualPrice float64
-	var originalURL string
-	var exists bool
-
 	difficulty := req.Difficulty
 	if difficulty == "" {
 		difficulty = "hard" // Default to hard mode for backward compatibility
 	}
 
-	if difficulty == "easy" {
-		// Check Lookers listings
-		if lookersListing, found := h.lookersListings[req.ListingID]; found {
-			actualPrice = lookersListing.Price
-			originalURL = lookersListing.OriginalURL
-			exists = true
-		}
-	} else {
-		// Check Bonhams listings (hard mode)
-		if bonhamsListing, found := h.bonhamsListings[req.ListingID]; found {
-			actualPrice = bonhamsListing.Price
-			originalURL = bonhamsListing.OriginalURL
-			exists = true
-		}
-	}
-
-	h.mu.RUnlock()
-
+	listing, exists := h.findListing(difficulty, req.ListingID)
 	if !exists {
 		log.Printf("CheckGuess: Listing not found - ID: %s, Difficulty: %s", req.ListingID, difficulty)
-		log.Printf("CheckGuess: Available listings (Hard): %d, (Easy): %d", len(h.bonhamsListings), len(h.lookersListings))
 		c.JSON(http.StatusNotFound, gin.H{"error": "Listing not found", "requestedId": req.ListingID, "difficulty": difficulty})
 		return
 	}
+	actualPrice := listing.ListingPrice()
+	originalURL := listing.ListingURL()
 
-	// Calculate difference and percentage
-	difference := math.Abs(actualPrice - req.GuessedPrice)
-	percentage := (difference / actualPrice) * 100
-
+	// Calculate difference and both accuracy metrics
 	response := models.GuessResponse{
 		ActualPrice:  actualPrice,
 		GuessedPrice: req.GuessedPrice,
-		Difference:   difference,
-		Percentage:   percentage,
+		Difference:   math.Abs(actualPrice - req.GuessedPrice),
+		Percentage:   models.PercentageError(req.GuessedPrice, actualPrice),
+		LogRatio:     models.LogRatioError(req.GuessedPrice, actualPrice),
 		OriginalURL:  originalURL,
 	}
 
@@ -303,53 +248,164 @@ func (h *Handler) CheckGuess(c *gin.Context) {
 		return
 	}
 
-	h.mu.Lock()
+	if req.GameMode == "zero" || req.GameMode == "streak" {
+		session, err := h.recordSessionGuess(c, sessionID, req.GameMode, difficulty, req.Metric, req.ListingID, req.GuessedPrice, actualPrice)
+		if errors.Is(err, models.ErrListingNotServed) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Listing was not served to this session"})
+			return
+		}
+		if errors.Is(err, models.ErrListingAlreadyGuessed) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Listing already guessed in this game"})
+			return
+		}
+		if err != nil {
+			log.Printf("CheckGuess: Failed to update game session %s: %v", sessionID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update game session"})
+			return
+		}
+		response.Score = session.CurrentScore
+
+		switch req.GameMode {
+		case "zero":
+			response.Correct = true // Always continue in this mode
+			response.Message = "Keep your cumulative difference as low as possible!"
+
+		case "streak":
+			if session.IsActive {
+				response.Correct = true
+				response.Message = "Great guess! Keep the streak going!"
+			} else {
+				response.Correct = false
+				response.GameOver = true
+				response.Message = "Game Over! Your guess was off by more than 10%"
+				if session.AccuracyMetric == models.MetricLogRatio {
+					response.Message = "Game Over! Your guess was off by more than a factor of 1.1"
+				}
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// recordSessionGuess applies a streak or zero mode guess to the persisted game session.
+// Only the listing last served to the session can be guessed, and only once, so a revealed price can't be replayed.
+func (h *Handler) recordSessionGuess(c *gin.Context, sessionID, gameMode, difficulty, metric, listingID string, guessedPrice, actualPrice float64) (*models.GameSession, error) {
+	h.mu.Lock() // Serialise read-modify-write of sessions
 	defer h.mu.Unlock()
 
-	switch req.GameMode {
+	if current, ok := h.currentListings.Get(sessionID); !ok || current != listingID {
+		return nil, models.ErrListingNotServed
+	}
+
+	// A streak keeps the tolerance metric it started with
+	session, _, err := h.loadOrStartSession(c, sessionID, gameMode, difficulty, metric)
+	if err != nil {
+		return nil, err
+	}
+	if isRecentlyShown(session.CarsShown, listingID) {
+		return nil, models.ErrListingAlreadyGuessed
+	}
+
+	session.CarsShown = append(session.CarsShown, listingID)
+
+	switch gameMode {
 	case "zero":
 		// Stay at Zero mode
-		h.zeroScores[sessionID] += difference
-		response.Score = int(h.zeroScores[sessionID])
-		response.Correct = true // Always continue in this mode
-		response.Message = "Keep your cumulative difference as low as possible!"
+		session.CurrentDifference += math.Abs(actualPrice - guessedPrice)
+		session.CurrentScore = int(session.CurrentDifference)
 
 	case "streak":
-		// Streak mode - must guess within 10%
-		if percentage <= 10 {
-			h.streakScores[sessionID]++
-			response.Correct = true
-			response.Score = h.streakScores[sessionID]
-			response.Message = "Great guess! Keep the streak going!"
+		// Streak mode - must guess within 10% under the session's accuracy metric
+		if models.WithinStreakTolerance(session.AccuracyMetric, guessedPrice, actualPrice) {
+			session.CurrentScore++
 		} else {
-			response.Correct = false
-			response.GameOver = true
-			response.Score = h.streakScores[sessionID]
-			response.Message = "Game Over! Your guess was off by more than 10%"
+			session.IsActive = false // Streak over
+		}
+	}
+
+	if err := h.db.UpdateGameSession(session); err != nil {
+		return nil, err
+	}
+	h.currentListings.Delete(sessionID)
+
+	return session, nil
+}
 
-			// Reset streak
-			delete(h.streakScores, sessionID)
+// loadOrStartSession returns the active game session for an ID, starting a new one when none exists
+// or the previous game with this ID has ended or was a different mode. A new session uses the given
+// accuracy metric, defaulting to percentage. Caller must hold h.mu.
+func (h *Handler) loadOrStartSession(c *gin.Context, sessionID, gameMode, difficulty, metric string) (session *models.GameSession, started bool, err error) {
+	session, err = h.db.GetGameSession(sessionID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if session != nil && session.IsActive && session.GameMode == gameMode && session.Difficulty == difficulty {
+		return session, false, nil
+	}
+
+	session = &models.GameSession{
+		SessionID:      sessionID,
+		GameMode:       gameMode,
+		Difficulty:     difficulty,
+		CarsShown:      make([]string, 0),
+		IsActive:       true,
+		AccuracyMetric: metric,
+		GameID:         generateSessionID(),
+	}
+
+	// Get user context if available
+	if user, exists := c.Get("user"); exists {
+		if u, ok := user.(*models.User); ok {
+			session.UserID = &u.ID
 		}
 	}
 
-	c.JSON(http.StatusOK, response)
+	if err := h.db.StartGameSession(session); err != nil {
+		return nil, false, err
+	}
+
+	return session, true, nil
 }
 
 // GetLeaderboard godoc
 // @Summary Get the game leaderboard
-// @Description Returns the leaderboard optionally filtered by game mode and difficulty, sorted by score (descending for challenge, ascending for streak)
+// @Description Returns the leaderboard optionally filtered by game mode and difficulty, best first. Each game mode ranks in its own direction (lowest first for zero mode, highest first otherwise) and breaks ties by its tie-breakers, such as the earliest submission. When more entries follow, the X-Next-Cursor response header holds the cursor for the next page. With aroundUser, returns the logged-in caller's best entry and the entries either side of it, with their ranks.
 // @Tags game
 // @Produce json
-// @Param mode query string false "Game mode filter (streak or challenge)"
+// @Param mode query string false "Game mode filter (challenge, streak, zero, daily or higherlower)"
 // @Param difficulty query string false "Difficulty filter (easy or hard)"
-// @Param limit query int false "Maximum number of entries to return (default: 10)"
-// @Success 200 {array} models.LeaderboardEntry
+// @Param period query string false "Only count entries from the current day, week, month or season (default: all)"
+// @Param view query string false "all for every entry, or best for each registered player's best entry (default: all; best needs mode and difficulty)"
+// @Param limit query int false "Maximum number of entries to return (default: 10, max: 100)"
+// @Param cursor query string false "X-Next-Cursor value from the previous page"
+// @Param aroundUser query int false "Return this many entries above and below the caller (1 to 25; needs mode, difficulty and login)"
+// @Param friends query bool false "Only show entries by the logged-in caller and their friends"
+// @Success 200 {array} models.LeaderboardEntry "Entries, or models.RankedLeaderboardEntry with aroundUser"
+// @Header 200 {string} X-Next-Cursor "Cursor for the next page, when there is one"
+// @Failure 400 {object} map[string]string "error: Unknown game mode, difficulty, period or view, or an invalid cursor"
+// @Failure 401 {object} map[string]string "error: aroundUser and friends need a logged-in user"
+// @Failure 404 {object} map[string]string "error: The caller has no entries on the leaderboard"
 // @Router /api/leaderboard [get]
 func (h *Handler) GetLeaderboard(c *gin.Context) {
 	gameMode := c.Query("mode")
 	difficulty := c.Query("difficulty")
 	limit := 10 // Default limit
 
+	mode := models.DefaultGameMode
+	if gameMode != "" {
+		var ok bool
+		if mode, ok = models.LookupGameMode(gameMode); !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown game mode"})
+			return
+		}
+	}
+	if difficulty != "" && !mode.HasDifficulty(difficulty) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown difficulty for this game mode"})
+		return
+	}
+
 	// Parse limit if provided
 	if limitStr := c.Query("limit"); limitStr != "" {
 		if l := parseInt(limitStr); l > 0 && l <= 100 {
@@ -357,26 +413,83 @@ func (h *Handler) GetLeaderboard(c *gin.Context) {
 		}
 	}
 
-	// Get leaderboard from database
-	entries, err := h.db.GetLeaderboard(gameMode, difficulty, limit)
+	window, ok := leaderboardWindow(c)
+	if !ok {
+		return
+	}
+
+	view := c.DefaultQuery("view", leaderboardViewAll)
+	if view != leaderboardViewAll && view != leaderboardViewBest {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "View must be all or best"})
+		return
+	}
+	if view == leaderboardViewBest && (gameMode == "" || difficulty == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The best view needs a game mode and difficulty"})
+		return
+	}
+
+	query := models.LeaderboardQuery{
+		GameMode:   gameMode,
+		Difficulty: difficulty,
+		Window:     window,
+		BestOnly:   view == leaderboardViewBest,
+	}
+
+	if c.Query("friends") == "true" {
+		user, ok := leaderboardUser(c, "Log in to see your friends leaderboard")
+		if !ok {
+			return
+		}
+		query.FriendsOf = user.ID
+	}
+
+	if around := c.Query("aroundUser"); around != "" {
+		h.leaderboardAroundUser(c, query, around)
+		return
+	}
+
+	if cursor := c.Query("cursor"); cursor != "" {
+		if query.After, ok = decodeLeaderboardCursor(cursor); !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+	}
+
+	// Read one entry past the page to tell whether another page follows
+	query.Limit = limit + 1
+	entries, err := h.db.QueryLeaderboard(query)
+	if errors.Is(err, models.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+		return
+	}
 	if err != nil {
 		log.Printf("Failed to get leaderboard from database: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch leaderboard"})
 		return
 	}
 
+	if len(entries) > limit {
+		entries = entries[:limit]
+		c.Header(nextCursorHeader, encodeLeaderboardCursor(entries[limit-1].ID))
+	}
+	if entries == nil {
+		entries = []models.LeaderboardEntry{}
+	}
+
 	c.JSON(http.StatusOK, entries)
 }
 
 // SubmitScore godoc
 // @Summary Submit a score to the leaderboard
-// @Description Submit a score to the leaderboard for streak or challenge mode. Validates the score against the session data.
+// @Description Submit a score to the leaderboard for streak, zero, challenge, daily or higherlower mode. The score is verified against the server-side session record and each game can only be submitted once; a streak or zero mode session ID can be reused for later games. Rejections include a "code" field (SESSION_REQUIRED, SESSION_NOT_FOUND, SESSION_ACTIVE, MODE_MISMATCH, SCORE_MISMATCH, SCORE_OUT_OF_RANGE, UNRANKED_POLICY, ALREADY_SUBMITTED). Challenges with a custom scoring policy or theme and streaks played with the log-ratio tolerance are not ranked.
 // @Tags game
 // @Accept json
 // @Produce json
 // @Param submission body models.LeaderboardSubmissionRequest true "Score submission data"
 // @Success 200 {object} map[string]interface{} "success message and leaderboard position"
-// @Failure 400 {object} map[string]string "error: Invalid request or score validation failed"
+// @Failure 400 {object} map[string]string "error and code: Invalid request or score validation failed"
+// @Failure 404 {object} map[string]string "error and code: Session not found"
+// @Failure 409 {object} map[string]string "error and code: Session already submitted"
 // @Failure 429 {object} map[string]string "error: Too Many Requests - Rate limited"
 // @Router /api/leaderboard/submit [post]
 func (h *Handler) SubmitScore(c *gin.Context) {
@@ -395,33 +508,33 @@ func (h *Handler) SubmitScore(c *gin.Context) {
 	// Sanitize name (remove any potentially harmful content)
 	req.Name = sanitizeName(req.Name)
 
-	h.mu.Lock()
-	defer h.mu.Unlock()
+	mode, ok := models.LookupGameMode(req.GameMode)
+	if !ok || (req.Difficulty != "" && !mode.HasDifficulty(req.Difficulty)) {
+		rejectSubmission(c, http.StatusBadRequest, submitErrModeMismatch, "Unknown game mode or difficulty")
+		return
+	}
+	if !mode.ScoreInBounds(req.Score) {
+		rejectSubmission(c, http.StatusBadRequest, submitErrScoreOutOfRange, "Score is outside the possible range for this game mode")
+		return
+	}
 
-	// Validate score for challenge mode by checking the session
-	if req.GameMode == "challenge" && req.SessionID != "" {
-		if session, exists := h.challengeSessions[req.SessionID]; exists {
-			if !session.IsComplete {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "Challenge session is not complete"})
-				return
-			}
-			if session.TotalScore != req.Score {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "Score does not match session data"})
-				return
-			}
-		} else {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
-			return
-		}
+	if req.SessionID == "" {
+		rejectSubmission(c, http.StatusBadRequest, submitErrSessionRequired, "A session ID is required to submit a score")
+		return
+	}
+	if err := validation.ValidateSessionID(req.SessionID); err != nil {
+		rejectSubmission(c, http.StatusBadRequest, submitErrSessionNotFound, "Invalid session ID")
+		return
 	}
 
-	// For streak mode, we'll trust the submitted score for now
-	// In a production environment, you'd want to validate this against session data too
+	// Serialise verification so a session can't be submitted twice concurrently
+	h.mu.Lock()
+	defer h.mu.Unlock()
 
-	// Create leaderboard entry
-	difficulty := req.Difficulty
-	if difficulty == "" {
-		difficulty = "hard" // Default to hard for backward compatibility
+	// Verify the score against the server-side session record
+	verified, ok := h.verifySubmission(c, &req)
+	if !ok {
+		return
 	}
 
 	// Get user context if available
@@ -435,18 +548,36 @@ func (h *Handler) SubmitScore(c *gin.Context) {
 	entry := models.LeaderboardEntry{
 		UserID:     userID,
 		Name:       req.Name,
-		Score:      req.Score,
+		Score:      verified.score,
 		GameMode:   req.GameMode,
-		Difficulty: difficulty,
+		Difficulty: verified.difficulty,
 		SessionID:  req.SessionID,
+		HintsUsed:  verified.hintsUsed,
+	}
+	// Streak and zero mode session IDs are reused by later games, so those entries are keyed by game
+	if verified.gameSession != nil {
+		entry.SessionID = verified.gameSession.GameID
 	}
 
-	// Save to database
-	if err := h.db.AddLeaderboardEntry(&entry); err != nil {
+	// Save to database (each session may only appear on the leaderboard once)
+	inserted, err := h.db.AddSessionLeaderboardEntry(&entry)
+	if err != nil {
 		log.Printf("Failed to save leaderboard entry to database: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save score"})
 		return
 	}
+	if !inserted {
+		rejectSubmission(c, http.StatusConflict, submitErrAlreadySubmitted, "This session has already been submitted to the leaderboard")
+		return
+	}
+
+	// Zero mode has no natural end, so submitting the score finishes the game
+	if verified.gameSession != nil && verified.gameSession.IsActive {
+		verified.gameSession.IsActive = false
+		if err := h.db.UpdateGameSession(verified.gameSession); err != nil {
+			log.Printf("Failed to close game session %s after submission: %v", req.SessionID, err)
+		}
+	}
 
 	// Update user's favorite difficulty and increment games played (for logged-in users)
 	if userID != nil {
@@ -464,734 +595,299 @@ func (h *Handler) SubmitScore(c *gin.Context) {
 	}
 
 	// Find position in leaderboard using database query
-	position := h.findLeaderboardPositionFromDB(entry)
-
-	c.JSON(http.StatusOK, gin.H{
-		"message":  "Score submitted successfully!",
-		"position": position,
-		"entry":    entry,
-	})
-}
-
-// TestScraper godoc
-// @Summary Test the car scraper directly (Admin Only)
-// @Description Tests the Bonhams scraper and returns up to 10 cars with full details. This is an expensive operation. Requires admin authentication.
-// @Tags admin
-// @Security AdminKey
-// @Produce json
-// @Success 200 {object} map[string]interface{} "message, count, and cars array"
-// @Failure 401 {object} map[string]string "error: Unauthorized - Admin key required"
-// @Failure 429 {object} map[string]string "error: Too Many Requests - Rate limited"
-// @Failure 500 {object} map[string]string "error and message"
-// @Router /api/admin/test-scraper [get]
-func (h *Handler) TestScraper(c *gin.Context) {
-	cars, err := h.scraper.GetCarListings(10)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   err.Error(),
-			"message": "Scraper failed",
-		})
-		return
-	}
-
-	if len(cars) == 0 {
-		c.JSON(http.StatusOK, gin.H{
-			"message": "No cars found from scraper",
-			"cars":    cars,
-		})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Scraper working!",
-		"count":   len(cars),
-		"cars":    cars,
-	})
-}
-
-// GetDataSource godoc
-// @Summary Get current data source information
-// @Description Returns information about both data sources (Bonhams and Lookers) including listing counts
-// @Tags debug
-// @Produce json
-// @Success 200 {object} map[string]interface{} "data sources info with Easy/Hard mode listings"
-// @Router /api/data-source [get]
-func (h *Handler) GetDataSource(c *gin.Context) {
-	dataSource := h.GetDataSourceInfo()
-	c.JSON(http.StatusOK, dataSource)
-}
-
-// GetDataSourceInfo returns data source information (for health checks and other internal use)
-func (h *Handler) GetDataSourceInfo() gin.H {
-	h.mu.RLock()
-	bonhamsListings := len(h.bonhamsListings)
-	lookersListings := len(h.lookersListings)
-	h.mu.RUnlock()
-
-	return gin.H{
-		"hard_mode": gin.H{
-			"data_source":    "bonhams_auctions",
-			"total_listings": bonhamsListings,
-			"description":    "Real Bonhams Car Auction results (Hard Mode)",
-		},
-		"easy_mode": gin.H{
-			"data_source":    "lookers_dealership",
-			"total_listings": lookersListings,
-			"description":    "Lookers used car dealership listings (Easy Mode)",
-		},
-		"total_listings":  bonhamsListings + lookersListings,
-		"modes_available": []string{"easy", "hard"},
-	}
-}
-
-// GetAllListings godoc
-// @Summary Get all available car listings (Admin Only)
-// @Description Returns all car listings currently loaded in the system with full details including prices. This reveals all answers and is restricted to admin access.
-// @Tags admin
-// @Security AdminKey
-// @Produce json
-// @Success 200 {object} map[string]interface{} "count and cars array"
-// @Failure 401 {object} map[string]string "error: Unauthorized - Admin key required"
-// @Failure 429 {object} map[string]string "error: Too Many Requests - Rate limited"
-// @Router /api/admin/listings [get]
-func (h *Handler) GetAllListings(c *gin.Context) {
-	h.mu.RLock()
-	defer h.mu.RUnlock()
-
-	cars := make([]*models.BonhamsCar, 0, len(h.bonhamsListings))
-	for _, car := range h.bonhamsListings {
-		cars = append(cars, car)
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"count": len(cars),
-		"cars":  cars,
-	})
-}
-
-func (h *Handler) initializeBonhamsListings() {
-	fmt.Println("Initializing Bonhams listings for Hard mode...")
-
-	// Try to load from cache first
-	if cachedListings, found := cache.LoadBonhamsFromCache(); found {
-		h.loadBonhamsListingsFromCache(cachedListings)
-		return
-	}
-
-	// Cache miss or expired, scrape fresh data (blocking to ensure Hard mode is ready)
-	fmt.Println("No Bonhams cache found - scraping fresh data before startup...")
-	h.refreshBonhamsListings()
-}
-
-func (h *Handler) loadBonhamsListingsFromCache(cachedListings []*models.BonhamsCar) {
-	h.mu.Lock()
-	defer h.mu.Unlock()
-
-	filteredCount := 0
-	for _, bonhamsCar := range cachedListings {
-		if bonhamsCar.Price != 700 {
-			h.bonhamsListings[bonhamsCar.ID] = bonhamsCar
-		} else {
-			filteredCount++
-			fmt.Printf("WARNING: Filtered cached car %s %s %d (£700 - no price found)\n", bonhamsCar.Make, bonhamsCar.Model, bonhamsCar.Year)
-		}
-	}
-
-	if filteredCount > 0 {
-		fmt.Printf("WARNING: Filtered %d cars with £700 price from cache\n", filteredCount)
-	}
-}
-
-// loadLookersFromCache loads Lookers listings from cache
-func (h *Handler) loadLookersFromCache(cachedListings []*models.LookersCar) {
-	h.mu.Lock()
-	defer h.mu.Unlock()
-
-	// Load Lookers listings
-	for _, lookersCar := range cachedListings {
-		h.lookersListings[lookersCar.ID] = lookersCar
-	}
-
-	fmt.Printf("Loaded %d Lookers cars from cache\n", len(cachedListings))
-}
-
-// verifyDataSourcesReady ensures both Hard and Easy modes have data available
-func (h *Handler) verifyDataSourcesReady() {
-	h.mu.RLock()
-	bonhamsCount := len(h.bonhamsListings)
-	lookersCount := len(h.lookersListings)
-	h.mu.RUnlock()
-
-	fmt.Printf("Data source verification:\n")
-	fmt.Printf("   Hard Mode (Bonhams): %d cars loaded\n", bonhamsCount)
-	fmt.Printf("   Easy Mode (Lookers): %d cars loaded\n", lookersCount)
-
-	if bonhamsCount == 0 {
-		fmt.Println("WARNING: Hard Mode has no cars - users will see errors!")
-	}
-	if lookersCount == 0 {
-		fmt.Println("WARNING: Easy Mode has no cars - users will see errors!")
-	}
-
-	if bonhamsCount > 0 && lookersCount > 0 {
-		fmt.Println("Both game modes have sufficient data")
-	}
-}
-
-// initializeLookersListings initializes Lookers listings for Easy mode
-func (h *Handler) initializeLookersListings() {
-	fmt.Println("Initializing Lookers listings for Easy mode...")
-
-	// Try to load from cache first
-	if cachedListings, found := cache.LoadLookersFromCache(); found {
-		h.loadLookersFromCache(cachedListings)
-		return
-	}
-
-	// Cache miss or expired, scrape fresh data (blocking to ensure Easy mode is ready)
-	fmt.Println("No Lookers cache found - scraping fresh data before startup...")
-	h.refreshLookersListings()
-}
-
-func (h *Handler) refreshBonhamsListings() {
-	fmt.Println("Refreshing listings from Bonhams Car Auctions...")
-
-	// Check if USE_BONHAMS_CACHE_ONLY env var is set (temporary fix)
-	useCacheOnly := os.Getenv("USE_BONHAMS_CACHE_ONLY")
-	if useCacheOnly == "true" || useCacheOnly == "1" {
-		fmt.Println("⚙️  USE_BONHAMS_CACHE_ONLY is set - loading from cache only")
-		cachedListings, cacheErr := cache.LoadBonhamsFromCacheIgnoreExpiry()
-		if cacheErr == nil && len(cachedListings) > 0 {
-			h.mu.Lock()
-			h.bonhamsListings = make(map[string]*models.BonhamsCar)
-			for _, bonhamsCar := range cachedListings {
-				if bonhamsCar.Price != 700 {
-					h.bonhamsListings[bonhamsCar.ID] = bonhamsCar
-				}
-			}
-			h.mu.Unlock()
-
-			// Bump expiry
-			if err := cache.BumpBonhamsExpiry(); err != nil {
-				fmt.Printf("WARNING: Failed to bump Bonhams cache expiry: %v\n", err)
-			}
-
-			fmt.Printf("Using %d cars from cache-only mode (extended expiry by 7 days)\n", len(h.bonhamsListings))
-			return
-		}
-		fmt.Println("ERROR: USE_BONHAMS_CACHE_ONLY set but no cache available!")
-	}
-
-	// Get fresh Bonhams data (250 cars with parallel scraping)
-	bonhamsCars, err := h.scraper.GetBonhamsListings(ListingAmount)
-	if err == nil && len(bonhamsCars) > 0 {
-		// Filter out listings with £700 price (indicates missing price data)
-		var validCars []*models.BonhamsCar
-		for _, car := range bonhamsCars {
-			if car.Price != 700 {
-				validCars = append(validCars, car)
-			} else {
-				fmt.Printf("WARNING: Filtered out %s %s %d (£700 - no price found)\n", car.Make, car.Model, car.Year)
-			}
-		}
-
-		h.mu.Lock()
-		// Clear existing listings
-		h.bonhamsListings = make(map[string]*models.BonhamsCar)
-
-		// Load valid listings only
-		for _, bonhamsCar := range validCars {
-			h.bonhamsListings[bonhamsCar.ID] = bonhamsCar
-		}
-		h.mu.Unlock()
-
-		// Save filtered listings to cache
-		if err := cache.SaveBonhamsToCache(validCars); err != nil {
-			fmt.Printf("WARNING: Failed to save Bonhams cache: %v\n", err)
-		}
-
-		fmt.Printf("Refreshed %d valid cars from Bonhams Car Auctions (%d filtered out)\n", len(validCars), len(bonhamsCars)-len(validCars))
-		return
-	}
-
-	// Scraping failed - try to fall back to cached data (even if expired)
-	fmt.Printf("ERROR: Bonhams scraper failed: %v\n", err)
-	fmt.Println("Attempting to load from expired cache as fallback...")
-
-	cachedListings, cacheErr := cache.LoadBonhamsFromCacheIgnoreExpiry()
-	if cacheErr == nil && len(cachedListings) > 0 {
-		// Successfully loaded from expired cache
-		h.mu.Lock()
-		h.bonhamsListings = make(map[string]*models.BonhamsCar)
-		for _, bonhamsCar := range cachedListings {
-			if bonhamsCar.Price != 700 {
-				h.bonhamsListings[bonhamsCar.ID] = bonhamsCar
-			}
-		}
-		h.mu.Unlock()
-
-		// Bump expiry to give us another week before retry
-		if err := cache.BumpBonhamsExpiry(); err != nil {
-			fmt.Printf("WARNING: Failed to bump Bonhams cache expiry: %v\n", err)
-		}
-
-		fmt.Printf("Using %d cars from fallback cache (extended expiry by 7 days)\n", len(h.bonhamsListings))
-		return
-	}
-
-	// No cache available - use minimal mock data as last resort
-	fmt.Println("ERROR: No cache available, creating minimal mock data for testing...")
-
-	mockCars := []*models.BonhamsCar{
-		{
-			ID:            "mock1",
-			Make:          "Volkswagen",
-			Model:         "Golf GTI",
-			Year:          2020,
-			Price:         18995,
-			Images:        []string{"https://images.unsplash.com/photo-1609521263047-f8f205293f24?w=600&h=400&fit=crop"},
-			OriginalURL:   "https://example.com/mock1",
-			Mileage:       "28,000 Miles",
-			Engine:        "1.4 TSI",
-			Gearbox:       "Manual",
-			ExteriorColor: "Silver",
-			InteriorColor: "Black",
-			Steering:      "Right-hand drive",
-			FuelType:      "Petrol",
-			KeyFacts:      []string{"Low mileage", "Full service history", "Great condition"},
-		},
-	}
-
-	h.mu.Lock()
-	defer h.mu.Unlock()
-
-	for _, mockCar := range mockCars {
-		h.bonhamsListings[mockCar.ID] = mockCar
-	}
-}
-
-// startAutoRefresh starts background goroutines that refresh listings on different schedules
-func (h *Handler) startAutoRefresh() {
-	// Create ticker for Bonhams (Hard mode) - 7-day intervals
-	h.bonhamsRefreshTicker = time.NewTicker(7 * 24 * time.Hour)
-
-	// Create ticker for Lookers (Easy mode) - 7-day intervals
-	h.lookersRefreshTicker = time.NewTicker(7 * 24 * time.Hour)
-
-	// Bonhams auto-refresh goroutine
-	go func() {
-		for range h.bonhamsRefreshTicker.C {
-			fmt.Println("Bonhams auto-refresh triggered (7 days elapsed)")
-
-			// Check if already refreshing
-			if h.isRefreshingBonhams.Load() {
-				fmt.Println("WARNING: Bonhams refresh already in progress, skipping")
-				continue
-			}
-
-			// Check for active games before refreshing
-			if h.hasActiveGames() {
-				fmt.Println("WARNING: Active games detected, postponing Bonhams refresh for 1 hour")
-				time.AfterFunc(1*time.Hour, func() {
-					// Check again if not already refreshing
-					if !h.isRefreshingBonhams.CompareAndSwap(false, true) {
-						fmt.Println("WARNING: Bonhams refresh already in progress, skipping postponed refresh")
-						return
-					}
-					go func() {
-						defer h.isRefreshingBonhams.Store(false)
-						h.refreshBonhamsListingsAsync()
-					}()
-				})
-			} else {
-				// Run refresh in background to avoid blocking gameplay
-				if h.isRefreshingBonhams.CompareAndSwap(false, true) {
-					go func() {
-						defer h.isRefreshingBonhams.Store(false)
-						h.refreshBonhamsListingsAsync()
-					}()
-				}
-			}
-		}
-	}()
-
-	// Lookers auto-refresh goroutine
-	go func() {
-		for range h.lookersRefreshTicker.C {
-			fmt.Println("Lookers auto-refresh triggered (7 days elapsed)")
-
-			// Check if already refreshing
-			if h.isRefreshingLookers.Load() {
-				fmt.Println("WARNING: Lookers refresh already in progress, skipping")
-				continue
-			}
-
-			// Check for active games before refreshing
-			if h.hasActiveGames() {
-				fmt.Println("WARNING: Active games detected, postponing Lookers refresh for 1 hour")
-				time.AfterFunc(1*time.Hour, func() {
-					// Check again if not already refreshing
-					if !h.isRefreshingLookers.CompareAndSwap(false, true) {
-						fmt.Println("WARNING: Lookers refresh already in progress, skipping postponed refresh")
-						return
-					}
-					go func() {
-						defer h.isRefreshingLookers.Store(false)
-						h.refreshLookersListingsAsync()
-					}()
-				})
-			} else {
-				// Run refresh in background to avoid blocking gameplay
-				if h.isRefreshingLookers.CompareAndSwap(false, true) {
-					go func() {
-						defer h.isRefreshingLookers.Store(false)
-						h.refreshLookersListingsAsync()
-					}()
-				}
-			}
-		}
-	}()
-}
-
-// hasActiveGames checks if there are any active game sessions or challenge sessions
-func (h *Handler) hasActiveGames() bool {
-	h.mu.RLock()
-	defer h.mu.RUnlock()
-
-	// Check for active challenge sessions (incomplete sessions)
-	for _, session := range h.challengeSessions {
-		if !session.IsComplete {
-			return true
-		}
-	}
-
-	// Check for active streak/zero mode sessions (non-zero scores indicate active games)
-	if len(h.streakScores) > 0 || len(h.zeroScores) > 0 {
-		return true
-	}
-
-	return false
-}
-
-// refreshListingsAsync performs a non-blocking refresh that doesn't interrupt gameplay
-func (h *Handler) refreshBonhamsListingsAsync() {
-	fmt.Println("Starting background refresh (non-blocking)...")
-
-	// Check if USE_BONHAMS_CACHE_ONLY env var is set (temporary fix)
-	useCacheOnly := os.Getenv("USE_BONHAMS_CACHE_ONLY")
-	if useCacheOnly == "true" || useCacheOnly == "1" {
-		fmt.Println("USE_BONHAMS_CACHE_ONLY is set - loading from cache only")
-		cachedListings, cacheErr := cache.LoadBonhamsFromCacheIgnoreExpiry()
-		if cacheErr == nil && len(cachedListings) > 0 {
-			h.mu.Lock()
-			oldCount := len(h.bonhamsListings)
-			h.bonhamsListings = make(map[string]*models.BonhamsCar)
-			for _, bonhamsCar := range cachedListings {
-				if bonhamsCar.Price != 700 {
-					h.bonhamsListings[bonhamsCar.ID] = bonhamsCar
-				}
-			}
-			h.mu.Unlock()
-
-			// Bump expiry
-			if err := cache.BumpBonhamsExpiry(); err != nil {
-				fmt.Printf("WARNING: Failed to bump Bonhams cache expiry: %v\n", err)
-			}
-
-			fmt.Printf("Background refresh used cache-only mode: %d cars (was %d, extended expiry by 7 days)\n",
-				len(h.bonhamsListings), oldCount)
-			return
-		}
-		fmt.Println("ERROR: USE_BONHAMS_CACHE_ONLY set but no cache available - keeping existing data")
-		return
-	}
-
-	// Get fresh Bonhams data (this may take a few minutes) - 250 cars with parallel scraping
-	bonhamsCars, err := h.scraper.GetBonhamsListings(ListingAmount)
-	if err != nil || len(bonhamsCars) == 0 {
-		fmt.Printf("ERROR: Background refresh failed: %v\n", err)
-
-		// Try to fall back to cached data (even if expired)
-		fmt.Println("Attempting to load from expired cache as fallback...")
-		cachedListings, cacheErr := cache.LoadBonhamsFromCacheIgnoreExpiry()
-		if cacheErr == nil && len(cachedListings) > 0 {
-			h.mu.Lock()
-			oldCount := len(h.bonhamsListings)
-			h.bonhamsListings = make(map[string]*models.BonhamsCar)
-			for _, bonhamsCar := range cachedListings {
-				if bonhamsCar.Price != 700 {
-					h.bonhamsListings[bonhamsCar.ID] = bonhamsCar
-				}
-			}
-			h.mu.Unlock()
-
-			// Bump expiry to give us another week before retry
-			if err := cache.BumpBonhamsExpiry(); err != nil {
-				fmt.Printf("WARNING: Failed to bump Bonhams cache expiry: %v\n", err)
-			}
-
-			fmt.Printf("Background refresh used fallback cache: %d cars (was %d, extended expiry by 7 days)\n",
-				len(h.bonhamsListings), oldCount)
-			return
-		}
-
-		fmt.Println("ERROR: No cache available for fallback - keeping existing data")
-		return
-	}
-
-	// Filter out listings with £700 price (indicates missing price data)
-	var validCars []*models.BonhamsCar
-	for _, car := range bonhamsCars {
-		if car.Price != 700 {
-			validCars = append(validCars, car)
-		}
-	}
-
-	// Quick atomic update - only lock briefly
-	h.mu.Lock()
-	oldCount := len(h.bonhamsListings)
-	h.bonhamsListings = make(map[string]*models.BonhamsCar)
-	for _, bonhamsCar := range validCars {
-		h.bonhamsListings[bonhamsCar.ID] = bonhamsCar
-	}
-	h.mu.Unlock()
-
-	// Save to cache (this can take time, but doesn't block gameplay)
-	if err := cache.SaveBonhamsToCache(validCars); err != nil {
-		fmt.Printf("WARNING: Failed to save Bonhams cache during background refresh: %v\n", err)
+	var position int
+	if verified.dailyDate != "" {
+		position = h.findDailyPositionFromDB(verified.dailyDate, entry)
+	} else {
+		position = h.findLeaderboardPositionFromDB(entry)
 	}
 
-	fmt.Printf("Background refresh complete: %d cars updated (was %d, filtered %d)\n",
-		len(validCars), oldCount, len(bonhamsCars)-len(validCars))
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Score submitted successfully!",
+		"position": position,
+		"entry":    entry,
+	})
 }
 
-// refreshLookersListings refreshes Lookers listings for Easy mode
-func (h *Handler) refreshLookersListings() {
-	fmt.Println("Refreshing Lookers listings for Easy mode...")
-
-	// Check if USE_LOOKERS_CACHE_ONLY env var is set (temporary fix)
-	useCacheOnly := os.Getenv("USE_LOOKERS_CACHE_ONLY")
-	if useCacheOnly == "true" || useCacheOnly == "1" {
-		fmt.Println("USE_LOOKERS_CACHE_ONLY is set - loading from cache only")
-		cachedListings, cacheErr := cache.LoadLookersFromCacheIgnoreExpiry()
-		if cacheErr == nil && len(cachedListings) > 0 {
-			h.mu.Lock()
-			h.lookersListings = make(map[string]*models.LookersCar)
-			for _, lookersCar := range cachedListings {
-				h.lookersListings[lookersCar.ID] = lookersCar
-			}
-			h.mu.Unlock()
+// Leaderboard submission error codes returned in the "code" field
+const (
+	submitErrSessionRequired  = "SESSION_REQUIRED"
+	submitErrSessionNotFound  = "SESSION_NOT_FOUND"
+	submitErrSessionActive    = "SESSION_ACTIVE"
+	submitErrModeMismatch     = "MODE_MISMATCH"
+	submitErrScoreMismatch    = "SCORE_MISMATCH"
+	submitErrScoreOutOfRange  = "SCORE_OUT_OF_RANGE"
+	submitErrAlreadySubmitted = "ALREADY_SUBMITTED"
+	submitErrUnrankedPolicy   = "UNRANKED_POLICY"
+)
 
-			// Bump expiry
-			if err := cache.BumpLookersExpiry(); err != nil {
-				fmt.Printf("WARNING: Failed to bump Lookers cache expiry: %v\n", err)
-			}
+// verifiedScore is a leaderboard score confirmed against a server-side session
+type verifiedScore struct {
+	score       int
+	difficulty  string
+	gameSession *models.GameSession // Set for streak and zero mode submissions
+	dailyDate   string              // Set for daily challenge submissions
+	hintsUsed   int                 // Set for challenge and daily submissions
+}
 
-			fmt.Printf("Using %d cars from cache-only mode (extended expiry by 7 days)\n", len(cachedListings))
-			return
-		}
-		fmt.Println("ERROR: USE_LOOKERS_CACHE_ONLY set but no cache available!")
-	}
-
-	// Get fresh Lookers data (scraper is now stateless)
-	lookersCars, err := h.scraper.GetLookersListings()
-	if err != nil || len(lookersCars) == 0 {
-		fmt.Printf("ERROR: Lookers scraper failed: %v\n", err)
-
-		// Try to fall back to cached data (even if expired)
-		fmt.Println("Attempting to load from expired cache as fallback...")
-		cachedListings, cacheErr := cache.LoadLookersFromCacheIgnoreExpiry()
-		if cacheErr == nil && len(cachedListings) > 0 {
-			h.mu.Lock()
-			h.lookersListings = make(map[string]*models.LookersCar)
-			for _, lookersCar := range cachedListings {
-				h.lookersListings[lookersCar.ID] = lookersCar
-			}
-			h.mu.Unlock()
+// rejectSubmission responds with a structured leaderboard submission error
+func rejectSubmission(c *gin.Context, status int, code, message string) {
+	c.JSON(status, gin.H{"error": message, "code": code})
+}
 
-			// Bump expiry to give us another week before retry
-			if err := cache.BumpLookersExpiry(); err != nil {
-				fmt.Printf("WARNING: Failed to bump Lookers cache expiry: %v\n", err)
-			}
+// verifySubmission checks a leaderboard submission against the stored session.
+// Writes an error response and returns false if the submission is rejected. Caller must hold h.mu.
+func (h *Handler) verifySubmission(c *gin.Context, req *models.LeaderboardSubmissionRequest) (*verifiedScore, bool) {
+	var verified verifiedScore
+
+	if req.GameMode == "challenge" || req.GameMode == "daily" {
+		session, err := h.db.GetChallengeSession(req.SessionID)
+		if err != nil {
+			log.Printf("Failed to load challenge session %s for submission: %v", req.SessionID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify score"})
+			return nil, false
+		}
+		if session == nil {
+			rejectSubmission(c, http.StatusNotFound, submitErrSessionNotFound, "Challenge session not found or expired")
+			return nil, false
+		}
+		if !session.IsComplete {
+			rejectSubmission(c, http.StatusBadRequest, submitErrSessionActive, "Challenge session is not complete")
+			return nil, false
+		}
+		// Only standard, unthemed challenges are ranked so leaderboard scores stay comparable
+		if !session.Scoring.IsDefault() || session.Theme != "" {
+			rejectSubmission(c, http.StatusBadRequest, submitErrUnrankedPolicy, "Challenges with a custom scoring policy or theme are not ranked")
+			return nil, false
+		}
 
-			fmt.Printf("Using %d cars from fallback cache (extended expiry by 7 days)\n", len(cachedListings))
-			return
+		// Daily attempts go on the daily leaderboard only, and vice versa
+		attempt, err := h.db.GetDailyAttemptBySession(req.SessionID)
+		if err != nil {
+			log.Printf("Failed to load daily attempt %s for submission: %v", req.SessionID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify score"})
+			return nil, false
+		}
+		if (attempt != nil) != (req.GameMode == "daily") {
+			rejectSubmission(c, http.StatusBadRequest, submitErrModeMismatch, "Session was not played in this game mode")
+			return nil, false
+		}
+		if attempt != nil {
+			verified.dailyDate = attempt.Date
 		}
 
-		fmt.Println("ERROR: No cache available for fallback")
-		return
+		verified.score = session.TotalScore
+		verified.difficulty = session.Difficulty
+		verified.hintsUsed = len(session.Hints)
+	} else {
+		session, err := h.db.GetGameSession(req.SessionID)
+		if err != nil {
+			log.Printf("Failed to load game session %s for submission: %v", req.SessionID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify score"})
+			return nil, false
+		}
+		if session == nil {
+			rejectSubmission(c, http.StatusNotFound, submitErrSessionNotFound, "Game session not found")
+			return nil, false
+		}
+		if session.GameMode != req.GameMode {
+			rejectSubmission(c, http.StatusBadRequest, submitErrModeMismatch, "Session was not played in this game mode")
+			return nil, false
+		}
+		// Streaks must be over before they can be submitted; zero mode is ended by submitting
+		if req.GameMode != "zero" && session.IsActive {
+			rejectSubmission(c, http.StatusBadRequest, submitErrSessionActive, "Game is still in progress")
+			return nil, false
+		}
+		// Only percentage-tolerance streaks are ranked so the streak leaderboard stays comparable
+		if session.AccuracyMetric != "" && session.AccuracyMetric != models.MetricPercentage {
+			rejectSubmission(c, http.StatusBadRequest, submitErrUnrankedPolicy, "Streaks played with the log-ratio tolerance are not ranked")
+			return nil, false
+		}
+		verified.score = session.CurrentScore
+		verified.difficulty = session.Difficulty
+		verified.gameSession = session
 	}
 
-	h.mu.Lock()
-	defer h.mu.Unlock()
-
-	// Clear existing Lookers listings
-	h.lookersListings = make(map[string]*models.LookersCar)
-
-	// Load Lookers listings
-	for _, lookersCar := range lookersCars {
-		h.lookersListings[lookersCar.ID] = lookersCar
+	if req.Difficulty != "" && req.Difficulty != verified.difficulty {
+		rejectSubmission(c, http.StatusBadRequest, submitErrModeMismatch, "Session was not played on this difficulty")
+		return nil, false
 	}
-
-	if err := cache.SaveLookersToCache(lookersCars); err != nil {
-		fmt.Printf("WARNING: Failed to save Lookers cache: %v\n", err)
+	if req.Score != verified.score {
+		rejectSubmission(c, http.StatusBadRequest, submitErrScoreMismatch, "Score does not match session data")
+		return nil, false
 	}
 
-	fmt.Printf("Refreshed %d cars from Lookers for Easy mode\n", len(lookersCars))
+	return &verified, true
 }
 
-// refreshLookersListingsAsync performs a non-blocking Lookers refresh that doesn't interrupt gameplay
-func (h *Handler) refreshLookersListingsAsync() {
-	fmt.Println("Starting Lookers background refresh (non-blocking)...")
-
-	// Check if USE_LOOKERS_CACHE_ONLY env var is set (temporary fix)
-	useCacheOnly := os.Getenv("USE_LOOKERS_CACHE_ONLY")
-	if useCacheOnly == "true" || useCacheOnly == "1" {
-		fmt.Println("USE_LOOKERS_CACHE_ONLY is set - loading from cache only")
-		cachedListings, cacheErr := cache.LoadLookersFromCacheIgnoreExpiry()
-		if cacheErr == nil && len(cachedListings) > 0 {
-			h.mu.Lock()
-			oldCount := len(h.lookersListings)
-			h.lookersListings = make(map[string]*models.LookersCar)
-			for _, lookersCar := range cachedListings {
-				h.lookersListings[lookersCar.ID] = lookersCar
-			}
-			h.mu.Unlock()
-
-			// Bump expiry
-			if err := cache.BumpLookersExpiry(); err != nil {
-				fmt.Printf("WARNING: Failed to bump Lookers cache expiry: %v\n", err)
-			}
+// TestScraper godoc
+// @Summary Test the car scraper directly (Admin Only)
+// @Description Tests the Bonhams scraper and returns up to 10 cars with full details. This is an expensive operation. Requires admin authentication.
+// @Tags admin
+// @Security AdminKey
+// @Produce json
+// @Success 200 {object} map[string]interface{} "message, count, and cars array"
+// @Failure 401 {object} map[string]string "error: Unauthorized - Admin key required"
+// @Failure 429 {object} map[string]string "error: Too Many Requests - Rate limited"
+// @Failure 500 {object} map[string]string "error and message"
+// @Router /api/admin/test-scraper [get]
+func (h *Handler) TestScraper(c *gin.Context) {
+	cars, err := h.scraper.GetCarListings(10)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   err.Error(),
+			"message": "Scraper failed",
+		})
+		return
+	}
 
-			fmt.Printf("Lookers background refresh used cache-only mode: %d cars (was %d, extended expiry by 7 days)\n",
-				len(cachedListings), oldCount)
-			return
-		}
-		fmt.Println("ERROR: USE_LOOKERS_CACHE_ONLY set but no cache available - keeping existing data")
+	if len(cars) == 0 {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "No cars found from scraper",
+			"cars":    cars,
+		})
 		return
 	}
 
-	// Get fresh Lookers data (scraper is now stateless)
-	lookersCars, err := h.scraper.GetLookersListings()
-	if err != nil || len(lookersCars) == 0 {
-		fmt.Printf("ERROR: Lookers background refresh failed: %v\n", err)
-
-		// Try to fall back to cached data (even if expired)
-		fmt.Println("Attempting to load from expired cache as fallback...")
-		cachedListings, cacheErr := cache.LoadLookersFromCacheIgnoreExpiry()
-		if cacheErr == nil && len(cachedListings) > 0 {
-			h.mu.Lock()
-			oldCount := len(h.lookersListings)
-			h.lookersListings = make(map[string]*models.LookersCar)
-			for _, lookersCar := range cachedListings {
-				h.lookersListings[lookersCar.ID] = lookersCar
-			}
-			h.mu.Unlock()
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Scraper working!",
+		"count":   len(cars),
+		"cars":    cars,
+	})
+}
 
-			// Bump expiry to give us another week before retry
-			if err := cache.BumpLookersExpiry(); err != nil {
-				fmt.Printf("WARNING: Failed to bump Lookers cache expiry: %v\n", err)
-			}
+// GetDataSource godoc
+// @Summary Get current data source information
+// @Description Returns information about both data sources (Bonhams and Lookers) including listing counts
+// @Tags debug
+// @Produce json
+// @Success 200 {object} map[string]interface{} "data sources info with Easy/Hard mode listings"
+// @Router /api/data-source [get]
+func (h *Handler) GetDataSource(c *gin.Context) {
+	dataSource := h.GetDataSourceInfo()
+	c.JSON(http.StatusOK, dataSource)
+}
 
-			fmt.Printf("Lookers background refresh used fallback cache: %d cars (was %d, extended expiry by 7 days)\n",
-				len(cachedListings), oldCount)
-			return
-		}
+// GetDataSourceInfo returns data source information (for health checks and other internal use)
+func (h *Handler) GetDataSourceInfo() gin.H {
+	info := gin.H{}
+	totalListings := 0
+	modes := make([]string, 0, len(h.pools))
 
-		fmt.Println("ERROR: No cache available for fallback - keeping existing data")
-		return
-	}
+	for _, pool := range h.orderedPools() {
+		count := len(pool.listings().ids)
 
-	// Quick atomic update - only lock briefly
-	h.mu.Lock()
-	oldCount := len(h.lookersListings)
-	h.lookersListings = make(map[string]*models.LookersCar)
-	for _, lookersCar := range lookersCars {
-		h.lookersListings[lookersCar.ID] = lookersCar
+		info[pool.source.Difficulty()+"_mode"] = gin.H{
+			"data_source":    pool.source.Name(),
+			"total_listings": count,
+			"description":    pool.source.Description(),
+		}
+		totalListings += count
+		modes = append(modes, pool.source.Difficulty())
 	}
-	h.mu.Unlock()
 
-	// Save to cache (this can take time, but doesn't block gameplay)
-	if err := cache.SaveLookersToCache(lookersCars); err != nil {
-		fmt.Printf("WARNING: Failed to save Lookers cache during background refresh: %v\n", err)
+	info["total_listings"] = totalListings
+	info["modes_available"] = modes
+	return info
+}
+
+// GetAllListings godoc
+// @Summary Get all available car listings (Admin Only)
+// @Description Returns all car listings currently loaded in the system with full details including prices. This reveals all answers and is restricted to admin access.
+// @Tags admin
+// @Security AdminKey
+// @Produce json
+// @Success 200 {object} map[string]interface{} "count and cars array"
+// @Failure 401 {object} map[string]string "error: Unauthorized - Admin key required"
+// @Failure 429 {object} map[string]string "error: Too Many Requests - Rate limited"
+// @Router /api/admin/listings [get]
+func (h *Handler) GetAllListings(c *gin.Context) {
+	cars := make([]models.Listing, 0)
+	for _, pool := range h.orderedPools() {
+		snap := pool.listings()
+		for _, id := range snap.ids {
+			cars = append(cars, snap.byID[id])
+		}
 	}
 
-	fmt.Printf("Lookers background refresh complete: %d cars updated (was %d)\n",
-		len(lookersCars), oldCount)
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(cars),
+		"cars":  cars,
+	})
 }
 
-// StopAutoRefresh stops the automatic refresh tickers (useful for cleanup)
-func (h *Handler) StopAutoRefresh() {
-	if h.bonhamsRefreshTicker != nil {
-		h.bonhamsRefreshTicker.Stop()
-		fmt.Println("Bonhams auto-refresh stopped")
+// hasActiveGames checks if any streak, zero or challenge session has been played recently
+func (h *Handler) hasActiveGames() bool {
+	// Check for unfinished challenge sessions with a recent start or guess
+	now := time.Now()
+	if h.challengeSessions.ActiveWithin(activeSessionWindow, func(session *models.ChallengeSession) bool {
+		return !session.IsComplete && !session.IsExpired(now)
+	}) {
+		return true
 	}
-	if h.lookersRefreshTicker != nil {
-		h.lookersRefreshTicker.Stop()
-		fmt.Println("Lookers auto-refresh stopped")
+
+	// Check for streak/zero mode sessions with a recent guess
+	if count, err := h.db.CountActiveGameSessions(activeSessionWindow); err != nil {
+		log.Printf("Failed to count active game sessions: %v", err)
+	} else if count > 0 {
+		return true
 	}
+
+	return false
 }
 
 // ManualRefresh godoc
 // @Summary Manually refresh car listings (Admin Only)
-// @Description Triggers a non-blocking background refresh of car listings. Supports mode query param (bonhams/lookers/both). Requires admin authentication and has a 30-minute cooldown between requests. Game continues normally during refresh.
+// @Description Triggers a non-blocking background refresh of car listings. Supports mode query param naming a listing source (bonhams/lookers) or "both" for every source. Requires admin authentication and has a 30-minute cooldown between requests. Game continues normally during refresh.
 // @Tags admin
 // @Security AdminKey
 // @Produce json
-// @Param mode query string false "Refresh mode (bonhams, lookers, both)" Enums(bonhams, lookers, both) default(both)
+// @Param mode query string false "Refresh mode (a source name such as bonhams or lookers, or both)" default(both)
 // @Success 200 {object} map[string]interface{} "message: refresh started, status: refreshing, note: game continues normally"
+// @Failure 400 {object} map[string]string "error: Invalid mode"
 // @Failure 401 {object} map[string]string "error: Unauthorized - Admin key required"
 // @Failure 429 {object} map[string]string "error: Too Many Requests - Rate limited or refresh cooldown active"
 // @Router /api/admin/refresh-listings [post]
 func (h *Handler) ManualRefresh(c *gin.Context) {
-	mode := c.DefaultQuery("mode", "both") // Default to refreshing both
+	mode := c.DefaultQuery("mode", "both") // Default to refreshing every source
 
 	fmt.Printf("🔄 Manual refresh requested for mode: %s\n", mode)
 
-	switch mode {
-	case "bonhams":
-		go func() {
-			h.refreshBonhamsListingsAsync()
-		}()
-		c.JSON(http.StatusOK, gin.H{
-			"message": "Bonhams refresh started in background (non-blocking)",
-			"status":  "refreshing",
-			"mode":    "bonhams",
-			"note":    "Game will continue normally while refresh happens in background",
-		})
-	case "lookers":
-		go func() {
-			h.refreshLookersListingsAsync()
-		}()
-		c.JSON(http.StatusOK, gin.H{
-			"message": "Lookers refresh started in background (non-blocking)",
-			"status":  "refreshing",
-			"mode":    "lookers",
-			"note":    "Game will continue normally while refresh happens in background",
-		})
-	case "both":
-		go func() {
-			h.refreshBonhamsListingsAsync()
-		}()
-		go func() {
-			h.refreshLookersListingsAsync()
-		}()
-		c.JSON(http.StatusOK, gin.H{
-			"message": "Both Bonhams and Lookers refresh started in background (non-blocking)",
-			"status":  "refreshing",
-			"mode":    "both",
-			"note":    "Game will continue normally while refresh happens in background",
-		})
-	default:
+	var pools []*listingPool
+	if mode == "both" || mode == "all" {
+		pools = h.orderedPools()
+	} else if pool, ok := h.poolForSource(mode); ok {
+		pools = []*listingPool{pool}
+	} else {
+		names := make([]string, 0, len(h.pools))
+		for _, pool := range h.orderedPools() {
+			names = append(names, pool.source.Name())
+		}
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid mode. Use: bonhams, lookers, or both",
+			"error": fmt.Sprintf("Invalid mode. Use: %s, or both", strings.Join(names, ", ")),
 		})
+		return
 	}
+
+	started := make([]string, 0, len(pools))
+	for _, pool := range pools {
+		if h.triggerBackgroundRefresh(pool) {
+			started = append(started, pool.source.Name())
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("Refresh started in background for: %s (non-blocking)", strings.Join(started, ", ")),
+		"status":  "refreshing",
+		"mode":    mode,
+		"note":    "Game will continue normally while refresh happens in background",
+	})
 }
 
 // GetCacheStatus godoc
 // @Summary Get cache status information (Admin Only)
-// @Description Returns information about the current cache status, age, and listing counts. Requires admin authentication.
+// @Description Returns information about the current cache status, age, and listing counts for every listing source. Requires admin authentication.
 // @Tags admin
 // @Security AdminKey
 // @Produce json
@@ -1200,118 +896,87 @@ func (h *Handler) ManualRefresh(c *gin.Context) {
 // @Failure 429 {object} map[string]string "error: Too Many Requests - Rate limited"
 // @Router /api/admin/cache-status [get]
 func (h *Handler) GetCacheStatus(c *gin.Context) {
-	bonhamsAge, bonhamsErr := cache.GetBonhamsCacheAge()
-	lookersAge, lookersErr := cache.GetLookersCacheAge()
-	bonhamsExpired := cache.IsBonhamsCacheExpired()
-	lookersExpired := cache.IsLookersCacheExpired()
+	status := gin.H{}
+	totalListings := 0
 
-	h.mu.RLock()
-	bonhamsListings := len(h.bonhamsListings)
-	lookersListings := len(h.lookersListings)
-	h.mu.RUnlock()
+	for _, pool := range h.orderedPools() {
+		count := len(pool.listings().ids)
+		totalListings += count
 
-	status := gin.H{
-		"total_listings": bonhamsListings + lookersListings,
-		"bonhams": gin.H{
-			"listings":        bonhamsListings,
-			"cache_expired":   bonhamsExpired,
-			"next_refresh_in": "up to 7 days",
-		},
-		"lookers": gin.H{
-			"listings":        lookersListings,
-			"cache_expired":   lookersExpired,
+		sourceStatus := gin.H{
+			"listings":        count,
+			"difficulty":      pool.source.Difficulty(),
+			"cache_expired":   cache.IsExpired(pool.source.CacheKey()),
 			"next_refresh_in": "up to 7 days",
-		},
-	}
+		}
 
-	// Bonhams cache age
-	if bonhamsErr == nil {
-		status["bonhams"].(gin.H)["cache_age"] = bonhamsAge.Round(time.Hour).String()
-		status["bonhams"].(gin.H)["cache_age_hours"] = bonhamsAge.Hours()
-	} else {
-		status["bonhams"].(gin.H)["cache_age"] = "no cache file"
-	}
+		if age, err := cache.Age(pool.source.CacheKey()); err == nil {
+			sourceStatus["cache_age"] = age.Round(time.Hour).String()
+			sourceStatus["cache_age_hours"] = age.Hours()
+		} else {
+			sourceStatus["cache_age"] = "no cache file"
+		}
 
-	// Lookers cache age
-	if lookersErr == nil {
-		status["lookers"].(gin.H)["cache_age"] = lookersAge.Round(time.Hour).String()
-		status["lookers"].(gin.H)["cache_age_hours"] = lookersAge.Hours()
-	} else {
-		status["lookers"].(gin.H)["cache_age"] = "no cache file"
+		status[pool.source.Name()] = sourceStatus
 	}
 
+	status["total_listings"] = totalListings
 	c.JSON(http.StatusOK, status)
 }
 
+// GetSessionStats godoc
+// @Summary Get in-memory session statistics (Admin Only)
+// @Description Returns the size, limits and eviction counters of every in-memory session store, and whether any game is currently being played. Requires admin authentication.
+// @Tags admin
+// @Security AdminKey
+// @Produce json
+// @Success 200 {object} map[string]interface{} "stores array, activeGames"
+// @Failure 401 {object} map[string]string "error: Unauthorized - Admin key required"
+// @Failure 429 {object} map[string]string "error: Too Many Requests - Rate limited"
+// @Router /api/admin/session-stats [get]
+func (h *Handler) GetSessionStats(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"stores":      []sessions.Stats{h.challengeSessions.Stats(), h.recentlyShown.Stats(), h.currentListings.Stats()},
+		"activeGames": h.hasActiveGames(),
+	})
+}
+
 // StartChallenge godoc
 // @Summary Start a new Challenge Mode session
-// @Description Starts a new 10-car challenge session with GeoGuessr-style scoring. Supports difficulty query param (easy/hard). Rate limited to 60 requests per minute per IP.
+// @Description Starts a new challenge session with GeoGuessr-style scoring. Supports difficulty query param (easy/hard), an optional scoring policy (the default is 10 cars on an exponential curve) and an optional theme that limits which cars are picked. Rate limited to 60 requests per minute per IP.
 // @Tags challenge
 // @Produce json
 // @Param difficulty query string false "Difficulty mode (easy for Lookers, hard for Bonhams)" Enums(easy, hard)
-// @Success 200 {object} models.ChallengeSession "sessionId, cars array (10 cars with prices hidden), currentCar: 0, totalScore: 0"
-// @Failure 404 {object} map[string]string "error: Not enough cars available for challenge mode"
+// @Param carCount query int false "Number of cars" Enums(5, 10, 20)
+// @Param curve query string false "Scoring curve" Enums(linear, exponential, logratio)
+// @Param scale query number false "How quickly points fall off (curve-specific)"
+// @Param cutoff query number false "Error at or beyond which a guess scores nothing: a percentage, or |ln(guess/actual)| for logratio"
+// @Param theme query string false "Challenge theme slug"
+// @Success 200 {object} models.ChallengeSession "sessionId, cars array (prices hidden), scoring, theme, currentCar: 0, totalScore: 0"
+// @Failure 400 {object} map[string]interface{} "error: Invalid scoring policy, or code THEME_TOO_NARROW with matched and needed car counts"
+// @Failure 404 {object} map[string]string "error: Not enough cars available for challenge mode, or theme not found"
 // @Failure 429 {object} map[string]string "error: Too Many Requests - Rate limited"
 // @Router /api/challenge/start [post]
 func (h *Handler) StartChallenge(c *gin.Context) {
-	difficulty := c.DefaultQuery("difficulty", "hard") // Default to hard mode for backward compatibility
-
-	h.mu.RLock()
-
-	var selectedCars []*models.EnhancedCar
-
-	if difficulty == "easy" {
-		// Easy mode - use Lookers listings
-		if len(h.lookersListings) < 10 {
-			h.mu.RUnlock()
-			c.JSON(http.StatusNotFound, gin.H{"error": "Not enough easy mode cars available for challenge mode"})
-			return
-		}
-
-		// Select 10 random Lookers cars
-		var allCars []*models.LookersCar
-		for _, car := range h.lookersListings {
-			allCars = append(allCars, car)
-		}
-		h.mu.RUnlock() // Release read lock before processing
-
-		// Shuffle and select 10
-		mathrand.Shuffle(len(allCars), func(i, j int) {
-			allCars[i], allCars[j] = allCars[j], allCars[i]
-		})
-
-		selectedCars = make([]*models.EnhancedCar, 10)
-		for i := 0; i < 10; i++ {
-			enhancedCar := allCars[i].ToEnhancedCar()
-			enhancedCar.Price = 0 // Hide price for guessing
-			selectedCars[i] = enhancedCar
-		}
-	} else {
-		// Hard mode - use Bonhams listings (default)
-		if len(h.bonhamsListings) < 10 {
-			h.mu.RUnlock()
-			c.JSON(http.StatusNotFound, gin.H{"error": "Not enough hard mode cars available for challenge mode"})
-			return
-		}
+	difficulty := h.resolveDifficulty(c.DefaultQuery("difficulty", "hard")) // Default to hard mode for backward compatibility
 
-		// Select 10 random Bonhams cars
-		var allCars []*models.BonhamsCar
-		for _, car := range h.bonhamsListings {
-			allCars = append(allCars, car)
-		}
-		h.mu.RUnlock() // Release read lock before processing
+	var policy models.ScoringPolicy
+	if err := c.ShouldBindQuery(&policy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid scoring policy", "details": err.Error()})
+		return
+	}
+	policy = policy.WithDefaults()
 
-		// Shuffle and select 10
-		mathrand.Shuffle(len(allCars), func(i, j int) {
-			allCars[i], allCars[j] = allCars[j], allCars[i]
-		})
+	theme, err := h.findTheme(c.Query("theme"))
+	if err != nil {
+		respondChallengeSetupError(c, difficulty, err)
+		return
+	}
 
-		selectedCars = make([]*models.EnhancedCar, 10)
-		for i := 0; i < 10; i++ {
-			enhancedCar := allCars[i].ToEnhancedCar()
-			enhancedCar.Price = 0 // Hide price for guessing
-			selectedCars[i] = enhancedCar
-		}
+	selectedCars, pricedCars, err := h.selectChallengeCars(difficulty, policy.CarCount, nil, theme)
+	if err != nil {
+		respondChallengeSetupError(c, difficulty, err)
+		return
 	}
 
 	// Create challenge session
@@ -1320,6 +985,9 @@ func (h *Handler) StartChallenge(c *gin.Context) {
 		SessionID:  sessionID,
 		Difficulty: difficulty, // Add the missing difficulty field
 		Cars:       selectedCars,
+		PricedCars: pricedCars,
+		Scoring:    policy,
+		Theme:      c.Query("theme"), // Checked by findTheme above
 		CurrentCar: 0,
 		Guesses:    make([]models.ChallengeGuess, 0),
 		TotalScore: 0,
@@ -1344,9 +1012,7 @@ func (h *Handler) StartChallenge(c *gin.Context) {
 	}
 
 	// Also store in memory for backward compatibility during transition
-	h.mu.Lock()
-	h.challengeSessions[sessionID] = session
-	h.mu.Unlock()
+	h.challengeSessions.Put(sessionID, session)
 
 	c.JSON(http.StatusOK, session)
 }
@@ -1359,6 +1025,7 @@ func (h *Handler) StartChallenge(c *gin.Context) {
 // @Param sessionId path string true "Session ID"
 // @Success 200 {object} models.ChallengeSession
 // @Failure 404 {object} map[string]string "error: Session not found"
+// @Failure 410 {object} map[string]string "error: Session expired, code SESSION_EXPIRED"
 // @Router /api/challenge/{sessionId} [get]
 func (h *Handler) GetChallengeSession(c *gin.Context) {
 	sessionID := c.Param("sessionId")
@@ -1369,24 +1036,8 @@ func (h *Handler) GetChallengeSession(c *gin.Context) {
 		return
 	}
 
-	// Try to get session from database first
-	session, err := h.db.GetChallengeSession(sessionID)
-	if err != nil {
-		log.Printf("Failed to get challenge session from database: %v", err)
-		// Fallback to in-memory storage
-		h.mu.RLock()
-		sessionMem, exists := h.challengeSessions[sessionID]
-		h.mu.RUnlock()
-
-		if !exists {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Challenge session not found"})
-			return
-		}
-		session = sessionMem
-	}
-
-	if session == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Challenge session not found"})
+	session, ok := h.loadChallengeSession(c, sessionID)
+	if !ok {
 		return
 	}
 
@@ -1395,7 +1046,7 @@ func (h *Handler) GetChallengeSession(c *gin.Context) {
 
 // SubmitChallengeGuess godoc
 // @Summary Submit a guess for challenge mode
-// @Description Submit a price guess for the current car in challenge mode. Returns points based on accuracy (max 5000 points). Rate limited to 60 requests per minute per IP.
+// @Description Submit a price guess for the current car in challenge mode. Returns points based on accuracy (max 5000 points, less the cost of any hints unlocked for the car). Rate limited to 60 requests per minute per IP.
 // @Tags challenge
 // @Accept json
 // @Produce json
@@ -1404,6 +1055,7 @@ func (h *Handler) GetChallengeSession(c *gin.Context) {
 // @Success 200 {object} models.ChallengeResponse "points earned, totalScore, isLastCar, message, originalUrl"
 // @Failure 400 {object} map[string]string "error: Invalid request, session complete, or price exceeds maximum"
 // @Failure 404 {object} map[string]string "error: Session not found"
+// @Failure 410 {object} map[string]string "error: Session expired, code SESSION_EXPIRED"
 // @Failure 429 {object} map[string]string "error: Too Many Requests - Rate limited"
 // @Router /api/challenge/{sessionId}/guess [post]
 func (h *Handler) SubmitChallengeGuess(c *gin.Context) {
@@ -1428,24 +1080,8 @@ func (h *Handler) SubmitChallengeGuess(c *gin.Context) {
 		return
 	}
 
-	// Get session from database first
-	session, err := h.db.GetChallengeSession(sessionID)
-	if err != nil {
-		log.Printf("Failed to get challenge session from database: %v", err)
-		// Fallback to in-memory storage
-		h.mu.Lock()
-		sessionMem, exists := h.challengeSessions[sessionID]
-		if !exists {
-			h.mu.Unlock()
-			c.JSON(http.StatusNotFound, gin.H{"error": "Challenge session not found"})
-			return
-		}
-		session = sessionMem
-		h.mu.Unlock()
-	}
-
-	if session == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Challenge session not found"})
+	session, ok := h.loadChallengeSession(c, sessionID)
+	if !ok {
 		return
 	}
 
@@ -1460,51 +1096,40 @@ func (h *Handler) SubmitChallengeGuess(c *gin.Context) {
 		return
 	}
 
-	// Get the current car and look up its price under read lock
+	// Get the current car and its price from the session's snapshot
 	currentCar := session.Cars[session.CurrentCar]
-	var actualPrice float64
-	var originalURL string
-	found := false
-
-	// Use read lock only for looking up car prices
-	h.mu.RLock()
-	// Try Bonhams listings first (hard mode)
-	if bonhamsCar, exists := h.bonhamsListings[currentCar.ID]; exists {
-		actualPrice = bonhamsCar.Price
-		originalURL = bonhamsCar.OriginalURL
-		found = true
-	}
-
-	// If not found in Bonhams, try Lookers listings (easy mode)
-	if !found {
-		if lookersCar, exists := h.lookersListings[currentCar.ID]; exists {
-			actualPrice = lookersCar.Price
-			originalURL = lookersCar.OriginalURL
-			found = true
-		}
-	}
-	h.mu.RUnlock()
-
-	if !found || actualPrice == 0 {
+	pricedCar, ok := h.pricedChallengeCar(session, session.CurrentCar)
+	if !ok {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not find actual price for this car"})
 		return
 	}
+	actualPrice := pricedCar.Price
+	originalURL := pricedCar.OriginalURL
 
-	// Calculate difference and percentage (no lock needed)
+	// Calculate difference and both accuracy metrics (no lock needed)
 	difference := math.Abs(actualPrice - req.GuessedPrice)
-	percentage := (difference / actualPrice) * 100
+	percentage := models.PercentageError(req.GuessedPrice, actualPrice)
+	logRatio := models.LogRatioError(req.GuessedPrice, actualPrice)
 
-	// Calculate points using Geoguessr-like scoring
-	points := h.calculateChallengePoints(percentage)
+	// Calculate points with the session's scoring policy, less any hints bought for this car
+	hintsUsed, hintPenalty := hintUsage(session, session.CurrentCar)
+	points := session.Scoring.Points(req.GuessedPrice, actualPrice) - hintPenalty
+	if points < 0 {
+		points = 0
+	}
 
 	// Create guess record
 	guess := models.ChallengeGuess{
+		CarIndex:     session.CurrentCar,
 		CarID:        currentCar.ID,
 		GuessedPrice: req.GuessedPrice,
 		ActualPrice:  actualPrice,
 		Difference:   difference,
 		Percentage:   percentage,
+		LogRatio:     logRatio,
 		Points:       points,
+		HintsUsed:    hintsUsed,
+		HintPenalty:  hintPenalty,
 	}
 
 	// Update session (no lock needed - working on copy)
@@ -1528,10 +1153,8 @@ func (h *Handler) SubmitChallengeGuess(c *gin.Context) {
 		log.Printf("Failed to update challenge session in database: %v", err)
 	}
 
-	// Only lock briefly to update in-memory storage
-	h.mu.Lock()
-	h.challengeSessions[sessionID] = session
-	h.mu.Unlock()
+	// Update in-memory storage
+	h.challengeSessions.Put(sessionID, session)
 
 	// Create response
 	response := models.ChallengeResponse{
@@ -1544,7 +1167,7 @@ func (h *Handler) SubmitChallengeGuess(c *gin.Context) {
 
 	if !isLastCar {
 		response.NextCarNumber = session.CurrentCar + 1
-		response.Message = fmt.Sprintf("Car %d/10 - %d points! Moving to next car...", session.CurrentCar, points)
+		response.Message = fmt.Sprintf("Car %d/%d - %d points! Moving to next car...", session.CurrentCar, len(session.Cars), points)
 	} else {
 		response.Message = fmt.Sprintf("Challenge Complete! Final Score: %d points", session.TotalScore)
 	}
@@ -1552,82 +1175,21 @@ func (h *Handler) SubmitChallengeGuess(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
-// calculateChallengePoints calculates points based on guess accuracy (Geoguessr-style)
-func (h *Handler) calculateChallengePoints(percentage float64) int {
-	// Points scale: 5000 max points for perfect guess, decreasing with error percentage
-	// Perfect guess (0% error): 5000 points
-	// 1% error: ~4950 points
-	// 5% error: ~4750 points
-	// 10% error: ~4500 points
-	// 25% error: ~3750 points
-	// 50% error: ~2500 points
-	// 100% error or more: 0 points
+// CreateTemplateChallenge creates a challenge session template for friend challenges.
+// Every participant's session copies the template, including its scoring policy and theme.
+// Returns models.ErrThemeNotFound or a *models.ThemeTooNarrowError if the theme can't be used.
+func (h *Handler) CreateTemplateChallenge(difficulty string, userID int, policy models.ScoringPolicy, themeSlug string) (*models.ChallengeSession, error) {
+	difficulty = h.resolveDifficulty(difficulty)
+	policy = policy.WithDefaults()
 
-	if percentage >= 100 {
-		return 0
+	theme, err := h.findTheme(themeSlug)
+	if err != nil {
+		return nil, err
 	}
 
-	// Use exponential decay for scoring
-	// Points = 5000 * e^(-percentage/20)
-	points := 5000 * math.Exp(-percentage/20)
-
-	// Round to nearest integer
-	return int(math.Round(points))
-}
-
-// CreateTemplateChallenge creates a challenge session template for friend challenges
-func (h *Handler) CreateTemplateChallenge(difficulty string, userID int) (*models.ChallengeSession, error) {
-	h.mu.RLock()
-	var selectedCars []*models.EnhancedCar
-
-	if difficulty == "easy" {
-		// Easy mode - use Lookers listings
-		if len(h.lookersListings) < 10 {
-			h.mu.RUnlock()
-			return nil, fmt.Errorf("not enough easy mode cars available")
-		}
-
-		var allCars []*models.LookersCar
-		for _, car := range h.lookersListings {
-			allCars = append(allCars, car)
-		}
-		h.mu.RUnlock()
-
-		// Shuffle and select 10
-		mathrand.Shuffle(len(allCars), func(i, j int) {
-			allCars[i], allCars[j] = allCars[j], allCars[i]
-		})
-
-		selectedCars = make([]*models.EnhancedCar, 10)
-		for i := 0; i < 10; i++ {
-			enhancedCar := allCars[i].ToEnhancedCar()
-			enhancedCar.Price = 0 // Hide price for guessing
-			selectedCars[i] = enhancedCar
-		}
-	} else {
-		// Hard mode - use Bonhams listings
-		if len(h.bonhamsListings) < 10 {
-			h.mu.RUnlock()
-			return nil, fmt.Errorf("not enough hard mode cars available")
-		}
-
-		var allCars []*models.BonhamsCar
-		for _, car := range h.bonhamsListings {
-			allCars = append(allCars, car)
-		}
-		h.mu.RUnlock()
-
-		// Shuffle and select 10
-		mathrand.Shuffle(len(allCars), func(i, j int) {
-			allCars[i], allCars[j] = allCars[j], allCars[i]
-		})
-
-		selectedCars = make([]*models.EnhancedCar, 10)
-		for i := 0; i < 10; i++ {
-			enhancedCar := allCars[i].ToEnhancedCar()
-			enhancedCar.Price = 0 // Hide price for guessing
-			selectedCars[i] = enhancedCar
-		}
+	selectedCars, pricedCars, err := h.selectChallengeCars(difficulty, policy.CarCount, nil, theme)
+	if err != nil {
+		return nil, err
 	}
 
 	sessionID := generateSessionID()
@@ -1637,6 +1199,9 @@ func (h *Handler) CreateTemplateChallenge(difficulty string, userID int) (*model
 		UserID:     userID,
 		Difficulty: difficulty,
 		Cars:       selectedCars,
+		PricedCars: pricedCars,
+		Scoring:    policy,
+		Theme:      themeSlug,
 		CurrentCar: 0,
 		Guesses:    []models.ChallengeGuess{},
 		TotalScore: 0,
@@ -1652,6 +1217,100 @@ func (h *Handler) CreateTemplateChallenge(difficulty string, userID int) (*model
 	return session, nil
 }
 
+// selectChallengeCars picks count random cars from a difficulty pool.
+// Returns the cars with prices and hints hidden for the player, and a full snapshot for scoring and hints.
+// A seeded rng makes the selection deterministic for the same pool (used by the daily challenge).
+// When a theme is given only cars matching its filter are picked, and a *models.ThemeTooNarrowError
+// is returned if too few match.
+func (h *Handler) selectChallengeCars(difficulty string, count int, rng *mathrand.Rand, theme *models.ChallengeTheme) (hidden, priced []*models.EnhancedCar, err error) {
+	pool, ok := h.pools[difficulty]
+	if !ok {
+		return nil, nil, fmt.Errorf("no listing source for %s mode", difficulty)
+	}
+
+	snap := pool.listings()
+	if len(snap.ids) < count {
+		return nil, nil, fmt.Errorf("not enough %s mode cars available", difficulty)
+	}
+
+	// Snapshot IDs are sorted, so a seeded shuffle is reproducible
+	allCars := make([]models.Listing, 0, len(snap.ids))
+	for _, id := range snap.ids {
+		car := snap.byID[id]
+		if theme != nil && !theme.Filter.Matches(car.ToEnhancedCar()) {
+			continue
+		}
+		allCars = append(allCars, car)
+	}
+
+	if len(allCars) < count {
+		return nil, nil, &models.ThemeTooNarrowError{Theme: theme.Name, Matched: len(allCars), Needed: count}
+	}
+
+	// Shuffle and select
+	swap := func(i, j int) {
+		allCars[i], allCars[j] = allCars[j], allCars[i]
+	}
+	if rng != nil {
+		rng.Shuffle(len(allCars), swap)
+	} else {
+		mathrand.Shuffle(len(allCars), swap)
+	}
+
+	hidden = make([]*models.EnhancedCar, count)
+	priced = make([]*models.EnhancedCar, count)
+	for i := 0; i < count; i++ {
+		priced[i] = allCars[i].ToEnhancedCar()
+		hidden[i] = priced[i].WithoutHints() // Hide price and hint attributes for guessing
+	}
+	return hidden, priced, nil
+}
+
+// cleanupGameSessions removes abandoned streak and zero mode sessions and expires abandoned challenges
+func (h *Handler) cleanupGameSessions() {
+	for {
+		time.Sleep(sessionCleanupInterval)
+
+		h.sweepChallengeSessions()
+
+		deleted, err := h.db.DeleteStaleGameSessions(staleSessionAge)
+		if err != nil {
+			log.Printf("Failed to clean up game sessions: %v", err)
+			continue
+		}
+		if deleted > 0 {
+			log.Printf("Cleaned up %d stale game sessions", deleted)
+		}
+	}
+}
+
+// sweepChallengeSessions marks unfinished challenge sessions past their expiry as expired, and drops
+// finished, expired and idle sessions from memory (the database remains the source of truth for all of them)
+func (h *Handler) sweepChallengeSessions() {
+	expired, err := h.db.ExpireAbandonedChallengeSessions()
+	if err != nil {
+		log.Printf("Failed to expire challenge sessions: %v", err)
+	} else if expired > 0 {
+		log.Printf("Expired %d abandoned challenge sessions", expired)
+	}
+
+	now := time.Now()
+	freed := h.challengeSessions.DeleteFunc(func(_ string, session *models.ChallengeSession) bool {
+		return session.IsComplete || session.IsExpired(now)
+	})
+	freed += h.challengeSessions.Sweep()
+	if freed > 0 {
+		log.Printf("Freed %d challenge sessions from memory", freed)
+	}
+
+	if idle := h.recentlyShown.Sweep(); idle > 0 {
+		log.Printf("Freed %d idle listing histories from memory", idle)
+	}
+	if idle := h.currentListings.Sweep(); idle > 0 {
+		log.Printf("Freed %d unguessed listings from memory", idle)
+	}
+}
+
 // generateSessionID returns a cryptographically secure, URL-safe identifier used to track anonymous sessions.
 func generateSessionID() string {
 	b := make([]byte, 16)
@@ -1665,25 +1324,26 @@ func generateSessionID() string {
 }
 
 // addToRecentlyShown adds a car ID to the recently shown list for a session
+// Requests without a session ID have no history to keep, so nothing is stored for them.
 func (h *Handler) addToRecentlyShown(sessionID, carID string) {
 	const maxRecentCars = 10 // Keep track of last 10 cars
 
-	if h.recentlyShown[sessionID] == nil {
-		h.recentlyShown[sessionID] = make([]string, 0, maxRecentCars)
+	if sessionID == "" {
+		return
 	}
 
-	// Add to front of list
-	h.recentlyShown[sessionID] = append([]string{carID}, h.recentlyShown[sessionID]...)
-
-	// Keep only last maxRecentCars
-	if len(h.recentlyShown[sessionID]) > maxRecentCars {
-		h.recentlyShown[sessionID] = h.recentlyShown[sessionID][:maxRecentCars]
-	}
+	h.recentlyShown.Update(sessionID, func(recent []string, _ bool) []string {
+		// Add to front of list, keeping only the last maxRecentCars
+		recent = append([]string{carID}, recent...)
+		if len(recent) > maxRecentCars {
+			recent = recent[:maxRecentCars]
+		}
+		return recent
+	})
 }
 
-// isRecentlyShown checks if a car was recently shown to this session
-func (h *Handler) isRecentlyShown(sessionID, carID string) bool {
-	recentCars := h.recentlyShown[sessionID]
+// isRecentlyShown checks if a car is in a session's recently shown list
+func isRecentlyShown(recentCars []string, carID string) bool {
 	for _, recentID := range recentCars {
 		if recentID == carID {
 			return true
@@ -1696,9 +1356,14 @@ func (h *Handler) isRecentlyShown(sessionID, carID string) bool {
 func (h *Handler) selectRandomCarWithHistory(sessionID string, allIDs []string) string {
 	const maxAttempts = 20 // Prevent infinite loops
 
+	var recentCars []string
+	if sessionID != "" {
+		recentCars, _ = h.recentlyShown.Get(sessionID)
+	}
+
 	for attempt := 0; attempt < maxAttempts; attempt++ {
 		randomID := allIDs[mathrand.Intn(len(allIDs))]
-		if !h.isRecentlyShown(sessionID, randomID) {
+		if !isRecentlyShown(recentCars, randomID) {
 			return randomID
 		}
 	}
@@ -1755,22 +1420,13 @@ func parseInt(s string) int {
 
 // findLeaderboardPositionFromDB calculates the 1-based rank for the submitted entry.
 func (h *Handler) findLeaderboardPositionFromDB(entry models.LeaderboardEntry) int {
-	// Get all entries for the same game mode and difficulty, ordered by score
-	allEntries, err := h.db.GetLeaderboard(entry.GameMode, entry.Difficulty, 0) // 0 = no limit
+	position, err := h.db.GetRank(entry.GameMode, entry.Difficulty, entry.Score)
 	if err != nil {
-		log.Printf("Failed to get leaderboard for position calculation: %v", err)
+		log.Printf("Failed to get leaderboard position: %v", err)
 		return -1
 	}
 
-	// Find position of the entry (entries are already sorted by score in descending order)
-	for i, e := range allEntries {
-		if e.Score <= entry.Score {
-			return i + 1
-		}
-	}
-
-	// If entry score is lower than all existing entries
-	return len(allEntries) + 1
+	return position
 }
 
 // GetLeaderboardStatus godoc
@@ -1785,33 +1441,24 @@ func (h *Handler) findLeaderboardPositionFromDB(entry models.LeaderboardEntry) i
 // @Router /api/admin/leaderboard-status [get]
 func (h *Handler) GetLeaderboardStatus(c *gin.Context) {
 	// Get counts from database for each mode and difficulty combination
-	counts := make(map[string]int)
+	breakdown := gin.H{}
 	totalEntries := 0
 
-	modes := []string{"challenge", "streak", "zero"}
-	difficulties := []string{"easy", "hard"}
-
-	for _, mode := range modes {
-		for _, difficulty := range difficulties {
-			entries, err := h.db.GetLeaderboard(mode, difficulty, 0) // 0 = no limit
-			if err == nil {
-				key := mode + "_" + difficulty
-				counts[key] = len(entries)
-				totalEntries += len(entries)
+	for _, mode := range models.GameModes() {
+		for _, difficulty := range mode.Difficulties {
+			count, err := h.db.CountEntries(mode.Name, difficulty)
+			if err != nil {
+				log.Printf("Failed to count %s %s leaderboard entries: %v", mode.Name, difficulty, err)
+				continue
 			}
+			breakdown[mode.Name+"_"+difficulty] = count
+			totalEntries += count
 		}
 	}
 
 	status := gin.H{
 		"total_entries": totalEntries,
-		"breakdown": gin.H{
-			"challenge_easy": counts["challenge_easy"],
-			"challenge_hard": counts["challenge_hard"],
-			"streak_easy":    counts["streak_easy"],
-			"streak_hard":    counts["streak_hard"],
-			"zero_easy":      counts["zero_easy"],
-			"zero_hard":      counts["zero_hard"],
-		},
+		"breakdown":     breakdown,
 		"storage":       "database",
 		"database_path": "./data/carguessr.db",
 	}
@@ -0,0 +1,261 @@
+package game
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"autotraderguesser/internal/database"
+	"autotraderguesser/internal/models"
+	"autotraderguesser/internal/sessions"
+)
+
+func TestMain(m *testing.M) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		panic(err)
+	}
+	root := filepath.Join(cwd, "..", "..")
+	if err := os.Chdir(root); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// newTestHandler creates a handler with a single hard mode pool, a fresh database and no listing sources
+func newTestHandler(t *testing.T) (*Handler, *gin.Engine) {
+	t.Helper()
+	db, err := database.NewDatabase(filepath.Join(t.TempDir(), "game.db"))
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	pool := &listingPool{}
+	pool.snapshot.Store(newListingSnapshot(benchListings(20)))
+	h := &Handler{
+		db:                db,
+		pools:             map[string]*listingPool{"hard": pool},
+		challengeSessions: sessions.New[*models.ChallengeSession]("challengeSessions", models.ChallengeSessionTTL, maxChallengeSessions),
+		recentlyShown:     sessions.NewSharded[[]string]("recentlyShown", recentlyShownShards, recentlyShownTTL, maxRecentlyShownSessions),
+		currentListings:   sessions.NewSharded[string]("currentListings", recentlyShownShards, recentlyShownTTL, maxRecentlyShownSessions),
+	}
+
+	r := gin.New()
+	r.GET("/api/random-listing", h.GetRandomListing)
+	r.POST("/api/check-guess", h.CheckGuess)
+	r.POST("/api/leaderboard/submit", h.SubmitScore)
+	return h, r
+}
+
+func performRequest(r *gin.Engine, method, path string, body interface{}, sessionID string) *httptest.ResponseRecorder {
+	var payload []byte
+	if body != nil {
+		payload, _ = json.Marshal(body)
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if sessionID != "" {
+		req.Header.Set("X-Session-ID", sessionID)
+	}
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec
+}
+
+// serveListing fetches a listing for a session and returns its ID and real price
+func serveListing(t *testing.T, h *Handler, r *gin.Engine, sessionID string) (string, float64) {
+	t.Helper()
+	rec := performRequest(r, http.MethodGet, "/api/random-listing", nil, sessionID)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected a listing, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var car models.EnhancedCar
+	if err := json.Unmarshal(rec.Body.Bytes(), &car); err != nil {
+		t.Fatalf("failed to decode listing: %v", err)
+	}
+	listing, ok := h.findListing("hard", car.ID)
+	if !ok {
+		t.Fatalf("served listing %s is not in the pool", car.ID)
+	}
+	return car.ID, listing.ListingPrice()
+}
+
+func guess(r *gin.Engine, sessionID, listingID, gameMode string, price float64) *httptest.ResponseRecorder {
+	return performRequest(r, http.MethodPost, "/api/check-guess", models.GuessRequest{
+		ListingID:    listingID,
+		GuessedPrice: price,
+		GameMode:     gameMode,
+		Difficulty:   "hard",
+	}, sessionID)
+}
+
+func TestCheckGuessOnlyAcceptsServedListings(t *testing.T) {
+	h, r := newTestHandler(t)
+	const sessionID = "replaysession0001"
+
+	listingID, price := serveListing(t, h, r, sessionID)
+	other := "car-0000"
+	if other == listingID {
+		other = "car-0001"
+	}
+
+	if rec := guess(r, sessionID, other, "streak", price); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a listing that wasn't served, got %d", rec.Code)
+	}
+	if rec := guess(r, "", listingID, "streak", price); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a guess without a session, got %d", rec.Code)
+	}
+	if rec := guess(r, sessionID, listingID, "streak", price); rec.Code != http.StatusOK {
+		t.Fatalf("expected the served listing to be accepted, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// Once guessed, the listing has to be served again before another guess
+	if rec := guess(r, sessionID, listingID, "streak", price); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a replayed guess, got %d", rec.Code)
+	}
+
+	// And even then it can't be guessed twice in one game
+	h.currentListings.Put(sessionID, listingID)
+	if rec := guess(r, sessionID, listingID, "streak", price); rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for a listing already guessed in this game, got %d", rec.Code)
+	}
+
+	session, err := h.db.GetGameSession(sessionID)
+	if err != nil || session == nil {
+		t.Fatalf("failed to load session: %v", err)
+	}
+	if session.CurrentScore != 1 || len(session.CarsShown) != 1 {
+		t.Fatalf("expected one scored guess, got score %d cars %v", session.CurrentScore, session.CarsShown)
+	}
+}
+
+func TestSubmitScoreVerifiesSession(t *testing.T) {
+	h, r := newTestHandler(t)
+	const sessionID = "streaksession0001"
+
+	for i := 0; i < 2; i++ {
+		listingID, price := serveListing(t, h, r, sessionID)
+		if rec := guess(r, sessionID, listingID, "streak", price); rec.Code != http.StatusOK {
+			t.Fatalf("expected guess %d to be accepted, got %d", i+1, rec.Code)
+		}
+	}
+
+	submit := func(req models.LeaderboardSubmissionRequest) (int, string) {
+		rec := performRequest(r, http.MethodPost, "/api/leaderboard/submit", req, "")
+		var body struct {
+			Code string `json:"code"`
+		}
+		_ = json.Unmarshal(rec.Body.Bytes(), &body)
+		return rec.Code, body.Code
+	}
+	valid := models.LeaderboardSubmissionRequest{Name: "Tester", Score: 2, GameMode: "streak", Difficulty: "hard", SessionID: sessionID}
+
+	if status, code := submit(valid); status != http.StatusBadRequest || code != submitErrSessionActive {
+		t.Fatalf("expected %s while the streak is running, got %d %q", submitErrSessionActive, status, code)
+	}
+
+	// End the streak with a wild guess
+	listingID, price := serveListing(t, h, r, sessionID)
+	if rec := guess(r, sessionID, listingID, "streak", price*3); rec.Code != http.StatusOK {
+		t.Fatalf("expected the final guess to be accepted, got %d", rec.Code)
+	}
+
+	unranked := &models.GameSession{
+		SessionID:      "logratiosession01",
+		GameMode:       "streak",
+		Difficulty:     "hard",
+		CurrentScore:   2,
+		CarsShown:      []string{},
+		AccuracyMetric: models.MetricLogRatio,
+	}
+	if err := h.db.StartGameSession(unranked); err != nil {
+		t.Fatalf("StartGameSession failed: %v", err)
+	}
+
+	cases := []struct {
+		name   string
+		modify func(*models.LeaderboardSubmissionRequest)
+		status int
+		code   string
+	}{
+		{"no session", func(req *models.LeaderboardSubmissionRequest) { req.SessionID = "" }, http.StatusBadRequest, submitErrSessionRequired},
+		{"unknown session", func(req *models.LeaderboardSubmissionRequest) { req.SessionID = "missingsession001" }, http.StatusNotFound, submitErrSessionNotFound},
+		{"wrong mode", func(req *models.LeaderboardSubmissionRequest) { req.GameMode = "zero" }, http.StatusBadRequest, submitErrModeMismatch},
+		{"wrong difficulty", func(req *models.LeaderboardSubmissionRequest) { req.Difficulty = "easy" }, http.StatusBadRequest, submitErrModeMismatch},
+		{"wrong score", func(req *models.LeaderboardSubmissionRequest) { req.Score = 3 }, http.StatusBadRequest, submitErrScoreMismatch},
+		{"impossible score", func(req *models.LeaderboardSubmissionRequest) { req.GameMode, req.Score = "challenge", 1000000 }, http.StatusBadRequest, submitErrScoreOutOfRange},
+		{"log-ratio streak", func(req *models.LeaderboardSubmissionRequest) { req.SessionID = unranked.SessionID }, http.StatusBadRequest, submitErrUnrankedPolicy},
+	}
+	for _, tc := range cases {
+		req := valid
+		tc.modify(&req)
+		if status, code := submit(req); status != tc.status || code != tc.code {
+			t.Errorf("%s: expected %d %s, got %d %q", tc.name, tc.status, tc.code, status, code)
+		}
+	}
+
+	if status, code := submit(valid); status != http.StatusOK {
+		t.Fatalf("expected the verified score to be accepted, got %d %q", status, code)
+	}
+	if status, code := submit(valid); status != http.StatusConflict || code != submitErrAlreadySubmitted {
+		t.Fatalf("expected %s for a second submission, got %d %q", submitErrAlreadySubmitted, status, code)
+	}
+
+	// The next game with the same session ID is a new game and can be submitted too
+	for _, factor := range []float64{1, 3} {
+		listingID, price := serveListing(t, h, r, sessionID)
+		if rec := guess(r, sessionID, listingID, "streak", price*factor); rec.Code != http.StatusOK {
+			t.Fatalf("expected the next game's guess to be accepted, got %d", rec.Code)
+		}
+	}
+	valid.Score = 1
+	if status, code := submit(valid); status != http.StatusOK {
+		t.Fatalf("expected the next game to be accepted, got %d %q", status, code)
+	}
+	if status, code := submit(valid); status != http.StatusConflict || code != submitErrAlreadySubmitted {
+		t.Fatalf("expected %s for the next game's second submission, got %d %q", submitErrAlreadySubmitted, status, code)
+	}
+}
+
+func TestSubmitChallengeGuessFallsBackToLiveListing(t *testing.T) {
+	h, r := newTestHandler(t)
+	r.POST("/api/challenge/:sessionId/guess", h.SubmitChallengeGuess)
+
+	// Sessions from before the price snapshot only stored the hidden cars
+	listing, _ := h.findListing("hard", "car-0003")
+	hidden := listing.ToEnhancedCar()
+	hidden.Price = 0
+	session := &models.ChallengeSession{
+		SessionID:  "legacychallenge01",
+		Difficulty: "hard",
+		Cars:       []*models.EnhancedCar{hidden},
+		Scoring:    models.DefaultScoringPolicy(),
+	}
+	if err := h.db.CreateChallengeSession(session); err != nil {
+		t.Fatalf("CreateChallengeSession failed: %v", err)
+	}
+
+	rec := performRequest(r, http.MethodPost, "/api/challenge/legacychallenge01/guess", models.ChallengeGuessRequest{GuessedPrice: 5000}, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected the guess to be scored against the live listing, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var body struct {
+		ActualPrice float64 `json:"actualPrice"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body.ActualPrice != listing.ListingPrice() {
+		t.Fatalf("expected actual price %.0f, got %.0f err=%v", listing.ListingPrice(), body.ActualPrice, err)
+	}
+}
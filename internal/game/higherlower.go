@@ -0,0 +1,222 @@
+package game
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"autotraderguesser/internal/models"
+	"autotraderguesser/internal/validation"
+)
+
+// higherLowerMode is the game_mode value for higher-or-lower sessions and leaderboard entries
+const higherLowerMode = "higherlower"
+
+// pickHigherLowerPair selects two different listings from a difficulty pool, avoiding recently shown cars.
+// The returned cars include their actual prices.
+func (h *Handler) pickHigherLowerPair(sessionID, difficulty string) (left, right *models.EnhancedCar, err error) {
+	pool, ok := h.pools[difficulty]
+	if !ok {
+		return nil, nil, fmt.Errorf("no listing source for %s mode", difficulty)
+	}
+
+	snap := pool.listings()
+	if len(snap.ids) < 2 {
+		return nil, nil, fmt.Errorf("not enough %s mode listings available", difficulty)
+	}
+
+	leftID := h.selectRandomCarWithHistory(sessionID, snap.ids)
+	h.addToRecentlyShown(sessionID, leftID)
+
+	// Remove the first pick so the pair is always two different cars
+	remaining := make([]string, 0, len(snap.ids)-1)
+	for _, id := range snap.ids {
+		if id != leftID {
+			remaining = append(remaining, id)
+		}
+	}
+	rightID := h.selectRandomCarWithHistory(sessionID, remaining)
+	h.addToRecentlyShown(sessionID, rightID)
+
+	return snap.byID[leftID].ToEnhancedCar(), snap.byID[rightID].ToEnhancedCar(), nil
+}
+
+// hiddenPair returns player-facing copies of a pair with prices hidden
+func hiddenPair(pair *models.HigherLowerPair) (left, right *models.EnhancedCar) {
+	leftCar, rightCar := *pair.Left, *pair.Right
+	leftCar.Price = 0
+	rightCar.Price = 0
+	return &leftCar, &rightCar
+}
+
+// higherLowerSessionID reads and validates the session ID header.
+// A new ID is generated when none is sent and allowNew is set.
+func higherLowerSessionID(c *gin.Context, allowNew bool) (string, bool) {
+	sessionID := c.GetHeader("X-Session-ID")
+	if sessionID == "" {
+		if allowNew {
+			return generateSessionID(), true
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "X-Session-ID header is required"})
+		return "", false
+	}
+	if err := validation.ValidateSessionID(sessionID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID format"})
+		return "", false
+	}
+	return sessionID, true
+}
+
+// GetHigherLowerPair godoc
+// @Summary Get the current higher-or-lower pair
+// @Description Returns two cars from the same difficulty pool with prices hidden. The player picks which one sold for more. The pair is stored server-side, so asking again returns the same pair until it has been answered.
+// @Tags game
+// @Produce json
+// @Param difficulty query string false "Difficulty mode (easy or hard)" Enums(easy, hard)
+// @Param X-Session-ID header string false "Game session ID (generated if omitted)"
+// @Success 200 {object} map[string]interface{} "sessionId, left, right, score"
+// @Failure 400 {object} map[string]string "error: Invalid session ID format"
+// @Failure 404 {object} map[string]string "error: Not enough listings available"
+// @Router /api/higher-lower/pair [get]
+func (h *Handler) GetHigherLowerPair(c *gin.Context) {
+	difficulty := h.resolveDifficulty(c.DefaultQuery("difficulty", "hard"))
+
+	sessionID, ok := higherLowerSessionID(c, true)
+	if !ok {
+		return
+	}
+
+	h.mu.Lock() // Serialise read-modify-write of sessions
+	defer h.mu.Unlock()
+
+	session, started, err := h.loadOrStartSession(c, sessionID, higherLowerMode, difficulty, "")
+	if err != nil {
+		log.Printf("Failed to load higher-or-lower session %s: %v", sessionID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load game session"})
+		return
+	}
+
+	// Keep serving an unanswered pair so players can't reroll for an easier one
+	var pair *models.HigherLowerPair
+	if !started {
+		pair, err = h.db.GetHigherLowerPair(sessionID)
+		if err != nil {
+			log.Printf("Failed to load higher-or-lower pair %s: %v", sessionID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load game session"})
+			return
+		}
+	}
+
+	// Only a new pair is picked, so asking again doesn't fill the history with cars the player never saw
+	if pair == nil {
+		left, right, err := h.pickHigherLowerPair(sessionID, difficulty)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Not enough %s mode listings available", difficulty)})
+			return
+		}
+		pair = &models.HigherLowerPair{SessionID: sessionID, Left: left, Right: right}
+		if err := h.db.SetHigherLowerPair(pair); err != nil {
+			log.Printf("Failed to store higher-or-lower pair %s: %v", sessionID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start round"})
+			return
+		}
+	}
+
+	hiddenLeft, hiddenRight := hiddenPair(pair)
+	c.JSON(http.StatusOK, gin.H{
+		"sessionId": sessionID,
+		"left":      hiddenLeft,
+		"right":     hiddenRight,
+		"score":     session.CurrentScore,
+	})
+}
+
+// SubmitHigherLowerGuess godoc
+// @Summary Pick which car sold for more
+// @Description Answers the current higher-or-lower pair. A correct pick extends the streak; a wrong pick ends the game. Equal prices count as correct either way.
+// @Tags game
+// @Accept json
+// @Produce json
+// @Param X-Session-ID header string true "Game session ID"
+// @Param guess body models.HigherLowerGuessRequest true "Which car sold for more (left or right)"
+// @Success 200 {object} models.HigherLowerResponse
+// @Failure 400 {object} map[string]string "error: Invalid request or no pair in play"
+// @Router /api/higher-lower/guess [post]
+func (h *Handler) SubmitHigherLowerGuess(c *gin.Context) {
+	sessionID, ok := higherLowerSessionID(c, false)
+	if !ok {
+		return
+	}
+
+	var req models.HigherLowerGuessRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format", "details": err.Error()})
+		return
+	}
+
+	h.mu.Lock() // Serialise read-modify-write of sessions
+	defer h.mu.Unlock()
+
+	session, err := h.db.GetGameSession(sessionID)
+	if err != nil {
+		log.Printf("Failed to load higher-or-lower session %s: %v", sessionID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load game session"})
+		return
+	}
+	if session == nil || session.GameMode != higherLowerMode || !session.IsActive {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No higher-or-lower game in progress for this session"})
+		return
+	}
+
+	pair, err := h.db.GetHigherLowerPair(sessionID)
+	if err != nil {
+		log.Printf("Failed to load higher-or-lower pair %s: %v", sessionID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load game session"})
+		return
+	}
+	if pair == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No pair in play - request a new pair first"})
+		return
+	}
+
+	// Equal prices mean either pick is right
+	correct := pair.Left.Price == pair.Right.Price ||
+		(req.Choice == "left" && pair.Left.Price > pair.Right.Price) ||
+		(req.Choice == "right" && pair.Right.Price > pair.Left.Price)
+
+	session.CarsShown = append(session.CarsShown, pair.Left.ID, pair.Right.ID)
+	if correct {
+		session.CurrentScore++
+	} else {
+		session.IsActive = false // Streak over
+	}
+
+	if err := h.db.UpdateGameSession(session); err != nil {
+		log.Printf("Failed to update higher-or-lower session %s: %v", sessionID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update game session"})
+		return
+	}
+	if err := h.db.ClearHigherLowerPair(sessionID); err != nil {
+		log.Printf("Failed to clear higher-or-lower pair %s: %v", sessionID, err)
+	}
+
+	response := models.HigherLowerResponse{
+		Correct:    correct,
+		Choice:     req.Choice,
+		LeftPrice:  pair.Left.Price,
+		RightPrice: pair.Right.Price,
+		LeftURL:    pair.Left.OriginalURL,
+		RightURL:   pair.Right.OriginalURL,
+		Score:      session.CurrentScore,
+		GameOver:   !correct,
+	}
+	if correct {
+		response.Message = "Correct! Keep the streak going!"
+	} else {
+		response.Message = "Game Over! That car sold for less"
+	}
+
+	c.JSON(http.StatusOK, response)
+}
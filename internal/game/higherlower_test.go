@@ -0,0 +1,40 @@
+package game
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestGetHigherLowerPairOnlyRecordsServedCars(t *testing.T) {
+	h, r := newTestHandler(t)
+	r.GET("/api/higher-lower/pair", h.GetHigherLowerPair)
+	const sessionID = "higherlower00001"
+
+	type servedPair struct {
+		Left  struct{ ID string } `json:"left"`
+		Right struct{ ID string } `json:"right"`
+	}
+	var first servedPair
+	for i := 0; i < 5; i++ {
+		rec := performRequest(r, http.MethodGet, "/api/higher-lower/pair?difficulty=hard", nil, sessionID)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected a pair, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var pair servedPair
+		if err := json.Unmarshal(rec.Body.Bytes(), &pair); err != nil {
+			t.Fatalf("failed to decode pair: %v", err)
+		}
+		if i == 0 {
+			first = pair
+		} else if pair != first {
+			t.Fatalf("expected the unanswered pair to be served again, got %+v then %+v", first, pair)
+		}
+	}
+
+	// Asking again doesn't push unseen cars into the history (newest first)
+	recent, _ := h.recentlyShown.Get(sessionID)
+	if len(recent) != 2 || recent[0] != first.Right.ID || recent[1] != first.Left.ID {
+		t.Fatalf("expected only the served pair in the history, got %v", recent)
+	}
+}
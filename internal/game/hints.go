@@ -0,0 +1,135 @@
+package game
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"autotraderguesser/internal/models"
+)
+
+// hintUsage returns how many hints were unlocked for a car in a session and their total cost
+func hintUsage(session *models.ChallengeSession, carIndex int) (used, penalty int) {
+	for _, hint := range session.Hints {
+		if hint.CarIndex == carIndex {
+			used++
+			penalty += hint.Cost
+		}
+	}
+	return used, penalty
+}
+
+// nextHint returns the next hint in HintOrder that is still locked for a car and has something
+// to reveal, along with its value. Hints the car has no data for are skipped free of charge.
+func nextHint(session *models.ChallengeSession, carIndex int, car *models.EnhancedCar) (*models.ChallengeHint, error) {
+	unlocked := make(map[string]bool)
+	for _, hint := range session.Hints {
+		if hint.CarIndex == carIndex {
+			unlocked[hint.Hint] = true
+		}
+	}
+
+	for _, name := range models.HintOrder {
+		if unlocked[name] {
+			continue
+		}
+
+		value, err := car.HintValue(name)
+		if err != nil {
+			return nil, err
+		}
+		if value == nil {
+			continue
+		}
+
+		return &models.ChallengeHint{
+			CarIndex: carIndex,
+			Hint:     name,
+			Value:    value,
+			Cost:     models.HintCost(name),
+		}, nil
+	}
+
+	return nil, nil
+}
+
+// UnlockChallengeHint godoc
+// @Summary Unlock the next hint for the current challenge car
+// @Description Reveals the next hidden attribute of the current car, in order: mileage, key facts, location, sale date, then a price band. Each hint takes its cost off the points scored for that car.
+// @Tags challenge
+// @Produce json
+// @Param sessionId path string true "Challenge Session ID (16 alphanumeric characters)"
+// @Success 200 {object} map[string]interface{} "hint, hintsUsed and hintPenalty for the current car, hasMoreHints"
+// @Failure 400 {object} map[string]string "error: Session complete or no hints left for this car"
+// @Failure 404 {object} map[string]string "error: Session not found"
+// @Failure 410 {object} map[string]string "error: Session expired, code SESSION_EXPIRED"
+// @Router /api/challenge/{sessionId}/hint [post]
+func (h *Handler) UnlockChallengeHint(c *gin.Context) {
+	sessionID := c.Param("sessionId")
+
+	// Validate session ID
+	if !isValidSessionID(sessionID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID format"})
+		return
+	}
+
+	h.mu.Lock() // Serialise unlocks so a hint can't be bought twice
+	defer h.mu.Unlock()
+
+	session, err := h.db.GetChallengeSession(sessionID)
+	if err != nil {
+		log.Printf("Failed to get challenge session from database: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load challenge session"})
+		return
+	}
+	if session == nil {
+		if expired, err := h.db.IsChallengeSessionExpired(sessionID); err == nil && expired {
+			respondChallengeExpired(c)
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": "Challenge session not found"})
+		return
+	}
+
+	if session.IsComplete || session.CurrentCar >= len(session.Cars) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Challenge session is already complete"})
+		return
+	}
+	car, ok := h.pricedChallengeCar(session, session.CurrentCar)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not find actual price for this car"})
+		return
+	}
+
+	hint, err := nextHint(session, session.CurrentCar, car)
+	if err != nil {
+		log.Printf("Failed to build hint for session %s: %v", sessionID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlock hint"})
+		return
+	}
+	if hint == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No hints left for this car"})
+		return
+	}
+
+	if _, err := h.db.UnlockChallengeHint(sessionID, hint); err != nil {
+		log.Printf("Failed to save hint for session %s: %v", sessionID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlock hint"})
+		return
+	}
+	session.Hints = append(session.Hints, *hint)
+
+	used, penalty := hintUsage(session, session.CurrentCar)
+	remaining, err := nextHint(session, session.CurrentCar, car)
+	if err != nil {
+		log.Printf("Failed to check remaining hints for session %s: %v", sessionID, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"hint":         hint,
+		"hintsUsed":    used,
+		"hintPenalty":  penalty,
+		"hasMoreHints": remaining != nil,
+	})
+}
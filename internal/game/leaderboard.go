@@ -0,0 +1,98 @@
+package game
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"autotraderguesser/internal/models"
+)
+
+const (
+	leaderboardViewAll  = "all"  // Every submitted entry
+	leaderboardViewBest = "best" // Each registered player's best entry, with every guest entry
+	maxAroundUser       = 25     // Most entries returned either side of the caller
+	nextCursorHeader    = "X-Next-Cursor"
+)
+
+// leaderboardCursor is the position a leaderboard page continues from.
+// Clients treat the encoded cursor as opaque.
+type leaderboardCursor struct {
+	After int `json:"after"` // Last entry ID of the previous page
+}
+
+// encodeLeaderboardCursor returns the cursor for the page after an entry
+func encodeLeaderboardCursor(entryID int) string {
+	data, _ := json.Marshal(leaderboardCursor{After: entryID})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeLeaderboardCursor returns the entry ID a cursor continues after
+func decodeLeaderboardCursor(cursor string) (int, bool) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, false
+	}
+
+	var decoded leaderboardCursor
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded.After <= 0 {
+		return 0, false
+	}
+	return decoded.After, true
+}
+
+// leaderboardUser returns the logged-in user, or writes a 401 with the message and returns false
+func leaderboardUser(c *gin.Context, message string) (*models.User, bool) {
+	if u, exists := c.Get("user"); exists {
+		if user, ok := u.(*models.User); ok {
+			return user, true
+		}
+	}
+
+	c.JSON(http.StatusUnauthorized, gin.H{"error": message})
+	return nil, false
+}
+
+// leaderboardAroundUser writes the entries either side of the caller's best entry on a leaderboard
+func (h *Handler) leaderboardAroundUser(c *gin.Context, q models.LeaderboardQuery, around string) {
+	radius := parseInt(around)
+	if radius < 1 || radius > maxAroundUser {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "aroundUser must be between 1 and 25"})
+		return
+	}
+	if q.GameMode == "" || q.Difficulty == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "aroundUser needs a game mode and difficulty"})
+		return
+	}
+
+	user, ok := leaderboardUser(c, "Log in to see your leaderboard position")
+	if !ok {
+		return
+	}
+
+	entryID, err := h.db.GetUserBestEntryID(user.ID, q)
+	if err != nil {
+		log.Printf("Failed to find user's leaderboard entry: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch leaderboard"})
+		return
+	}
+	if entryID == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "You have no entries on this leaderboard"})
+		return
+	}
+
+	entries, err := h.db.GetBoardNeighbours(q, entryID, radius)
+	if err != nil {
+		log.Printf("Failed to get leaderboard neighbours: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch leaderboard"})
+		return
+	}
+	if entries == nil {
+		entries = []models.RankedLeaderboardEntry{}
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
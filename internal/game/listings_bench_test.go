@@ -0,0 +1,152 @@
+package game
+
+import (
+	"fmt"
+	mathrand "math/rand"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"autotraderguesser/internal/models"
+	"autotraderguesser/internal/sessions"
+)
+
+// Run with -cpu=1,2,4,8 to see how throughput scales with concurrent players:
+//
+//	go test ./internal/game -run '^$' -bench RandomListing -cpu 1,2,4,8
+
+// benchListings builds a pool's worth of listings
+func benchListings(n int) map[string]models.Listing {
+	listings := make(map[string]models.Listing, n)
+	for i := 0; i < n; i++ {
+		car := &models.BonhamsCar{ID: fmt.Sprintf("car-%04d", i), Make: "Porsche", Model: "911", Year: 1970 + i%50, Price: float64(10000 + i*100)}
+		listings[car.ID] = car
+	}
+	return listings
+}
+
+// newBenchHandler creates a handler with a single hard mode pool and no listing sources
+func newBenchHandler(b *testing.B) *Handler {
+	b.Helper()
+	pool := &listingPool{}
+	pool.snapshot.Store(newListingSnapshot(benchListings(ListingAmount)))
+	return &Handler{
+		pools:             map[string]*listingPool{"hard": pool},
+		challengeSessions: sessions.New[*models.ChallengeSession]("challengeSessions", models.ChallengeSessionTTL, maxChallengeSessions),
+		recentlyShown:     sessions.NewSharded[[]string]("recentlyShown", recentlyShownShards, recentlyShownTTL, maxRecentlyShownSessions),
+		currentListings:   sessions.NewSharded[string]("currentListings", recentlyShownShards, recentlyShownTTL, maxRecentlyShownSessions),
+	}
+}
+
+// benchSessionIDs hands each parallel goroutine its own player session
+func benchSessionIDs() func() string {
+	var next atomic.Int64
+	return func() string {
+		return fmt.Sprintf("bench-session-%06d", next.Add(1))
+	}
+}
+
+// BenchmarkRandomListingSnapshot picks listings the way the handlers do now: a lock-free snapshot
+// read plus the player's history in a sharded store.
+func BenchmarkRandomListingSnapshot(b *testing.B) {
+	h := newBenchHandler(b)
+	pool := h.pools["hard"]
+	sessionID := benchSessionIDs()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		id := sessionID()
+		for pb.Next() {
+			snap := pool.listings()
+			carID := h.selectRandomCarWithHistory(id, snap.ids)
+			h.addToRecentlyShown(id, carID)
+			_ = snap.byID[carID].ToEnhancedCar()
+		}
+	})
+}
+
+// BenchmarkRandomListingGlobalLock is the previous design for comparison: every request takes one
+// global write lock and rebuilds the ID slice from the listing map.
+func BenchmarkRandomListingGlobalLock(b *testing.B) {
+	listings := benchListings(ListingAmount)
+	history := make(map[string][]string)
+	var mu sync.Mutex
+	sessionID := benchSessionIDs()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		id := sessionID()
+		for pb.Next() {
+			mu.Lock()
+			ids := make([]string, 0, len(listings))
+			for carID := range listings {
+				ids = append(ids, carID)
+			}
+			carID := ids[mathrand.Intn(len(ids))]
+			for attempt := 0; attempt < 20 && isRecentlyShown(history[id], carID); attempt++ {
+				carID = ids[mathrand.Intn(len(ids))]
+			}
+			recent := append([]string{carID}, history[id]...)
+			if len(recent) > 10 {
+				recent = recent[:10]
+			}
+			history[id] = recent
+			_ = listings[carID].ToEnhancedCar()
+			mu.Unlock()
+		}
+	})
+}
+
+// BenchmarkRandomListingDuringRefresh measures reads while a writer keeps swapping in new snapshots
+func BenchmarkRandomListingDuringRefresh(b *testing.B) {
+	h := newBenchHandler(b)
+	pool := h.pools["hard"]
+	sessionID := benchSessionIDs()
+	fresh := benchListings(ListingAmount)
+
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				pool.snapshot.Store(newListingSnapshot(fresh))
+			}
+		}
+	}()
+	defer close(done)
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		id := sessionID()
+		for pb.Next() {
+			snap := pool.listings()
+			carID := h.selectRandomCarWithHistory(id, snap.ids)
+			h.addToRecentlyShown(id, carID)
+			_ = snap.byID[carID].ToEnhancedCar()
+		}
+	})
+}
+
+// BenchmarkServeRandomListing covers the full handler including JSON encoding
+func BenchmarkServeRandomListing(b *testing.B) {
+	gin.SetMode(gin.TestMode)
+	h := newBenchHandler(b)
+	sessionID := benchSessionIDs()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		id := sessionID()
+		for pb.Next() {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			h.serveRandomListing(c, "hard", id)
+		}
+	})
+}
@@ -0,0 +1,201 @@
+package game
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"autotraderguesser/internal/models"
+)
+
+const (
+	archiveStandingsSize       = 100 // Entries kept per closed period leaderboard
+	leaderboardArchiveInterval = time.Hour
+	defaultHistoryPeriods      = 10
+	maxHistoryPeriods          = 52
+	defaultHistoryTop          = 3
+)
+
+// leaderboardWindow returns the current window for the request's period query.
+// Returns nil for all time, and false after writing an error for an unknown period.
+func leaderboardWindow(c *gin.Context) (*models.PeriodWindow, bool) {
+	period := c.DefaultQuery("period", models.PeriodAll)
+	if period == models.PeriodAll {
+		return nil, true
+	}
+
+	window, ok := models.PeriodContaining(period, time.Now())
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Period must be day, week, month, season or all"})
+		return nil, false
+	}
+	return &window, true
+}
+
+// archiveLeaderboards periodically archives the standings of periods that have just closed
+func (h *Handler) archiveLeaderboards() {
+	for {
+		h.archiveClosedPeriods(time.Now())
+		time.Sleep(leaderboardArchiveInterval)
+	}
+}
+
+// archiveClosedPeriods archives every window of every period that has closed since the last one archived,
+// for every leaderboard, so windows missed while the server was down are caught up. Until anything has been
+// archived it starts from the earliest leaderboard entry. Boards that are already archived are skipped,
+// so this is safe to run repeatedly.
+func (h *Handler) archiveClosedPeriods(now time.Time) {
+	earliest, err := h.db.EarliestLeaderboardEntry()
+	if err != nil {
+		log.Printf("Failed to find leaderboard periods to archive: %v", err)
+		return
+	}
+	if earliest == nil {
+		return // Nothing submitted yet
+	}
+
+	for _, period := range models.ArchivedPeriods {
+		last, err := h.db.LastArchivedPeriodKey(period)
+		if err != nil {
+			log.Printf("Failed to find the last archived %s: %v", period, err)
+			continue
+		}
+
+		// The last archived window is checked again in case some of its boards failed
+		window, ok := models.ParsePeriodKey(period, last)
+		if !ok {
+			window, _ = models.PeriodContaining(period, *earliest)
+		}
+		for ; window.IsClosed(now); window = window.Next() {
+			if archived := h.archivePeriod(window); archived > 0 {
+				log.Printf("Archived %d leaderboard standings for %s %s", archived, period, window.Key)
+			}
+		}
+	}
+}
+
+// archivePeriod archives every leaderboard for a closed window and returns how many standings were stored
+func (h *Handler) archivePeriod(window models.PeriodWindow) int {
+	total := 0
+	for _, mode := range models.GameModes() {
+		for _, difficulty := range mode.Difficulties {
+			archived, err := h.db.ArchivePeriodStandings(window, mode.Name, difficulty, archiveStandingsSize)
+			if err != nil {
+				log.Printf("Failed to archive %s %s leaderboard for %s %s: %v", mode.Name, difficulty, window.Period, window.Key, err)
+				continue
+			}
+			total += archived
+		}
+	}
+	return total
+}
+
+// GetLeaderboardHistory godoc
+// @Summary Get past leaderboard winners
+// @Description Returns the final standings of closed leaderboard periods, newest first. Pass a period key (such as 2025-W23) to get one period's full archived standings.
+// @Tags game
+// @Produce json
+// @Param period query string false "Period (day, week, month or season, default: week)"
+// @Param mode query string true "Game mode (challenge, streak, zero, daily or higherlower)"
+// @Param difficulty query string false "Difficulty (easy or hard, default: hard)"
+// @Param key query string false "A single period key: 2025-06-02, 2025-W23, 2025-06 or 2025-Q2"
+// @Param limit query int false "Number of past periods to return (default: 10, max: 52)"
+// @Param top query int false "Standings to return per period (default: 3, or 100 with a key)"
+// @Success 200 {object} map[string]interface{} "period, mode, difficulty and periods array with standings"
+// @Failure 400 {object} map[string]string "error: Invalid period, mode, difficulty or key"
+// @Router /api/leaderboard/history [get]
+func (h *Handler) GetLeaderboardHistory(c *gin.Context) {
+	period := c.DefaultQuery("period", models.PeriodWeek)
+	if !models.IsArchivedPeriod(period) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Period must be day, week, month or season"})
+		return
+	}
+
+	mode, ok := models.LookupGameMode(c.Query("mode"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A valid game mode is required"})
+		return
+	}
+
+	difficulty := c.DefaultQuery("difficulty", "hard")
+	if !mode.HasDifficulty(difficulty) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown difficulty for this game mode"})
+		return
+	}
+
+	key := c.Query("key")
+	limit, top := defaultHistoryPeriods, defaultHistoryTop
+	if key != "" {
+		if _, ok := models.ParsePeriodKey(period, key); !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid period key"})
+			return
+		}
+		limit, top = 1, archiveStandingsSize
+	}
+	if l := parseInt(c.Query("limit")); l > 0 && l <= maxHistoryPeriods {
+		limit = l
+	}
+	if t := parseInt(c.Query("top")); t > 0 && t <= archiveStandingsSize {
+		top = t
+	}
+
+	periods, err := h.db.GetArchivedPeriods(period, mode.Name, difficulty, key, limit, top)
+	if err != nil {
+		log.Printf("Failed to get leaderboard history: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch leaderboard history"})
+		return
+	}
+	if periods == nil {
+		periods = []models.ArchivedPeriod{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"period":     period,
+		"mode":       mode.Name,
+		"difficulty": difficulty,
+		"periods":    periods,
+	})
+}
+
+// ArchiveLeaderboardPeriod godoc
+// @Summary Archive a closed leaderboard period (Admin Only)
+// @Description Stores the final standings of a closed period for every leaderboard. Closed periods are archived automatically every hour; use this to backfill one that was missed. Boards that are already archived are left unchanged. Requires admin authentication.
+// @Tags admin
+// @Security AdminKey
+// @Produce json
+// @Param period query string true "Period (day, week, month or season)"
+// @Param key query string false "Period key, defaulting to the most recently closed one"
+// @Success 200 {object} map[string]interface{} "period, key and number of standings archived"
+// @Failure 400 {object} map[string]string "error: Invalid period or key, or the period hasn't closed"
+// @Failure 401 {object} map[string]string "error: Unauthorized - Admin key required"
+// @Router /api/admin/leaderboard/archive [post]
+func (h *Handler) ArchiveLeaderboardPeriod(c *gin.Context) {
+	period := c.Query("period")
+	if !models.IsArchivedPeriod(period) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Period must be day, week, month or season"})
+		return
+	}
+
+	now := time.Now()
+	current, _ := models.PeriodContaining(period, now)
+	window := current.Previous()
+	if key := c.Query("key"); key != "" {
+		var ok bool
+		if window, ok = models.ParsePeriodKey(period, key); !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid period key"})
+			return
+		}
+	}
+	if !window.IsClosed(now) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "This period hasn't closed yet"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"period":   window.Period,
+		"key":      window.Key,
+		"archived": h.archivePeriod(window),
+	})
+}
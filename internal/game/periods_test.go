@@ -0,0 +1,43 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"autotraderguesser/internal/models"
+)
+
+func TestArchiveClosedPeriodsCatchesUp(t *testing.T) {
+	h, _ := newTestHandler(t)
+
+	// Nothing to archive before anything is submitted
+	h.archiveClosedPeriods(time.Now())
+
+	if err := h.db.AddLeaderboardEntry(&models.LeaderboardEntry{Name: "Early", Score: 5, GameMode: "challenge", Difficulty: "hard"}); err != nil {
+		t.Fatalf("AddLeaderboardEntry failed: %v", err)
+	}
+	submitted, err := h.db.EarliestLeaderboardEntry()
+	if err != nil || submitted == nil {
+		t.Fatalf("EarliestLeaderboardEntry failed: %v", err)
+	}
+
+	// A server that was down for months still archives the windows the entry fell in, not just the latest ones
+	h.archiveClosedPeriods(submitted.AddDate(1, 0, 0))
+	for _, period := range models.ArchivedPeriods {
+		window, _ := models.PeriodContaining(period, *submitted)
+		history, err := h.db.GetArchivedPeriods(period, "challenge", "hard", "", 10, 3)
+		if err != nil {
+			t.Fatalf("GetArchivedPeriods failed: %v", err)
+		}
+		if len(history) != 1 || history[0].Key != window.Key || len(history[0].Standings) != 1 {
+			t.Fatalf("expected %s %s to be archived, got %+v", period, window.Key, history)
+		}
+	}
+
+	// Running again picks up from the last archived window without archiving anything twice
+	h.archiveClosedPeriods(submitted.AddDate(1, 0, 0))
+	history, err := h.db.GetArchivedPeriods(models.PeriodDay, "challenge", "hard", "", 10, 3)
+	if err != nil || len(history) != 1 || len(history[0].Standings) != 1 {
+		t.Fatalf("expected a single archived day, got %+v err=%v", history, err)
+	}
+}
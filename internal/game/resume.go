@@ -0,0 +1,102 @@
+package game
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"autotraderguesser/internal/models"
+)
+
+// loadChallengeSession loads a playable challenge session, falling back to memory if the database fails.
+// Writes a 404, or a 410 for a session abandoned past its expiry, and returns false if there is none.
+func (h *Handler) loadChallengeSession(c *gin.Context, sessionID string) (*models.ChallengeSession, bool) {
+	session, err := h.db.GetChallengeSession(sessionID)
+	if err != nil {
+		log.Printf("Failed to get challenge session from database: %v", err)
+		// Fallback to in-memory storage
+		sessionMem, exists := h.challengeSessions.Get(sessionID)
+
+		if !exists {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Challenge session not found"})
+			return nil, false
+		}
+		if sessionMem.IsExpired(time.Now()) {
+			respondChallengeExpired(c)
+			return nil, false
+		}
+		return sessionMem, true
+	}
+
+	if session == nil {
+		if expired, err := h.db.IsChallengeSessionExpired(sessionID); err != nil {
+			log.Printf("Failed to check challenge session expiry: %v", err)
+		} else if expired {
+			respondChallengeExpired(c)
+			return nil, false
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": "Challenge session not found"})
+		return nil, false
+	}
+
+	return session, true
+}
+
+// respondChallengeExpired writes the response for a challenge session abandoned past its expiry
+func respondChallengeExpired(c *gin.Context) {
+	c.JSON(http.StatusGone, gin.H{
+		"error": "Challenge session has expired. Start a new challenge to keep playing.",
+		"code":  "SESSION_EXPIRED",
+	})
+}
+
+// ResumeChallenge godoc
+// @Summary Resume an unfinished challenge
+// @Description Returns the signed-in player's most recent unfinished challenge session so it can be continued on any device. Sessions must be finished within 24 hours of starting.
+// @Tags challenge
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.ChallengeSession "sessionId, cars (prices hidden), currentCar, guesses so far, expiresAt"
+// @Failure 401 {object} map[string]string "error: Authentication required"
+// @Failure 404 {object} map[string]string "error: No unfinished challenge"
+// @Router /api/challenge/resume [get]
+func (h *Handler) ResumeChallenge(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Sign in to resume a challenge"})
+		return
+	}
+	u := user.(*models.User)
+
+	session, err := h.db.GetResumableChallengeSession(u.ID)
+	if err != nil {
+		log.Printf("Failed to find resumable challenge for user %d: %v", u.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load challenge session"})
+		return
+	}
+	if session == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No unfinished challenge to resume"})
+		return
+	}
+
+	c.JSON(http.StatusOK, session)
+}
+
+// pricedChallengeCar returns a challenge car with its actual price from the session's snapshot, falling back
+// to the live listing for sessions started before the snapshot was stored
+func (h *Handler) pricedChallengeCar(session *models.ChallengeSession, index int) (*models.EnhancedCar, bool) {
+	if index < len(session.PricedCars) && session.PricedCars[index].Price > 0 {
+		return session.PricedCars[index], true
+	}
+	if index >= len(session.Cars) {
+		return nil, false
+	}
+
+	listing, ok := h.findListing(session.Difficulty, session.Cars[index].ID)
+	if !ok {
+		return nil, false
+	}
+	return listing.ToEnhancedCar(), true
+}
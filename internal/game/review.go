@@ -0,0 +1,162 @@
+package game
+
+import (
+	"log"
+	"math"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"autotraderguesser/internal/models"
+)
+
+// buildChallengeReview pairs every car of a completed session with the player's guess and hints
+func buildChallengeReview(session *models.ChallengeSession) *models.ChallengeReview {
+	review := &models.ChallengeReview{
+		SessionID:     session.SessionID,
+		Difficulty:    session.Difficulty,
+		Scoring:       session.Scoring,
+		Theme:         session.Theme,
+		TotalScore:    session.TotalScore,
+		HintsUsed:     len(session.Hints),
+		CompletedTime: session.CompletedTime,
+		Cars:          make([]models.ChallengeReviewCar, 0, len(session.Cars)),
+	}
+
+	// Sessions from before the price snapshot only have the hidden cars
+	cars := session.PricedCars
+	if len(cars) == 0 {
+		cars = session.Cars
+	}
+
+	// Match guesses by car ID, as guesses stored before car indexes were recorded all have index 0
+	guesses := make(map[string]models.ChallengeGuess, len(session.Guesses))
+	for _, guess := range session.Guesses {
+		guesses[guess.CarID] = guess
+	}
+
+	for i, car := range cars {
+		revealed := *car
+		entry := models.ChallengeReviewCar{
+			Car:   &revealed,
+			Hints: []models.ChallengeHint{},
+		}
+
+		if guess, ok := guesses[car.ID]; ok {
+			guess.CarIndex = i
+			guess.Difference = math.Abs(guess.ActualPrice - guess.GuessedPrice)
+			entry.ChallengeGuess = guess
+			if revealed.Price == 0 {
+				revealed.Price = guess.ActualPrice
+			}
+		} else {
+			entry.ChallengeGuess = models.ChallengeGuess{CarIndex: i, CarID: car.ID, ActualPrice: revealed.Price}
+		}
+		entry.OriginalURL = revealed.OriginalURL
+
+		for _, hint := range session.Hints {
+			if hint.CarIndex == i {
+				entry.Hints = append(entry.Hints, hint)
+			}
+		}
+
+		review.Cars = append(review.Cars, entry)
+	}
+
+	return review
+}
+
+// compareFriendGuesses adds a per-car comparison across every finished participant of a friend challenge
+func (h *Handler) compareFriendGuesses(review *models.ChallengeReview, friendChallengeID int) error {
+	participants, err := h.db.GetChallengeParticipants(friendChallengeID)
+	if err != nil {
+		return err
+	}
+
+	comparisons := make(map[string]*models.FriendCarComparison)
+	for _, p := range participants {
+		session, err := h.db.GetChallengeSession(p.SessionID)
+		if err != nil {
+			return err
+		}
+		if session == nil || !session.IsComplete {
+			continue // Unfinished guesses stay private
+		}
+
+		for _, guess := range session.Guesses {
+			comparison, ok := comparisons[guess.CarID]
+			if !ok {
+				comparison = &models.FriendCarComparison{BestPercentage: math.Inf(1)}
+				comparisons[guess.CarID] = comparison
+			}
+
+			comparison.Participants++
+			comparison.AverageError += guess.Percentage // Summed here, averaged below
+			if guess.Percentage < comparison.BestPercentage {
+				comparison.BestGuess = guess.GuessedPrice
+				comparison.BestPercentage = guess.Percentage
+				comparison.BestPlayer = p.UserDisplayName
+			}
+		}
+	}
+
+	for i := range review.Cars {
+		if comparison, ok := comparisons[review.Cars[i].CarID]; ok {
+			comparison.AverageError /= float64(comparison.Participants)
+			review.Cars[i].Friends = comparison
+		}
+	}
+
+	return nil
+}
+
+// GetChallengeReview godoc
+// @Summary Review a completed challenge
+// @Description Reveals every car of a completed challenge session with its actual price, the player's guess, points, percentage error, hints and original listing. Friend challenge sessions also get a per-car comparison of the best guess and average error across finished participants. Nothing is revealed until the session is complete.
+// @Tags challenge
+// @Produce json
+// @Param sessionId path string true "Challenge Session ID (16 alphanumeric characters)"
+// @Success 200 {object} models.ChallengeReview
+// @Failure 400 {object} map[string]string "error: Invalid session ID format"
+// @Failure 403 {object} map[string]string "error: Session not complete"
+// @Failure 404 {object} map[string]string "error: Session not found"
+// @Router /api/challenge/{sessionId}/review [get]
+func (h *Handler) GetChallengeReview(c *gin.Context) {
+	sessionID := c.Param("sessionId")
+
+	// Validate session ID
+	if !isValidSessionID(sessionID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID format"})
+		return
+	}
+
+	session, err := h.db.GetChallengeSession(sessionID)
+	if err != nil {
+		log.Printf("Failed to get challenge session from database: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load challenge session"})
+		return
+	}
+	if session == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Challenge session not found"})
+		return
+	}
+
+	if !session.IsComplete {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Challenge review is only available once the challenge is complete"})
+		return
+	}
+
+	review := buildChallengeReview(session)
+
+	participant, err := h.db.GetChallengeParticipantBySession(sessionID)
+	if err != nil {
+		log.Printf("Failed to check friend challenge for session %s: %v", sessionID, err)
+	} else if participant != nil {
+		review.FriendChallengeID = &participant.FriendChallengeID
+		if err := h.compareFriendGuesses(review, participant.FriendChallengeID); err != nil {
+			log.Printf("Failed to compare friend challenge guesses for session %s: %v", sessionID, err)
+		}
+	}
+
+	c.JSON(http.StatusOK, review)
+}
@@ -0,0 +1,321 @@
+package game
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"autotraderguesser/internal/cache"
+	"autotraderguesser/internal/models"
+	"autotraderguesser/internal/scraper"
+)
+
+// refreshInterval is how often each source is re-scraped
+const refreshInterval = 7 * 24 * time.Hour
+
+// DefaultSources returns the listing sources the game ships with
+func DefaultSources() *scraper.Registry {
+	return scraper.NewRegistry(
+		scraper.NewBonhamsSource(ListingAmount), // Hard mode
+		scraper.NewLookersSource(),              // Easy mode
+	)
+}
+
+// listingSnapshot is an immutable set of listings. Refreshes build a new snapshot and swap it in
+// atomically, so gameplay reads listings without taking any lock. Never modify a snapshot once published.
+type listingSnapshot struct {
+	ids     []string // Every listing ID, sorted, for random picks
+	yearIDs []string // IDs of listings with a known year, sorted, for year mode
+	byID    map[string]models.Listing
+}
+
+// newListingSnapshot indexes a set of listings
+func newListingSnapshot(byID map[string]models.Listing) *listingSnapshot {
+	snap := &listingSnapshot{
+		ids:  make([]string, 0, len(byID)),
+		byID: byID,
+	}
+	for id, listing := range byID {
+		snap.ids = append(snap.ids, id)
+		if listing.ToEnhancedCar().Year > 0 {
+			snap.yearIDs = append(snap.yearIDs, id)
+		}
+	}
+	sort.Strings(snap.ids)
+	sort.Strings(snap.yearIDs)
+	return snap
+}
+
+// listingPool holds the live listings for one registered source
+type listingPool struct {
+	source        scraper.ListingSource
+	snapshot      atomic.Pointer[listingSnapshot]
+	refreshTicker *time.Ticker
+	isRefreshing  atomic.Bool // Prevents concurrent refreshes
+}
+
+// listings returns the pool's current snapshot
+func (p *listingPool) listings() *listingSnapshot {
+	return p.snapshot.Load()
+}
+
+// newListingPools creates an empty pool per registered source, keyed by difficulty
+func newListingPools(sources *scraper.Registry) map[string]*listingPool {
+	pools := make(map[string]*listingPool)
+	for _, src := range sources.Sources() {
+		pool := &listingPool{source: src}
+		pool.snapshot.Store(newListingSnapshot(map[string]models.Listing{}))
+		pools[src.Difficulty()] = pool
+	}
+	return pools
+}
+
+// orderedPools returns the pools in source registration order
+func (h *Handler) orderedPools() []*listingPool {
+	var pools []*listingPool
+	for _, src := range h.scraper.Sources().Sources() {
+		if pool, ok := h.pools[src.Difficulty()]; ok {
+			pools = append(pools, pool)
+		}
+	}
+	return pools
+}
+
+// poolForSource looks up a pool by source name
+func (h *Handler) poolForSource(name string) (*listingPool, bool) {
+	src, ok := h.scraper.Sources().Get(name)
+	if !ok {
+		return nil, false
+	}
+	pool, ok := h.pools[src.Difficulty()]
+	return pool, ok
+}
+
+// resolveDifficulty maps unknown difficulties to hard mode for backward compatibility
+func (h *Handler) resolveDifficulty(difficulty string) string {
+	if _, ok := h.pools[difficulty]; ok {
+		return difficulty
+	}
+	return "hard"
+}
+
+// findListing looks up a listing in the pool serving a difficulty
+func (h *Handler) findListing(difficulty, id string) (models.Listing, bool) {
+	pool, ok := h.pools[difficulty]
+	if !ok {
+		return nil, false
+	}
+
+	listing, found := pool.listings().byID[id]
+	return listing, found
+}
+
+// replaceListings swaps in a new listing set for a pool, keeping only listings the source validates
+func (h *Handler) replaceListings(pool *listingPool, listings []models.Listing, logFiltered bool) (valid []models.Listing, oldCount int) {
+	fresh := make(map[string]models.Listing, len(listings))
+	for _, listing := range listings {
+		if !pool.source.Validate(listing) {
+			if logFiltered {
+				fmt.Printf("WARNING: Filtered %s listing %s (invalid price %.0f)\n",
+					pool.source.Name(), listing.ListingID(), listing.ListingPrice())
+			}
+			continue
+		}
+		fresh[listing.ListingID()] = listing
+		valid = append(valid, listing)
+	}
+
+	// Readers holding the old snapshot keep using it until they finish
+	old := pool.snapshot.Swap(newListingSnapshot(fresh))
+	oldCount = len(old.ids)
+
+	return valid, oldCount
+}
+
+// initializeSource loads a source from cache, or scrapes it before startup on a cache miss
+func (h *Handler) initializeSource(pool *listingPool) {
+	src := pool.source
+	fmt.Printf("Initializing %s listings for %s mode...\n", src.Name(), src.Difficulty())
+
+	// Try to load from cache first
+	if data, found := cache.Load(src.CacheKey()); found {
+		listings, err := src.Decode(data)
+		if err == nil {
+			valid, _ := h.replaceListings(pool, listings, true)
+			if filtered := len(listings) - len(valid); filtered > 0 {
+				fmt.Printf("WARNING: Filtered %d invalid %s cars from cache\n", filtered, src.Name())
+			}
+			return
+		}
+		fmt.Printf("ERROR: Failed to decode %s cache: %v\n", src.Name(), err)
+	}
+
+	// Cache miss or expired, scrape fresh data (blocking to ensure the mode is ready)
+	fmt.Printf("No %s cache found - scraping fresh data before startup...\n", src.Name())
+	h.refreshSource(pool, false)
+}
+
+// refreshSource re-scrapes a source and swaps in the results.
+// Background refreshes keep the existing data when scraping and the cache both fail;
+// startup refreshes fall back to mock data if the source provides any.
+func (h *Handler) refreshSource(pool *listingPool, background bool) {
+	src := pool.source
+	if background {
+		fmt.Printf("Starting %s background refresh (non-blocking)...\n", src.Name())
+	} else {
+		fmt.Printf("Refreshing listings from %s...\n", src.Name())
+	}
+
+	// Check if USE_<SOURCE>_CACHE_ONLY env var is set (temporary fix)
+	envVar := "USE_" + strings.ToUpper(src.Name()) + "_CACHE_ONLY"
+	if useCacheOnly := os.Getenv(envVar); useCacheOnly == "true" || useCacheOnly == "1" {
+		fmt.Printf("%s is set - loading from cache only\n", envVar)
+		if h.loadFallbackCache(pool, "cache-only mode") {
+			return
+		}
+		if background {
+			fmt.Printf("ERROR: %s set but no cache available - keeping existing data\n", envVar)
+			return
+		}
+		fmt.Printf("ERROR: %s set but no cache available!\n", envVar)
+	}
+
+	// Get fresh data (this may take a few minutes)
+	listings, err := src.Fetch()
+	if err == nil && len(listings) > 0 {
+		valid, oldCount := h.replaceListings(pool, listings, !background)
+
+		// Save to cache (this can take time, but doesn't block gameplay)
+		if err := cache.Save(src.CacheKey(), valid); err != nil {
+			fmt.Printf("WARNING: Failed to save %s cache: %v\n", src.Name(), err)
+		}
+
+		fmt.Printf("Refreshed %d valid cars from %s (was %d, %d filtered out)\n",
+			len(valid), src.Name(), oldCount, len(listings)-len(valid))
+		return
+	}
+
+	// Scraping failed - try to fall back to cached data (even if expired)
+	fmt.Printf("ERROR: %s scraper failed: %v\n", src.Name(), err)
+	fmt.Println("Attempting to load from expired cache as fallback...")
+	if h.loadFallbackCache(pool, "fallback cache") {
+		return
+	}
+
+	if background {
+		fmt.Println("ERROR: No cache available for fallback - keeping existing data")
+		return
+	}
+
+	// No cache available - use minimal mock data as last resort
+	if mockSource, ok := src.(scraper.MockSource); ok {
+		fmt.Println("ERROR: No cache available, creating minimal mock data for testing...")
+		h.replaceListings(pool, mockSource.MockListings(), false)
+		return
+	}
+
+	fmt.Println("ERROR: No cache available for fallback")
+}
+
+// loadFallbackCache loads a source's cache regardless of expiry and bumps the expiry
+// to give us another week before retrying
+func (h *Handler) loadFallbackCache(pool *listingPool, reason string) bool {
+	src := pool.source
+
+	data, err := cache.LoadIgnoreExpiry(src.CacheKey())
+	if err != nil {
+		return false
+	}
+
+	listings, err := src.Decode(data)
+	if err != nil || len(listings) == 0 {
+		return false
+	}
+
+	valid, oldCount := h.replaceListings(pool, listings, false)
+
+	if err := cache.Bump(src.CacheKey()); err != nil {
+		fmt.Printf("WARNING: Failed to bump %s cache expiry: %v\n", src.Name(), err)
+	}
+
+	fmt.Printf("Using %d %s cars from %s (was %d, extended expiry by 7 days)\n",
+		len(valid), src.Name(), reason, oldCount)
+	return true
+}
+
+// triggerBackgroundRefresh starts a background refresh unless one is already running
+func (h *Handler) triggerBackgroundRefresh(pool *listingPool) bool {
+	if !pool.isRefreshing.CompareAndSwap(false, true) {
+		fmt.Printf("WARNING: %s refresh already in progress, skipping\n", pool.source.Name())
+		return false
+	}
+	go func() {
+		defer pool.isRefreshing.Store(false)
+		h.refreshSource(pool, true)
+	}()
+	return true
+}
+
+// startAutoRefresh starts a background goroutine per source that refreshes its listings
+func (h *Handler) startAutoRefresh() {
+	for _, pool := range h.orderedPools() {
+		pool.refreshTicker = time.NewTicker(refreshInterval)
+
+		go func(pool *listingPool) {
+			for range pool.refreshTicker.C {
+				fmt.Printf("%s auto-refresh triggered (7 days elapsed)\n", pool.source.Name())
+
+				// Check if already refreshing
+				if pool.isRefreshing.Load() {
+					fmt.Printf("WARNING: %s refresh already in progress, skipping\n", pool.source.Name())
+					continue
+				}
+
+				// Check for active games before refreshing
+				if h.hasActiveGames() {
+					fmt.Printf("WARNING: Active games detected, postponing %s refresh for 1 hour\n", pool.source.Name())
+					time.AfterFunc(1*time.Hour, func() {
+						h.triggerBackgroundRefresh(pool)
+					})
+					continue
+				}
+
+				// Run refresh in background to avoid blocking gameplay
+				h.triggerBackgroundRefresh(pool)
+			}
+		}(pool)
+	}
+}
+
+// StopAutoRefresh stops the automatic refresh tickers (useful for cleanup)
+func (h *Handler) StopAutoRefresh() {
+	for _, pool := range h.orderedPools() {
+		if pool.refreshTicker != nil {
+			pool.refreshTicker.Stop()
+			fmt.Printf("%s auto-refresh stopped\n", pool.source.Name())
+		}
+	}
+}
+
+// verifyDataSourcesReady ensures every registered mode has data available
+func (h *Handler) verifyDataSourcesReady() {
+	fmt.Printf("Data source verification:\n")
+
+	allReady := true
+	for _, pool := range h.orderedPools() {
+		count := len(pool.listings().ids)
+
+		fmt.Printf("   %s mode (%s): %d cars loaded\n", pool.source.Difficulty(), pool.source.Name(), count)
+		if count == 0 {
+			fmt.Printf("WARNING: %s mode has no cars - users will see errors!\n", pool.source.Difficulty())
+			allReady = false
+		}
+	}
+
+	if allReady {
+		fmt.Println("All game modes have sufficient data")
+	}
+}
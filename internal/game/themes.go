@@ -0,0 +1,215 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"autotraderguesser/internal/models"
+	"autotraderguesser/internal/validation"
+)
+
+// findTheme looks up an active challenge theme by slug.
+// Returns nil without error for an empty slug, and models.ErrThemeNotFound if the theme is missing or retired.
+func (h *Handler) findTheme(slug string) (*models.ChallengeTheme, error) {
+	if slug == "" {
+		return nil, nil
+	}
+
+	theme, err := h.db.GetChallengeTheme(slug)
+	if err != nil {
+		return nil, err
+	}
+	if theme == nil || !theme.IsActive {
+		return nil, models.ErrThemeNotFound
+	}
+
+	return theme, nil
+}
+
+// respondChallengeSetupError writes the response for a challenge that couldn't be set up
+func respondChallengeSetupError(c *gin.Context, difficulty string, err error) {
+	var tooNarrow *models.ThemeTooNarrowError
+
+	switch {
+	case errors.Is(err, models.ErrThemeNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Challenge theme not found"})
+	case errors.As(err, &tooNarrow):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("The %s theme only has %d %s mode cars right now, but the challenge needs %d. Try fewer cars or another difficulty.",
+				tooNarrow.Theme, tooNarrow.Matched, difficulty, tooNarrow.Needed),
+			"code":    "THEME_TOO_NARROW",
+			"matched": tooNarrow.Matched,
+			"needed":  tooNarrow.Needed,
+		})
+	default:
+		log.Printf("Failed to set up %s mode challenge: %v", difficulty, err)
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Not enough %s mode cars available for challenge mode", difficulty)})
+	}
+}
+
+// countThemeCars returns how many cars in each difficulty pool match a theme
+func (h *Handler) countThemeCars(theme *models.ChallengeTheme) map[string]int {
+	counts := make(map[string]int)
+
+	for _, pool := range h.orderedPools() {
+		count := 0
+		for _, car := range pool.listings().byID {
+			if theme.Filter.Matches(car.ToEnhancedCar()) {
+				count++
+			}
+		}
+		counts[pool.source.Difficulty()] = count
+	}
+
+	return counts
+}
+
+// GetChallengeThemes godoc
+// @Summary List challenge themes
+// @Description Returns the active challenge themes, with how many cars each currently matches per difficulty. Pass a theme's slug to /api/challenge/start to play it.
+// @Tags challenge
+// @Produce json
+// @Success 200 {object} map[string]interface{} "themes array, each with availableCars per difficulty"
+// @Router /api/challenge/themes [get]
+func (h *Handler) GetChallengeThemes(c *gin.Context) {
+	themes, err := h.db.ListChallengeThemes(true)
+	if err != nil {
+		log.Printf("Failed to list challenge themes: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load challenge themes"})
+		return
+	}
+
+	result := make([]gin.H, 0, len(themes))
+	for i := range themes {
+		result = append(result, gin.H{
+			"slug":          themes[i].Slug,
+			"name":          themes[i].Name,
+			"description":   themes[i].Description,
+			"filter":        themes[i].Filter,
+			"availableCars": h.countThemeCars(&themes[i]),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"themes": result})
+}
+
+// CreateChallengeTheme godoc
+// @Summary Create a challenge theme (Admin Only)
+// @Description Defines a new challenge theme from a car filter (makes, year range, body types, fuel types, price range). The slug is what players pass to start a themed challenge. Requires admin authentication.
+// @Tags admin
+// @Security AdminKey
+// @Accept json
+// @Produce json
+// @Param request body models.CreateThemeRequest true "Theme slug, name, description and filter"
+// @Success 201 {object} models.ChallengeTheme
+// @Failure 400 {object} map[string]string "error: Invalid slug or empty filter"
+// @Failure 401 {object} map[string]string "error: Unauthorized - Admin key required"
+// @Failure 409 {object} map[string]string "error: A theme with this slug already exists"
+// @Router /api/admin/themes [post]
+func (h *Handler) CreateChallengeTheme(c *gin.Context) {
+	var req models.CreateThemeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	if err := validation.ValidateThemeSlug(req.Slug); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Filter.IsEmpty() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Theme filter must set at least one criterion"})
+		return
+	}
+
+	if req.Filter.YearFrom > 0 && req.Filter.YearTo > 0 && req.Filter.YearFrom > req.Filter.YearTo {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Theme yearFrom must not be after yearTo"})
+		return
+	}
+
+	if req.Filter.MinPrice < 0 || req.Filter.MaxPrice < 0 ||
+		(req.Filter.MaxPrice > 0 && req.Filter.MinPrice >= req.Filter.MaxPrice) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Theme price range is invalid"})
+		return
+	}
+
+	theme := &models.ChallengeTheme{
+		Slug:        req.Slug,
+		Name:        strings.TrimSpace(req.Name),
+		Description: strings.TrimSpace(req.Description),
+		Filter:      req.Filter,
+		IsActive:    true,
+	}
+
+	created, err := h.db.CreateChallengeTheme(theme)
+	if err != nil {
+		log.Printf("Failed to create challenge theme %s: %v", req.Slug, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create challenge theme"})
+		return
+	}
+	if !created {
+		c.JSON(http.StatusConflict, gin.H{"error": "A theme with this slug already exists"})
+		return
+	}
+
+	// Re-read for the stored creation time
+	if stored, err := h.db.GetChallengeTheme(theme.Slug); err == nil && stored != nil {
+		theme = stored
+	}
+
+	c.JSON(http.StatusCreated, theme)
+}
+
+// ListChallengeThemes godoc
+// @Summary List all challenge themes (Admin Only)
+// @Description Returns every challenge theme, including retired ones. Requires admin authentication.
+// @Tags admin
+// @Security AdminKey
+// @Produce json
+// @Success 200 {object} map[string]interface{} "themes array"
+// @Failure 401 {object} map[string]string "error: Unauthorized - Admin key required"
+// @Router /api/admin/themes [get]
+func (h *Handler) ListChallengeThemes(c *gin.Context) {
+	themes, err := h.db.ListChallengeThemes(false)
+	if err != nil {
+		log.Printf("Failed to list challenge themes: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load challenge themes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"themes": themes})
+}
+
+// RetireChallengeTheme godoc
+// @Summary Retire a challenge theme (Admin Only)
+// @Description Stops new challenges being started with a theme. Sessions already using it are unaffected. Requires admin authentication.
+// @Tags admin
+// @Security AdminKey
+// @Produce json
+// @Param slug path string true "Theme slug"
+// @Success 200 {object} map[string]string "message: Theme retired"
+// @Failure 401 {object} map[string]string "error: Unauthorized - Admin key required"
+// @Failure 404 {object} map[string]string "error: Theme not found"
+// @Router /api/admin/themes/{slug}/retire [post]
+func (h *Handler) RetireChallengeTheme(c *gin.Context) {
+	slug := c.Param("slug")
+
+	updated, err := h.db.SetChallengeThemeActive(slug, false)
+	if err != nil {
+		log.Printf("Failed to retire challenge theme %s: %v", slug, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retire challenge theme"})
+		return
+	}
+	if !updated {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Challenge theme not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Theme retired"})
+}
@@ -0,0 +1,142 @@
+package game
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"autotraderguesser/internal/models"
+	"autotraderguesser/internal/validation"
+)
+
+// GetYearListing godoc
+// @Summary Get a random car for guess-the-year mode
+// @Description Returns a random car with its year, price and any year-revealing text redacted from the title, key facts and description. Only listings with a known year are served.
+// @Tags game
+// @Produce json
+// @Param difficulty query string false "Difficulty mode (easy for Lookers, hard for Bonhams)" Enums(easy, hard)
+// @Param X-Session-ID header string false "Session ID for avoiding repeats"
+// @Success 200 {object} models.EnhancedCar
+// @Failure 400 {object} map[string]string "error: Invalid session ID format"
+// @Failure 404 {object} map[string]string "error: No listings available"
+// @Router /api/year/listing [get]
+func (h *Handler) GetYearListing(c *gin.Context) {
+	difficulty := h.resolveDifficulty(c.DefaultQuery("difficulty", "hard")) // Bonhams spans the most decades
+
+	// Anonymous requests get no repeat avoidance
+	sessionID := c.GetHeader("X-Session-ID")
+	if sessionID != "" {
+		if err := validation.ValidateSessionID(sessionID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID format"})
+			return
+		}
+	}
+
+	pool, ok := h.pools[difficulty]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No listings available"})
+		return
+	}
+
+	// Only cars with a parsed year can be played
+	snap := pool.listings()
+	if len(snap.yearIDs) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("No %s mode listings with a known year available", difficulty)})
+		return
+	}
+
+	randomID := h.selectRandomCarWithHistory(sessionID, snap.yearIDs)
+	h.addToRecentlyShown(sessionID, randomID)
+
+	redacted := snap.byID[randomID].ToEnhancedCar().RedactYear()
+	redacted.Price = 0
+
+	c.JSON(http.StatusOK, redacted)
+}
+
+// SubmitYearGuess godoc
+// @Summary Submit a build year guess for a car
+// @Description Reveals the car's year and scores the guess by how many years off it was
+// @Tags game
+// @Accept json
+// @Produce json
+// @Param guess body models.YearGuessRequest true "Year guess data"
+// @Success 200 {object} models.YearGuessResponse
+// @Failure 400 {object} map[string]string "error: Invalid request format"
+// @Failure 404 {object} map[string]string "error: Listing not found"
+// @Router /api/year/guess [post]
+func (h *Handler) SubmitYearGuess(c *gin.Context) {
+	var req models.YearGuessRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format", "details": err.Error()})
+		return
+	}
+
+	if !isValidListingID(req.ListingID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid listing ID format"})
+		return
+	}
+
+	difficulty := req.Difficulty
+	if difficulty == "" {
+		difficulty = "hard"
+	}
+
+	listing, exists := h.findListing(difficulty, req.ListingID)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Listing not found", "requestedId": req.ListingID, "difficulty": difficulty})
+		return
+	}
+
+	actualYear := listing.ToEnhancedCar().Year
+	if actualYear == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Listing has no known year", "requestedId": req.ListingID})
+		return
+	}
+
+	yearsOff := req.GuessedYear - actualYear
+	if yearsOff < 0 {
+		yearsOff = -yearsOff
+	}
+
+	response := models.YearGuessResponse{
+		ActualYear:  actualYear,
+		GuessedYear: req.GuessedYear,
+		YearsOff:    yearsOff,
+		Points:      calculateYearPoints(yearsOff),
+		OriginalURL: listing.ListingURL(),
+	}
+
+	switch {
+	case yearsOff == 0:
+		response.Message = "Spot on!"
+	case yearsOff <= 5:
+		response.Message = "Close - within five years!"
+	default:
+		response.Message = fmt.Sprintf("Off by %d years", yearsOff)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// calculateYearPoints calculates points based on how many years off a guess was
+func calculateYearPoints(yearsOff int) int {
+	// Points scale: 5000 max points for the exact year, decreasing with each year off
+	// Exact year: 5000 points
+	// 1 year off: ~4094 points
+	// 2 years off: ~3352 points
+	// 5 years off: ~1839 points
+	// 10 years off: ~677 points
+	// 30 years off or more: 0 points
+
+	if yearsOff >= 30 {
+		return 0
+	}
+
+	// Points = 5000 * e^(-yearsOff/5)
+	points := 5000 * math.Exp(-float64(yearsOff)/5)
+
+	return int(math.Round(points))
+}
@@ -3,7 +3,9 @@ package handlers
 import (
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"strings"
@@ -14,6 +16,7 @@ import (
 
 	"autotraderguesser/internal/database"
 	"autotraderguesser/internal/models"
+	"autotraderguesser/internal/sessions"
 	"autotraderguesser/internal/validation"
 )
 
@@ -38,12 +41,18 @@ func isSecureCookieEnabled() bool {
 	return false
 }
 
+var tokenRandReader io.Reader = rand.Reader
+
 type AuthHandler struct {
-	db *database.Database
+	db            *database.Database
+	now           func() time.Time // Clock for failed attempt backoff and two-factor codes, replaced in tests
+	partialLogins *sessions.Store[*partialLogin]
 }
 
 func NewAuthHandler(db *database.Database) *AuthHandler {
-	return &AuthHandler{db: db}
+	h := &AuthHandler{db: db, now: time.Now}
+	h.partialLogins = sessions.NewWithClock[*partialLogin]("partialLogins", models.PartialLoginTTL, maxPartialLogins, func() time.Time { return h.now() })
+	return h
 }
 
 // Registration and Login requests
@@ -61,10 +70,12 @@ type LoginRequest struct {
 }
 
 type AuthResponse struct {
-	Success      bool         `json:"success"`
-	Message      string       `json:"message"`
-	User         *models.User `json:"user,omitempty"`
-	SessionToken string       `json:"sessionToken,omitempty"`
+	Success           bool         `json:"success"`
+	Message           string       `json:"message"`
+	User              *models.User `json:"user,omitempty"`
+	SessionToken      string       `json:"sessionToken,omitempty"`
+	TwoFactorRequired bool         `json:"twoFactorRequired,omitempty"` // Login needs a code, sent with PartialToken to /api/auth/login/2fa
+	PartialToken      string       `json:"partialToken,omitempty"`
 }
 
 // Register godoc
@@ -146,9 +157,6 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	// Generate session token
-	sessionToken := generateSessionToken()
-
 	user := &models.User{
 		Username:           req.Username,
 		PasswordHash:       string(hashedPassword),
@@ -156,7 +164,6 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		SecurityQuestion:   req.SecurityQuestion,
 		SecurityAnswerHash: string(hashedSecurityAnswer),
 		IsGuest:            false,
-		SessionToken:       sessionToken,
 	}
 
 	// Create user in database
@@ -168,16 +175,16 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	// Set session cookie with SameSite protection (7 days to match server expiration)
-	http.SetCookie(c.Writer, &http.Cookie{
-		Name:     "session_token",
-		Value:    sessionToken,
-		MaxAge:   86400 * 7, // 7 days
-		Path:     "/",
-		HttpOnly: true,
-		Secure:   isSecureCookieEnabled(), // Automatically enabled in production/HTTPS
-		SameSite: http.SameSiteLaxMode,
-	})
+	// Log the new account in on this device
+	sessionToken, err := h.startSession(c, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, AuthResponse{
+			Success: false,
+			Message: "Account created, but failed to log in",
+		})
+		return
+	}
+	user.SessionToken = sessionToken
 
 	// Don't return password hash
 	user.PasswordHash = ""
@@ -192,14 +199,16 @@ func (h *AuthHandler) Register(c *gin.Context) {
 
 // Login godoc
 // @Summary Login to an existing account
-// @Description Authenticates a user with username and password. Returns a session token valid for 7 days. Automatically upgrades password hashes to current security standards.
+// @Description Authenticates a user with username and password. Returns a session token valid for 7 days. Automatically upgrades password hashes to current security standards. Accounts with two-factor authentication get a 202 with a short-lived partial token instead, to be completed at /api/auth/login/2fa.
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param credentials body LoginRequest true "Login credentials"
 // @Success 200 {object} AuthResponse "Login successful"
+// @Success 202 {object} AuthResponse "Two-factor code required (twoFactorRequired, partialToken)"
 // @Failure 400 {object} AuthResponse "Invalid request data"
 // @Failure 401 {object} AuthResponse "Invalid username or password"
+// @Failure 429 {object} AuthResponse "Too many failed attempts for this account (see Retry-After)"
 // @Failure 500 {object} AuthResponse "Failed to create session"
 // @Router /api/auth/login [post]
 func (h *AuthHandler) Login(c *gin.Context) {
@@ -222,6 +231,12 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
+	// Accounts with too many failed logins must wait before the password is checked again.
+	// The attempt counts as failed until the password checks out, so parallel guesses can't share one allowance.
+	if !h.reserveAuthAttempt(c, user.ID, models.AuthAttemptLogin) {
+		return
+	}
+
 	// Check password
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
 		c.JSON(http.StatusUnauthorized, AuthResponse{
@@ -244,30 +259,40 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		}
 	}
 
-	// Generate new session token
-	sessionToken := generateSessionToken()
-	user.SessionToken = sessionToken
-	user.LastActive = time.Now()
-
-	// Update user session in database
-	if err := h.db.UpdateUserSession(user.ID, sessionToken); err != nil {
+	// Accounts with two-factor authentication finish logging in with a code
+	totp, err := h.db.GetUserTOTP(user.ID)
+	if err != nil {
+		fmt.Printf("Failed to get two-factor settings for user %d: %v\n", user.ID, err)
 		c.JSON(http.StatusInternalServerError, AuthResponse{
 			Success: false,
 			Message: "Failed to create session",
 		})
 		return
 	}
+	if totp.Enabled {
+		// Earlier failures stay counted until the code is checked too
+		h.releaseAuthAttempt(user.ID, models.AuthAttemptLogin)
+		h.startPartialLogin(c, user)
+		return
+	}
 
-	// Set session cookie with SameSite protection (7 days to match server expiration)
-	http.SetCookie(c.Writer, &http.Cookie{
-		Name:     "session_token",
-		Value:    sessionToken,
-		MaxAge:   86400 * 7, // 7 days
-		Path:     "/",
-		HttpOnly: true,
-		Secure:   isSecureCookieEnabled(), // Automatically enabled in production/HTTPS
-		SameSite: http.SameSiteLaxMode,
-	})
+	h.clearAuthFailures(user.ID, models.AuthAttemptLogin)
+	h.completeLogin(c, user)
+}
+
+// completeLogin starts a session for a user whose credentials have all been checked and writes the login response
+func (h *AuthHandler) completeLogin(c *gin.Context, user *models.User) {
+	// Start a new session for this device, leaving the user's other devices logged in
+	sessionToken, err := h.startSession(c, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, AuthResponse{
+			Success: false,
+			Message: "Failed to create session",
+		})
+		return
+	}
+	user.SessionToken = sessionToken
+	user.LastActive = time.Now()
 
 	// Don't return password hash
 	user.PasswordHash = ""
@@ -282,7 +307,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 
 // Logout godoc
 // @Summary Logout from current session
-// @Description Invalidates the current session token and clears the session cookie. Can be called without authentication.
+// @Description Ends the current device's session and clears the session cookie. The user's other devices stay logged in. Can be called without authentication.
 // @Tags auth
 // @Produce json
 // @Success 200 {object} AuthResponse "Logout successful"
@@ -292,8 +317,9 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 	user, exists := c.Get("user")
 	if exists {
 		if u, ok := user.(*models.User); ok {
-			// Clear session token in database
-			h.db.UpdateUserSession(u.ID, "")
+			if session := currentSession(c); session != nil {
+				h.db.RevokeUserSession(u.ID, session.ID)
+			}
 		}
 	}
 
@@ -421,7 +447,7 @@ func (h *AuthHandler) UpdateProfile(c *gin.Context) {
 
 // ResetPassword godoc
 // @Summary Reset user password
-// @Description Resets a user's password by verifying their username, display name, and security question answer. Includes timing attack protection.
+// @Description Resets a user's password by verifying their username, display name, and security question answer. Includes timing attack protection. Repeated wrong answers for an account back off and then lock password resets for it.
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -429,6 +455,7 @@ func (h *AuthHandler) UpdateProfile(c *gin.Context) {
 // @Success 200 {object} AuthResponse "Password reset successfully"
 // @Failure 400 {object} AuthResponse "Invalid request data"
 // @Failure 401 {object} AuthResponse "Invalid credentials or security answer"
+// @Failure 429 {object} AuthResponse "Too many failed attempts for this account (see Retry-After)"
 // @Failure 500 {object} AuthResponse "Failed to update password"
 // @Router /api/auth/reset-password [post]
 func (h *AuthHandler) ResetPassword(c *gin.Context) {
@@ -469,6 +496,11 @@ func (h *AuthHandler) ResetPassword(c *gin.Context) {
 		return
 	}
 
+	// Accounts with too many wrong answers must wait before the answer is checked again
+	if !h.reserveAuthAttempt(c, user.ID, models.AuthAttemptReset) {
+		return
+	}
+
 	// Verify security answer (normalize to lowercase for comparison)
 	normalizedAnswer := strings.ToLower(strings.TrimSpace(req.SecurityAnswer))
 	if err := bcrypt.CompareHashAndPassword([]byte(user.SecurityAnswerHash), []byte(normalizedAnswer)); err != nil {
@@ -498,6 +530,9 @@ func (h *AuthHandler) ResetPassword(c *gin.Context) {
 		return
 	}
 
+	// The new password is known, so lift any login lockout as well
+	h.clearAuthFailures(user.ID, models.AuthAttemptReset, models.AuthAttemptLogin)
+
 	c.JSON(http.StatusOK, AuthResponse{
 		Success: true,
 		Message: "Password reset successfully",
@@ -573,27 +608,23 @@ func (h *AuthHandler) AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// Get user by session token
-		user, err := h.db.GetUserBySessionToken(sessionToken)
+		// Look up the device's session (expired and revoked sessions aren't found)
+		user, session, err := h.db.GetUserSession(sessionToken)
 		if err != nil {
+			if errors.Is(err, models.ErrUserSessionNotFound) {
+				c.SetCookie("session_token", "", -1, "/", "", false, true)
+			}
 			c.Next() // Continue without user context
 			return
 		}
 
-		// Check if session has expired
-		if user.SessionExpiresAt != nil && time.Now().After(*user.SessionExpiresAt) {
-			// Session expired - clear the cookie and reject
-			c.SetCookie("session_token", "", -1, "/", "", false, true)
-			c.Next() // Continue without user context
-			return
-		}
-
-		// Update last active time
+		// Update last seen and last active times
 		user.LastActive = time.Now()
-		h.db.UpdateUserLastActive(user.ID)
+		h.db.TouchUserSession(session)
 
-		// Set user in context
+		// Set user and session in context
 		c.Set("user", user)
+		c.Set("userSession", session)
 		c.Next()
 	}
 }
@@ -625,13 +656,12 @@ func (h *AuthHandler) RequireAuth() gin.HandlerFunc {
 	}
 }
 
-// generateSessionToken creates a cryptographically secure session token
-func generateSessionToken() string {
+// generateSessionToken creates a cryptographically secure session token.
+// Fails rather than fall back to a guessable token if crypto/rand does.
+func generateSessionToken() (string, error) {
 	bytes := make([]byte, 32)
-	if _, err := rand.Read(bytes); err != nil {
-		// Fallback to timestamp-based token if crypto/rand fails
-		// This is not ideal but better than returning an error during auth
-		return fmt.Sprintf("fallback_%d_%d", time.Now().UnixNano(), time.Now().Unix()%10000)
+	if _, err := io.ReadFull(tokenRandReader, bytes); err != nil {
+		return "", fmt.Errorf("failed to generate session token: %w", err)
 	}
-	return hex.EncodeToString(bytes)
+	return hex.EncodeToString(bytes), nil
 }
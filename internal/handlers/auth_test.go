@@ -87,9 +87,14 @@ func TestRegisterFlow(t *testing.T) {
 	if err != nil {
 		t.Fatalf("expected user to be created: %v", err)
 	}
-	if created.SessionToken == "" {
+	var registered AuthResponse
+	_ = json.Unmarshal(rec.Body.Bytes(), &registered)
+	if registered.SessionToken == "" {
 		t.Fatalf("expected session token to be generated")
 	}
+	if sessionUser, _, err := db.GetUserSession(registered.SessionToken); err != nil || sessionUser.ID != created.ID {
+		t.Fatalf("expected the new account to be logged in: %+v err=%v", sessionUser, err)
+	}
 
 	// Invalid username
 	badReq := RegisterRequest{Username: "ab", Password: "password", DisplayName: "User", SecurityQuestion: "What is your pet?", SecurityAnswer: "A"}
@@ -150,9 +155,23 @@ func TestLoginFlow(t *testing.T) {
 		t.Fatalf("expected login success, got %d", rec.Code)
 	}
 
-	fresh, err := db.GetUserByUsername("loginuser")
-	if err != nil || fresh.SessionToken == "session-old" {
-		t.Fatalf("expected session token to be updated: %+v err=%v", fresh, err)
+	var first AuthResponse
+	_ = json.Unmarshal(rec.Body.Bytes(), &first)
+	if first.SessionToken == "" || first.SessionToken == "session-old" {
+		t.Fatalf("expected a new session token, got %q", first.SessionToken)
+	}
+
+	// Logging in on a second device keeps the first logged in
+	rec = performJSONRequest(r, http.MethodPost, "/login", loginReq, map[string]string{"User-Agent": "Mozilla/5.0 (iPhone) Safari/604.1"})
+	var second AuthResponse
+	_ = json.Unmarshal(rec.Body.Bytes(), &second)
+	if second.SessionToken == "" || second.SessionToken == first.SessionToken {
+		t.Fatalf("expected a second session token, got %q", second.SessionToken)
+	}
+	for _, token := range []string{first.SessionToken, second.SessionToken} {
+		if _, _, err := db.GetUserSession(token); err != nil {
+			t.Fatalf("expected both sessions to be valid: %v", err)
+		}
 	}
 
 	// Wrong password
@@ -405,11 +424,11 @@ func TestAuthMiddlewareAndRequire(t *testing.T) {
 	defer cleanup()
 	user := createUser(t, db, "authmw", "Auth MW", "password123", "tok")
 
-	// Update session for retrieval
-	if err := db.UpdateUserSession(user.ID, "tok"+time.Now().Format("150405")); err != nil {
+	// Update session for retrieval (only its digest is stored, so keep the token)
+	token := "tok" + time.Now().Format("150405")
+	if err := db.UpdateUserSession(user.ID, token); err != nil {
 		t.Fatalf("failed to set session: %v", err)
 	}
-	fresh, _ := db.GetUserByUsername("authmw")
 
 	r := gin.New()
 	r.Use(handler.AuthMiddleware())
@@ -424,13 +443,13 @@ func TestAuthMiddlewareAndRequire(t *testing.T) {
 	}
 
 	// Provide via cookie
-	rec = performJSONRequest(r, http.MethodGet, "/protected", nil, map[string]string{"Cookie": "session_token=" + fresh.SessionToken, "User-Agent": "Mozilla"})
+	rec = performJSONRequest(r, http.MethodGet, "/protected", nil, map[string]string{"Cookie": "session_token=" + token, "User-Agent": "Mozilla"})
 	if rec.Code != http.StatusOK {
 		t.Fatalf("expected 200 with valid cookie, got %d", rec.Code)
 	}
 
 	// Provide via header
-	rec = performJSONRequest(r, http.MethodGet, "/protected", nil, map[string]string{"Authorization": "Bearer " + fresh.SessionToken, "User-Agent": "Mozilla"})
+	rec = performJSONRequest(r, http.MethodGet, "/protected", nil, map[string]string{"Authorization": "Bearer " + token, "User-Agent": "Mozilla"})
 	if rec.Code != http.StatusOK {
 		t.Fatalf("expected 200 with Authorization header, got %d", rec.Code)
 	}
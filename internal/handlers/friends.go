@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"crypto/rand"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -26,7 +27,7 @@ var sessionRandReader io.Reader = rand.Reader
 
 // GameHandlerInterface defines the methods we need from game handler
 type GameHandlerInterface interface {
-	CreateTemplateChallenge(difficulty string, userID int) (*models.ChallengeSession, error)
+	CreateTemplateChallenge(difficulty string, userID int, policy models.ScoringPolicy, themeSlug string) (*models.ChallengeSession, error)
 }
 
 // NewFriendsHandler wires the database and game bridge used for friend challenges.
@@ -73,6 +74,27 @@ func (h *FriendsHandler) CreateFriendChallenge(c *gin.Context) {
 		return
 	}
 
+	challenge, sessionID, ok := h.createChallenge(c, u, req, nil)
+	if !ok {
+		return
+	}
+
+	// Return challenge details including creator's session ID
+	c.JSON(http.StatusCreated, gin.H{
+		"success":          true,
+		"message":          "Friend challenge created successfully!",
+		"challenge":        challenge,
+		"challengeCode":    challenge.ChallengeCode,
+		"sessionId":        sessionID, // Add creator's session ID
+		"participantCount": 1,
+		"shareMessage":     fmt.Sprintf("Join my CarGuessr challenge '%s'! Use code: %s", challenge.Title, challenge.ChallengeCode),
+	})
+}
+
+// createChallenge creates a friend challenge with the user as its first participant, optionally sent
+// directly to one invited user. Returns the challenge and the creator's session ID, or writes an error
+// response and returns false.
+func (h *FriendsHandler) createChallenge(c *gin.Context, u *models.User, req models.CreateFriendChallengeRequest, invitedUserID *int) (*models.FriendChallenge, string, bool) {
 	// Validate and sanitize challenge title
 	sanitizedTitle, err := validation.ValidateChallengeTitle(req.Title)
 	if err != nil {
@@ -80,7 +102,7 @@ func (h *FriendsHandler) CreateFriendChallenge(c *gin.Context) {
 			"success": false,
 			"message": err.Error(),
 		})
-		return
+		return nil, "", false
 	}
 	req.Title = sanitizedTitle
 
@@ -94,18 +116,36 @@ func (h *FriendsHandler) CreateFriendChallenge(c *gin.Context) {
 				"success": false,
 				"message": "Failed to validate challenge code",
 			})
-			return
+			return nil, "", false
 		} else if !exists {
 			break
 		}
 		challengeCode = generateChallengeCode()
 	}
 
-	// Create template challenge session (10 cars for consistent gameplay)
-	templateSession, err := h.gameHandler.CreateTemplateChallenge(req.Difficulty, u.ID)
-	if err != nil {
+	// Create template challenge session (standard 10-car policy unless the creator picked one)
+	var policy models.ScoringPolicy
+	if req.Scoring != nil {
+		policy = *req.Scoring
+	}
+	templateSession, err := h.gameHandler.CreateTemplateChallenge(req.Difficulty, u.ID, policy.WithDefaults(), req.Theme)
+	var tooNarrow *models.ThemeTooNarrowError
+	switch {
+	case errors.Is(err, models.ErrThemeNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "Challenge theme not found",
+		})
+		return nil, "", false
+	case errors.As(err, &tooNarrow):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": fmt.Sprintf("The %s theme only has %d cars right now, but the challenge needs %d", tooNarrow.Theme, tooNarrow.Matched, tooNarrow.Needed),
+		})
+		return nil, "", false
+	case err != nil:
 		util.SafeErrorResponse(c, http.StatusInternalServerError, "Failed to create challenge template", err)
-		return
+		return nil, "", false
 	}
 
 	// Create friend challenge
@@ -119,11 +159,12 @@ func (h *FriendsHandler) CreateFriendChallenge(c *gin.Context) {
 		IsActive:          true,
 		CreatedAt:         time.Now(),
 		ExpiresAt:         time.Now().Add(48 * time.Hour), // 48 hours to complete
+		InvitedUserID:     invitedUserID,
 	}
 
 	if err := h.db.CreateFriendChallenge(challenge); err != nil {
 		util.SafeErrorResponse(c, http.StatusInternalServerError, "Failed to create friend challenge", err)
-		return
+		return nil, "", false
 	}
 
 	// Add creator as first participant
@@ -139,19 +180,10 @@ func (h *FriendsHandler) CreateFriendChallenge(c *gin.Context) {
 			"success": false,
 			"message": "Failed to add creator as participant",
 		})
-		return
+		return nil, "", false
 	}
 
-	// Return challenge details including creator's session ID
-	c.JSON(http.StatusCreated, gin.H{
-		"success":          true,
-		"message":          "Friend challenge created successfully!",
-		"challenge":        challenge,
-		"challengeCode":    challengeCode,
-		"sessionId":        templateSession.SessionID, // Add creator's session ID
-		"participantCount": 1,
-		"shareMessage":     fmt.Sprintf("Join my CarGuessr challenge '%s'! Use code: %s", req.Title, challengeCode),
-	})
+	return challenge, templateSession.SessionID, true
 }
 
 // GetFriendChallenge godoc
@@ -215,6 +247,7 @@ func (h *FriendsHandler) GetFriendChallenge(c *gin.Context) {
 // @Success 200 {object} map[string]interface{} "success, message, sessionId, challenge"
 // @Failure 400 {object} map[string]interface{} "Invalid code, already participating, challenge full, or expired"
 // @Failure 401 {object} map[string]interface{} "Authentication required"
+// @Failure 403 {object} map[string]interface{} "Challenge was sent directly to another user, or either user has blocked the other"
 // @Failure 404 {object} map[string]interface{} "Challenge not found"
 // @Failure 500 {object} map[string]interface{} "Failed to join challenge"
 // @Router /api/friends/challenges/{code}/join [post]
@@ -259,6 +292,31 @@ func (h *FriendsHandler) JoinFriendChallenge(c *gin.Context) {
 		return
 	}
 
+	// A challenge sent directly to a friend is only open to them
+	if challenge.InvitedUserID != nil && *challenge.InvitedUserID != u.ID && challenge.CreatorUserID != u.ID {
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"message": "This challenge was sent to someone else",
+		})
+		return
+	}
+
+	// Blocking either way keeps users out of each other's challenges
+	if challenge.CreatorUserID != u.ID {
+		blocked, err := h.db.IsBlocked(u.ID, challenge.CreatorUserID)
+		if err != nil {
+			util.SafeErrorResponse(c, http.StatusInternalServerError, "Failed to check blocks", err)
+			return
+		}
+		if blocked {
+			c.JSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"message": "You can't join this challenge",
+			})
+			return
+		}
+	}
+
 	// Check if user is already participating
 	participants, err := h.db.GetChallengeParticipants(challenge.ID)
 	if err != nil {
@@ -290,7 +348,7 @@ func (h *FriendsHandler) JoinFriendChallenge(c *gin.Context) {
 
 	// Create a new challenge session for this participant (same cars as template)
 	templateSession, err := h.db.GetChallengeSession(challenge.TemplateSessionID)
-	if err != nil {
+	if err != nil || templateSession == nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"message": "Failed to get challenge template",
@@ -303,7 +361,10 @@ func (h *FriendsHandler) JoinFriendChallenge(c *gin.Context) {
 		SessionID:  generateSessionID(),
 		UserID:     u.ID,
 		Difficulty: challenge.Difficulty,
-		Cars:       templateSession.Cars, // Same cars as template
+		Cars:       templateSession.Cars,       // Same cars as template
+		PricedCars: templateSession.PricedCars, // Same price snapshot as template
+		Scoring:    templateSession.Scoring,    // Same scoring policy as template
+		Theme:      templateSession.Theme,      // Same theme as template
 		CurrentCar: 0,
 		Guesses:    []models.ChallengeGuess{},
 		TotalScore: 0,
@@ -489,11 +550,11 @@ func (h *FriendsHandler) GetUserParticipation(c *gin.Context) {
 
 // GetMyChallenges godoc
 // @Summary Get user's challenges
-// @Description Returns all challenges the authenticated user has created or is participating in. Requires authentication.
+// @Description Returns all challenges the authenticated user has created or is participating in, and challenges friends have sent them directly that they haven't joined yet. Requires authentication.
 // @Tags friends
 // @Security BearerAuth
 // @Produce json
-// @Success 200 {object} map[string]interface{} "success, created (array), participating (array)"
+// @Success 200 {object} map[string]interface{} "success, created (array), participating (array), invited (array)"
 // @Failure 401 {object} map[string]interface{} "Authentication required"
 // @Failure 500 {object} map[string]interface{} "Failed to get challenges"
 // @Router /api/friends/challenges/my-challenges [get]
@@ -530,10 +591,21 @@ func (h *FriendsHandler) GetMyChallenges(c *gin.Context) {
 		return
 	}
 
+	// Get challenges friends have sent the user directly
+	invitedChallenges, err := h.db.GetUserChallengeInvites(u.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to get challenge invites",
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"success":       true,
 		"created":       createdChallenges,
 		"participating": participatingChallenges,
+		"invited":       invitedChallenges,
 	})
 }
 
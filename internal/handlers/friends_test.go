@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -39,11 +40,15 @@ type fakeGameHandler struct {
 	err            error
 	lastDifficulty string
 	lastUserID     int
+	lastPolicy     models.ScoringPolicy
+	lastTheme      string
 }
 
-func (f *fakeGameHandler) CreateTemplateChallenge(difficulty string, userID int) (*models.ChallengeSession, error) {
+func (f *fakeGameHandler) CreateTemplateChallenge(difficulty string, userID int, policy models.ScoringPolicy, themeSlug string) (*models.ChallengeSession, error) {
 	f.lastDifficulty = difficulty
 	f.lastUserID = userID
+	f.lastPolicy = policy
+	f.lastTheme = themeSlug
 	if f.err != nil {
 		return nil, f.err
 	}
@@ -150,6 +155,9 @@ func TestCreateFriendChallenge(t *testing.T) {
 	if game.lastDifficulty != "easy" || game.lastUserID != creator.ID {
 		t.Fatalf("game handler not invoked correctly: %+v", game)
 	}
+	if game.lastPolicy != models.DefaultScoringPolicy() {
+		t.Fatalf("expected default scoring policy when none is given, got %+v", game.lastPolicy)
+	}
 
 	var resp map[string]interface{}
 	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
@@ -171,6 +179,45 @@ func TestCreateFriendChallenge(t *testing.T) {
 		t.Fatalf("expected template session to match")
 	}
 
+	// Creator-chosen scoring policy
+	customReq := req
+	customReq.Scoring = &models.ScoringPolicy{Curve: models.CurveLinear, CarCount: 5}
+	rec = invokeFriendsHandler(t, handler.CreateFriendChallenge, http.MethodPost, "/friends", nil, customReq, creator)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201 with scoring policy, got %d", rec.Code)
+	}
+	if game.lastPolicy.Curve != models.CurveLinear || game.lastPolicy.CarCount != 5 || game.lastPolicy.Scale != 100 {
+		t.Fatalf("expected linear 5-car policy with defaults, got %+v", game.lastPolicy)
+	}
+
+	customReq.Scoring = &models.ScoringPolicy{CarCount: 7}
+	rec = invokeFriendsHandler(t, handler.CreateFriendChallenge, http.MethodPost, "/friends", nil, customReq, creator)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unsupported car count, got %d", rec.Code)
+	}
+
+	// Themed challenges
+	challengeRandReader = &sequenceReader{sequences: [][]byte{{'C', 'C', 'C', 'C', 'C', 'C'}}}
+	themedReq := req
+	themedReq.Theme = "classic-porsches"
+	rec = invokeFriendsHandler(t, handler.CreateFriendChallenge, http.MethodPost, "/friends", nil, themedReq, creator)
+	if rec.Code != http.StatusCreated || game.lastTheme != "classic-porsches" {
+		t.Fatalf("expected 201 with theme passed through, got %d and %q", rec.Code, game.lastTheme)
+	}
+
+	game.err = models.ErrThemeNotFound
+	rec = invokeFriendsHandler(t, handler.CreateFriendChallenge, http.MethodPost, "/friends", nil, themedReq, creator)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown theme, got %d", rec.Code)
+	}
+
+	game.err = &models.ThemeTooNarrowError{Theme: "Classic Porsches", Matched: 3, Needed: 10}
+	rec = invokeFriendsHandler(t, handler.CreateFriendChallenge, http.MethodPost, "/friends", nil, themedReq, creator)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "only has 3 cars") {
+		t.Fatalf("expected 400 explaining the theme is too narrow, got %d: %s", rec.Code, rec.Body.String())
+	}
+	game.err = nil
+
 	// Invalid payload
 	rec = invokeFriendsHandler(t, handler.CreateFriendChallenge, http.MethodPost, "/friends", nil, gin.H{"title": 123}, creator)
 	if rec.Code != http.StatusBadRequest {
@@ -199,6 +246,9 @@ func seedFriendChallenge(t *testing.T, db *database.Database, creator *models.Us
 		UserID:     creator.ID,
 		Difficulty: "easy",
 		Cars:       cars,
+		PricedCars: []*models.EnhancedCar{{ID: "car1", Price: 12000}},
+		Scoring:    models.ScoringPolicy{Curve: models.CurveLogRatio, CarCount: 5},
+		Theme:      "classic-porsches",
 	}
 	if err := db.CreateChallengeSession(template); err != nil {
 		t.Fatalf("failed to create template session: %v", err)
@@ -246,6 +296,24 @@ func TestJoinFriendChallenge(t *testing.T) {
 		if rec.Code != http.StatusOK {
 			t.Fatalf("expected join success, got %d", rec.Code)
 		}
+
+		var resp map[string]interface{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("failed to parse response: %v", err)
+		}
+		session, err := db.GetChallengeSession(resp["sessionId"].(string))
+		if err != nil || session == nil {
+			t.Fatalf("participant session not stored: %v", err)
+		}
+		if len(session.PricedCars) != 1 || session.PricedCars[0].Price != 12000 {
+			t.Fatalf("expected participant session to copy the template price snapshot, got %+v", session.PricedCars)
+		}
+		if session.Scoring.Curve != models.CurveLogRatio || session.Scoring.CarCount != 5 {
+			t.Fatalf("expected participant session to copy the template scoring policy, got %+v", session.Scoring)
+		}
+		if session.Theme != "classic-porsches" {
+			t.Fatalf("expected participant session to copy the template theme, got %q", session.Theme)
+		}
 	})
 
 	t.Run("already participating", func(t *testing.T) {
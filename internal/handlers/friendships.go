@@ -0,0 +1,468 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"autotraderguesser/internal/models"
+	"autotraderguesser/internal/util"
+)
+
+// requireFriendsUser returns the authenticated user, or writes a 401 and returns false
+func requireFriendsUser(c *gin.Context) (*models.User, bool) {
+	if user, exists := c.Get("user"); exists {
+		if u, ok := user.(*models.User); ok {
+			return u, true
+		}
+	}
+
+	c.JSON(http.StatusUnauthorized, gin.H{
+		"success": false,
+		"message": "Authentication required",
+	})
+	return nil, false
+}
+
+// bindOtherUser reads the username in a friends request and looks the user up.
+// Writes an error and returns false if the request is invalid, the user doesn't exist, or it's the caller.
+func (h *FriendsHandler) bindOtherUser(c *gin.Context, u *models.User) (*models.User, bool) {
+	var req models.FriendUsernameRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid request data",
+			"error":   err.Error(),
+		})
+		return nil, false
+	}
+
+	return h.lookupOtherUser(c, u, req.Username)
+}
+
+// lookupOtherUser finds another user by username, writing an error and returning false if there's
+// no such user or it's the caller
+func (h *FriendsHandler) lookupOtherUser(c *gin.Context, u *models.User, username string) (*models.User, bool) {
+	other, err := h.db.GetUserByUsername(username)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "User not found",
+		})
+		return nil, false
+	}
+	if other.ID == u.ID {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "That's your own account",
+		})
+		return nil, false
+	}
+	return other, true
+}
+
+// GetFriends godoc
+// @Summary Get friends and friend requests
+// @Description Returns the authenticated user's friends, the friend requests waiting for them to respond, and the requests they have sent. Requires authentication.
+// @Tags friends
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} map[string]interface{} "success, friends, incoming, outgoing"
+// @Failure 401 {object} map[string]interface{} "Authentication required"
+// @Failure 500 {object} map[string]interface{} "Failed to get friends"
+// @Router /api/friends [get]
+func (h *FriendsHandler) GetFriends(c *gin.Context) {
+	u, ok := requireFriendsUser(c)
+	if !ok {
+		return
+	}
+
+	list, err := h.db.GetFriendList(u.ID)
+	if err != nil {
+		util.SafeErrorResponse(c, http.StatusInternalServerError, "Failed to get friends", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"friends":  list.Friends,
+		"incoming": list.Incoming,
+		"outgoing": list.Outgoing,
+	})
+}
+
+// SendFriendRequest godoc
+// @Summary Send a friend request
+// @Description Asks another registered user to be friends. If they have already sent you a request, it is accepted instead. Requires authentication.
+// @Tags friends
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body models.FriendUsernameRequest true "Username of the user to add"
+// @Success 201 {object} map[string]interface{} "success, message, friendship"
+// @Failure 400 {object} map[string]interface{} "Invalid request, or you're adding yourself"
+// @Failure 401 {object} map[string]interface{} "Authentication required"
+// @Failure 403 {object} map[string]interface{} "One of you has blocked the other"
+// @Failure 404 {object} map[string]interface{} "User not found"
+// @Failure 409 {object} map[string]interface{} "Already friends or request already sent"
+// @Router /api/friends/requests [post]
+func (h *FriendsHandler) SendFriendRequest(c *gin.Context) {
+	u, ok := requireFriendsUser(c)
+	if !ok {
+		return
+	}
+	other, ok := h.bindOtherUser(c, u)
+	if !ok {
+		return
+	}
+
+	friendship, err := h.db.SendFriendRequest(u.ID, other.ID)
+	switch {
+	case errors.Is(err, models.ErrUserBlocked):
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"message": "You can't send a friend request to this user",
+		})
+		return
+	case errors.Is(err, models.ErrAlreadyFriends):
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"message": fmt.Sprintf("You are already friends with %s", other.DisplayName),
+		})
+		return
+	case errors.Is(err, models.ErrFriendRequestExists):
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"message": fmt.Sprintf("You have already sent %s a friend request", other.DisplayName),
+		})
+		return
+	case err != nil:
+		util.SafeErrorResponse(c, http.StatusInternalServerError, "Failed to send friend request", err)
+		return
+	}
+
+	message := fmt.Sprintf("Friend request sent to %s", other.DisplayName)
+	if friendship.Status == models.FriendshipAccepted {
+		message = fmt.Sprintf("You and %s are now friends!", other.DisplayName)
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success":    true,
+		"message":    message,
+		"friendship": friendship,
+	})
+}
+
+// friendRequestID parses the request ID path parameter, writing a 400 and returning false if it's invalid
+func friendRequestID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid friend request ID",
+		})
+		return 0, false
+	}
+	return id, true
+}
+
+// AcceptFriendRequest godoc
+// @Summary Accept a friend request
+// @Description Accepts a pending friend request sent to the authenticated user. Requires authentication.
+// @Tags friends
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Friend request ID (friendshipId from the incoming list)"
+// @Success 200 {object} map[string]interface{} "success, message, friendship"
+// @Failure 400 {object} map[string]interface{} "Invalid friend request ID"
+// @Failure 401 {object} map[string]interface{} "Authentication required"
+// @Failure 404 {object} map[string]interface{} "Friend request not found"
+// @Router /api/friends/requests/{id}/accept [post]
+func (h *FriendsHandler) AcceptFriendRequest(c *gin.Context) {
+	u, ok := requireFriendsUser(c)
+	if !ok {
+		return
+	}
+	requestID, ok := friendRequestID(c)
+	if !ok {
+		return
+	}
+
+	friendship, err := h.db.AcceptFriendRequest(requestID, u.ID)
+	if errors.Is(err, models.ErrFriendRequestNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "Friend request not found",
+		})
+		return
+	}
+	if err != nil {
+		util.SafeErrorResponse(c, http.StatusInternalServerError, "Failed to accept friend request", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"message":    "Friend request accepted",
+		"friendship": friendship,
+	})
+}
+
+// DeclineFriendRequest godoc
+// @Summary Decline a friend request
+// @Description Declines a pending friend request sent to the authenticated user. The sender can ask again later; block them to stop that. Requires authentication.
+// @Tags friends
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Friend request ID (friendshipId from the incoming list)"
+// @Success 200 {object} map[string]interface{} "success, message"
+// @Failure 400 {object} map[string]interface{} "Invalid friend request ID"
+// @Failure 401 {object} map[string]interface{} "Authentication required"
+// @Failure 404 {object} map[string]interface{} "Friend request not found"
+// @Router /api/friends/requests/{id}/decline [post]
+func (h *FriendsHandler) DeclineFriendRequest(c *gin.Context) {
+	u, ok := requireFriendsUser(c)
+	if !ok {
+		return
+	}
+	requestID, ok := friendRequestID(c)
+	if !ok {
+		return
+	}
+
+	err := h.db.DeclineFriendRequest(requestID, u.ID)
+	if errors.Is(err, models.ErrFriendRequestNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "Friend request not found",
+		})
+		return
+	}
+	if err != nil {
+		util.SafeErrorResponse(c, http.StatusInternalServerError, "Failed to decline friend request", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Friend request declined",
+	})
+}
+
+// RemoveFriend godoc
+// @Summary Remove a friend
+// @Description Ends a friendship with another user, or withdraws a friend request you sent them. Requires authentication.
+// @Tags friends
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body models.FriendUsernameRequest true "Username of the friend to remove"
+// @Success 200 {object} map[string]interface{} "success, message"
+// @Failure 400 {object} map[string]interface{} "Invalid request data"
+// @Failure 401 {object} map[string]interface{} "Authentication required"
+// @Failure 404 {object} map[string]interface{} "User not found, or not a friend"
+// @Router /api/friends/remove [post]
+func (h *FriendsHandler) RemoveFriend(c *gin.Context) {
+	u, ok := requireFriendsUser(c)
+	if !ok {
+		return
+	}
+	other, ok := h.bindOtherUser(c, u)
+	if !ok {
+		return
+	}
+
+	removed, err := h.db.RemoveFriend(u.ID, other.ID)
+	if err != nil {
+		util.SafeErrorResponse(c, http.StatusInternalServerError, "Failed to remove friend", err)
+		return
+	}
+	if !removed {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": fmt.Sprintf("%s is not on your friends list", other.DisplayName),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": fmt.Sprintf("Removed %s from your friends", other.DisplayName),
+	})
+}
+
+// GetBlockedUsers godoc
+// @Summary Get blocked users
+// @Description Returns the users the authenticated user has blocked, most recent first. Requires authentication.
+// @Tags friends
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} map[string]interface{} "success, blocked (array)"
+// @Failure 401 {object} map[string]interface{} "Authentication required"
+// @Failure 500 {object} map[string]interface{} "Failed to get blocked users"
+// @Router /api/friends/blocked [get]
+func (h *FriendsHandler) GetBlockedUsers(c *gin.Context) {
+	u, ok := requireFriendsUser(c)
+	if !ok {
+		return
+	}
+
+	blocked, err := h.db.GetBlockedUsers(u.ID)
+	if err != nil {
+		util.SafeErrorResponse(c, http.StatusInternalServerError, "Failed to get blocked users", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"blocked": blocked,
+	})
+}
+
+// BlockUser godoc
+// @Summary Block a user
+// @Description Stops another user sending you friend requests or challenges, and ends any friendship or pending request between you. Requires authentication.
+// @Tags friends
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body models.FriendUsernameRequest true "Username of the user to block"
+// @Success 200 {object} map[string]interface{} "success, message"
+// @Failure 400 {object} map[string]interface{} "Invalid request data"
+// @Failure 401 {object} map[string]interface{} "Authentication required"
+// @Failure 404 {object} map[string]interface{} "User not found"
+// @Router /api/friends/block [post]
+func (h *FriendsHandler) BlockUser(c *gin.Context) {
+	u, ok := requireFriendsUser(c)
+	if !ok {
+		return
+	}
+	other, ok := h.bindOtherUser(c, u)
+	if !ok {
+		return
+	}
+
+	if err := h.db.BlockUser(u.ID, other.ID); err != nil {
+		util.SafeErrorResponse(c, http.StatusInternalServerError, "Failed to block user", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": fmt.Sprintf("Blocked %s", other.DisplayName),
+	})
+}
+
+// UnblockUser godoc
+// @Summary Unblock a user
+// @Description Lifts a block. Any friendship ended by the block is not restored. Requires authentication.
+// @Tags friends
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body models.FriendUsernameRequest true "Username of the user to unblock"
+// @Success 200 {object} map[string]interface{} "success, message"
+// @Failure 400 {object} map[string]interface{} "Invalid request data"
+// @Failure 401 {object} map[string]interface{} "Authentication required"
+// @Failure 404 {object} map[string]interface{} "User not found, or not blocked"
+// @Router /api/friends/unblock [post]
+func (h *FriendsHandler) UnblockUser(c *gin.Context) {
+	u, ok := requireFriendsUser(c)
+	if !ok {
+		return
+	}
+	other, ok := h.bindOtherUser(c, u)
+	if !ok {
+		return
+	}
+
+	unblocked, err := h.db.UnblockUser(u.ID, other.ID)
+	if err != nil {
+		util.SafeErrorResponse(c, http.StatusInternalServerError, "Failed to unblock user", err)
+		return
+	}
+	if !unblocked {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": fmt.Sprintf("%s is not blocked", other.DisplayName),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": fmt.Sprintf("Unblocked %s", other.DisplayName),
+	})
+}
+
+// ChallengeFriend godoc
+// @Summary Challenge a friend directly
+// @Description Creates a two-player friend challenge sent straight to one of your friends, so no code needs sharing. It appears in their invited challenges and only they can join it. Requires authentication.
+// @Tags friends
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param challenge body models.DirectChallengeRequest true "Friend to challenge and challenge settings"
+// @Success 201 {object} map[string]interface{} "success, message, challenge, sessionId"
+// @Failure 400 {object} map[string]interface{} "Invalid request data or validation failed"
+// @Failure 401 {object} map[string]interface{} "Authentication required"
+// @Failure 403 {object} map[string]interface{} "Not friends with that user"
+// @Failure 404 {object} map[string]interface{} "User or challenge theme not found"
+// @Failure 500 {object} map[string]interface{} "Failed to create challenge"
+// @Router /api/friends/challenges/direct [post]
+func (h *FriendsHandler) ChallengeFriend(c *gin.Context) {
+	u, ok := requireFriendsUser(c)
+	if !ok {
+		return
+	}
+
+	var req models.DirectChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid request data",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	friend, ok := h.lookupOtherUser(c, u, req.Username)
+	if !ok {
+		return
+	}
+
+	// Friendships end when either user blocks the other, so this also keeps out blocked users
+	friends, err := h.db.AreFriends(u.ID, friend.ID)
+	if err != nil {
+		util.SafeErrorResponse(c, http.StatusInternalServerError, "Failed to check friendship", err)
+		return
+	}
+	if !friends {
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"message": "You can only challenge your friends directly",
+		})
+		return
+	}
+
+	challenge, sessionID, ok := h.createChallenge(c, u, models.CreateFriendChallengeRequest{
+		Title:           req.Title,
+		Difficulty:      req.Difficulty,
+		MaxParticipants: 2,
+		Scoring:         req.Scoring,
+		Theme:           req.Theme,
+	}, &friend.ID)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success":   true,
+		"message":   fmt.Sprintf("Challenge sent to %s!", friend.DisplayName),
+		"challenge": challenge,
+		"sessionId": sessionID,
+	})
+}
@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"autotraderguesser/internal/models"
+)
+
+func TestFriendRequestFlow(t *testing.T) {
+	handler, db, cleanup := setupFriendsHandler(t, &fakeGameHandler{})
+	defer cleanup()
+
+	alice := createUserForFriends(t, db, "alice", "Alice")
+	bob := createUserForFriends(t, db, "bob", "Bob")
+
+	rec := invokeFriendsHandler(t, handler.SendFriendRequest, http.MethodPost, "/api/friends/requests", nil, gin.H{"username": "bob"}, nil)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without a user, got %d", rec.Code)
+	}
+	rec = invokeFriendsHandler(t, handler.SendFriendRequest, http.MethodPost, "/api/friends/requests", nil, gin.H{"username": "alice"}, alice)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a request to yourself, got %d", rec.Code)
+	}
+	rec = invokeFriendsHandler(t, handler.SendFriendRequest, http.MethodPost, "/api/friends/requests", nil, gin.H{"username": "nobody"}, alice)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown user, got %d", rec.Code)
+	}
+
+	rec = invokeFriendsHandler(t, handler.SendFriendRequest, http.MethodPost, "/api/friends/requests", nil, gin.H{"username": "BOB"}, alice)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec = invokeFriendsHandler(t, handler.SendFriendRequest, http.MethodPost, "/api/friends/requests", nil, gin.H{"username": "bob"}, alice)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for a repeat request, got %d", rec.Code)
+	}
+
+	rec = invokeFriendsHandler(t, handler.GetFriends, http.MethodGet, "/api/friends", nil, nil, bob)
+	var list models.FriendList
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil {
+		t.Fatalf("failed to decode friends: %v", err)
+	}
+	if len(list.Incoming) != 1 || list.Incoming[0].Username != "alice" {
+		t.Fatalf("expected alice's request for bob, got %s", rec.Body.String())
+	}
+
+	requestID := gin.Params{{Key: "id", Value: strconv.Itoa(list.Incoming[0].FriendshipID)}}
+	rec = invokeFriendsHandler(t, handler.AcceptFriendRequest, http.MethodPost, "/api/friends/requests/x/accept", requestID, nil, alice)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected the sender to be unable to accept, got %d", rec.Code)
+	}
+	rec = invokeFriendsHandler(t, handler.AcceptFriendRequest, http.MethodPost, "/api/friends/requests/x/accept", requestID, nil, bob)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec = invokeFriendsHandler(t, handler.AcceptFriendRequest, http.MethodPost, "/api/friends/requests/x/accept", gin.Params{{Key: "id", Value: "abc"}}, nil, bob)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a bad request ID, got %d", rec.Code)
+	}
+
+	rec = invokeFriendsHandler(t, handler.BlockUser, http.MethodPost, "/api/friends/block", nil, gin.H{"username": "alice"}, bob)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 for block, got %d", rec.Code)
+	}
+	rec = invokeFriendsHandler(t, handler.SendFriendRequest, http.MethodPost, "/api/friends/requests", nil, gin.H{"username": "bob"}, alice)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 once blocked, got %d", rec.Code)
+	}
+	rec = invokeFriendsHandler(t, handler.RemoveFriend, http.MethodPost, "/api/friends/remove", nil, gin.H{"username": "bob"}, alice)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected the block to have ended the friendship, got %d", rec.Code)
+	}
+	rec = invokeFriendsHandler(t, handler.UnblockUser, http.MethodPost, "/api/friends/unblock", nil, gin.H{"username": "alice"}, bob)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 for unblock, got %d", rec.Code)
+	}
+}
+
+func TestChallengeFriend(t *testing.T) {
+	template := &models.ChallengeSession{SessionID: "direct-template", Difficulty: "hard"}
+	game := &fakeGameHandler{session: template}
+	handler, db, cleanup := setupFriendsHandler(t, game)
+	defer cleanup()
+	if err := db.CreateChallengeSession(template); err != nil {
+		t.Fatalf("failed to store template session: %v", err)
+	}
+
+	alice := createUserForFriends(t, db, "alice", "Alice")
+	bob := createUserForFriends(t, db, "bob", "Bob")
+	carol := createUserForFriends(t, db, "carol", "Carol")
+
+	body := gin.H{"username": "bob", "title": "Head to head", "difficulty": "hard"}
+	rec := invokeFriendsHandler(t, handler.ChallengeFriend, http.MethodPost, "/api/friends/challenges/direct", nil, body, alice)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 before being friends, got %d", rec.Code)
+	}
+
+	request, err := db.SendFriendRequest(alice.ID, bob.ID)
+	if err != nil {
+		t.Fatalf("SendFriendRequest failed: %v", err)
+	}
+	if _, err := db.AcceptFriendRequest(request.ID, bob.ID); err != nil {
+		t.Fatalf("AcceptFriendRequest failed: %v", err)
+	}
+
+	rec = invokeFriendsHandler(t, handler.ChallengeFriend, http.MethodPost, "/api/friends/challenges/direct", nil, body, alice)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var created struct {
+		Challenge models.FriendChallenge `json:"challenge"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatalf("failed to decode challenge: %v", err)
+	}
+	if created.Challenge.MaxParticipants != 2 || created.Challenge.InvitedUserID == nil || *created.Challenge.InvitedUserID != bob.ID {
+		t.Fatalf("expected a two-player challenge for bob, got %+v", created.Challenge)
+	}
+
+	// The challenge reaches bob without a code being shared
+	rec = invokeFriendsHandler(t, handler.GetMyChallenges, http.MethodGet, "/api/friends/challenges/my-challenges", nil, nil, bob)
+	var mine struct {
+		Invited []models.FriendChallenge `json:"invited"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &mine); err != nil {
+		t.Fatalf("failed to decode challenges: %v", err)
+	}
+	if len(mine.Invited) != 1 || mine.Invited[0].ID != created.Challenge.ID {
+		t.Fatalf("expected bob to be invited, got %s", rec.Body.String())
+	}
+
+	code := gin.Params{{Key: "code", Value: created.Challenge.ChallengeCode}}
+	rec = invokeFriendsHandler(t, handler.JoinFriendChallenge, http.MethodPost, "/api/friends/challenges/x/join", code, nil, carol)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for someone else joining, got %d", rec.Code)
+	}
+	rec = invokeFriendsHandler(t, handler.JoinFriendChallenge, http.MethodPost, "/api/friends/challenges/x/join", code, nil, bob)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected bob to join, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = invokeFriendsHandler(t, handler.GetMyChallenges, http.MethodGet, "/api/friends/challenges/my-challenges", nil, nil, bob)
+	if err := json.Unmarshal(rec.Body.Bytes(), &mine); err != nil {
+		t.Fatalf("failed to decode challenges: %v", err)
+	}
+	if len(mine.Invited) != 0 {
+		t.Fatalf("expected the invite to clear once joined, got %s", rec.Body.String())
+	}
+}
+
+func TestChallengeFriendBlocked(t *testing.T) {
+	template := &models.ChallengeSession{SessionID: "blocked-template", Difficulty: "hard"}
+	game := &fakeGameHandler{session: template}
+	handler, db, cleanup := setupFriendsHandler(t, game)
+	defer cleanup()
+	if err := db.CreateChallengeSession(template); err != nil {
+		t.Fatalf("failed to store template session: %v", err)
+	}
+
+	alice := createUserForFriends(t, db, "alice", "Alice")
+	bob := createUserForFriends(t, db, "bob", "Bob")
+	request, err := db.SendFriendRequest(alice.ID, bob.ID)
+	if err != nil {
+		t.Fatalf("SendFriendRequest failed: %v", err)
+	}
+	if _, err := db.AcceptFriendRequest(request.ID, bob.ID); err != nil {
+		t.Fatalf("AcceptFriendRequest failed: %v", err)
+	}
+
+	body := gin.H{"username": "bob", "title": "Head to head", "difficulty": "hard"}
+	rec := invokeFriendsHandler(t, handler.ChallengeFriend, http.MethodPost, "/api/friends/challenges/direct", nil, body, alice)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var created struct {
+		Challenge models.FriendChallenge `json:"challenge"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatalf("failed to decode challenge: %v", err)
+	}
+	code := gin.Params{{Key: "code", Value: created.Challenge.ChallengeCode}}
+
+	// A block either way hides the invite and stops bob joining
+	for _, pair := range [][2]*models.User{{alice, bob}, {bob, alice}} {
+		if err := db.BlockUser(pair[0].ID, pair[1].ID); err != nil {
+			t.Fatalf("BlockUser failed: %v", err)
+		}
+
+		invites, err := db.GetUserChallengeInvites(bob.ID)
+		if err != nil || len(invites) != 0 {
+			t.Fatalf("expected no invites while %s blocks %s, got %+v err=%v", pair[0].Username, pair[1].Username, invites, err)
+		}
+		rec = invokeFriendsHandler(t, handler.JoinFriendChallenge, http.MethodPost, "/api/friends/challenges/x/join", code, nil, bob)
+		if rec.Code != http.StatusForbidden {
+			t.Fatalf("expected 403 while %s blocks %s, got %d", pair[0].Username, pair[1].Username, rec.Code)
+		}
+
+		if _, err := db.UnblockUser(pair[0].ID, pair[1].ID); err != nil {
+			t.Fatalf("UnblockUser failed: %v", err)
+		}
+	}
+
+	invites, err := db.GetUserChallengeInvites(bob.ID)
+	if err != nil || len(invites) != 1 {
+		t.Fatalf("expected the invite back after unblocking, got %+v err=%v", invites, err)
+	}
+}
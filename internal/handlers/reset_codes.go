@@ -0,0 +1,253 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+
+	"autotraderguesser/internal/models"
+)
+
+// resetCodeAlphabet has 32 characters, leaving out 0, 1, I and O which are easily misread
+const resetCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// generateResetCode returns a random code of dash-separated groups, such as ABCD-EFGH-JKLM.
+// Fails closed if no randomness is available.
+func generateResetCode(groups, groupLength int) (string, error) {
+	b := make([]byte, groups*groupLength)
+	if _, err := io.ReadFull(tokenRandReader, b); err != nil {
+		return "", fmt.Errorf("failed to generate reset code: %w", err)
+	}
+
+	var code strings.Builder
+	for i, v := range b {
+		if i > 0 && i%groupLength == 0 {
+			code.WriteByte('-')
+		}
+		code.WriteByte(resetCodeAlphabet[int(v)%len(resetCodeAlphabet)]) // 256 is a multiple of 32, so no bias
+	}
+	return code.String(), nil
+}
+
+// CreateResetCode godoc
+// @Summary Issue a password reset code
+// @Description Creates a single-use code the user can redeem to set a new password, valid for one hour. Any earlier unredeemed admin code for the user stops working. Requires the admin key.
+// @Tags admin
+// @Security AdminKey
+// @Produce json
+// @Param username path string true "Username"
+// @Success 201 {object} map[string]interface{} "success, message, code, expiresAt"
+// @Failure 401 {object} map[string]string "error: Unauthorized - Admin key required"
+// @Failure 404 {object} AuthResponse "User not found"
+// @Failure 500 {object} AuthResponse "Failed to create reset code"
+// @Router /api/admin/users/{username}/reset-code [post]
+func (h *AuthHandler) CreateResetCode(c *gin.Context) {
+	user, err := h.db.GetUserByUsername(c.Param("username"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, AuthResponse{
+			Success: false,
+			Message: "User not found",
+		})
+		return
+	}
+
+	code, err := generateResetCode(3, 4)
+	expiresAt := h.now().UTC().Add(models.ResetCodeTTL).Truncate(time.Second)
+	if err == nil {
+		err = h.db.CreateAdminResetCode(user.ID, code, expiresAt)
+	}
+	if err != nil {
+		fmt.Printf("Failed to create reset code for user %d: %v\n", user.ID, err)
+		c.JSON(http.StatusInternalServerError, AuthResponse{
+			Success: false,
+			Message: "Failed to create reset code",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success":   true,
+		"message":   "Reset code created",
+		"code":      code,
+		"expiresAt": expiresAt,
+	})
+}
+
+// RedeemResetCode godoc
+// @Summary Reset password with a one-time code
+// @Description Sets a new password using an admin-issued reset code or one of the user's recovery codes. Each code works once. The user is logged out on every device. Wrong codes count towards the account's password reset backoff and lockout.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param reset body models.RedeemResetCodeRequest true "Username, code and new password"
+// @Success 200 {object} AuthResponse "Password reset successfully"
+// @Failure 400 {object} AuthResponse "Invalid request data"
+// @Failure 401 {object} AuthResponse "Invalid or expired reset code"
+// @Failure 429 {object} AuthResponse "Too many failed attempts for this account (see Retry-After)"
+// @Failure 500 {object} AuthResponse "Failed to update password"
+// @Router /api/auth/redeem-reset-code [post]
+func (h *AuthHandler) RedeemResetCode(c *gin.Context) {
+	start := time.Now()
+	defer func() {
+		// Ensure minimum response time to prevent timing attacks
+		elapsed := time.Since(start)
+		if elapsed < 200*time.Millisecond {
+			time.Sleep(200*time.Millisecond - elapsed)
+		}
+	}()
+
+	var req models.RedeemResetCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, AuthResponse{
+			Success: false,
+			Message: "Invalid request data",
+		})
+		return
+	}
+
+	user, err := h.db.GetUserByUsername(req.Username)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, AuthResponse{
+			Success: false,
+			Message: "Invalid or expired reset code",
+		})
+		return
+	}
+
+	if !h.reserveAuthAttempt(c, user.ID, models.AuthAttemptReset) {
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcryptCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, AuthResponse{
+			Success: false,
+			Message: "Failed to process new password",
+		})
+		return
+	}
+
+	if _, err := h.db.RedeemResetCode(user.ID, req.Code, string(hashedPassword), h.now()); err != nil {
+		if errors.Is(err, models.ErrResetCodeInvalid) {
+			c.JSON(http.StatusUnauthorized, AuthResponse{
+				Success: false,
+				Message: "Invalid or expired reset code",
+			})
+			return
+		}
+		fmt.Printf("Failed to redeem reset code for user %d: %v\n", user.ID, err)
+		c.JSON(http.StatusInternalServerError, AuthResponse{
+			Success: false,
+			Message: "Failed to update password",
+		})
+		return
+	}
+
+	h.clearAuthFailures(user.ID, models.AuthAttemptReset, models.AuthAttemptLogin)
+	c.SetCookie("session_token", "", -1, "/", "", isSecureCookieEnabled(), true)
+
+	c.JSON(http.StatusOK, AuthResponse{
+		Success: true,
+		Message: "Password reset successfully",
+	})
+}
+
+// GetRecoveryCodes godoc
+// @Summary Count recovery codes
+// @Description Returns how many of the authenticated user's recovery codes are still unused. The codes themselves are only shown when generated. Requires authentication.
+// @Tags auth
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} map[string]interface{} "success: true, remaining: number of unused codes"
+// @Failure 401 {object} AuthResponse "Not authenticated"
+// @Failure 500 {object} AuthResponse "Failed to get recovery codes"
+// @Router /api/auth/profile/recovery-codes [get]
+func (h *AuthHandler) GetRecoveryCodes(c *gin.Context) {
+	u, ok := sessionUser(c)
+	if !ok {
+		return
+	}
+
+	remaining, err := h.db.CountRecoveryCodes(u.ID)
+	if err != nil {
+		fmt.Printf("Failed to count recovery codes for user %d: %v\n", u.ID, err)
+		c.JSON(http.StatusInternalServerError, AuthResponse{
+			Success: false,
+			Message: "Failed to get recovery codes",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"remaining": remaining,
+	})
+}
+
+// GenerateRecoveryCodes godoc
+// @Summary Generate recovery codes
+// @Description Creates a new set of single-use recovery codes for resetting the password, replacing any earlier set. The codes are only shown once. Requires authentication and the current password; wrong passwords count towards the login backoff and lockout.
+// @Tags auth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body object{password=string} true "Current password"
+// @Success 200 {object} map[string]interface{} "success, message, codes: array of recovery codes"
+// @Failure 400 {object} AuthResponse "Invalid request data"
+// @Failure 401 {object} AuthResponse "Not authenticated or incorrect password"
+// @Failure 429 {object} AuthResponse "Too many failed attempts for this account (see Retry-After)"
+// @Failure 500 {object} AuthResponse "Failed to generate recovery codes"
+// @Router /api/auth/profile/recovery-codes [post]
+func (h *AuthHandler) GenerateRecoveryCodes(c *gin.Context) {
+	u, ok := sessionUser(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Password string `json:"password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, AuthResponse{
+			Success: false,
+			Message: "Invalid request data",
+		})
+		return
+	}
+
+	// A stolen session alone shouldn't be enough to get a way back into the account
+	if !h.checkPassword(c, u, req.Password) {
+		return
+	}
+
+	codes := make([]string, models.RecoveryCodeCount)
+	var err error
+	for i := range codes {
+		if codes[i], err = generateResetCode(2, 5); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = h.db.ReplaceRecoveryCodes(u.ID, codes)
+	}
+	if err != nil {
+		fmt.Printf("Failed to generate recovery codes for user %d: %v\n", u.ID, err)
+		c.JSON(http.StatusInternalServerError, AuthResponse{
+			Success: false,
+			Message: "Failed to generate recovery codes",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Recovery codes generated. Store them somewhere safe, they won't be shown again",
+		"codes":   codes,
+	})
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+
+	"autotraderguesser/internal/models"
+)
+
+func TestAdminResetCode(t *testing.T) {
+	handler, db, cleanup := setupAuthHandler(t)
+	defer cleanup()
+	user := createUser(t, db, "locked", "Locked Out", "password123", "")
+	if _, err := db.CreateUserSession(user.ID, "stolen-token", "", ""); err != nil {
+		t.Fatalf("CreateUserSession failed: %v", err)
+	}
+
+	now := time.Date(2025, 6, 4, 12, 0, 0, 0, time.UTC)
+	handler.now = func() time.Time { return now }
+
+	r := gin.New()
+	r.POST("/admin/users/:username/reset-code", handler.CreateResetCode)
+	r.POST("/redeem", handler.RedeemResetCode)
+
+	issue := func() string {
+		rec := performJSONRequest(r, http.MethodPost, "/admin/users/locked/reset-code", nil, nil)
+		var resp struct {
+			Code      string    `json:"code"`
+			ExpiresAt time.Time `json:"expiresAt"`
+		}
+		if rec.Code != http.StatusCreated || json.Unmarshal(rec.Body.Bytes(), &resp) != nil {
+			t.Fatalf("expected a reset code, got %d: %s", rec.Code, rec.Body.String())
+		}
+		if !regexp.MustCompile(`^[A-Z2-9]{4}-[A-Z2-9]{4}-[A-Z2-9]{4}$`).MatchString(resp.Code) || !resp.ExpiresAt.Equal(now.Add(models.ResetCodeTTL)) {
+			t.Fatalf("unexpected reset code %+v", resp)
+		}
+		return resp.Code
+	}
+	redeem := func(code, password string) int {
+		req := models.RedeemResetCodeRequest{Username: "locked", Code: code, NewPassword: password}
+		return performJSONRequest(r, http.MethodPost, "/redeem", req, nil).Code
+	}
+
+	if rec := performJSONRequest(r, http.MethodPost, "/admin/users/nobody/reset-code", nil, nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown user, got %d", rec.Code)
+	}
+
+	// Codes expire
+	code := issue()
+	now = now.Add(models.ResetCodeTTL)
+	if status := redeem(code, "newpass1"); status != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for an expired code, got %d", status)
+	}
+
+	code = issue()
+	if status := redeem(code, "newpass1"); status != http.StatusOK {
+		t.Fatalf("expected the code to reset the password, got %d", status)
+	}
+	fresh, err := db.GetUserByUsername("locked")
+	if err != nil || bcrypt.CompareHashAndPassword([]byte(fresh.PasswordHash), []byte("newpass1")) != nil {
+		t.Fatalf("expected the password to be newpass1, err=%v", err)
+	}
+	if sessions, err := db.ListUserSessions(user.ID); err != nil || len(sessions) != 0 {
+		t.Fatalf("expected every session to be logged out, got %+v err=%v", sessions, err)
+	}
+	if throttle, err := db.GetAuthThrottle(user.ID, models.AuthAttemptReset); err != nil || throttle.Failures != 0 {
+		t.Fatalf("expected a successful reset to clear failures, got %+v err=%v", throttle, err)
+	}
+
+	// Used codes are rejected and count towards the reset backoff
+	if status := redeem(code, "newpass2"); status != http.StatusUnauthorized {
+		t.Fatalf("expected a used code to be rejected, got %d", status)
+	}
+	if throttle, err := db.GetAuthThrottle(user.ID, models.AuthAttemptReset); err != nil || throttle.Failures != 1 {
+		t.Fatalf("expected the failed attempt to be counted, got %+v err=%v", throttle, err)
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	handler, db, cleanup := setupAuthHandler(t)
+	defer cleanup()
+	user := createUser(t, db, "careful", "Careful", "password123", "")
+	if _, err := db.CreateUserSession(user.ID, "careful-token", "", ""); err != nil {
+		t.Fatalf("CreateUserSession failed: %v", err)
+	}
+	auth := map[string]string{"Authorization": "Bearer careful-token"}
+
+	r := gin.New()
+	r.Use(handler.AuthMiddleware())
+	r.GET("/recovery-codes", handler.RequireAuth(), handler.GetRecoveryCodes)
+	r.POST("/recovery-codes", handler.RequireAuth(), handler.GenerateRecoveryCodes)
+	r.POST("/redeem", handler.RedeemResetCode)
+
+	remaining := func() int {
+		rec := performJSONRequest(r, http.MethodGet, "/recovery-codes", nil, auth)
+		var resp struct {
+			Remaining int `json:"remaining"`
+		}
+		if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &resp) != nil {
+			t.Fatalf("expected a recovery code count, got %d", rec.Code)
+		}
+		return resp.Remaining
+	}
+
+	if rec := performJSONRequest(r, http.MethodPost, "/recovery-codes", gin.H{"password": "password123"}, nil); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without a session, got %d", rec.Code)
+	}
+	if rec := performJSONRequest(r, http.MethodPost, "/recovery-codes", gin.H{"password": "wrong"}, auth); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for a wrong password, got %d", rec.Code)
+	}
+	if n := remaining(); n != 0 {
+		t.Fatalf("expected no recovery codes yet, got %d", n)
+	}
+
+	rec := performJSONRequest(r, http.MethodPost, "/recovery-codes", gin.H{"password": "password123"}, auth)
+	var resp struct {
+		Codes []string `json:"codes"`
+	}
+	if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &resp) != nil || len(resp.Codes) != models.RecoveryCodeCount {
+		t.Fatalf("expected %d recovery codes, got %d: %s", models.RecoveryCodeCount, rec.Code, rec.Body.String())
+	}
+	seen := map[string]bool{}
+	for _, code := range resp.Codes {
+		if !regexp.MustCompile(`^[A-Z2-9]{5}-[A-Z2-9]{5}$`).MatchString(code) || seen[code] {
+			t.Fatalf("unexpected recovery codes %v", resp.Codes)
+		}
+		seen[code] = true
+	}
+	if n := remaining(); n != models.RecoveryCodeCount {
+		t.Fatalf("expected %d unused codes, got %d", models.RecoveryCodeCount, n)
+	}
+
+	// A recovery code resets the password once, typed in any case
+	typed := strings.ToLower(strings.Replace(resp.Codes[0], "-", " ", 1))
+	req := models.RedeemResetCodeRequest{Username: "careful", Code: typed, NewPassword: "newpass1"}
+	if rec := performJSONRequest(r, http.MethodPost, "/redeem", req, nil); rec.Code != http.StatusOK {
+		t.Fatalf("expected the recovery code to reset the password, got %d", rec.Code)
+	}
+	if rec := performJSONRequest(r, http.MethodPost, "/redeem", req, nil); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected a used recovery code to be rejected, got %d", rec.Code)
+	}
+	if count, err := db.CountRecoveryCodes(user.ID); err != nil || count != models.RecoveryCodeCount-1 {
+		t.Fatalf("expected %d codes left, got %d err=%v", models.RecoveryCodeCount-1, count, err)
+	}
+}
+
+func TestGenerateResetCodeFailsClosed(t *testing.T) {
+	original := tokenRandReader
+	tokenRandReader = brokenReader{}
+	defer func() { tokenRandReader = original }()
+
+	if code, err := generateResetCode(3, 4); err == nil || code != "" {
+		t.Fatalf("expected no code without randomness, got %q err=%v", code, err)
+	}
+}
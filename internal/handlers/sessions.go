@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"autotraderguesser/internal/models"
+)
+
+const maxDeviceLabelLength = 100
+
+// Browser and platform markers checked in order, so the more specific user agent tokens come first
+var (
+	browserMarkers = []struct{ token, name string }{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"SamsungBrowser/", "Samsung Internet"},
+		{"Firefox/", "Firefox"},
+		{"CriOS/", "Chrome"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+	}
+	platformMarkers = []struct{ token, name string }{
+		{"iPhone", "iPhone"},
+		{"iPad", "iPad"},
+		{"Android", "Android"},
+		{"Windows", "Windows"},
+		{"Macintosh", "macOS"},
+		{"CrOS", "ChromeOS"},
+		{"Linux", "Linux"},
+	}
+)
+
+// describeDevice turns a User-Agent header into a short label such as "Firefox on Windows"
+func describeDevice(userAgent string) string {
+	var browser, platform string
+	for _, m := range browserMarkers {
+		if strings.Contains(userAgent, m.token) {
+			browser = m.name
+			break
+		}
+	}
+	for _, m := range platformMarkers {
+		if strings.Contains(userAgent, m.token) {
+			platform = m.name
+			break
+		}
+	}
+
+	switch {
+	case browser != "" && platform != "":
+		return browser + " on " + platform
+	case browser != "" || platform != "":
+		return browser + platform
+	case userAgent == "":
+		return "Unknown device"
+	case len(userAgent) > maxDeviceLabelLength:
+		return userAgent[:maxDeviceLabelLength]
+	default:
+		return userAgent
+	}
+}
+
+// startSession creates a login session for the requesting device and sets the session cookie.
+// Returns the new session token.
+func (h *AuthHandler) startSession(c *gin.Context, userID int) (string, error) {
+	sessionToken, err := generateSessionToken()
+	if err != nil {
+		return "", err
+	}
+	if _, err := h.db.CreateUserSession(userID, sessionToken, describeDevice(c.Request.UserAgent()), c.ClientIP()); err != nil {
+		return "", err
+	}
+
+	// Set session cookie with SameSite protection (7 days to match server expiration)
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     "session_token",
+		Value:    sessionToken,
+		MaxAge:   int(models.UserSessionTTL.Seconds()),
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   isSecureCookieEnabled(), // Automatically enabled in production/HTTPS
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	return sessionToken, nil
+}
+
+// currentSession returns the login session the request was authenticated with, if any
+func currentSession(c *gin.Context) *models.UserSession {
+	if session, exists := c.Get("userSession"); exists {
+		if s, ok := session.(*models.UserSession); ok {
+			return s
+		}
+	}
+	return nil
+}
+
+// sessionUser returns the authenticated user, or writes a 401 and returns false
+func sessionUser(c *gin.Context) (*models.User, bool) {
+	if user, exists := c.Get("user"); exists {
+		if u, ok := user.(*models.User); ok {
+			return u, true
+		}
+	}
+
+	c.JSON(http.StatusUnauthorized, AuthResponse{
+		Success: false,
+		Message: "Not authenticated",
+	})
+	return nil, false
+}
+
+// ListSessions godoc
+// @Summary List login sessions
+// @Description Returns the devices the authenticated user is logged in on, most recently used first. The session making the request is marked current. Requires authentication.
+// @Tags auth
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} map[string]interface{} "success: true, sessions: array of sessions"
+// @Failure 401 {object} AuthResponse "Not authenticated"
+// @Failure 500 {object} AuthResponse "Failed to get sessions"
+// @Router /api/auth/sessions [get]
+func (h *AuthHandler) ListSessions(c *gin.Context) {
+	u, ok := sessionUser(c)
+	if !ok {
+		return
+	}
+
+	sessions, err := h.db.ListUserSessions(u.ID)
+	if err != nil {
+		fmt.Printf("Failed to list sessions for user %d: %v\n", u.ID, err)
+		c.JSON(http.StatusInternalServerError, AuthResponse{
+			Success: false,
+			Message: "Failed to get sessions",
+		})
+		return
+	}
+
+	if current := currentSession(c); current != nil {
+		for i := range sessions {
+			sessions[i].Current = sessions[i].ID == current.ID
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"sessions": sessions,
+	})
+}
+
+// RevokeSession godoc
+// @Summary Revoke a login session
+// @Description Logs the authenticated user out on one device. Revoking the current session also clears the session cookie. Requires authentication.
+// @Tags auth
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Session ID"
+// @Success 200 {object} AuthResponse "Session revoked"
+// @Failure 400 {object} AuthResponse "Invalid session ID"
+// @Failure 401 {object} AuthResponse "Not authenticated"
+// @Failure 404 {object} AuthResponse "Session not found"
+// @Failure 500 {object} AuthResponse "Failed to revoke session"
+// @Router /api/auth/sessions/{id}/revoke [post]
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	u, ok := sessionUser(c)
+	if !ok {
+		return
+	}
+
+	sessionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || sessionID <= 0 {
+		c.JSON(http.StatusBadRequest, AuthResponse{
+			Success: false,
+			Message: "Invalid session ID",
+		})
+		return
+	}
+
+	revoked, err := h.db.RevokeUserSession(u.ID, sessionID)
+	if err != nil {
+		fmt.Printf("Failed to revoke session %d for user %d: %v\n", sessionID, u.ID, err)
+		c.JSON(http.StatusInternalServerError, AuthResponse{
+			Success: false,
+			Message: "Failed to revoke session",
+		})
+		return
+	}
+	if !revoked {
+		c.JSON(http.StatusNotFound, AuthResponse{
+			Success: false,
+			Message: "Session not found",
+		})
+		return
+	}
+
+	if current := currentSession(c); current != nil && current.ID == sessionID {
+		c.SetCookie("session_token", "", -1, "/", "", isSecureCookieEnabled(), true)
+	}
+
+	c.JSON(http.StatusOK, AuthResponse{
+		Success: true,
+		Message: "Session revoked",
+	})
+}
+
+// RevokeAllSessions godoc
+// @Summary Revoke all login sessions
+// @Description Logs the authenticated user out everywhere. With keepCurrent=true the session making the request stays logged in. Requires authentication.
+// @Tags auth
+// @Security BearerAuth
+// @Produce json
+// @Param keepCurrent query bool false "Keep the current session"
+// @Success 200 {object} map[string]interface{} "success, message, revoked: number of sessions ended"
+// @Failure 401 {object} AuthResponse "Not authenticated"
+// @Failure 500 {object} AuthResponse "Failed to revoke sessions"
+// @Router /api/auth/sessions/revoke-all [post]
+func (h *AuthHandler) RevokeAllSessions(c *gin.Context) {
+	u, ok := sessionUser(c)
+	if !ok {
+		return
+	}
+
+	keepSessionID := 0
+	current := currentSession(c)
+	if current != nil && c.Query("keepCurrent") == "true" {
+		keepSessionID = current.ID
+	}
+
+	revoked, err := h.db.RevokeUserSessions(u.ID, keepSessionID)
+	if err != nil {
+		fmt.Printf("Failed to revoke sessions for user %d: %v\n", u.ID, err)
+		c.JSON(http.StatusInternalServerError, AuthResponse{
+			Success: false,
+			Message: "Failed to revoke sessions",
+		})
+		return
+	}
+
+	message := "Logged out everywhere"
+	if keepSessionID == 0 {
+		c.SetCookie("session_token", "", -1, "/", "", isSecureCookieEnabled(), true)
+	} else {
+		message = "Logged out on all other devices"
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": message,
+		"revoked": revoked,
+	})
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"autotraderguesser/internal/models"
+)
+
+func TestSessionManagement(t *testing.T) {
+	handler, db, cleanup := setupAuthHandler(t)
+	defer cleanup()
+	createUser(t, db, "devices", "Devices", "password123", "")
+
+	r := gin.New()
+	r.Use(handler.AuthMiddleware())
+	r.POST("/login", handler.Login)
+	r.GET("/sessions", handler.RequireAuth(), handler.ListSessions)
+	r.POST("/sessions/:id/revoke", handler.RequireAuth(), handler.RevokeSession)
+	r.POST("/sessions/revoke-all", handler.RequireAuth(), handler.RevokeAllSessions)
+
+	login := func(userAgent string) string {
+		rec := performJSONRequest(r, http.MethodPost, "/login", LoginRequest{Username: "devices", Password: "password123"}, map[string]string{"User-Agent": userAgent})
+		var resp AuthResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp.SessionToken == "" {
+			t.Fatalf("expected login to succeed, got %d: %s", rec.Code, rec.Body.String())
+		}
+		return resp.SessionToken
+	}
+	bearer := func(token string) map[string]string {
+		return map[string]string{"Authorization": "Bearer " + token}
+	}
+	list := func(token string) []models.UserSession {
+		rec := performJSONRequest(r, http.MethodGet, "/sessions", nil, bearer(token))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected sessions, got %d", rec.Code)
+		}
+		var resp struct {
+			Sessions []models.UserSession `json:"sessions"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("failed to decode sessions: %v", err)
+		}
+		return resp.Sessions
+	}
+
+	laptop := login("Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:128.0) Gecko/20100101 Firefox/128.0")
+	phone := login("Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 Version/17.0 Mobile/15E148 Safari/604.1")
+	tablet := login("Mozilla/5.0 (Linux; Android 14) AppleWebKit/537.36 Chrome/126.0 Safari/537.36")
+
+	rec := performJSONRequest(r, http.MethodGet, "/sessions", nil, nil)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without a session, got %d", rec.Code)
+	}
+
+	sessions := list(laptop)
+	if len(sessions) != 3 {
+		t.Fatalf("expected 3 sessions, got %+v", sessions)
+	}
+	labels := map[string]bool{}
+	var laptopID, phoneID int
+	for _, s := range sessions {
+		labels[s.DeviceLabel] = s.Current
+		switch s.DeviceLabel {
+		case "Firefox on Windows":
+			laptopID = s.ID
+		case "Safari on iPhone":
+			phoneID = s.ID
+		}
+	}
+	if current, ok := labels["Firefox on Windows"]; !ok || !current || labels["Safari on iPhone"] || labels["Chrome on Android"] {
+		t.Fatalf("expected the laptop to be the current session, got %+v", sessions)
+	}
+
+	// Revoking the phone logs it out but nothing else
+	rec = performJSONRequest(r, http.MethodPost, fmt.Sprintf("/sessions/%d/revoke", phoneID), nil, bearer(laptop))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 for revoke, got %d", rec.Code)
+	}
+	rec = performJSONRequest(r, http.MethodGet, "/sessions", nil, bearer(phone))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected the phone to be logged out, got %d", rec.Code)
+	}
+	rec = performJSONRequest(r, http.MethodPost, fmt.Sprintf("/sessions/%d/revoke", phoneID), nil, bearer(laptop))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for a revoked session, got %d", rec.Code)
+	}
+	rec = performJSONRequest(r, http.MethodPost, "/sessions/abc/revoke", nil, bearer(laptop))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a bad session ID, got %d", rec.Code)
+	}
+
+	// Logging out everywhere else keeps the current session
+	rec = performJSONRequest(r, http.MethodPost, "/sessions/revoke-all?keepCurrent=true", nil, bearer(laptop))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 for revoke-all, got %d", rec.Code)
+	}
+	if sessions := list(laptop); len(sessions) != 1 || sessions[0].ID != laptopID {
+		t.Fatalf("expected only the laptop to be left, got %+v", sessions)
+	}
+	rec = performJSONRequest(r, http.MethodGet, "/sessions", nil, bearer(tablet))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected the tablet to be logged out, got %d", rec.Code)
+	}
+
+	rec = performJSONRequest(r, http.MethodPost, "/sessions/revoke-all", nil, bearer(laptop))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 for revoke-all, got %d", rec.Code)
+	}
+	rec = performJSONRequest(r, http.MethodGet, "/sessions", nil, bearer(laptop))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected every session to be logged out, got %d", rec.Code)
+	}
+}
+
+func TestLogoutEndsOnlyCurrentSession(t *testing.T) {
+	handler, db, cleanup := setupAuthHandler(t)
+	defer cleanup()
+	user := createUser(t, db, "logoutone", "Logout One", "password123", "")
+
+	first, err := db.CreateUserSession(user.ID, "first-token", "", "")
+	if err != nil {
+		t.Fatalf("CreateUserSession failed: %v", err)
+	}
+	if _, err := db.CreateUserSession(user.ID, "second-token", "", ""); err != nil {
+		t.Fatalf("CreateUserSession failed: %v", err)
+	}
+
+	r := gin.New()
+	r.Use(handler.AuthMiddleware())
+	r.POST("/logout", handler.Logout)
+
+	rec := performJSONRequest(r, http.MethodPost, "/logout", nil, map[string]string{"Cookie": "session_token=first-token"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+
+	sessions, err := db.ListUserSessions(user.ID)
+	if err != nil || len(sessions) != 1 || sessions[0].ID == first.ID {
+		t.Fatalf("expected only the other session to be left, got %+v err=%v", sessions, err)
+	}
+}
+
+func TestDescribeDevice(t *testing.T) {
+	cases := map[string]string{
+		"Mozilla/5.0 (Macintosh; Intel Mac OS X 14_5) AppleWebKit/605.1.15 Version/17.5 Safari/605.1.15":            "Safari on macOS",
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 Chrome/126.0 Safari/537.36 Edg/126.0":         "Edge on Windows",
+		"Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X) AppleWebKit/605.1.15 CriOS/126.0 Mobile Safari/604": "Chrome on iPhone",
+		"curl/8.5.0": "curl/8.5.0",
+		"":           "Unknown device",
+	}
+	for userAgent, want := range cases {
+		if got := describeDevice(userAgent); got != want {
+			t.Errorf("describeDevice(%q) = %q, want %q", userAgent, got, want)
+		}
+	}
+}
+
+type brokenReader struct{}
+
+func (brokenReader) Read([]byte) (int, error) {
+	return 0, errors.New("entropy unavailable")
+}
+
+func TestLoginFailsClosedWithoutRandomness(t *testing.T) {
+	handler, db, cleanup := setupAuthHandler(t)
+	defer cleanup()
+	user := createUser(t, db, "norandom", "No Random", "password123", "")
+
+	original := tokenRandReader
+	tokenRandReader = brokenReader{}
+	defer func() { tokenRandReader = original }()
+
+	r := gin.New()
+	r.POST("/login", handler.Login)
+	rec := performJSONRequest(r, http.MethodPost, "/login", LoginRequest{Username: "norandom", Password: "password123"}, nil)
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500 when no token can be generated, got %d", rec.Code)
+	}
+	if cookies := rec.Result().Cookies(); len(cookies) != 0 {
+		t.Fatalf("expected no session cookie, got %v", cookies)
+	}
+	if sessions, err := db.ListUserSessions(user.ID); err != nil || len(sessions) != 0 {
+		t.Fatalf("expected no session to be created, got %+v err=%v", sessions, err)
+	}
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+
+	"autotraderguesser/internal/models"
+)
+
+// reserveAuthAttempt counts an attempt of this kind as failed before it is checked, writing a 429 and
+// returning false if the account must wait first. Clear or release the attempt if it succeeds.
+// Fails closed if the attempt can't be reserved.
+func (h *AuthHandler) reserveAuthAttempt(c *gin.Context, userID int, kind string) bool {
+	now := h.now()
+	throttle, reserved, err := h.db.ReserveAuthAttempt(userID, kind, models.DefaultAuthThrottle, now)
+	if err != nil {
+		fmt.Printf("Failed to reserve %s attempt for user %d: %v\n", kind, userID, err)
+		c.JSON(http.StatusInternalServerError, AuthResponse{
+			Success: false,
+			Message: "Failed to check account",
+		})
+		return false
+	}
+	if reserved {
+		return true
+	}
+
+	// Another attempt took the last one allowed just before this one
+	wait := throttle.RetryAfter(now)
+	if wait <= 0 {
+		wait = time.Second
+	}
+
+	message := "Too many failed attempts, please wait before trying again"
+	if throttle.LockedOut(models.DefaultAuthThrottle) {
+		message = "Account temporarily locked after too many failed attempts"
+		fmt.Printf("Rejected %s attempt for user %d locked after %d failed attempts\n", kind, userID, throttle.Failures)
+	}
+	c.Header("Retry-After", strconv.Itoa(int((wait+time.Second-1)/time.Second)))
+	c.JSON(http.StatusTooManyRequests, AuthResponse{
+		Success: false,
+		Message: message,
+	})
+	return false
+}
+
+// releaseAuthAttempt hands back a reserved attempt that succeeded, leaving earlier failures counted
+func (h *AuthHandler) releaseAuthAttempt(userID int, kind string) {
+	if err := h.db.ReleaseAuthAttempt(userID, kind); err != nil {
+		fmt.Printf("Failed to release %s attempt for user %d: %v\n", kind, userID, err)
+	}
+}
+
+// clearAuthFailures forgets an account's failed attempts of the given kinds after a successful one
+func (h *AuthHandler) clearAuthFailures(userID int, kinds ...string) {
+	if err := h.db.ClearAuthFailures(userID, kinds...); err != nil {
+		fmt.Printf("Failed to clear failed attempts for user %d: %v\n", userID, err)
+	}
+}
+
+// checkPassword verifies the user's current password before a sensitive change, writing a 401 or 429 and returning false if it can't be.
+// Wrong passwords count as failed logins.
+func (h *AuthHandler) checkPassword(c *gin.Context, u *models.User, password string) bool {
+	if !h.reserveAuthAttempt(c, u.ID, models.AuthAttemptLogin) {
+		return false
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)); err != nil {
+		c.JSON(http.StatusUnauthorized, AuthResponse{
+			Success: false,
+			Message: "Incorrect password",
+		})
+		return false
+	}
+	h.releaseAuthAttempt(u.ID, models.AuthAttemptLogin)
+	return true
+}
+
+// UnlockAccount godoc
+// @Summary Unlock an account
+// @Description Clears an account's failed login and password reset attempts, lifting any backoff or lockout. Requires the admin key.
+// @Tags admin
+// @Security AdminKey
+// @Produce json
+// @Param username path string true "Username"
+// @Success 200 {object} map[string]interface{} "success, message, unlocked: whether there were failed attempts to clear"
+// @Failure 401 {object} map[string]string "error: Unauthorized - Admin key required"
+// @Failure 404 {object} AuthResponse "User not found"
+// @Failure 500 {object} AuthResponse "Failed to unlock account"
+// @Router /api/admin/users/{username}/unlock [post]
+func (h *AuthHandler) UnlockAccount(c *gin.Context) {
+	user, err := h.db.GetUserByUsername(c.Param("username"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, AuthResponse{
+			Success: false,
+			Message: "User not found",
+		})
+		return
+	}
+
+	unlocked, err := h.db.UnlockAccount(user.ID)
+	if err != nil {
+		fmt.Printf("Failed to unlock user %d: %v\n", user.ID, err)
+		c.JSON(http.StatusInternalServerError, AuthResponse{
+			Success: false,
+			Message: "Failed to unlock account",
+		})
+		return
+	}
+
+	message := "Account unlocked"
+	if !unlocked {
+		message = "Account was not locked"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"message":  message,
+		"unlocked": unlocked,
+	})
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"autotraderguesser/internal/models"
+)
+
+func TestLoginBackoffAndLockout(t *testing.T) {
+	handler, db, cleanup := setupAuthHandler(t)
+	defer cleanup()
+	createUser(t, db, "guessme", "Guess Me", "password123", "")
+	createUser(t, db, "bystander", "Bystander", "password123", "")
+
+	now := time.Date(2025, 6, 4, 12, 0, 0, 0, time.UTC)
+	handler.now = func() time.Time { return now }
+	policy := models.DefaultAuthThrottle
+
+	r := gin.New()
+	r.POST("/login", handler.Login)
+	r.POST("/admin/users/:username/unlock", handler.UnlockAccount)
+
+	login := func(username, password string) (int, string) {
+		rec := performJSONRequest(r, http.MethodPost, "/login", LoginRequest{Username: username, Password: password}, nil)
+		return rec.Code, rec.Header().Get("Retry-After")
+	}
+
+	for i := 0; i < policy.FreeAttempts; i++ {
+		if code, _ := login("guessme", "wrong"); code != http.StatusUnauthorized {
+			t.Fatalf("expected 401 for free attempt %d, got %d", i+1, code)
+		}
+	}
+	if code, _ := login("guessme", "wrong"); code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for the first throttled failure, got %d", code)
+	}
+
+	// Even the right password has to wait out the backoff
+	if code, retry := login("guessme", "password123"); code != http.StatusTooManyRequests || retry != "2" {
+		t.Fatalf("expected 429 with Retry-After 2, got %d %q", code, retry)
+	}
+	if code, _ := login("bystander", "password123"); code != http.StatusOK {
+		t.Fatalf("expected other accounts to be unaffected, got %d", code)
+	}
+
+	// Keep guessing through each backoff until the account locks
+	for failures := policy.FreeAttempts + 1; failures < policy.LockoutAfter; failures++ {
+		now = now.Add(policy.Wait(failures))
+		if code, _ := login("guessme", "wrong"); code != http.StatusUnauthorized {
+			t.Fatalf("expected 401 after waiting, got %d", code)
+		}
+	}
+	if code, retry := login("guessme", "password123"); code != http.StatusTooManyRequests || retry != "900" {
+		t.Fatalf("expected a 15 minute lockout, got %d %q", code, retry)
+	}
+
+	rec := performJSONRequest(r, http.MethodPost, "/admin/users/guessme/unlock", nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected the admin to unlock the account, got %d", rec.Code)
+	}
+	if code, _ := login("guessme", "password123"); code != http.StatusOK {
+		t.Fatalf("expected login after unlocking, got %d", code)
+	}
+
+	rec = performJSONRequest(r, http.MethodPost, "/admin/users/nobody/unlock", nil, nil)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown user, got %d", rec.Code)
+	}
+}
+
+func TestLoginSuccessClearsFailures(t *testing.T) {
+	handler, db, cleanup := setupAuthHandler(t)
+	defer cleanup()
+	user := createUser(t, db, "forgetful", "Forgetful", "password123", "")
+
+	r := gin.New()
+	r.POST("/login", handler.Login)
+
+	for i := 0; i < models.DefaultAuthThrottle.FreeAttempts; i++ {
+		performJSONRequest(r, http.MethodPost, "/login", LoginRequest{Username: "forgetful", Password: "wrong"}, nil)
+	}
+	rec := performJSONRequest(r, http.MethodPost, "/login", LoginRequest{Username: "forgetful", Password: "password123"}, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected login to succeed, got %d", rec.Code)
+	}
+	if throttle, err := db.GetAuthThrottle(user.ID, models.AuthAttemptLogin); err != nil || throttle.Failures != 0 {
+		t.Fatalf("expected failures to be cleared, got %+v err=%v", throttle, err)
+	}
+}
+
+func TestResetPasswordBackoff(t *testing.T) {
+	handler, db, cleanup := setupAuthHandler(t)
+	defer cleanup()
+	user := createUser(t, db, "resetme", "Reset Me", "password123", "")
+
+	now := time.Date(2025, 6, 4, 12, 0, 0, 0, time.UTC)
+	handler.now = func() time.Time { return now }
+	policy := models.DefaultAuthThrottle
+
+	// Failed logins are lifted by a successful reset
+	for i := 1; i <= policy.LockoutAfter; i++ {
+		if _, reserved, err := db.ReserveAuthAttempt(user.ID, models.AuthAttemptLogin, policy, now); err != nil || !reserved {
+			t.Fatalf("expected login attempt %d to be reserved, got %v err=%v", i, reserved, err)
+		}
+		if i < policy.LockoutAfter {
+			now = now.Add(policy.Wait(i))
+		}
+	}
+
+	r := gin.New()
+	r.POST("/reset", handler.ResetPassword)
+
+	resetReq := models.PasswordResetRequest{
+		Username:       "resetme",
+		DisplayName:    "Reset Me",
+		SecurityAnswer: "wrong",
+		NewPassword:    "newpass",
+	}
+	for i := 0; i <= policy.FreeAttempts; i++ {
+		if rec := performJSONRequest(r, http.MethodPost, "/reset", resetReq, nil); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("expected 401 for a wrong answer, got %d", rec.Code)
+		}
+	}
+
+	resetReq.SecurityAnswer = "answer"
+	rec := performJSONRequest(r, http.MethodPost, "/reset", resetReq, nil)
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "2" {
+		t.Fatalf("expected 429 with Retry-After 2, got %d %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+
+	now = now.Add(2 * time.Second)
+	if rec := performJSONRequest(r, http.MethodPost, "/reset", resetReq, nil); rec.Code != http.StatusOK {
+		t.Fatalf("expected the reset to succeed after waiting, got %d", rec.Code)
+	}
+	for _, kind := range []string{models.AuthAttemptLogin, models.AuthAttemptReset} {
+		if throttle, err := db.GetAuthThrottle(user.ID, kind); err != nil || throttle.Failures != 0 {
+			t.Fatalf("expected failed %s attempts to be cleared, got %+v err=%v", kind, throttle, err)
+		}
+	}
+}
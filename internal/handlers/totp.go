@@ -0,0 +1,432 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"autotraderguesser/internal/models"
+)
+
+// maxPartialLogins caps the logins held waiting for a two-factor code
+const maxPartialLogins = 10000
+
+// partialLogin is a login whose password has been checked but which still needs a two-factor code
+type partialLogin struct {
+	userID    int
+	username  string
+	expiresAt time.Time
+	attempts  atomic.Int32 // Wrong codes tried so far
+}
+
+// generateTOTPSecret returns a new random base32 TOTP secret. Fails closed if no randomness is available.
+func generateTOTPSecret() (string, error) {
+	secret := make([]byte, models.TOTPSecretSize)
+	if _, err := io.ReadFull(tokenRandReader, secret); err != nil {
+		return "", fmt.Errorf("failed to generate TOTP secret: %w", err)
+	}
+	return models.EncodeTOTPSecret(secret), nil
+}
+
+// startPartialLogin holds a password-checked login until a two-factor code arrives and writes a 202 with its token
+func (h *AuthHandler) startPartialLogin(c *gin.Context, user *models.User) {
+	token, err := generateSessionToken()
+	if err != nil {
+		fmt.Printf("Failed to start two-factor login for user %d: %v\n", user.ID, err)
+		c.JSON(http.StatusInternalServerError, AuthResponse{
+			Success: false,
+			Message: "Failed to create session",
+		})
+		return
+	}
+	h.partialLogins.Put(token, &partialLogin{userID: user.ID, username: user.Username, expiresAt: h.now().Add(models.PartialLoginTTL)})
+
+	c.JSON(http.StatusAccepted, AuthResponse{
+		Success:           false,
+		Message:           "Two-factor code required",
+		TwoFactorRequired: true,
+		PartialToken:      token,
+	})
+}
+
+// checkSecondFactor accepts a current TOTP code or one of the user's unused recovery codes.
+// Accepted codes are used up so they can't be replayed.
+func (h *AuthHandler) checkSecondFactor(userID int, totp *models.UserTOTP, code string) (bool, error) {
+	if step, ok := models.MatchTOTPCode(totp.Secret, code, h.now(), totp.LastStep); ok {
+		return h.db.UseTOTPStep(userID, step)
+	}
+	return h.db.UseTOTPRecoveryCode(userID, code, h.now())
+}
+
+// generateTOTPRecoveryCodes returns a new set of two-factor recovery codes
+func generateTOTPRecoveryCodes() ([]string, error) {
+	codes := make([]string, models.TOTPRecoveryCodeCount)
+	for i := range codes {
+		code, err := generateResetCode(2, 5)
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = code
+	}
+	return codes, nil
+}
+
+// LoginTOTP godoc
+// @Summary Complete a login with a two-factor code
+// @Description Finishes a login that returned twoFactorRequired, using a code from the authenticator app or a recovery code. Partial tokens last five minutes and allow five wrong codes; wrong codes also count towards the account's login backoff and lockout.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.TOTPLoginRequest true "Partial token and code"
+// @Success 200 {object} AuthResponse "Login successful"
+// @Failure 400 {object} AuthResponse "Invalid request data"
+// @Failure 401 {object} AuthResponse "Invalid two-factor code or login expired"
+// @Failure 429 {object} AuthResponse "Too many failed attempts for this account (see Retry-After)"
+// @Failure 500 {object} AuthResponse "Failed to create session"
+// @Router /api/auth/login/2fa [post]
+func (h *AuthHandler) LoginTOTP(c *gin.Context) {
+	var req models.TOTPLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, AuthResponse{
+			Success: false,
+			Message: "Invalid request data",
+		})
+		return
+	}
+
+	pending, ok := h.partialLogins.Get(req.PartialToken)
+	if !ok || !h.now().Before(pending.expiresAt) {
+		h.partialLogins.Delete(req.PartialToken)
+		c.JSON(http.StatusUnauthorized, AuthResponse{
+			Success: false,
+			Message: "Login expired, please log in again",
+		})
+		return
+	}
+
+	user, err := h.db.GetUserByUsername(pending.username)
+	var totp *models.UserTOTP
+	if err == nil {
+		totp, err = h.db.GetUserTOTP(pending.userID)
+	}
+	if err != nil {
+		fmt.Printf("Failed to get user %d for two-factor login: %v\n", pending.userID, err)
+		c.JSON(http.StatusInternalServerError, AuthResponse{
+			Success: false,
+			Message: "Failed to create session",
+		})
+		return
+	}
+	if !totp.Enabled {
+		// Two-factor authentication was turned off since the password was checked
+		h.partialLogins.Delete(req.PartialToken)
+		c.JSON(http.StatusUnauthorized, AuthResponse{
+			Success: false,
+			Message: "Login expired, please log in again",
+		})
+		return
+	}
+
+	if !h.reserveAuthAttempt(c, user.ID, models.AuthAttemptLogin) {
+		return
+	}
+	accepted, err := h.checkSecondFactor(user.ID, totp, req.Code)
+	if err != nil {
+		fmt.Printf("Failed to check two-factor code for user %d: %v\n", user.ID, err)
+		c.JSON(http.StatusInternalServerError, AuthResponse{
+			Success: false,
+			Message: "Failed to create session",
+		})
+		return
+	}
+	if !accepted {
+		if pending.attempts.Add(1) >= models.PartialLoginMaxAttempts {
+			h.partialLogins.Delete(req.PartialToken)
+		}
+		c.JSON(http.StatusUnauthorized, AuthResponse{
+			Success: false,
+			Message: "Invalid two-factor code",
+		})
+		return
+	}
+
+	h.partialLogins.Delete(req.PartialToken)
+	h.clearAuthFailures(user.ID, models.AuthAttemptLogin)
+	h.completeLogin(c, user)
+}
+
+// GetTOTPStatus godoc
+// @Summary Get two-factor authentication status
+// @Description Returns whether two-factor authentication is on for the authenticated user. Requires authentication.
+// @Tags auth
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} map[string]interface{} "success: true, enabled: bool"
+// @Failure 401 {object} AuthResponse "Not authenticated"
+// @Failure 500 {object} AuthResponse "Failed to get two-factor settings"
+// @Router /api/auth/2fa [get]
+func (h *AuthHandler) GetTOTPStatus(c *gin.Context) {
+	u, ok := sessionUser(c)
+	if !ok {
+		return
+	}
+
+	totp, err := h.db.GetUserTOTP(u.ID)
+	if err != nil {
+		fmt.Printf("Failed to get two-factor settings for user %d: %v\n", u.ID, err)
+		c.JSON(http.StatusInternalServerError, AuthResponse{
+			Success: false,
+			Message: "Failed to get two-factor settings",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"enabled": totp.Enabled,
+	})
+}
+
+// EnrollTOTP godoc
+// @Summary Start two-factor enrolment
+// @Description Creates a new TOTP secret for the authenticated user and returns it with an otpauth URI for authenticator apps. Two-factor authentication stays off until a code is confirmed. Requires authentication and the current password.
+// @Tags auth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body object{password=string} true "Current password"
+// @Success 200 {object} map[string]interface{} "success, message, secret, otpauthUri"
+// @Failure 400 {object} AuthResponse "Invalid request data"
+// @Failure 401 {object} AuthResponse "Not authenticated or incorrect password"
+// @Failure 409 {object} AuthResponse "Two-factor authentication is already enabled"
+// @Failure 429 {object} AuthResponse "Too many failed attempts for this account (see Retry-After)"
+// @Failure 500 {object} AuthResponse "Failed to start two-factor enrolment"
+// @Router /api/auth/2fa/enroll [post]
+func (h *AuthHandler) EnrollTOTP(c *gin.Context) {
+	u, ok := sessionUser(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Password string `json:"password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, AuthResponse{
+			Success: false,
+			Message: "Invalid request data",
+		})
+		return
+	}
+
+	if !h.checkPassword(c, u, req.Password) {
+		return
+	}
+
+	secret, err := generateTOTPSecret()
+	if err == nil {
+		err = h.db.StartTOTPEnrolment(u.ID, secret)
+	}
+	if errors.Is(err, models.ErrTOTPAlreadyEnabled) {
+		c.JSON(http.StatusConflict, AuthResponse{
+			Success: false,
+			Message: "Two-factor authentication is already enabled",
+		})
+		return
+	}
+	if err != nil {
+		fmt.Printf("Failed to start two-factor enrolment for user %d: %v\n", u.ID, err)
+		c.JSON(http.StatusInternalServerError, AuthResponse{
+			Success: false,
+			Message: "Failed to start two-factor enrolment",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"message":    "Add the secret to your authenticator app, then confirm a code to turn on two-factor authentication",
+		"secret":     secret,
+		"otpauthUri": models.TOTPURI(u.Username, secret),
+	})
+}
+
+// ConfirmTOTP godoc
+// @Summary Confirm two-factor enrolment
+// @Description Turns on two-factor authentication once a code from the enrolled secret checks out, and returns recovery codes for logging in without the authenticator app. The recovery codes are only shown once. Requires authentication.
+// @Tags auth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body object{code=string} true "Code from the authenticator app"
+// @Success 200 {object} map[string]interface{} "success, message, recoveryCodes"
+// @Failure 400 {object} AuthResponse "Invalid code or enrolment not started"
+// @Failure 401 {object} AuthResponse "Not authenticated"
+// @Failure 409 {object} AuthResponse "Two-factor authentication is already enabled"
+// @Failure 500 {object} AuthResponse "Failed to enable two-factor authentication"
+// @Router /api/auth/2fa/confirm [post]
+func (h *AuthHandler) ConfirmTOTP(c *gin.Context) {
+	u, ok := sessionUser(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, AuthResponse{
+			Success: false,
+			Message: "Invalid request data",
+		})
+		return
+	}
+
+	totp, err := h.db.GetUserTOTP(u.ID)
+	if err != nil {
+		fmt.Printf("Failed to get two-factor settings for user %d: %v\n", u.ID, err)
+		c.JSON(http.StatusInternalServerError, AuthResponse{
+			Success: false,
+			Message: "Failed to enable two-factor authentication",
+		})
+		return
+	}
+	if totp.Enabled {
+		c.JSON(http.StatusConflict, AuthResponse{
+			Success: false,
+			Message: "Two-factor authentication is already enabled",
+		})
+		return
+	}
+	if totp.Secret == "" {
+		c.JSON(http.StatusBadRequest, AuthResponse{
+			Success: false,
+			Message: "Start two-factor enrolment first",
+		})
+		return
+	}
+
+	step, ok := models.MatchTOTPCode(totp.Secret, req.Code, h.now(), totp.LastStep)
+	if !ok {
+		c.JSON(http.StatusBadRequest, AuthResponse{
+			Success: false,
+			Message: "Invalid two-factor code",
+		})
+		return
+	}
+
+	recoveryCodes, err := generateTOTPRecoveryCodes()
+	if err == nil {
+		err = h.db.EnableTOTP(u.ID, step, recoveryCodes)
+	}
+	if errors.Is(err, models.ErrTOTPNotEnrolled) {
+		// Enabled or reset by another request in the meantime
+		c.JSON(http.StatusConflict, AuthResponse{
+			Success: false,
+			Message: "Two-factor enrolment changed, please start again",
+		})
+		return
+	}
+	if err != nil {
+		fmt.Printf("Failed to enable two-factor authentication for user %d: %v\n", u.ID, err)
+		c.JSON(http.StatusInternalServerError, AuthResponse{
+			Success: false,
+			Message: "Failed to enable two-factor authentication",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":       true,
+		"message":       "Two-factor authentication enabled. Store the recovery codes somewhere safe, they won't be shown again",
+		"recoveryCodes": recoveryCodes,
+	})
+}
+
+// DisableTOTP godoc
+// @Summary Turn off two-factor authentication
+// @Description Turns off two-factor authentication for the authenticated user, forgetting the secret and recovery codes. Requires authentication, the current password and a current code or recovery code.
+// @Tags auth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body object{password=string,code=string} true "Current password and two-factor code"
+// @Success 200 {object} AuthResponse "Two-factor authentication disabled"
+// @Failure 400 {object} AuthResponse "Invalid request data or two-factor authentication not enabled"
+// @Failure 401 {object} AuthResponse "Not authenticated, incorrect password or invalid code"
+// @Failure 429 {object} AuthResponse "Too many failed attempts for this account (see Retry-After)"
+// @Failure 500 {object} AuthResponse "Failed to disable two-factor authentication"
+// @Router /api/auth/2fa/disable [post]
+func (h *AuthHandler) DisableTOTP(c *gin.Context) {
+	u, ok := sessionUser(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Password string `json:"password" binding:"required"`
+		Code     string `json:"code" binding:"required,max=64"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, AuthResponse{
+			Success: false,
+			Message: "Invalid request data",
+		})
+		return
+	}
+
+	if !h.checkPassword(c, u, req.Password) {
+		return
+	}
+
+	totp, err := h.db.GetUserTOTP(u.ID)
+	if err != nil {
+		fmt.Printf("Failed to get two-factor settings for user %d: %v\n", u.ID, err)
+		c.JSON(http.StatusInternalServerError, AuthResponse{
+			Success: false,
+			Message: "Failed to disable two-factor authentication",
+		})
+		return
+	}
+	if !totp.Enabled {
+		c.JSON(http.StatusBadRequest, AuthResponse{
+			Success: false,
+			Message: "Two-factor authentication is not enabled",
+		})
+		return
+	}
+
+	if !h.reserveAuthAttempt(c, u.ID, models.AuthAttemptLogin) {
+		return
+	}
+	accepted, err := h.checkSecondFactor(u.ID, totp, req.Code)
+	if err == nil && accepted {
+		err = h.db.DisableTOTP(u.ID)
+	}
+	if err != nil {
+		fmt.Printf("Failed to disable two-factor authentication for user %d: %v\n", u.ID, err)
+		c.JSON(http.StatusInternalServerError, AuthResponse{
+			Success: false,
+			Message: "Failed to disable two-factor authentication",
+		})
+		return
+	}
+	if !accepted {
+		c.JSON(http.StatusUnauthorized, AuthResponse{
+			Success: false,
+			Message: "Invalid two-factor code",
+		})
+		return
+	}
+	h.releaseAuthAttempt(u.ID, models.AuthAttemptLogin)
+
+	c.JSON(http.StatusOK, AuthResponse{
+		Success: true,
+		Message: "Two-factor authentication disabled",
+	})
+}
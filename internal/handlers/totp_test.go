@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"autotraderguesser/internal/database"
+	"autotraderguesser/internal/models"
+)
+
+// totpTestRouter creates a logged in user and serves the login and two-factor endpoints, returning the user's auth header
+func totpTestRouter(t *testing.T, handler *AuthHandler, db *database.Database, username string) (*gin.Engine, map[string]string) {
+	t.Helper()
+	user := createUser(t, db, username, username, "password123", "")
+	if _, err := db.CreateUserSession(user.ID, username+"-token", "", ""); err != nil {
+		t.Fatalf("CreateUserSession failed: %v", err)
+	}
+
+	r := gin.New()
+	r.Use(handler.AuthMiddleware())
+	r.POST("/login", handler.Login)
+	r.POST("/login/2fa", handler.LoginTOTP)
+	r.GET("/2fa", handler.RequireAuth(), handler.GetTOTPStatus)
+	r.POST("/2fa/enroll", handler.RequireAuth(), handler.EnrollTOTP)
+	r.POST("/2fa/confirm", handler.RequireAuth(), handler.ConfirmTOTP)
+	r.POST("/2fa/disable", handler.RequireAuth(), handler.DisableTOTP)
+	return r, map[string]string{"Authorization": "Bearer " + username + "-token"}
+}
+
+// enableTOTP enrols and confirms two-factor authentication, returning the secret and recovery codes
+func enableTOTP(t *testing.T, r *gin.Engine, auth map[string]string, now time.Time) (string, []string) {
+	t.Helper()
+	rec := performJSONRequest(r, http.MethodPost, "/2fa/enroll", gin.H{"password": "password123"}, auth)
+	var enrolment struct {
+		Secret     string `json:"secret"`
+		OTPAuthURI string `json:"otpauthUri"`
+	}
+	if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &enrolment) != nil || enrolment.Secret == "" {
+		t.Fatalf("expected enrolment to start, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if !strings.HasPrefix(enrolment.OTPAuthURI, "otpauth://totp/CarGuessr:") || !strings.Contains(enrolment.OTPAuthURI, "secret="+enrolment.Secret) {
+		t.Fatalf("unexpected otpauth URI %q", enrolment.OTPAuthURI)
+	}
+
+	rec = performJSONRequest(r, http.MethodPost, "/2fa/confirm", gin.H{"code": totpCode(t, enrolment.Secret, now)}, auth)
+	var confirmed struct {
+		RecoveryCodes []string `json:"recoveryCodes"`
+	}
+	if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &confirmed) != nil || len(confirmed.RecoveryCodes) != models.TOTPRecoveryCodeCount {
+		t.Fatalf("expected two-factor authentication to be enabled, got %d: %s", rec.Code, rec.Body.String())
+	}
+	return enrolment.Secret, confirmed.RecoveryCodes
+}
+
+func totpCode(t *testing.T, secret string, at time.Time) string {
+	t.Helper()
+	code, err := models.TOTPCode(secret, models.TOTPStep(at))
+	if err != nil {
+		t.Fatalf("TOTPCode failed: %v", err)
+	}
+	return code
+}
+
+// startLogin logs in with the right password and returns the partial token for the two-factor step
+func startLogin(t *testing.T, r *gin.Engine, username string) string {
+	t.Helper()
+	rec := performJSONRequest(r, http.MethodPost, "/login", LoginRequest{Username: username, Password: "password123"}, nil)
+	var resp AuthResponse
+	if rec.Code != http.StatusAccepted || json.Unmarshal(rec.Body.Bytes(), &resp) != nil {
+		t.Fatalf("expected a two-factor step, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if resp.Success || !resp.TwoFactorRequired || resp.PartialToken == "" || resp.SessionToken != "" || len(rec.Result().Cookies()) != 0 {
+		t.Fatalf("expected only a partial token before the code, got %+v", resp)
+	}
+	return resp.PartialToken
+}
+
+func finishLogin(r *gin.Engine, partialToken, code string) *httptest.ResponseRecorder {
+	return performJSONRequest(r, http.MethodPost, "/login/2fa", models.TOTPLoginRequest{PartialToken: partialToken, Code: code}, nil)
+}
+
+func TestTOTPEnrolmentAndLogin(t *testing.T) {
+	handler, db, cleanup := setupAuthHandler(t)
+	defer cleanup()
+
+	now := time.Date(2025, 6, 4, 12, 0, 0, 0, time.UTC)
+	handler.now = func() time.Time { return now }
+	r, auth := totpTestRouter(t, handler, db, "secure")
+
+	if rec := performJSONRequest(r, http.MethodPost, "/2fa/enroll", gin.H{"password": "wrong"}, auth); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for a wrong password, got %d", rec.Code)
+	}
+	if rec := performJSONRequest(r, http.MethodPost, "/2fa/confirm", gin.H{"code": "123456"}, auth); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 before enrolment, got %d", rec.Code)
+	}
+
+	secret, recoveryCodes := enableTOTP(t, r, auth, now)
+	if rec := performJSONRequest(r, http.MethodPost, "/2fa/enroll", gin.H{"password": "password123"}, auth); rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 once enabled, got %d", rec.Code)
+	}
+	rec := performJSONRequest(r, http.MethodGet, "/2fa", nil, auth)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"enabled":true`) {
+		t.Fatalf("expected two-factor authentication to be reported on, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// The code used to confirm can't be replayed
+	partial := startLogin(t, r, "secure")
+	if rec := finishLogin(r, partial, totpCode(t, secret, now)); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected a replayed code to be rejected, got %d", rec.Code)
+	}
+
+	now = now.Add(models.TOTPPeriod)
+	rec = finishLogin(r, partial, totpCode(t, secret, now))
+	var resp AuthResponse
+	if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &resp) != nil || !resp.Success || resp.SessionToken == "" {
+		t.Fatalf("expected the code to finish logging in, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if _, _, err := db.GetUserSession(resp.SessionToken); err != nil {
+		t.Fatalf("expected a session for the new token, got %v", err)
+	}
+	if rec := finishLogin(r, partial, totpCode(t, secret, now)); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected the partial token to be used up, got %d", rec.Code)
+	}
+
+	// Recovery codes work once each
+	if rec := finishLogin(r, startLogin(t, r, "secure"), strings.ToLower(recoveryCodes[0])); rec.Code != http.StatusOK {
+		t.Fatalf("expected a recovery code to log in, got %d", rec.Code)
+	}
+	if rec := finishLogin(r, startLogin(t, r, "secure"), recoveryCodes[0]); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected a used recovery code to be rejected, got %d", rec.Code)
+	}
+
+	// Turning it off needs the password and a code
+	now = now.Add(models.TOTPPeriod)
+	if rec := performJSONRequest(r, http.MethodPost, "/2fa/disable", gin.H{"password": "password123", "code": "000000"}, auth); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for a wrong code, got %d", rec.Code)
+	}
+	if rec := performJSONRequest(r, http.MethodPost, "/2fa/disable", gin.H{"password": "password123", "code": totpCode(t, secret, now)}, auth); rec.Code != http.StatusOK {
+		t.Fatalf("expected two-factor authentication to be turned off, got %d", rec.Code)
+	}
+	if rec := performJSONRequest(r, http.MethodPost, "/login", LoginRequest{Username: "secure", Password: "password123"}, nil); rec.Code != http.StatusOK {
+		t.Fatalf("expected a normal login once turned off, got %d", rec.Code)
+	}
+}
+
+func TestTOTPPartialLoginLimits(t *testing.T) {
+	handler, db, cleanup := setupAuthHandler(t)
+	defer cleanup()
+
+	now := time.Date(2025, 6, 4, 12, 0, 0, 0, time.UTC)
+	handler.now = func() time.Time { return now }
+	r, auth := totpTestRouter(t, handler, db, "limits")
+	secret, _ := enableTOTP(t, r, auth, now)
+
+	// Partial tokens expire
+	partial := startLogin(t, r, "limits")
+	now = now.Add(models.PartialLoginTTL)
+	if rec := finishLogin(r, partial, totpCode(t, secret, now)); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected an expired partial token to be rejected, got %d", rec.Code)
+	}
+
+	// Too many wrong codes end the partial login, waiting out each backoff in between
+	partial = startLogin(t, r, "limits")
+	for i := 0; i < models.PartialLoginMaxAttempts; i++ {
+		if rec := finishLogin(r, partial, "000000"); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("expected 401 for a wrong code, got %d", rec.Code)
+		}
+		now = now.Add(10 * time.Second)
+	}
+	if rec := finishLogin(r, partial, totpCode(t, secret, now)); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected the partial login to be dropped, got %d", rec.Code)
+	}
+
+	// Wrong codes count as failed logins, so guessing can't go on with fresh partial tokens
+	throttle, err := db.GetAuthThrottle(createdUserID(t, db, "limits"), models.AuthAttemptLogin)
+	if err != nil || throttle.Failures != models.PartialLoginMaxAttempts {
+		t.Fatalf("expected %d failed logins, got %+v err=%v", models.PartialLoginMaxAttempts, throttle, err)
+	}
+	partial = startLogin(t, r, "limits")
+	if rec := finishLogin(r, partial, "000000"); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for a wrong code, got %d", rec.Code)
+	}
+	if rec := finishLogin(r, partial, totpCode(t, secret, now)); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected the account's backoff to apply, got %d", rec.Code)
+	}
+}
+
+func createdUserID(t *testing.T, db *database.Database, username string) int {
+	t.Helper()
+	user, err := db.GetUserByUsername(username)
+	if err != nil {
+		t.Fatalf("failed to get %s: %v", username, err)
+	}
+	return user.ID
+}
@@ -1,9 +1,11 @@
 package models
 
 import (
+	"errors"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // Car represents a vehicle listing
@@ -254,7 +256,8 @@ type GuessRequest struct {
 	ListingID    string  `json:"listingId" binding:"required"`
 	GuessedPrice float64 `json:"guessedPrice" binding:"required,min=0,max=10000000"`
 	GameMode     string  `json:"gameMode" binding:"required,oneof=zero streak challenge"`
-	Difficulty   string  `json:"difficulty" binding:"omitempty,oneof=easy hard"` // Default to hard for backward compatibility
+	Difficulty   string  `json:"difficulty" binding:"omitempty,oneof=easy hard"`       // Default to hard for backward compatibility
+	Metric       string  `json:"metric" binding:"omitempty,oneof=percentage logratio"` // Streak tolerance metric, fixed when a streak starts; defaults to percentage. Log-ratio streaks are unranked
 }
 
 // ChallengeGuessRequest represents a price guess in challenge mode
@@ -269,24 +272,45 @@ type GuessResponse struct {
 	GuessedPrice float64 `json:"guessedPrice"`
 	Difference   float64 `json:"difference"`
 	Percentage   float64 `json:"percentage"`
+	LogRatio     float64 `json:"logRatio"` // |ln(guess/actual)|
 	Score        int     `json:"score"`
 	GameOver     bool    `json:"gameOver"`
 	Message      string  `json:"message"`
 	OriginalURL  string  `json:"originalUrl,omitempty"`
 }
 
+// ChallengeSessionTTL is how long a player has to finish a challenge session
+const ChallengeSessionTTL = 24 * time.Hour
+
 // ChallengeSession represents a 10-car challenge game session
 type ChallengeSession struct {
 	SessionID     string           `json:"sessionId" db:"session_id"`
 	UserID        int              `json:"userId,omitempty" db:"user_id"`
 	Difficulty    string           `json:"difficulty" db:"difficulty"`
 	Cars          []*EnhancedCar   `json:"cars" db:"-"`
+	PricedCars    []*EnhancedCar   `json:"-" db:"-"`                        // Snapshot of the selected cars with actual prices, used for scoring
+	Scoring       ScoringPolicy    `json:"scoring" db:"-"`                  // Car count and scoring curve the session was created with
+	Theme         string           `json:"theme,omitempty" db:"theme_slug"` // Slug of the theme the cars were picked from, if any
 	CurrentCar    int              `json:"currentCar" db:"current_car"`
 	Guesses       []ChallengeGuess `json:"guesses" db:"-"`
+	Hints         []ChallengeHint  `json:"hints" db:"-"` // Hints unlocked so far, for any car
 	TotalScore    int              `json:"totalScore" db:"total_score"`
 	IsComplete    bool             `json:"isComplete" db:"is_complete"`
 	StartTime     string           `json:"startTime" db:"created_at"`
 	CompletedTime string           `json:"completedTime,omitempty" db:"completed_at"`
+	ExpiresAt     string           `json:"expiresAt,omitempty" db:"expires_at"` // Unfinished sessions can't be played after this
+}
+
+// IsExpired reports whether an unfinished session has run out of time. Completed sessions never expire.
+func (s *ChallengeSession) IsExpired(now time.Time) bool {
+	if s.IsComplete || s.ExpiresAt == "" {
+		return false
+	}
+	expiresAt, err := time.Parse(time.RFC3339, s.ExpiresAt)
+	if err != nil {
+		return false
+	}
+	return !now.Before(expiresAt)
 }
 
 // ChallengeGuess represents a single guess in challenge mode
@@ -297,7 +321,68 @@ type ChallengeGuess struct {
 	ActualPrice  float64 `json:"actualPrice" db:"actual_price"`
 	Difference   float64 `json:"difference" db:"-"` // Calculated field
 	Percentage   float64 `json:"percentage" db:"accuracy_percentage"`
-	Points       int     `json:"points" db:"points"`
+	LogRatio     float64 `json:"logRatio" db:"log_ratio"` // |ln(guess/actual)|
+	Points       int     `json:"points" db:"points"`      // After hint penalty
+	HintsUsed    int     `json:"hintsUsed" db:"hints_used"`
+	HintPenalty  int     `json:"hintPenalty" db:"hint_penalty"` // Points taken off for hints
+}
+
+// DailyChallenge is the shared set of cars every player gets for a UTC date and difficulty
+type DailyChallenge struct {
+	Date       string         `json:"date" db:"challenge_date"` // UTC date (YYYY-MM-DD)
+	Difficulty string         `json:"difficulty" db:"difficulty"`
+	Cars       []*EnhancedCar `json:"cars" db:"cars_json"` // Full car records including prices
+}
+
+// DailyAttempt links a player's single daily challenge attempt to its challenge session
+type DailyAttempt struct {
+	Date       string `json:"date" db:"challenge_date"`
+	Difficulty string `json:"difficulty" db:"difficulty"`
+	PlayerKey  string `json:"-" db:"player_key"` // "user:<id>" or "anon:<session id>"
+	UserID     *int   `json:"userId,omitempty" db:"user_id"`
+	SessionID  string `json:"sessionId" db:"session_id"`
+}
+
+// HigherLowerPair is the pair of cars currently in play in a higher-or-lower session
+type HigherLowerPair struct {
+	SessionID string       `json:"sessionId" db:"session_id"`
+	Left      *EnhancedCar `json:"left" db:"-"`  // Includes actual price - never sent to clients
+	Right     *EnhancedCar `json:"right" db:"-"` // Includes actual price - never sent to clients
+}
+
+// HigherLowerGuessRequest is the player's pick of which car sold for more
+type HigherLowerGuessRequest struct {
+	Choice string `json:"choice" binding:"required,oneof=left right"`
+}
+
+// HigherLowerResponse represents the result of a higher-or-lower pick
+type HigherLowerResponse struct {
+	Correct    bool    `json:"correct"`
+	Choice     string  `json:"choice"`
+	LeftPrice  float64 `json:"leftPrice"`
+	RightPrice float64 `json:"rightPrice"`
+	LeftURL    string  `json:"leftUrl,omitempty"`
+	RightURL   string  `json:"rightUrl,omitempty"`
+	Score      int     `json:"score"`
+	GameOver   bool    `json:"gameOver"`
+	Message    string  `json:"message"`
+}
+
+// YearGuessRequest represents a build year guess in guess-the-year mode
+type YearGuessRequest struct {
+	ListingID   string `json:"listingId" binding:"required"`
+	GuessedYear int    `json:"guessedYear" binding:"required,min=1880,max=2100"`
+	Difficulty  string `json:"difficulty" binding:"omitempty,oneof=easy hard"` // Default to hard, where cars span the most decades
+}
+
+// YearGuessResponse represents the result of a build year guess
+type YearGuessResponse struct {
+	ActualYear  int    `json:"actualYear"`
+	GuessedYear int    `json:"guessedYear"`
+	YearsOff    int    `json:"yearsOff"`
+	Points      int    `json:"points"`
+	Message     string `json:"message"`
+	OriginalURL string `json:"originalUrl,omitempty"`
 }
 
 // ChallengeResponse represents the response after submitting a challenge guess
@@ -311,6 +396,37 @@ type ChallengeResponse struct {
 	OriginalURL     string `json:"originalUrl,omitempty"`
 }
 
+// ChallengeReview reveals every car, guess and answer of a completed challenge session
+type ChallengeReview struct {
+	SessionID         string               `json:"sessionId"`
+	Difficulty        string               `json:"difficulty"`
+	Scoring           ScoringPolicy        `json:"scoring"`
+	Theme             string               `json:"theme,omitempty"`
+	TotalScore        int                  `json:"totalScore"`
+	HintsUsed         int                  `json:"hintsUsed"`
+	CompletedTime     string               `json:"completedTime,omitempty"`
+	Cars              []ChallengeReviewCar `json:"cars"`
+	FriendChallengeID *int                 `json:"friendChallengeId,omitempty"` // Set when the session belongs to a friend challenge
+}
+
+// ChallengeReviewCar is one car of a challenge review with the player's guess and its result
+type ChallengeReviewCar struct {
+	ChallengeGuess
+	Car         *EnhancedCar         `json:"car"` // Full details including the actual price
+	OriginalURL string               `json:"originalUrl,omitempty"`
+	Hints       []ChallengeHint      `json:"hints"`             // Hints unlocked for this car
+	Friends     *FriendCarComparison `json:"friends,omitempty"` // Friend challenges only
+}
+
+// FriendCarComparison compares how every finished participant of a friend challenge did on one car
+type FriendCarComparison struct {
+	Participants   int     `json:"participants"`   // Finished participants who guessed the car
+	BestGuess      float64 `json:"bestGuess"`      // Closest guess to the actual price
+	BestPercentage float64 `json:"bestPercentage"` // Percentage error of the closest guess
+	BestPlayer     string  `json:"bestPlayer"`
+	AverageError   float64 `json:"averageError"` // Mean percentage error across participants
+}
+
 // LeaderboardEntry represents a high score entry
 type LeaderboardEntry struct {
 	ID                int    `json:"id,omitempty" db:"id"`
@@ -322,14 +438,97 @@ type LeaderboardEntry struct {
 	Date              string `json:"date" db:"-"`                          // Formatted date for JSON response
 	SessionID         string `json:"sessionId,omitempty" db:"session_id"`
 	FriendChallengeID *int   `json:"friendChallengeId,omitempty" db:"friend_challenge_id"`
-	LegacyID          string `json:"legacyId,omitempty" db:"legacy_id"` // For migration from JSON
+	HintsUsed         int    `json:"hintsUsed,omitempty" db:"hints_used"` // Challenge hints unlocked during the session
+	LegacyID          string `json:"legacyId,omitempty" db:"legacy_id"`   // For migration from JSON
+}
+
+// RankedLeaderboardEntry is a leaderboard entry with its position on the board.
+// Entries with equal scores share a rank.
+type RankedLeaderboardEntry struct {
+	LeaderboardEntry
+	Rank int `json:"rank"`
+}
+
+// ErrInvalidCursor is returned when a leaderboard page cursor doesn't point into the board being read
+var ErrInvalidCursor = errors.New("invalid leaderboard cursor")
+
+// LeaderboardQuery selects a page of a leaderboard
+type LeaderboardQuery struct {
+	GameMode   string        // Empty for every game mode
+	Difficulty string        // Empty for both difficulties
+	Window     *PeriodWindow // Nil for all time
+	BestOnly   bool          // Keep only each registered player's best entry; guest entries all count
+	FriendsOf  int           // Only entries by this user and their friends, or 0 for everyone
+	After      int           // Entry ID the page starts after, or 0 for the top of the board
+	Limit      int           // 0 for no limit
 }
 
 // LeaderboardSubmissionRequest represents a request to submit a score to the leaderboard
 type LeaderboardSubmissionRequest struct {
 	Name       string `json:"name" binding:"required,min=1,max=20"`
 	Score      int    `json:"score" binding:"required,min=0"`
-	GameMode   string `json:"gameMode" binding:"required,oneof=streak challenge"`
+	GameMode   string `json:"gameMode" binding:"required,oneof=streak challenge zero daily higherlower"`
 	Difficulty string `json:"difficulty,omitempty" binding:"omitempty,oneof=easy hard"` // Default to hard for backward compatibility
-	SessionID  string `json:"sessionId,omitempty"`
+	SessionID  string `json:"sessionId,omitempty"`                                      // Server-side session the score is verified against
+}
+
+// Listing is implemented by every source-specific car record so the game can
+// store and serve cars without knowing which site they came from
+type Listing interface {
+	ListingID() string
+	ListingPrice() float64
+	ListingURL() string
+	ToEnhancedCar() *EnhancedCar
+}
+
+// ListingID returns the unique listing identifier
+func (bc *BonhamsCar) ListingID() string { return bc.ID }
+
+// ListingPrice returns the hammer price
+func (bc *BonhamsCar) ListingPrice() float64 { return bc.Price }
+
+// ListingURL returns the original auction URL
+func (bc *BonhamsCar) ListingURL() string { return bc.OriginalURL }
+
+// ListingID returns the unique listing identifier
+func (lc *LookersCar) ListingID() string { return lc.ID }
+
+// ListingPrice returns the advertised price
+func (lc *LookersCar) ListingPrice() float64 { return lc.Price }
+
+// ListingURL returns the original dealership URL
+func (lc *LookersCar) ListingURL() string { return lc.OriginalURL }
+
+// yearPattern matches text that gives away a build year: four-digit years
+// from 1800-2099, decades such as "1960s" and short forms like '67
+var yearPattern = regexp.MustCompile(`\b(?:18|19|20)\d{2}s?\b|['’]\d{2}s?\b`)
+
+// redactedYear replaces year-revealing text in redacted listings
+const redactedYear = "****"
+
+// RedactYears replaces any year-revealing text in s
+func RedactYears(s string) string {
+	return yearPattern.ReplaceAllString(s, redactedYear)
+}
+
+// RedactYear returns a copy of the car with its year and any text that reveals it hidden.
+// Registration plates and listing URLs are cleared as they usually encode the year too.
+func (ec *EnhancedCar) RedactYear() *EnhancedCar {
+	redacted := *ec
+	redacted.Year = 0
+	redacted.Registration = ""
+	redacted.OriginalURL = ""
+	redacted.Model = RedactYears(ec.Model)
+	redacted.Trim = RedactYears(ec.Trim)
+	redacted.FullTitle = RedactYears(ec.FullTitle)
+	redacted.Description = RedactYears(ec.Description)
+
+	if ec.KeyFacts != nil {
+		redacted.KeyFacts = make([]string, len(ec.KeyFacts))
+		for i, fact := range ec.KeyFacts {
+			redacted.KeyFacts[i] = RedactYears(fact)
+		}
+	}
+
+	return &redacted
 }
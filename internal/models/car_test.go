@@ -3,6 +3,7 @@ package models
 import (
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestBonhamsCarConversions(t *testing.T) {
@@ -95,3 +96,81 @@ func TestLookersCarToEnhancedCar(t *testing.T) {
 		t.Fatalf("expected full title preserved")
 	}
 }
+
+func TestListingInterface(t *testing.T) {
+	listings := []Listing{
+		&BonhamsCar{ID: "b1", Price: 1000, OriginalURL: "https://bonhams.example/b1"},
+		&LookersCar{ID: "l1", Price: 2000, OriginalURL: "https://lookers.example/l1"},
+	}
+
+	for _, listing := range listings {
+		enhanced := listing.ToEnhancedCar()
+		if enhanced.ID != listing.ListingID() {
+			t.Fatalf("expected enhanced ID %s, got %s", listing.ListingID(), enhanced.ID)
+		}
+		if enhanced.Price != listing.ListingPrice() {
+			t.Fatalf("expected enhanced price %.0f, got %.0f", listing.ListingPrice(), enhanced.Price)
+		}
+		if enhanced.OriginalURL != listing.ListingURL() {
+			t.Fatalf("expected enhanced URL %s, got %s", listing.ListingURL(), enhanced.OriginalURL)
+		}
+	}
+}
+
+func TestRedactYear(t *testing.T) {
+	car := &EnhancedCar{
+		ID:           "b1",
+		Year:         1967,
+		Model:        "E-Type",
+		FullTitle:    "1967 Jaguar E-Type Series 1 4.2-Litre Roadster",
+		Description:  "Restored in 2015 to '67 specification, a true 1960s icon.",
+		KeyFacts:     []string{"First registered in 1968", "5,000 miles since restoration"},
+		Registration: "LKV 67E",
+		OriginalURL:  "https://bonhams.example/1967-jaguar-e-type",
+		Price:        95000,
+	}
+
+	redacted := car.RedactYear()
+
+	if redacted.Year != 0 || redacted.Registration != "" || redacted.OriginalURL != "" {
+		t.Fatalf("expected year, registration and URL cleared, got %+v", redacted)
+	}
+	if redacted.FullTitle != "**** Jaguar E-Type Series 1 4.2-Litre Roadster" {
+		t.Fatalf("unexpected redacted title: %q", redacted.FullTitle)
+	}
+	if redacted.Description != "Restored in **** to **** specification, a true **** icon." {
+		t.Fatalf("unexpected redacted description: %q", redacted.Description)
+	}
+	if redacted.KeyFacts[0] != "First registered in ****" || redacted.KeyFacts[1] != "5,000 miles since restoration" {
+		t.Fatalf("unexpected redacted key facts: %v", redacted.KeyFacts)
+	}
+	if redacted.Model != "E-Type" || redacted.Price != car.Price {
+		t.Fatalf("expected non-year fields preserved, got %+v", redacted)
+	}
+
+	// The source car is left untouched
+	if car.Year != 1967 || car.KeyFacts[0] != "First registered in 1968" {
+		t.Fatalf("expected original car unchanged, got %+v", car)
+	}
+}
+
+func TestChallengeSessionIsExpired(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		session ChallengeSession
+		want    bool
+	}{
+		{"no expiry", ChallengeSession{}, false},
+		{"before expiry", ChallengeSession{ExpiresAt: "2025-06-01T12:00:01Z"}, false},
+		{"at expiry", ChallengeSession{ExpiresAt: "2025-06-01T12:00:00Z"}, true},
+		{"completed sessions never expire", ChallengeSession{ExpiresAt: "2025-05-01T00:00:00Z", IsComplete: true}, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.session.IsExpired(now); got != tt.want {
+			t.Errorf("%s: IsExpired() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// Friendship statuses
+const (
+	FriendshipPending  = "pending"
+	FriendshipAccepted = "accepted"
+)
+
+// Errors returned by friend requests
+var (
+	ErrFriendRequestNotFound = errors.New("friend request not found")
+	ErrAlreadyFriends        = errors.New("already friends")
+	ErrFriendRequestExists   = errors.New("friend request already sent")
+	ErrUserBlocked           = errors.New("user is blocked")
+)
+
+// Friendship is a friend request between two users, which becomes a friendship once accepted
+type Friendship struct {
+	ID          int        `json:"id" db:"id"`
+	RequesterID int        `json:"requesterId" db:"requester_id"`
+	AddresseeID int        `json:"addresseeId" db:"addressee_id"`
+	Status      string     `json:"status" db:"status"` // "pending" or "accepted"
+	CreatedAt   time.Time  `json:"createdAt" db:"created_at"`
+	RespondedAt *time.Time `json:"respondedAt,omitempty" db:"responded_at"`
+}
+
+// Friend is another user as listed on a user's friends, requests or blocked list
+type Friend struct {
+	UserID       int       `json:"userId"`
+	Username     string    `json:"username"`
+	DisplayName  string    `json:"displayName"`
+	AvatarURL    string    `json:"avatarUrl,omitempty"`
+	FriendshipID int       `json:"friendshipId,omitempty"` // The request to accept or decline
+	Since        time.Time `json:"since"`                  // When they became friends, or the request or block was made
+}
+
+// FriendList is a user's friends and pending friend requests
+type FriendList struct {
+	Friends  []Friend `json:"friends"`
+	Incoming []Friend `json:"incoming"` // Requests waiting for this user to respond
+	Outgoing []Friend `json:"outgoing"` // Requests this user has sent
+}
+
+// FriendUsernameRequest names another user for a friend request, removal or block
+type FriendUsernameRequest struct {
+	Username string `json:"username" binding:"required,min=2,max=20"`
+}
+
+// DirectChallengeRequest creates a friend challenge sent straight to one friend
+type DirectChallengeRequest struct {
+	Username   string         `json:"username" binding:"required,min=2,max=20"` // The friend to challenge
+	Title      string         `json:"title" binding:"required,min=1,max=100"`
+	Difficulty string         `json:"difficulty" binding:"required,oneof=easy hard"`
+	Scoring    *ScoringPolicy `json:"scoring,omitempty"`
+	Theme      string         `json:"theme,omitempty" binding:"omitempty,max=50"`
+}
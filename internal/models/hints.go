@@ -0,0 +1,108 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// Challenge hints, in the order they are unlocked
+const (
+	HintMileage   = "mileage"
+	HintKeyFacts  = "keyFacts"
+	HintLocation  = "location"
+	HintSaleDate  = "saleDate"
+	HintPriceBand = "priceBand"
+)
+
+// HintOrder is the order hints are unlocked in for each car
+var HintOrder = []string{HintMileage, HintKeyFacts, HintLocation, HintSaleDate, HintPriceBand}
+
+// hintCosts is how many points each hint takes off the car's score
+var hintCosts = map[string]int{
+	HintMileage:   250,
+	HintKeyFacts:  500,
+	HintLocation:  250,
+	HintSaleDate:  250,
+	HintPriceBand: 1500,
+}
+
+// HintCost returns the points a hint takes off the car's score
+func HintCost(hint string) int {
+	return hintCosts[hint]
+}
+
+// ChallengeHint is a hint unlocked for one car in a challenge session
+type ChallengeHint struct {
+	CarIndex int             `json:"carIndex" db:"car_index"`
+	Hint     string          `json:"hint" db:"hint"`
+	Value    json.RawMessage `json:"value" db:"value_json"`
+	Cost     int             `json:"cost" db:"cost"`
+}
+
+// PriceBand is the price range revealed by the price band hint
+type PriceBand struct {
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+}
+
+// PriceBandFor returns the band of the price's leading digit, e.g. £42,000 is in £40,000-£50,000
+func PriceBandFor(price float64) PriceBand {
+	if price < 1 {
+		return PriceBand{Min: 0, Max: 1}
+	}
+	magnitude := math.Pow(10, math.Floor(math.Log10(price)))
+	lower := math.Floor(price/magnitude) * magnitude
+	return PriceBand{Min: lower, Max: lower + magnitude}
+}
+
+// HintValue returns the JSON value a hint reveals for the car.
+// Returns nil if the car has nothing to reveal for that hint, such as a dealer car with no sale date.
+func (ec *EnhancedCar) HintValue(hint string) (json.RawMessage, error) {
+	var value interface{}
+
+	switch hint {
+	case HintMileage:
+		switch {
+		case ec.MileageFormatted != "":
+			value = ec.MileageFormatted
+		case ec.Mileage > 0:
+			value = fmt.Sprintf("%d miles", ec.Mileage)
+		}
+	case HintKeyFacts:
+		if len(ec.KeyFacts) > 0 {
+			value = ec.KeyFacts
+		}
+	case HintLocation:
+		if ec.Location != "" {
+			value = ec.Location
+		}
+	case HintSaleDate:
+		if ec.SaleDate != "" {
+			value = ec.SaleDate
+		}
+	case HintPriceBand:
+		if ec.Price > 0 {
+			value = PriceBandFor(ec.Price)
+		}
+	default:
+		return nil, fmt.Errorf("unknown hint %q", hint)
+	}
+
+	if value == nil {
+		return nil, nil
+	}
+	return json.Marshal(value)
+}
+
+// WithoutHints returns a player-facing copy of the car with its price and every hint attribute hidden
+func (ec *EnhancedCar) WithoutHints() *EnhancedCar {
+	hidden := *ec
+	hidden.Price = 0
+	hidden.Mileage = 0
+	hidden.MileageFormatted = ""
+	hidden.KeyFacts = nil
+	hidden.Location = ""
+	hidden.SaleDate = ""
+	return &hidden
+}
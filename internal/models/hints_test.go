@@ -0,0 +1,67 @@
+package models
+
+import "testing"
+
+func TestPriceBandFor(t *testing.T) {
+	tests := []struct {
+		price    float64
+		min, max float64
+	}{
+		{42000, 40000, 50000},
+		{8500, 8000, 9000},
+		{150000, 100000, 200000},
+		{0, 0, 1},
+	}
+	for _, tt := range tests {
+		if band := PriceBandFor(tt.price); band.Min != tt.min || band.Max != tt.max {
+			t.Fatalf("PriceBandFor(%.0f) = %+v, want %.0f-%.0f", tt.price, band, tt.min, tt.max)
+		}
+	}
+}
+
+func TestHintValuesAndRedaction(t *testing.T) {
+	car := &EnhancedCar{
+		ID:               "b1",
+		Price:            42000,
+		Mileage:          41565,
+		MileageFormatted: "41,565 Miles",
+		KeyFacts:         []string{"Matching numbers"},
+		Location:         "Goodwood",
+		Make:             "Jaguar",
+	}
+
+	want := map[string]string{
+		HintMileage:   `"41,565 Miles"`,
+		HintKeyFacts:  `["Matching numbers"]`,
+		HintLocation:  `"Goodwood"`,
+		HintPriceBand: `{"min":40000,"max":50000}`,
+	}
+	for hint, expected := range want {
+		value, err := car.HintValue(hint)
+		if err != nil || string(value) != expected {
+			t.Fatalf("HintValue(%s) = %s err=%v, want %s", hint, value, err, expected)
+		}
+	}
+
+	// Nothing to reveal without a sale date
+	if value, err := car.HintValue(HintSaleDate); err != nil || value != nil {
+		t.Fatalf("expected no sale date hint, got %s err=%v", value, err)
+	}
+	if _, err := car.HintValue("colour"); err == nil {
+		t.Fatalf("expected error for unknown hint")
+	}
+
+	hidden := car.WithoutHints()
+	if hidden.Price != 0 || hidden.Mileage != 0 || hidden.MileageFormatted != "" || hidden.KeyFacts != nil || hidden.Location != "" {
+		t.Fatalf("expected hint attributes hidden, got %+v", hidden)
+	}
+	if hidden.Make != "Jaguar" || car.Location != "Goodwood" {
+		t.Fatalf("expected other fields and the original car untouched")
+	}
+
+	for _, hint := range HintOrder {
+		if HintCost(hint) <= 0 {
+			t.Fatalf("expected a cost for hint %s", hint)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package models
+
+// Tie-breakers order leaderboard entries that have equal scores. Each names a leaderboard_entries
+// column where the smaller value ranks first. Entries still tied are ordered by id.
+const (
+	TieBreakFewestHints = "hints_used" // Fewer challenge hints unlocked
+	TieBreakEarliest    = "created_at" // Submitted first
+)
+
+// GameMode declares how a game mode's leaderboard is ranked and what it accepts
+type GameMode struct {
+	Name           string   `json:"name"`
+	HigherIsBetter bool     `json:"higherIsBetter"`
+	TieBreakers    []string `json:"tieBreakers"`
+	Difficulties   []string `json:"difficulties"`
+	MinScore       int      `json:"minScore"`
+	MaxScore       int      `json:"maxScore,omitempty"` // 0 means unbounded
+}
+
+// maxChallengeScore is the best total a ranked challenge can reach
+const maxChallengeScore = MaxGuessPoints * DefaultChallengeCarCount
+
+// gameModes is the registry of leaderboard game modes, in display order
+var gameModes = []GameMode{
+	{
+		Name:           "challenge",
+		HigherIsBetter: true,
+		TieBreakers:    []string{TieBreakFewestHints, TieBreakEarliest},
+		Difficulties:   []string{"easy", "hard"},
+		MaxScore:       maxChallengeScore,
+	},
+	{
+		Name:           "streak",
+		HigherIsBetter: true,
+		TieBreakers:    []string{TieBreakEarliest},
+		Difficulties:   []string{"easy", "hard"},
+	},
+	{
+		Name:           "zero",
+		HigherIsBetter: false, // Cumulative price difference, so closest to zero wins
+		TieBreakers:    []string{TieBreakEarliest},
+		Difficulties:   []string{"easy", "hard"},
+	},
+	{
+		Name:           "daily",
+		HigherIsBetter: true,
+		TieBreakers:    []string{TieBreakFewestHints, TieBreakEarliest},
+		Difficulties:   []string{"easy", "hard"},
+		MaxScore:       maxChallengeScore,
+	},
+	{
+		Name:           "higherlower",
+		HigherIsBetter: true,
+		TieBreakers:    []string{TieBreakEarliest},
+		Difficulties:   []string{"easy", "hard"},
+	},
+}
+
+// DefaultGameMode is the ranking used when a leaderboard query spans every game mode
+var DefaultGameMode = GameMode{
+	HigherIsBetter: true,
+	TieBreakers:    []string{TieBreakEarliest},
+	Difficulties:   []string{"easy", "hard"},
+}
+
+// GameModes returns every leaderboard game mode
+func GameModes() []GameMode {
+	modes := make([]GameMode, len(gameModes))
+	copy(modes, gameModes)
+	return modes
+}
+
+// LookupGameMode returns the registered game mode with a name
+func LookupGameMode(name string) (GameMode, bool) {
+	for _, mode := range gameModes {
+		if mode.Name == name {
+			return mode, true
+		}
+	}
+	return GameMode{}, false
+}
+
+// HasDifficulty reports whether the mode is played on a difficulty
+func (m GameMode) HasDifficulty(difficulty string) bool {
+	for _, d := range m.Difficulties {
+		if d == difficulty {
+			return true
+		}
+	}
+	return false
+}
+
+// ScoreInBounds reports whether a score is one the mode can produce
+func (m GameMode) ScoreInBounds(score int) bool {
+	return score >= m.MinScore && (m.MaxScore == 0 || score <= m.MaxScore)
+}
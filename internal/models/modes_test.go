@@ -0,0 +1,55 @@
+package models
+
+import "testing"
+
+func TestGameModeRegistry(t *testing.T) {
+	for _, mode := range GameModes() {
+		if len(mode.Difficulties) == 0 {
+			t.Errorf("%s mode declares no difficulties", mode.Name)
+		}
+		for _, tieBreaker := range mode.TieBreakers {
+			if tieBreaker != TieBreakEarliest && tieBreaker != TieBreakFewestHints {
+				t.Errorf("%s mode has unknown tie-breaker %q", mode.Name, tieBreaker)
+			}
+		}
+	}
+
+	streak, ok := LookupGameMode("streak")
+	if !ok || !streak.HigherIsBetter {
+		t.Fatalf("expected streak to rank higher scores first, got %+v ok=%v", streak, ok)
+	}
+	zero, ok := LookupGameMode("zero")
+	if !ok || zero.HigherIsBetter {
+		t.Fatalf("expected zero mode to rank lower scores first, got %+v ok=%v", zero, ok)
+	}
+	if _, ok := LookupGameMode("year"); ok {
+		t.Fatalf("expected unranked modes to be missing from the registry")
+	}
+
+	if !streak.HasDifficulty("easy") || streak.HasDifficulty("medium") {
+		t.Fatalf("unexpected difficulties for streak: %v", streak.Difficulties)
+	}
+}
+
+func TestGameModeScoreBounds(t *testing.T) {
+	challenge, _ := LookupGameMode("challenge")
+	streak, _ := LookupGameMode("streak")
+
+	cases := []struct {
+		mode  GameMode
+		score int
+		want  bool
+	}{
+		{challenge, 0, true},
+		{challenge, MaxGuessPoints * DefaultChallengeCarCount, true},
+		{challenge, MaxGuessPoints*DefaultChallengeCarCount + 1, false},
+		{challenge, -1, false},
+		{streak, 1000000, true},
+		{streak, -1, false},
+	}
+	for _, tc := range cases {
+		if got := tc.mode.ScoreInBounds(tc.score); got != tc.want {
+			t.Errorf("%s score %d: expected in bounds %v, got %v", tc.mode.Name, tc.score, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Leaderboard periods. Periods run in UTC.
+const (
+	PeriodDay    = "day"
+	PeriodWeek   = "week"   // ISO week, Monday to Sunday
+	PeriodMonth  = "month"  // Calendar month
+	PeriodSeason = "season" // Calendar quarter
+	PeriodAll    = "all"    // All time, never closes
+)
+
+// ArchivedPeriods are the periods whose final standings are archived when they close
+var ArchivedPeriods = []string{PeriodDay, PeriodWeek, PeriodMonth, PeriodSeason}
+
+// PeriodWindow is one occurrence of a leaderboard period, such as the week starting 2 June 2025
+type PeriodWindow struct {
+	Period string    `json:"period"`
+	Key    string    `json:"key"` // 2025-06-02, 2025-W23, 2025-06 or 2025-Q2
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"` // Exclusive
+}
+
+// ArchivedPeriod is the final standings of a closed leaderboard period
+type ArchivedPeriod struct {
+	PeriodWindow
+	GameMode   string                   `json:"gameMode"`
+	Difficulty string                   `json:"difficulty"`
+	Standings  []RankedLeaderboardEntry `json:"standings"`
+}
+
+// IsArchivedPeriod reports whether a period is one that closes and gets archived
+func IsArchivedPeriod(period string) bool {
+	for _, p := range ArchivedPeriods {
+		if p == period {
+			return true
+		}
+	}
+	return false
+}
+
+// PeriodContaining returns the window of a period that contains t.
+// Returns false for the all-time period and unknown periods, which have no window.
+func PeriodContaining(period string, t time.Time) (PeriodWindow, bool) {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+
+	w := PeriodWindow{Period: period}
+	switch period {
+	case PeriodDay:
+		w.Start, w.End = day, day.AddDate(0, 0, 1)
+		w.Key = w.Start.Format("2006-01-02")
+	case PeriodWeek:
+		w.Start = day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7)) // Back to Monday
+		w.End = w.Start.AddDate(0, 0, 7)
+		year, week := w.Start.ISOWeek()
+		w.Key = fmt.Sprintf("%d-W%02d", year, week)
+	case PeriodMonth:
+		w.Start = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+		w.End = w.Start.AddDate(0, 1, 0)
+		w.Key = w.Start.Format("2006-01")
+	case PeriodSeason:
+		quarter := (int(t.Month()) - 1) / 3
+		w.Start = time.Date(t.Year(), time.Month(quarter*3+1), 1, 0, 0, 0, 0, time.UTC)
+		w.End = w.Start.AddDate(0, 3, 0)
+		w.Key = fmt.Sprintf("%d-Q%d", t.Year(), quarter+1)
+	default:
+		return PeriodWindow{}, false
+	}
+
+	return w, true
+}
+
+// ParsePeriodKey returns the window a period key names, such as 2025-W23 for a week
+func ParsePeriodKey(period, key string) (PeriodWindow, bool) {
+	var start time.Time
+	var err error
+
+	switch period {
+	case PeriodDay:
+		start, err = time.Parse("2006-01-02", key)
+	case PeriodWeek:
+		var year, week int
+		if _, err = fmt.Sscanf(key, "%d-W%d", &year, &week); err == nil {
+			// ISO week 1 is the week containing 4 January
+			jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, time.UTC)
+			start = jan4.AddDate(0, 0, (week-1)*7-(int(jan4.Weekday())+6)%7)
+		}
+	case PeriodMonth:
+		start, err = time.Parse("2006-01", key)
+	case PeriodSeason:
+		var year, quarter int
+		if _, err = fmt.Sscanf(key, "%d-Q%d", &year, &quarter); err == nil {
+			start = time.Date(year, time.Month((quarter-1)*3+1), 1, 0, 0, 0, 0, time.UTC)
+		}
+	default:
+		return PeriodWindow{}, false
+	}
+	if err != nil {
+		return PeriodWindow{}, false
+	}
+
+	// Reject keys that don't round-trip, such as week 60 or quarter 5
+	w, ok := PeriodContaining(period, start)
+	if !ok || w.Key != key {
+		return PeriodWindow{}, false
+	}
+	return w, true
+}
+
+// Previous returns the window immediately before this one
+func (w PeriodWindow) Previous() PeriodWindow {
+	prev, _ := PeriodContaining(w.Period, w.Start.Add(-time.Second))
+	return prev
+}
+
+// Next returns the window immediately after this one
+func (w PeriodWindow) Next() PeriodWindow {
+	next, _ := PeriodContaining(w.Period, w.End)
+	return next
+}
+
+// IsClosed reports whether the window has ended
+func (w PeriodWindow) IsClosed(now time.Time) bool {
+	return !now.Before(w.End)
+}
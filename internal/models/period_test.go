@@ -0,0 +1,84 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPeriodContaining(t *testing.T) {
+	// Wednesday 4 June 2025, late evening UTC
+	now := time.Date(2025, 6, 4, 23, 30, 0, 0, time.UTC)
+
+	cases := []struct {
+		period     string
+		key        string
+		start, end string
+	}{
+		{PeriodDay, "2025-06-04", "2025-06-04", "2025-06-05"},
+		{PeriodWeek, "2025-W23", "2025-06-02", "2025-06-09"},
+		{PeriodMonth, "2025-06", "2025-06-01", "2025-07-01"},
+		{PeriodSeason, "2025-Q2", "2025-04-01", "2025-07-01"},
+	}
+	for _, tc := range cases {
+		w, ok := PeriodContaining(tc.period, now)
+		if !ok {
+			t.Fatalf("expected a %s window", tc.period)
+		}
+		if w.Key != tc.key || w.Start.Format("2006-01-02") != tc.start || w.End.Format("2006-01-02") != tc.end {
+			t.Errorf("%s: got key %s from %s to %s", tc.period, w.Key, w.Start, w.End)
+		}
+
+		parsed, ok := ParsePeriodKey(tc.period, tc.key)
+		if !ok || parsed != w {
+			t.Errorf("%s: expected key %s to parse back to %+v, got %+v ok=%v", tc.period, tc.key, w, parsed, ok)
+		}
+	}
+
+	if _, ok := PeriodContaining(PeriodAll, now); ok {
+		t.Fatalf("expected no window for all time")
+	}
+
+	// Times in other zones use their UTC day
+	tokyo := time.FixedZone("JST", 9*60*60)
+	if w, _ := PeriodContaining(PeriodDay, time.Date(2025, 6, 5, 8, 0, 0, 0, tokyo)); w.Key != "2025-06-04" {
+		t.Fatalf("expected the UTC day, got %s", w.Key)
+	}
+}
+
+func TestPeriodWindowNavigation(t *testing.T) {
+	// ISO week 1 of 2025 starts on Monday 30 December 2024
+	week, ok := ParsePeriodKey(PeriodWeek, "2025-W01")
+	if !ok || week.Start.Format("2006-01-02") != "2024-12-30" {
+		t.Fatalf("unexpected first week of 2025: %+v ok=%v", week, ok)
+	}
+	if prev := week.Previous(); prev.Key != "2024-W52" {
+		t.Fatalf("expected previous week 2024-W52, got %s", prev.Key)
+	}
+	if next := week.Previous().Next(); next.Key != week.Key {
+		t.Fatalf("expected the week after 2024-W52 to be 2025-W01, got %s", next.Key)
+	}
+
+	season, _ := ParsePeriodKey(PeriodSeason, "2025-Q1")
+	if prev := season.Previous(); prev.Key != "2024-Q4" {
+		t.Fatalf("expected previous season 2024-Q4, got %s", prev.Key)
+	}
+	if next := season.Next(); next.Key != "2025-Q2" {
+		t.Fatalf("expected next season 2025-Q2, got %s", next.Key)
+	}
+
+	if !week.IsClosed(week.End) || week.IsClosed(week.End.Add(-time.Second)) {
+		t.Fatalf("expected the week to close exactly at its end")
+	}
+
+	for _, bad := range []struct{ period, key string }{
+		{PeriodWeek, "2025-W60"},
+		{PeriodSeason, "2025-Q5"},
+		{PeriodMonth, "2025-13"},
+		{PeriodDay, "June 4th"},
+		{PeriodAll, "2025"},
+	} {
+		if _, ok := ParsePeriodKey(bad.period, bad.key); ok {
+			t.Errorf("expected %s key %q to be rejected", bad.period, bad.key)
+		}
+	}
+}
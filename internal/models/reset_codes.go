@@ -0,0 +1,31 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// Kinds of one-time password reset code
+const (
+	ResetCodeAdmin    = "admin"    // Issued by an admin, expires after ResetCodeTTL
+	ResetCodeRecovery = "recovery" // Generated by the user as a backup, never expires
+	ResetCodeTOTP     = "totp"     // Issued with two-factor authentication in place of an authenticator code, never expires
+)
+
+const (
+	// ResetCodeTTL is how long an admin-issued reset code can be redeemed
+	ResetCodeTTL = time.Hour
+
+	// RecoveryCodeCount is how many recovery codes are generated at a time
+	RecoveryCodeCount = 10
+)
+
+// ErrResetCodeInvalid is returned when a reset code is unknown, used or expired
+var ErrResetCodeInvalid = errors.New("invalid or expired reset code")
+
+// RedeemResetCodeRequest resets a password with an admin-issued or recovery code
+type RedeemResetCodeRequest struct {
+	Username    string `json:"username" binding:"required"`
+	Code        string `json:"code" binding:"required,max=64"`
+	NewPassword string `json:"newPassword" binding:"required,min=6"`
+}
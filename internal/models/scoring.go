@@ -0,0 +1,136 @@
+package models
+
+import "math"
+
+// Scoring curves supported by challenge sessions
+const (
+	CurveLinear      = "linear"
+	CurveExponential = "exponential"
+	CurveLogRatio    = "logratio"
+)
+
+// Accuracy metrics for price guesses
+const (
+	MetricPercentage = "percentage" // |actual-guess|/actual*100 - guessing half is 50% off but double is 100% off
+	MetricLogRatio   = "logratio"   // |ln(guess/actual)| - guessing half or double are equally far off
+)
+
+// streakTolerancePercent is how far off a streak guess can be under the percentage metric
+const streakTolerancePercent = 10
+
+// PercentageError returns how far off a guess is as a percentage of the actual price
+func PercentageError(guessed, actual float64) float64 {
+	return math.Abs(actual-guessed) / actual * 100
+}
+
+// LogRatioError returns |ln(guess/actual)|, which treats over- and under-guesses by the same factor alike.
+// Prices below £1 are treated as £1 so a zero guess has a finite error.
+func LogRatioError(guessed, actual float64) float64 {
+	return math.Abs(math.Log(math.Max(guessed, 1) / math.Max(actual, 1)))
+}
+
+// WithinStreakTolerance reports whether a streak guess is close enough to keep the streak going.
+// The percentage metric allows 10% either way; the log-ratio metric allows a factor of 1.1 either way.
+func WithinStreakTolerance(metric string, guessed, actual float64) bool {
+	if metric == MetricLogRatio {
+		return LogRatioError(guessed, actual) <= math.Log(1+streakTolerancePercent/100.0)
+	}
+	return PercentageError(guessed, actual) <= streakTolerancePercent
+}
+
+// MaxGuessPoints is the score for a perfect challenge guess
+const MaxGuessPoints = 5000
+
+// DefaultChallengeCarCount is the number of cars in a standard challenge
+const DefaultChallengeCarCount = 10
+
+// ScoringPolicy controls how many cars a challenge has and how each guess is scored.
+// It is stored with the session so replays and leaderboards are scored the same way.
+type ScoringPolicy struct {
+	Curve    string  `json:"curve" form:"curve" binding:"omitempty,oneof=linear exponential logratio"`
+	Scale    float64 `json:"scale,omitempty" form:"scale" binding:"omitempty,gt=0,lte=1000"`   // How quickly points fall off - see Points
+	Cutoff   float64 `json:"cutoff,omitempty" form:"cutoff" binding:"omitempty,gt=0,lte=1000"` // Error at or beyond which a guess scores nothing, in the curve's metric - see Points
+	CarCount int     `json:"carCount,omitempty" form:"carCount" binding:"omitempty,oneof=5 10 20"`
+}
+
+// DefaultScoringPolicy returns the standard 10-car exponential policy used by ranked challenges
+func DefaultScoringPolicy() ScoringPolicy {
+	return ScoringPolicy{}.WithDefaults()
+}
+
+// WithDefaults fills in any unset fields with the defaults for the policy's curve
+func (p ScoringPolicy) WithDefaults() ScoringPolicy {
+	if p.Curve == "" {
+		p.Curve = CurveExponential
+	}
+	if p.CarCount == 0 {
+		p.CarCount = DefaultChallengeCarCount
+	}
+
+	switch p.Curve {
+	case CurveLinear:
+		if p.Scale == 0 {
+			p.Scale = 100 // Points reach zero at 100% error
+		}
+		if p.Cutoff == 0 {
+			p.Cutoff = 100
+		}
+	case CurveLogRatio:
+		if p.Scale == 0 {
+			p.Scale = 0.2 // Decay per unit of |ln(guess/actual)|
+		}
+		if p.Cutoff == 0 {
+			p.Cutoff = math.Ln10 // Off by a factor of 10 either way
+		}
+	default:
+		if p.Scale == 0 {
+			p.Scale = 20 // Decay per percentage point of error
+		}
+		if p.Cutoff == 0 {
+			p.Cutoff = 100
+		}
+	}
+
+	return p
+}
+
+// IsDefault reports whether the policy scores the same as the standard ranked policy
+func (p ScoringPolicy) IsDefault() bool {
+	return p.WithDefaults() == DefaultScoringPolicy()
+}
+
+// Points scores a price guess against the actual price.
+// Linear: MaxGuessPoints * (1 - percentage/scale)
+// Exponential: MaxGuessPoints * e^(-percentage/scale)
+// Log-ratio: MaxGuessPoints * e^(-|ln(guess/actual)|/scale), so guessing double or half scores the same
+// The cutoff is a percentage error for the linear and exponential curves, and a |ln(guess/actual)| for log-ratio.
+func (p ScoringPolicy) Points(guessed, actual float64) int {
+	p = p.WithDefaults()
+
+	if actual <= 0 {
+		return 0
+	}
+
+	// Measure the error in the curve's own metric so the cutoff means the same thing as the scale
+	errorValue := PercentageError(guessed, actual)
+	if p.Curve == CurveLogRatio {
+		if guessed <= 0 {
+			return 0
+		}
+		errorValue = LogRatioError(guessed, actual)
+	}
+	if errorValue >= p.Cutoff {
+		return 0
+	}
+
+	var points float64
+	switch p.Curve {
+	case CurveLinear:
+		points = MaxGuessPoints * math.Max(0, 1-errorValue/p.Scale)
+	default:
+		// Exponential and log-ratio share the decay, only the metric differs
+		points = MaxGuessPoints * math.Exp(-errorValue/p.Scale)
+	}
+
+	return int(math.Round(points))
+}
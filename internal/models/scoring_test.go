@@ -0,0 +1,90 @@
+package models
+
+import (
+	"math"
+	"testing"
+)
+
+func TestScoringPolicyDefaults(t *testing.T) {
+	policy := DefaultScoringPolicy()
+	if policy.Curve != CurveExponential || policy.Scale != 20 || policy.Cutoff != 100 || policy.CarCount != 10 {
+		t.Fatalf("unexpected default policy: %+v", policy)
+	}
+
+	if !(ScoringPolicy{}).IsDefault() {
+		t.Fatalf("expected zero value policy to be the default")
+	}
+	if (ScoringPolicy{CarCount: 5}).IsDefault() {
+		t.Fatalf("expected 5-car policy not to be the default")
+	}
+	if (ScoringPolicy{Curve: CurveLinear}).IsDefault() {
+		t.Fatalf("expected linear policy not to be the default")
+	}
+	if logRatio := (ScoringPolicy{Curve: CurveLogRatio}).WithDefaults(); logRatio.Cutoff != math.Ln10 {
+		t.Fatalf("expected log-ratio cutoff to be a factor of 10, got %v", logRatio.Cutoff)
+	}
+}
+
+func TestScoringPolicyPoints(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  ScoringPolicy
+		guessed float64
+		actual  float64
+		want    int
+	}{
+		{"exponential perfect", ScoringPolicy{}, 10000, 10000, 5000},
+		{"exponential 10 percent", ScoringPolicy{}, 9000, 10000, 3033},
+		{"exponential cutoff", ScoringPolicy{}, 20000, 10000, 0},
+		{"linear half", ScoringPolicy{Curve: CurveLinear}, 5000, 10000, 2500},
+		{"linear custom scale", ScoringPolicy{Curve: CurveLinear, Scale: 50}, 7500, 10000, 2500},
+		{"linear beyond scale", ScoringPolicy{Curve: CurveLinear, Scale: 50}, 4000, 10000, 0},
+		{"log-ratio double", ScoringPolicy{Curve: CurveLogRatio}, 20000, 10000, 156},
+		{"log-ratio half", ScoringPolicy{Curve: CurveLogRatio}, 5000, 10000, 156},
+		{"log-ratio zero guess", ScoringPolicy{Curve: CurveLogRatio}, 0, 10000, 0},
+		{"log-ratio within cutoff", ScoringPolicy{Curve: CurveLogRatio, Cutoff: math.Ln2}, 15000, 10000, 658},
+		{"log-ratio cutoff over", ScoringPolicy{Curve: CurveLogRatio, Cutoff: math.Ln2}, 25000, 10000, 0},
+		{"log-ratio cutoff under", ScoringPolicy{Curve: CurveLogRatio, Cutoff: math.Ln2}, 4000, 10000, 0},
+		{"no actual price", ScoringPolicy{}, 1000, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.Points(tt.guessed, tt.actual); got != tt.want {
+				t.Fatalf("Points(%.0f, %.0f) = %d, want %d", tt.guessed, tt.actual, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAccuracyMetrics(t *testing.T) {
+	// Percentage error is asymmetric, log-ratio error is not
+	if PercentageError(5000, 10000) != 50 || PercentageError(20000, 10000) != 100 {
+		t.Fatalf("unexpected percentage errors")
+	}
+	if half, double := LogRatioError(5000, 10000), LogRatioError(20000, 10000); math.Abs(half-double) > 1e-9 || math.Abs(half-math.Ln2) > 1e-9 {
+		t.Fatalf("expected half and double to both be ln 2 off, got %f and %f", half, double)
+	}
+	if math.IsInf(LogRatioError(0, 10000), 0) {
+		t.Fatalf("expected a finite log-ratio for a zero guess")
+	}
+
+	tests := []struct {
+		metric  string
+		guessed float64
+		want    bool
+	}{
+		{MetricPercentage, 11000, true},
+		{MetricPercentage, 9000, true},
+		{MetricPercentage, 8900, false},
+		{MetricLogRatio, 11000, true},
+		{MetricLogRatio, 9100, true},
+		{MetricLogRatio, 9000, false}, // 10000/9000 is more than a factor of 1.1
+		{"", 9000, true},              // Percentage is the default
+	}
+	for _, tt := range tests {
+		if got := WithinStreakTolerance(tt.metric, tt.guessed, 10000); got != tt.want {
+			t.Fatalf("WithinStreakTolerance(%q, %.0f) = %v, want %v", tt.metric, tt.guessed, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// UserSessionTTL is how long a login stays valid on a device
+const UserSessionTTL = 7 * 24 * time.Hour
+
+// ErrUserSessionNotFound is returned when a session token doesn't match an unexpired login session
+var ErrUserSessionNotFound = errors.New("session not found")
+
+// UserSession is one device's login. Only a digest of the session token is stored.
+type UserSession struct {
+	ID          int       `json:"id" db:"id"`
+	UserID      int       `json:"-" db:"user_id"`
+	DeviceLabel string    `json:"deviceLabel" db:"device_label"`
+	IPAddress   string    `json:"ipAddress" db:"ip_address"`
+	CreatedAt   time.Time `json:"createdAt" db:"created_at"`
+	LastSeenAt  time.Time `json:"lastSeenAt" db:"last_seen_at"`
+	ExpiresAt   time.Time `json:"expiresAt" db:"expires_at"`
+	Current     bool      `json:"current"` // Set for the session making the request
+}
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrThemeNotFound is returned when a challenge asks for a theme that doesn't exist or is inactive
+var ErrThemeNotFound = errors.New("challenge theme not found")
+
+// ThemeTooNarrowError is returned when a theme matches fewer cars than a challenge needs
+type ThemeTooNarrowError struct {
+	Theme   string
+	Matched int
+	Needed  int
+}
+
+func (e *ThemeTooNarrowError) Error() string {
+	return fmt.Sprintf("theme %q matches %d cars but the challenge needs %d", e.Theme, e.Matched, e.Needed)
+}
+
+// ThemeFilter selects the cars a themed challenge is built from. Empty fields match everything.
+type ThemeFilter struct {
+	Makes     []string `json:"makes,omitempty"`     // Any of these makes, case-insensitive
+	YearFrom  int      `json:"yearFrom,omitempty"`  // Inclusive
+	YearTo    int      `json:"yearTo,omitempty"`    // Inclusive
+	BodyTypes []string `json:"bodyTypes,omitempty"` // Any of these body types, case-insensitive
+	FuelTypes []string `json:"fuelTypes,omitempty"` // Any of these fuel types, case-insensitive
+	MinPrice  float64  `json:"minPrice,omitempty"`  // Inclusive
+	MaxPrice  float64  `json:"maxPrice,omitempty"`  // Exclusive, so "under £20k" is MaxPrice 20000
+}
+
+// Matches reports whether a car passes every set criterion of the filter
+func (f *ThemeFilter) Matches(car *EnhancedCar) bool {
+	if len(f.Makes) > 0 && !containsFold(f.Makes, car.Make) {
+		return false
+	}
+	if f.YearFrom > 0 && car.Year < f.YearFrom {
+		return false
+	}
+	if f.YearTo > 0 && (car.Year == 0 || car.Year > f.YearTo) {
+		return false
+	}
+	if len(f.BodyTypes) > 0 && !containsFold(f.BodyTypes, car.BodyType) {
+		return false
+	}
+	if len(f.FuelTypes) > 0 && !containsFold(f.FuelTypes, car.FuelType) {
+		return false
+	}
+	if f.MinPrice > 0 && car.Price < f.MinPrice {
+		return false
+	}
+	if f.MaxPrice > 0 && car.Price >= f.MaxPrice {
+		return false
+	}
+	return true
+}
+
+// IsEmpty reports whether the filter has no criteria
+func (f *ThemeFilter) IsEmpty() bool {
+	return len(f.Makes) == 0 && f.YearFrom == 0 && f.YearTo == 0 && len(f.BodyTypes) == 0 &&
+		len(f.FuelTypes) == 0 && f.MinPrice == 0 && f.MaxPrice == 0
+}
+
+// containsFold reports whether value is in values, ignoring case and surrounding space
+func containsFold(values []string, value string) bool {
+	value = strings.TrimSpace(value)
+	for _, v := range values {
+		if strings.EqualFold(strings.TrimSpace(v), value) {
+			return true
+		}
+	}
+	return false
+}
+
+// ChallengeTheme is an admin-defined set of cars that challenges can be built from
+type ChallengeTheme struct {
+	ID          int         `json:"id" db:"id"`
+	Slug        string      `json:"slug" db:"slug"`
+	Name        string      `json:"name" db:"name"`
+	Description string      `json:"description,omitempty" db:"description"`
+	Filter      ThemeFilter `json:"filter" db:"-"`
+	IsActive    bool        `json:"isActive" db:"is_active"`
+	CreatedAt   time.Time   `json:"createdAt" db:"created_at"`
+}
+
+// CreateThemeRequest is an admin request to define a new challenge theme
+type CreateThemeRequest struct {
+	Slug        string      `json:"slug" binding:"required,min=3,max=50"`
+	Name        string      `json:"name" binding:"required,min=1,max=100"`
+	Description string      `json:"description" binding:"max=500"`
+	Filter      ThemeFilter `json:"filter"`
+}
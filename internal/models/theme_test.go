@@ -0,0 +1,39 @@
+package models
+
+import "testing"
+
+func TestThemeFilterMatches(t *testing.T) {
+	porsche := &EnhancedCar{Make: "Porsche", Year: 1973, Price: 95000, BodyType: "Coupe", FuelType: "Petrol"}
+	leaf := &EnhancedCar{Make: "Nissan", Year: 2019, Price: 14500, BodyType: "Hatchback", FuelType: "Electric"}
+	unknownYear := &EnhancedCar{Make: "Porsche", Price: 40000}
+
+	tests := []struct {
+		name   string
+		filter ThemeFilter
+		car    *EnhancedCar
+		want   bool
+	}{
+		{"empty filter matches anything", ThemeFilter{}, leaf, true},
+		{"make ignores case", ThemeFilter{Makes: []string{"porsche "}}, porsche, true},
+		{"make mismatch", ThemeFilter{Makes: []string{"Porsche"}}, leaf, false},
+		{"year range inclusive", ThemeFilter{YearFrom: 1973, YearTo: 1973}, porsche, true},
+		{"before year range", ThemeFilter{YearFrom: 1980}, porsche, false},
+		{"unknown year fails an upper bound", ThemeFilter{YearTo: 1990}, unknownYear, false},
+		{"body type", ThemeFilter{BodyTypes: []string{"Coupe", "Convertible"}}, porsche, true},
+		{"fuel type", ThemeFilter{FuelTypes: []string{"electric"}}, leaf, true},
+		{"under max price", ThemeFilter{FuelTypes: []string{"Electric"}, MaxPrice: 20000}, leaf, true},
+		{"max price is exclusive", ThemeFilter{MaxPrice: 14500}, leaf, false},
+		{"min price is inclusive", ThemeFilter{MinPrice: 95000}, porsche, true},
+		{"every criterion must match", ThemeFilter{Makes: []string{"Porsche"}, FuelTypes: []string{"Electric"}}, porsche, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.filter.Matches(tt.car); got != tt.want {
+			t.Errorf("%s: Matches() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if !(&ThemeFilter{}).IsEmpty() || (&ThemeFilter{MinPrice: 1}).IsEmpty() {
+		t.Fatalf("IsEmpty reported the wrong result")
+	}
+}
@@ -0,0 +1,85 @@
+package models
+
+import "time"
+
+// Kinds of failed authentication attempt, counted separately for each account
+const (
+	AuthAttemptLogin = "login" // Wrong password
+	AuthAttemptReset = "reset" // Wrong security answer
+)
+
+// AuthThrottlePolicy sets how failed attempts against one account slow down and then lock it
+type AuthThrottlePolicy struct {
+	FreeAttempts    int           // Failures allowed before any wait
+	BaseDelay       time.Duration // Wait after the first failure past FreeAttempts, doubling with each one after
+	LockoutAfter    int           // Failures that lock the account
+	LockoutDuration time.Duration // How long a lockout lasts, and the longest backoff wait
+	ResetAfter      time.Duration // Failures are forgotten after this long without another
+}
+
+// DefaultAuthThrottle is the policy applied to logins and password resets
+var DefaultAuthThrottle = AuthThrottlePolicy{
+	FreeAttempts:    3,
+	BaseDelay:       2 * time.Second,
+	LockoutAfter:    10,
+	LockoutDuration: 15 * time.Minute,
+	ResetAfter:      24 * time.Hour,
+}
+
+// AuthThrottle is the failed attempt count for one account and kind of attempt
+type AuthThrottle struct {
+	UserID        int        `json:"userId" db:"user_id"`
+	Kind          string     `json:"kind" db:"kind"`
+	Failures      int        `json:"failures" db:"failures"`
+	LastFailureAt time.Time  `json:"lastFailureAt" db:"last_failure_at"`
+	LockedUntil   *time.Time `json:"lockedUntil,omitempty" db:"locked_until"` // No attempts are checked before this
+}
+
+// Wait returns how long an account must wait before trying again after a number of failures
+func (p AuthThrottlePolicy) Wait(failures int) time.Duration {
+	if failures >= p.LockoutAfter {
+		return p.LockoutDuration
+	}
+	extra := failures - p.FreeAttempts
+	if extra <= 0 {
+		return 0
+	}
+
+	wait := p.BaseDelay
+	for i := 1; i < extra && wait < p.LockoutDuration; i++ {
+		wait *= 2
+	}
+	if wait > p.LockoutDuration {
+		return p.LockoutDuration
+	}
+	return wait
+}
+
+// Fail records another failure at now and sets how long the account must wait.
+// Failures older than the policy's ResetAfter are forgotten first.
+func (t *AuthThrottle) Fail(p AuthThrottlePolicy, now time.Time) {
+	if t.Failures > 0 && now.Sub(t.LastFailureAt) >= p.ResetAfter {
+		t.Failures = 0
+	}
+	t.Failures++
+	t.LastFailureAt = now
+
+	t.LockedUntil = nil
+	if wait := p.Wait(t.Failures); wait > 0 {
+		until := now.Add(wait)
+		t.LockedUntil = &until
+	}
+}
+
+// RetryAfter returns how long is left before another attempt is allowed, or 0 if one is allowed now
+func (t *AuthThrottle) RetryAfter(now time.Time) time.Duration {
+	if t == nil || t.LockedUntil == nil || !now.Before(*t.LockedUntil) {
+		return 0
+	}
+	return t.LockedUntil.Sub(now)
+}
+
+// LockedOut reports whether the account has reached the policy's lockout rather than a backoff wait
+func (t *AuthThrottle) LockedOut(p AuthThrottlePolicy) bool {
+	return t != nil && t.Failures >= p.LockoutAfter
+}
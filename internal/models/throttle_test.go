@@ -0,0 +1,72 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAuthThrottlePolicyWait(t *testing.T) {
+	p := DefaultAuthThrottle
+
+	cases := map[int]time.Duration{
+		0:  0,
+		3:  0,
+		4:  2 * time.Second,
+		5:  4 * time.Second,
+		6:  8 * time.Second,
+		9:  64 * time.Second,
+		10: 15 * time.Minute,
+		50: 15 * time.Minute,
+	}
+	for failures, want := range cases {
+		if got := p.Wait(failures); got != want {
+			t.Errorf("Wait(%d) = %v, want %v", failures, got, want)
+		}
+	}
+
+	// Backoff never waits longer than a lockout
+	p.LockoutAfter = 100
+	if got := p.Wait(60); got != p.LockoutDuration {
+		t.Errorf("expected the backoff to be capped at %v, got %v", p.LockoutDuration, got)
+	}
+}
+
+func TestAuthThrottleFail(t *testing.T) {
+	p := DefaultAuthThrottle
+	now := time.Date(2025, 6, 4, 12, 0, 0, 0, time.UTC)
+
+	var throttle AuthThrottle
+	for i := 0; i < p.FreeAttempts; i++ {
+		throttle.Fail(p, now)
+	}
+	if wait := throttle.RetryAfter(now); wait != 0 {
+		t.Fatalf("expected free attempts not to wait, got %v", wait)
+	}
+
+	throttle.Fail(p, now)
+	if wait := throttle.RetryAfter(now); wait != 2*time.Second {
+		t.Fatalf("expected a 2s wait, got %v", wait)
+	}
+	if wait := throttle.RetryAfter(now.Add(2 * time.Second)); wait != 0 {
+		t.Fatalf("expected the wait to be over, got %v", wait)
+	}
+
+	for throttle.Failures < p.LockoutAfter {
+		throttle.Fail(p, now)
+	}
+	if !throttle.LockedOut(p) || throttle.RetryAfter(now) != p.LockoutDuration {
+		t.Fatalf("expected a lockout, got %+v", throttle)
+	}
+
+	// A long quiet spell starts the count again
+	later := now.Add(p.ResetAfter)
+	throttle.Fail(p, later)
+	if throttle.Failures != 1 || throttle.LockedOut(p) || throttle.RetryAfter(later) != 0 {
+		t.Fatalf("expected old failures to be forgotten, got %+v", throttle)
+	}
+
+	var none *AuthThrottle
+	if none.RetryAfter(now) != 0 || none.LockedOut(p) {
+		t.Fatalf("expected no throttle to allow attempts")
+	}
+}
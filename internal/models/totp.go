@@ -0,0 +1,125 @@
+package models
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TOTP settings (RFC 6238 defaults, which every authenticator app supports)
+const (
+	TOTPIssuer     = "CarGuessr"
+	TOTPPeriod     = 30 * time.Second
+	TOTPDigits     = 6
+	TOTPSkew       = 1  // Steps either side of now still accepted, for clock drift
+	TOTPSecretSize = 20 // Bytes, the SHA-1 block size RFC 4226 recommends
+
+	// PartialLoginTTL is how long a password-checked login has to be completed with a code
+	PartialLoginTTL = 5 * time.Minute
+
+	// PartialLoginMaxAttempts is how many wrong codes end a partial login
+	PartialLoginMaxAttempts = 5
+
+	// TOTPRecoveryCodeCount is how many recovery codes are issued when two-factor authentication is turned on
+	TOTPRecoveryCodeCount = 10
+)
+
+var (
+	// ErrTOTPAlreadyEnabled is returned when enrolling an account that already has two-factor authentication
+	ErrTOTPAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+
+	// ErrTOTPNotEnrolled is returned when confirming without a pending secret
+	ErrTOTPNotEnrolled = errors.New("two-factor authentication enrolment not started")
+)
+
+// totpEncoding is the unpadded base32 used for secrets in otpauth URIs
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// UserTOTP is an account's two-factor authentication state
+type UserTOTP struct {
+	Secret   string // Base32, set from enrolment onwards
+	Enabled  bool   // Set once a code from the secret has been confirmed
+	LastStep int64  // Time step of the last accepted code, so codes can't be replayed
+}
+
+// TOTPLoginRequest completes a login with a code from an authenticator app or a recovery code
+type TOTPLoginRequest struct {
+	PartialToken string `json:"partialToken" binding:"required"`
+	Code         string `json:"code" binding:"required,max=64"`
+}
+
+// EncodeTOTPSecret returns the base32 form of a secret, as shown to users and stored
+func EncodeTOTPSecret(secret []byte) string {
+	return totpEncoding.EncodeToString(secret)
+}
+
+// TOTPURI returns the otpauth:// URI authenticator apps scan to add an account
+func TOTPURI(account, secret string) string {
+	label := url.PathEscape(TOTPIssuer + ":" + account)
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", TOTPIssuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(TOTPDigits))
+	query.Set("period", fmt.Sprint(int(TOTPPeriod.Seconds())))
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// TOTPStep returns the time step t falls in
+func TOTPStep(t time.Time) int64 {
+	return t.Unix() / int64(TOTPPeriod.Seconds())
+}
+
+// TOTPCode returns the code for a base32 secret at a time step (RFC 4226 HOTP with the step as counter)
+func TOTPCode(secret string, step int64) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", fmt.Errorf("invalid TOTP secret: %w", err)
+	}
+
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	modulus := uint32(1)
+	for i := 0; i < TOTPDigits; i++ {
+		modulus *= 10
+	}
+	return fmt.Sprintf("%0*d", TOTPDigits, value%modulus), nil
+}
+
+// MatchTOTPCode checks a code against the steps around t, skipping steps at or before lastStep.
+// Returns the matching step.
+func MatchTOTPCode(secret, code string, t time.Time, lastStep int64) (int64, bool) {
+	code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+	if len(code) != TOTPDigits {
+		return 0, false
+	}
+
+	now := TOTPStep(t)
+	for step := now - TOTPSkew; step <= now+TOTPSkew; step++ {
+		if step <= lastStep {
+			continue
+		}
+		expected, err := TOTPCode(secret, step)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,73 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA-1 key from the RFC 6238 test vectors
+var rfc6238Secret = EncodeTOTPSecret([]byte("12345678901234567890"))
+
+func TestTOTPCode(t *testing.T) {
+	// RFC 6238 appendix B, truncated to six digits
+	cases := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+	for unix, want := range cases {
+		got, err := TOTPCode(rfc6238Secret, TOTPStep(time.Unix(unix, 0)))
+		if err != nil || got != want {
+			t.Errorf("code at %d = %q err=%v, want %q", unix, got, err, want)
+		}
+	}
+
+	if _, err := TOTPCode("not base32!", 1); err == nil {
+		t.Fatalf("expected an invalid secret to be rejected")
+	}
+}
+
+func TestMatchTOTPCode(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+	step := TOTPStep(now)
+
+	if got, ok := MatchTOTPCode(rfc6238Secret, "081 804", now, 0); !ok || got != step {
+		t.Fatalf("expected the current code to match step %d, got %d ok=%v", step, got, ok)
+	}
+
+	// Codes from the neighbouring steps allow for clock drift, but no further
+	previous, _ := TOTPCode(rfc6238Secret, step-1)
+	if got, ok := MatchTOTPCode(rfc6238Secret, previous, now, 0); !ok || got != step-1 {
+		t.Fatalf("expected the previous code to match, got %d ok=%v", got, ok)
+	}
+	stale, _ := TOTPCode(rfc6238Secret, step-2)
+	if _, ok := MatchTOTPCode(rfc6238Secret, stale, now, 0); ok {
+		t.Fatalf("expected a code two steps old to be rejected")
+	}
+
+	// A code can't be used again once its step has been used
+	if _, ok := MatchTOTPCode(rfc6238Secret, "081804", now, step); ok {
+		t.Fatalf("expected a replayed code to be rejected")
+	}
+
+	for _, code := range []string{"", "08180", "0818045", "abcdef"} {
+		if _, ok := MatchTOTPCode(rfc6238Secret, code, now, 0); ok {
+			t.Errorf("expected %q to be rejected", code)
+		}
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri := TOTPURI("jane doe", "JBSWY3DPEHPK3PXP")
+	want := "otpauth://totp/CarGuessr:jane%20doe?"
+	if !strings.HasPrefix(uri, want) {
+		t.Fatalf("expected %q to start with %q", uri, want)
+	}
+	for _, param := range []string{"secret=JBSWY3DPEHPK3PXP", "issuer=CarGuessr", "digits=6", "period=30", "algorithm=SHA1"} {
+		if !strings.Contains(uri, param) {
+			t.Errorf("expected %q to contain %s", uri, param)
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"errors"
+	"time"
+)
 
 // User represents a user account
 type User struct {
@@ -62,6 +65,7 @@ type FriendChallenge struct {
 	IsActive           bool                   `json:"isActive" db:"is_active"`
 	CreatedAt          time.Time              `json:"createdAt" db:"created_at"`
 	ExpiresAt          time.Time              `json:"expiresAt" db:"expires_at"`
+	InvitedUserID      *int                   `json:"invitedUserId,omitempty" db:"invited_user_id"` // Set for a challenge sent directly to a friend
 	Participants       []ChallengeParticipant `json:"participants,omitempty"`
 	CreatorDisplayName string                 `json:"creatorDisplayName,omitempty"` // Populated in queries
 	ParticipantCount   int                    `json:"participantCount,omitempty"`   // Populated in queries
@@ -85,9 +89,11 @@ type ChallengeParticipant struct {
 
 // CreateFriendChallengeRequest for creating new friend challenges
 type CreateFriendChallengeRequest struct {
-	Title           string `json:"title" binding:"required,min=1,max=100"`
-	Difficulty      string `json:"difficulty" binding:"required,oneof=easy hard"`
-	MaxParticipants int    `json:"maxParticipants" binding:"min=2,max=50"`
+	Title           string         `json:"title" binding:"required,min=1,max=100"`
+	Difficulty      string         `json:"difficulty" binding:"required,oneof=easy hard"`
+	MaxParticipants int            `json:"maxParticipants" binding:"min=2,max=50"`
+	Scoring         *ScoringPolicy `json:"scoring,omitempty"`                          // Optional car count and scoring curve, defaults to the standard policy
+	Theme           string         `json:"theme,omitempty" binding:"omitempty,max=50"` // Optional challenge theme slug
 }
 
 // JoinFriendChallengeRequest for joining friend challenges
@@ -106,6 +112,16 @@ type GameSession struct {
 	CarsShown         []string  `json:"carsShown" db:"-"`  // Parsed from JSON
 	CarsShownJSON     string    `json:"-" db:"cars_shown"` // Raw JSON for database
 	IsActive          bool      `json:"isActive" db:"is_active"`
+	AccuracyMetric    string    `json:"accuracyMetric" db:"accuracy_metric"` // Streak tolerance metric
+	GameID            string    `json:"gameId" db:"game_id"`                 // Server-issued per game; the session ID is reused across games
 	CreatedAt         time.Time `json:"createdAt" db:"created_at"`
 	LastGuessAt       time.Time `json:"lastGuessAt" db:"last_guess_at"`
 }
+
+var (
+	// ErrListingNotServed is returned when a streak or zero mode guess is for a listing the session isn't playing
+	ErrListingNotServed = errors.New("listing was not served to this session")
+
+	// ErrListingAlreadyGuessed is returned when a listing is guessed twice in one game
+	ErrListingAlreadyGuessed = errors.New("listing already guessed in this game")
+)
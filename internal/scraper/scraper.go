@@ -9,28 +9,37 @@ import (
 	"github.com/go-rod/rod/lib/launcher"
 )
 
+// Scraper fetches listings from the registered listing sources
 type Scraper struct {
-	bonhamsScraper *BonhamsScraper
-	lookersScraper *LookersScraper
+	sources *Registry
 }
 
-func New() *Scraper {
-	return &Scraper{
-		bonhamsScraper: NewBonhamsScraper(),
-		lookersScraper: NewLookersScraper(),
-	}
+// New creates a scraper backed by the given source registry
+func New(sources *Registry) *Scraper {
+	return &Scraper{sources: sources}
 }
 
-// GetBonhamsListings gets Bonhams car listings directly
-func (s *Scraper) GetBonhamsListings(maxListings int) ([]*models.BonhamsCar, error) {
-	fmt.Println("Fetching Bonhams data directly...")
-	return s.bonhamsScraper.ScrapeCarListings(maxListings)
+// Sources returns the registry of listing sources
+func (s *Scraper) Sources() *Registry {
+	return s.sources
+}
+
+// Fetch scrapes fresh listings from the named source
+func (s *Scraper) Fetch(name string) ([]models.Listing, error) {
+	src, ok := s.sources.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown listing source: %s", name)
+	}
+	return src.Fetch()
 }
 
 // GetCarListings gets car listings from Bonhams (legacy method - use GetEnhancedListings instead)
 func (s *Scraper) GetCarListings(maxListings int) ([]*models.Car, error) {
 	fmt.Println("Fetching data from Bonhams Car Auctions...")
-	bonhamsCars, err := s.bonhamsScraper.ScrapeCarListings(maxListings)
+	bonhamsScraper := NewBonhamsScraper()
+	defer bonhamsScraper.Close()
+
+	bonhamsCars, err := bonhamsScraper.ScrapeCarListings(maxListings)
 	if err != nil {
 		return nil, err
 	}
@@ -43,51 +52,32 @@ func (s *Scraper) GetCarListings(maxListings int) ([]*models.Car, error) {
 	return cars, nil
 }
 
-// GetLookersListings gets Lookers car listings for Easy mode
-func (s *Scraper) GetLookersListings() ([]*models.LookersCar, error) {
-	fmt.Println("Fetching Lookers data for Easy mode...")
-	return s.lookersScraper.ScrapeCarListings()
-}
-
-// GetEnhancedListings gets enhanced car listings based on difficulty
+// GetEnhancedListings gets enhanced car listings from the source serving a difficulty
 func (s *Scraper) GetEnhancedListings(difficulty string) ([]*models.EnhancedCar, error) {
-	if difficulty == "easy" {
-		fmt.Println("Fetching Easy mode data from Lookers...")
-		lookersCars, err := s.GetLookersListings()
-		if err != nil {
-			return nil, err
-		}
+	src, ok := s.sources.ForDifficulty(difficulty)
+	if !ok {
+		return nil, fmt.Errorf("no listing source for difficulty: %s", difficulty)
+	}
 
-		// Convert LookersCar to EnhancedCar models
-		var enhancedCars []*models.EnhancedCar
-		for _, lookersCar := range lookersCars {
-			enhancedCars = append(enhancedCars, lookersCar.ToEnhancedCar())
-		}
-		return enhancedCars, nil
-	} else {
-		// Default to hard mode (Bonhams)
-		fmt.Println("Fetching Hard mode data from Bonhams...")
-		bonhamsCars, err := s.GetBonhamsListings(50) // Get more for variety
-		if err != nil {
-			return nil, err
-		}
+	fmt.Printf("Fetching %s mode data from %s...\n", difficulty, src.Name())
+	listings, err := src.Fetch()
+	if err != nil {
+		return nil, err
+	}
 
-		// Convert BonhamsCar to EnhancedCar models
-		var enhancedCars []*models.EnhancedCar
-		for _, bonhamsCar := range bonhamsCars {
-			enhancedCars = append(enhancedCars, bonhamsCar.ToEnhancedCar())
-		}
-		return enhancedCars, nil
+	enhancedCars := make([]*models.EnhancedCar, 0, len(listings))
+	for _, listing := range listings {
+		enhancedCars = append(enhancedCars, listing.ToEnhancedCar())
 	}
+	return enhancedCars, nil
 }
 
-// Close closes any open browser connections
+// Close closes any open browser connections held by the sources
 func (s *Scraper) Close() {
-	if s.bonhamsScraper != nil {
-		s.bonhamsScraper.Close()
-	}
-	if s.lookersScraper != nil {
-		s.lookersScraper.Close()
+	for _, src := range s.sources.Sources() {
+		if closer, ok := src.(interface{ Close() }); ok {
+			closer.Close()
+		}
 	}
 }
 
@@ -0,0 +1,253 @@
+package scraper
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"autotraderguesser/internal/models"
+)
+
+// ListingSource is a site that supplies cars for one difficulty pool.
+// Adding a new site only needs a ListingSource implementation and a Register call.
+type ListingSource interface {
+	// Name is the short identifier used in admin endpoints and env vars (e.g. "bonhams")
+	Name() string
+	// Difficulty is the game difficulty this source serves (e.g. "hard")
+	Difficulty() string
+	// Description is a human readable summary for status endpoints
+	Description() string
+	// CacheKey identifies the on-disk cache file for this source
+	CacheKey() string
+	// Fetch scrapes fresh listings from the site
+	Fetch() ([]models.Listing, error)
+	// Validate reports whether a listing is usable in the game
+	Validate(listing models.Listing) bool
+	// Decode turns cached JSON back into this source's listing type
+	Decode(data json.RawMessage) ([]models.Listing, error)
+}
+
+// MockSource is implemented by sources that can provide placeholder listings
+// when both scraping and the cache fail at startup
+type MockSource interface {
+	MockListings() []models.Listing
+}
+
+// Registry holds the listing sources the game draws cars from
+type Registry struct {
+	mu      sync.RWMutex
+	sources []ListingSource
+}
+
+// NewRegistry creates a registry with the given sources.
+// It panics on duplicate names or difficulties since that is a wiring bug.
+func NewRegistry(sources ...ListingSource) *Registry {
+	r := &Registry{}
+	for _, src := range sources {
+		if err := r.Register(src); err != nil {
+			panic(err)
+		}
+	}
+	return r
+}
+
+// Register adds a source. Each name and difficulty may only be registered once.
+func (r *Registry) Register(src ListingSource) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.sources {
+		if existing.Name() == src.Name() {
+			return fmt.Errorf("listing source %q already registered", src.Name())
+		}
+		if existing.Difficulty() == src.Difficulty() {
+			return fmt.Errorf("difficulty %q already served by %q", src.Difficulty(), existing.Name())
+		}
+	}
+
+	r.sources = append(r.sources, src)
+	return nil
+}
+
+// Sources returns all registered sources in registration order
+func (r *Registry) Sources() []ListingSource {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	sources := make([]ListingSource, len(r.sources))
+	copy(sources, r.sources)
+	return sources
+}
+
+// Get looks up a source by name
+func (r *Registry) Get(name string) (ListingSource, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, src := range r.sources {
+		if src.Name() == name {
+			return src, true
+		}
+	}
+	return nil, false
+}
+
+// ForDifficulty looks up the source serving a difficulty
+func (r *Registry) ForDifficulty(difficulty string) (ListingSource, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, src := range r.sources {
+		if src.Difficulty() == difficulty {
+			return src, true
+		}
+	}
+	return nil, false
+}
+
+// Difficulties returns the difficulties served by registered sources
+func (r *Registry) Difficulties() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	difficulties := make([]string, 0, len(r.sources))
+	for _, src := range r.sources {
+		difficulties = append(difficulties, src.Difficulty())
+	}
+	return difficulties
+}
+
+// BonhamsSource serves Bonhams auction results for Hard mode
+type BonhamsSource struct {
+	scraper     *BonhamsScraper
+	maxListings int
+}
+
+// NewBonhamsSource creates the Hard mode source scraping up to maxListings cars
+func NewBonhamsSource(maxListings int) *BonhamsSource {
+	return &BonhamsSource{
+		scraper:     NewBonhamsScraper(),
+		maxListings: maxListings,
+	}
+}
+
+func (s *BonhamsSource) Name() string       { return "bonhams" }
+func (s *BonhamsSource) Difficulty() string { return "hard" }
+func (s *BonhamsSource) CacheKey() string   { return "bonhams" }
+func (s *BonhamsSource) Description() string {
+	return "Real Bonhams Car Auction results (Hard Mode)"
+}
+
+// Fetch scrapes Bonhams auction results
+func (s *BonhamsSource) Fetch() ([]models.Listing, error) {
+	fmt.Println("Fetching Bonhams data directly...")
+	cars, err := s.scraper.ScrapeCarListings(s.maxListings)
+	if err != nil {
+		return nil, err
+	}
+	return bonhamsToListings(cars), nil
+}
+
+// Validate rejects cars with the £700 placeholder price (indicates missing price data)
+func (s *BonhamsSource) Validate(listing models.Listing) bool {
+	return listing.ListingPrice() > 0 && listing.ListingPrice() != 700
+}
+
+// Decode parses cached Bonhams cars
+func (s *BonhamsSource) Decode(data json.RawMessage) ([]models.Listing, error) {
+	var cars []*models.BonhamsCar
+	if err := json.Unmarshal(data, &cars); err != nil {
+		return nil, err
+	}
+	return bonhamsToListings(cars), nil
+}
+
+// MockListings returns minimal mock data for testing when no data is available
+func (s *BonhamsSource) MockListings() []models.Listing {
+	return []models.Listing{
+		&models.BonhamsCar{
+			ID:            "mock1",
+			Make:          "Volkswagen",
+			Model:         "Golf GTI",
+			Year:          2020,
+			Price:         18995,
+			Images:        []string{"https://images.unsplash.com/photo-1609521263047-f8f205293f24?w=600&h=400&fit=crop"},
+			OriginalURL:   "https://example.com/mock1",
+			Mileage:       "28,000 Miles",
+			Engine:        "1.4 TSI",
+			Gearbox:       "Manual",
+			ExteriorColor: "Silver",
+			InteriorColor: "Black",
+			Steering:      "Right-hand drive",
+			FuelType:      "Petrol",
+			KeyFacts:      []string{"Low mileage", "Full service history", "Great condition"},
+		},
+	}
+}
+
+// Close closes any open browser connection
+func (s *BonhamsSource) Close() {
+	s.scraper.Close()
+}
+
+// LookersSource serves Lookers dealership listings for Easy mode
+type LookersSource struct {
+	scraper *LookersScraper
+}
+
+// NewLookersSource creates the Easy mode source
+func NewLookersSource() *LookersSource {
+	return &LookersSource{scraper: NewLookersScraper()}
+}
+
+func (s *LookersSource) Name() string       { return "lookers" }
+func (s *LookersSource) Difficulty() string { return "easy" }
+func (s *LookersSource) CacheKey() string   { return "lookers" }
+func (s *LookersSource) Description() string {
+	return "Lookers used car dealership listings (Easy Mode)"
+}
+
+// Fetch scrapes Lookers dealership listings
+func (s *LookersSource) Fetch() ([]models.Listing, error) {
+	fmt.Println("Fetching Lookers data for Easy mode...")
+	cars, err := s.scraper.ScrapeCarListings()
+	if err != nil {
+		return nil, err
+	}
+	return lookersToListings(cars), nil
+}
+
+// Validate rejects listings without a price
+func (s *LookersSource) Validate(listing models.Listing) bool {
+	return listing.ListingPrice() > 0
+}
+
+// Decode parses cached Lookers cars
+func (s *LookersSource) Decode(data json.RawMessage) ([]models.Listing, error) {
+	var cars []*models.LookersCar
+	if err := json.Unmarshal(data, &cars); err != nil {
+		return nil, err
+	}
+	return lookersToListings(cars), nil
+}
+
+// Close closes any open browser connection
+func (s *LookersSource) Close() {
+	s.scraper.Close()
+}
+
+func bonhamsToListings(cars []*models.BonhamsCar) []models.Listing {
+	listings := make([]models.Listing, 0, len(cars))
+	for _, car := range cars {
+		listings = append(listings, car)
+	}
+	return listings
+}
+
+func lookersToListings(cars []*models.LookersCar) []models.Listing {
+	listings := make([]models.Listing, 0, len(cars))
+	for _, car := range cars {
+		listings = append(listings, car)
+	}
+	return listings
+}
@@ -0,0 +1,121 @@
+package sessions
+
+import (
+	"hash/maphash"
+	"time"
+)
+
+// Sharded spreads sessions across several Stores so requests for different sessions
+// rarely contend on the same lock. The TTL applies per session and the size cap is split
+// evenly between shards.
+type Sharded[T any] struct {
+	name   string
+	seed   maphash.Seed
+	shards []*Store[T]
+}
+
+// NewSharded creates a sharded store. shardCount is raised to 1 if smaller.
+func NewSharded[T any](name string, shardCount int, ttl time.Duration, maxSize int) *Sharded[T] {
+	return NewShardedWithClock[T](name, shardCount, ttl, maxSize, time.Now)
+}
+
+// NewShardedWithClock creates a sharded store that reads the time from now, for tests
+func NewShardedWithClock[T any](name string, shardCount int, ttl time.Duration, maxSize int, now func() time.Time) *Sharded[T] {
+	if shardCount < 1 {
+		shardCount = 1
+	}
+
+	perShard := 0
+	if maxSize > 0 {
+		perShard = (maxSize + shardCount - 1) / shardCount
+	}
+
+	s := &Sharded[T]{name: name, seed: maphash.MakeSeed(), shards: make([]*Store[T], shardCount)}
+	for i := range s.shards {
+		s.shards[i] = NewWithClock[T](name, ttl, perShard, now)
+	}
+	return s
+}
+
+// shard returns the store holding a session
+func (s *Sharded[T]) shard(id string) *Store[T] {
+	return s.shards[maphash.String(s.seed, id)%uint64(len(s.shards))]
+}
+
+// Get returns a session's value and marks the session as active
+func (s *Sharded[T]) Get(id string) (T, bool) {
+	return s.shard(id).Get(id)
+}
+
+// Put stores a session's value and marks the session as active
+func (s *Sharded[T]) Put(id string, value T) {
+	s.shard(id).Put(id, value)
+}
+
+// Update replaces a session's value with fn's result and marks the session as active
+func (s *Sharded[T]) Update(id string, fn func(current T, exists bool) T) {
+	s.shard(id).Update(id, fn)
+}
+
+// Delete removes a session
+func (s *Sharded[T]) Delete(id string) {
+	s.shard(id).Delete(id)
+}
+
+// DeleteFunc removes every session for which fn returns true and returns how many were removed
+func (s *Sharded[T]) DeleteFunc(fn func(id string, value T) bool) int {
+	removed := 0
+	for _, shard := range s.shards {
+		removed += shard.DeleteFunc(fn)
+	}
+	return removed
+}
+
+// ActiveWithin reports whether any session matching fn was active within the window
+func (s *Sharded[T]) ActiveWithin(window time.Duration, fn func(value T) bool) bool {
+	for _, shard := range s.shards {
+		if shard.ActiveWithin(window, fn) {
+			return true
+		}
+	}
+	return false
+}
+
+// Sweep evicts every session idle past the TTL and returns how many were evicted
+func (s *Sharded[T]) Sweep() int {
+	evicted := 0
+	for _, shard := range s.shards {
+		evicted += shard.Sweep()
+	}
+	return evicted
+}
+
+// Len returns the number of sessions held
+func (s *Sharded[T]) Len() int {
+	total := 0
+	for _, shard := range s.shards {
+		total += shard.Len()
+	}
+	return total
+}
+
+// Stats returns the combined size and counters of every shard
+func (s *Sharded[T]) Stats() Stats {
+	total := Stats{Name: s.name}
+	for _, shard := range s.shards {
+		stats := shard.Stats()
+		total.Size += stats.Size
+		total.MaxSize += stats.MaxSize
+		total.TTLSeconds = stats.TTLSeconds
+		total.Created += stats.Created
+		total.Hits += stats.Hits
+		total.Misses += stats.Misses
+		total.Deleted += stats.Deleted
+		total.EvictedExpired += stats.EvictedExpired
+		total.EvictedOverflow += stats.EvictedOverflow
+		if stats.OldestActivityAt != "" && (total.OldestActivityAt == "" || stats.OldestActivityAt < total.OldestActivityAt) {
+			total.OldestActivityAt = stats.OldestActivityAt
+		}
+	}
+	return total
+}
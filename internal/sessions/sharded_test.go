@@ -0,0 +1,68 @@
+package sessions
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestShardedStore(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)}
+	store := NewShardedWithClock[int]("sharded", 4, time.Hour, 40, clock.Now)
+
+	for i := 0; i < 20; i++ {
+		store.Put(fmt.Sprintf("session-%d", i), i)
+	}
+	if store.Len() != 20 {
+		t.Fatalf("expected 20 sessions, got %d", store.Len())
+	}
+	for i := 0; i < 20; i++ {
+		if v, ok := store.Get(fmt.Sprintf("session-%d", i)); !ok || v != i {
+			t.Fatalf("expected session-%d to hold %d, got %d ok=%v", i, i, v, ok)
+		}
+	}
+
+	clock.Advance(30 * time.Minute)
+	store.Put("late", 99)
+	if !store.ActiveWithin(time.Minute, func(v int) bool { return v == 99 }) {
+		t.Fatalf("expected the late session to be active")
+	}
+
+	clock.Advance(45 * time.Minute)
+	if evicted := store.Sweep(); evicted != 20 || store.Len() != 1 {
+		t.Fatalf("expected the 20 idle sessions to be swept, evicted %d leaving %d", evicted, store.Len())
+	}
+
+	stats := store.Stats()
+	if stats.Name != "sharded" || stats.MaxSize != 40 || stats.Created != 21 || stats.Hits != 20 || stats.EvictedExpired != 20 {
+		t.Fatalf("unexpected combined stats: %+v", stats)
+	}
+}
+
+// Run with -cpu=1,2,4,8 to compare how a single lock and sharded locks scale
+func BenchmarkStoreUpdate(b *testing.B) {
+	store := New[[]string]("single", time.Hour, 0)
+	benchUpdates(b, store.Update)
+}
+
+func BenchmarkShardedUpdate(b *testing.B) {
+	store := NewSharded[[]string]("sharded", 32, time.Hour, 0)
+	benchUpdates(b, store.Update)
+}
+
+func benchUpdates(b *testing.B, update func(string, func([]string, bool) []string)) {
+	var next atomic.Int64
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		id := fmt.Sprintf("session-%d", next.Add(1))
+		for pb.Next() {
+			update(id, func(recent []string, _ bool) []string {
+				if len(recent) >= 10 {
+					recent = recent[:9]
+				}
+				return append(recent, "car")
+			})
+		}
+	})
+}
@@ -0,0 +1,226 @@
+package sessions
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Store keeps per-session in-memory game state with last-activity timestamps.
+// Entries idle for longer than the TTL are evicted by Sweep, and once the store is full
+// the least recently active entry is evicted to make room for a new one.
+// A Store is safe for concurrent use.
+type Store[T any] struct {
+	name    string
+	ttl     time.Duration
+	maxSize int
+	now     func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // Most recently active at the front
+	stats   Stats
+}
+
+// entry is a stored session value and when it was last used
+type entry[T any] struct {
+	id         string
+	value      T
+	lastActive time.Time
+}
+
+// Stats are the counters reported for a store
+type Stats struct {
+	Name             string  `json:"name"`
+	Size             int     `json:"size"`
+	MaxSize          int     `json:"maxSize"`
+	TTLSeconds       float64 `json:"ttlSeconds"`
+	Created          uint64  `json:"created"`
+	Hits             uint64  `json:"hits"`
+	Misses           uint64  `json:"misses"`
+	Deleted          uint64  `json:"deleted"`
+	EvictedExpired   uint64  `json:"evictedExpired"`   // Idle past the TTL
+	EvictedOverflow  uint64  `json:"evictedOverflow"`  // Least recently active when the store was full
+	OldestActivityAt string  `json:"oldestActivityAt"` // RFC3339, empty when the store is empty
+}
+
+// New creates a store. A zero ttl disables idle eviction and a zero maxSize disables the size cap.
+func New[T any](name string, ttl time.Duration, maxSize int) *Store[T] {
+	return NewWithClock[T](name, ttl, maxSize, time.Now)
+}
+
+// NewWithClock creates a store that reads the time from now, for tests
+func NewWithClock[T any](name string, ttl time.Duration, maxSize int, now func() time.Time) *Store[T] {
+	return &Store[T]{
+		name:    name,
+		ttl:     ttl,
+		maxSize: maxSize,
+		now:     now,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// Get returns a session's value and marks the session as active.
+// Entries past their TTL are treated as missing even if Sweep hasn't run yet.
+func (s *Store[T]) Get(id string) (T, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	elem, ok := s.entries[id]
+	if ok && s.expired(elem.Value.(*entry[T]), now) {
+		s.remove(elem)
+		s.stats.EvictedExpired++
+		ok = false
+	}
+	if !ok {
+		s.stats.Misses++
+		var zero T
+		return zero, false
+	}
+
+	s.stats.Hits++
+	e := elem.Value.(*entry[T])
+	e.lastActive = now
+	s.order.MoveToFront(elem)
+	return e.value, true
+}
+
+// Put stores a session's value and marks the session as active
+func (s *Store[T]) Put(id string, value T) {
+	s.Update(id, func(T, bool) T { return value })
+}
+
+// Update replaces a session's value with fn's result and marks the session as active.
+// fn receives the current value and whether there was one, and runs with the store locked.
+func (s *Store[T]) Update(id string, fn func(current T, exists bool) T) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if elem, ok := s.entries[id]; ok {
+		e := elem.Value.(*entry[T])
+		if s.expired(e, now) {
+			s.remove(elem)
+			s.stats.EvictedExpired++
+		} else {
+			e.value = fn(e.value, true)
+			e.lastActive = now
+			s.order.MoveToFront(elem)
+			return
+		}
+	}
+
+	var zero T
+	s.entries[id] = s.order.PushFront(&entry[T]{id: id, value: fn(zero, false), lastActive: now})
+	s.stats.Created++
+
+	for s.maxSize > 0 && s.order.Len() > s.maxSize {
+		s.remove(s.order.Back())
+		s.stats.EvictedOverflow++
+	}
+}
+
+// Delete removes a session
+func (s *Store[T]) Delete(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, ok := s.entries[id]; ok {
+		s.remove(elem)
+		s.stats.Deleted++
+	}
+}
+
+// DeleteFunc removes every session for which fn returns true and returns how many were removed
+func (s *Store[T]) DeleteFunc(fn func(id string, value T) bool) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed := 0
+	for elem := s.order.Front(); elem != nil; {
+		next := elem.Next()
+		e := elem.Value.(*entry[T])
+		if fn(e.id, e.value) {
+			s.remove(elem)
+			s.stats.Deleted++
+			removed++
+		}
+		elem = next
+	}
+	return removed
+}
+
+// ActiveWithin reports whether any session matching fn was active within the window.
+// A nil fn matches every session.
+func (s *Store[T]) ActiveWithin(window time.Duration, fn func(value T) bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cutoff := s.now().Add(-window)
+	for elem := s.order.Front(); elem != nil; elem = elem.Next() {
+		e := elem.Value.(*entry[T])
+		if e.lastActive.Before(cutoff) {
+			return false // Everything after this is older
+		}
+		if fn == nil || fn(e.value) {
+			return true
+		}
+	}
+	return false
+}
+
+// Sweep evicts every session idle past the TTL and returns how many were evicted
+func (s *Store[T]) Sweep() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	evicted := 0
+	for elem := s.order.Back(); elem != nil; {
+		prev := elem.Prev()
+		if !s.expired(elem.Value.(*entry[T]), now) {
+			break // Everything before this was active more recently
+		}
+		s.remove(elem)
+		evicted++
+		elem = prev
+	}
+	s.stats.EvictedExpired += uint64(evicted)
+	return evicted
+}
+
+// Len returns the number of sessions held
+func (s *Store[T]) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.order.Len()
+}
+
+// Stats returns the store's current size and counters
+func (s *Store[T]) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := s.stats
+	stats.Name = s.name
+	stats.Size = s.order.Len()
+	stats.MaxSize = s.maxSize
+	stats.TTLSeconds = s.ttl.Seconds()
+	if oldest := s.order.Back(); oldest != nil {
+		stats.OldestActivityAt = oldest.Value.(*entry[T]).lastActive.UTC().Format(time.RFC3339)
+	}
+	return stats
+}
+
+// expired reports whether an entry has been idle past the TTL
+func (s *Store[T]) expired(e *entry[T], now time.Time) bool {
+	return s.ttl > 0 && now.Sub(e.lastActive) >= s.ttl
+}
+
+// remove drops an element from both the index and the activity list
+func (s *Store[T]) remove(elem *list.Element) {
+	delete(s.entries, elem.Value.(*entry[T]).id)
+	s.order.Remove(elem)
+}
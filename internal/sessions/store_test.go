@@ -0,0 +1,134 @@
+package sessions
+
+import (
+	"testing"
+	"time"
+)
+
+type fakeClock struct{ now time.Time }
+
+func (f *fakeClock) Now() time.Time { return f.now }
+
+func (f *fakeClock) Advance(d time.Duration) { f.now = f.now.Add(d) }
+
+func newTestStore(ttl time.Duration, maxSize int) (*Store[int], *fakeClock) {
+	clock := &fakeClock{now: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)}
+	return NewWithClock[int]("test", ttl, maxSize, clock.Now), clock
+}
+
+func TestStoreGetPutUpdate(t *testing.T) {
+	store, _ := newTestStore(time.Hour, 0)
+
+	if _, ok := store.Get("missing"); ok {
+		t.Fatalf("expected miss for unknown session")
+	}
+
+	store.Put("a", 1)
+	if v, ok := store.Get("a"); !ok || v != 1 {
+		t.Fatalf("expected 1, got %d ok=%v", v, ok)
+	}
+
+	store.Update("a", func(current int, exists bool) int {
+		if !exists {
+			t.Fatalf("expected existing value")
+		}
+		return current + 1
+	})
+	store.Update("b", func(current int, exists bool) int {
+		if exists || current != 0 {
+			t.Fatalf("expected a new zero value, got %d exists=%v", current, exists)
+		}
+		return 10
+	})
+	if v, _ := store.Get("a"); v != 2 {
+		t.Fatalf("expected updated value 2, got %d", v)
+	}
+
+	store.Delete("b")
+	if _, ok := store.Get("b"); ok {
+		t.Fatalf("expected deleted session to be gone")
+	}
+
+	stats := store.Stats()
+	if stats.Size != 1 || stats.Created != 2 || stats.Hits != 2 || stats.Misses != 2 || stats.Deleted != 1 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestStoreTTLEviction(t *testing.T) {
+	store, clock := newTestStore(time.Hour, 0)
+
+	store.Put("old", 1)
+	clock.Advance(40 * time.Minute)
+	store.Put("recent", 2)
+	clock.Advance(30 * time.Minute)
+
+	// Idle sessions are treated as gone even before a sweep
+	if _, ok := store.Get("old"); ok {
+		t.Fatalf("expected idle session to have expired")
+	}
+
+	// Activity keeps a session alive
+	if _, ok := store.Get("recent"); !ok {
+		t.Fatalf("expected recent session to still be held")
+	}
+	clock.Advance(50 * time.Minute)
+	if evicted := store.Sweep(); evicted != 0 {
+		t.Fatalf("expected touched session to survive the sweep, evicted %d", evicted)
+	}
+
+	clock.Advance(10 * time.Minute)
+	if evicted := store.Sweep(); evicted != 1 || store.Len() != 0 {
+		t.Fatalf("expected the session to be swept, evicted %d leaving %d", evicted, store.Len())
+	}
+
+	if stats := store.Stats(); stats.EvictedExpired != 2 {
+		t.Fatalf("expected two expiry evictions, got %+v", stats)
+	}
+}
+
+func TestStoreSizeCap(t *testing.T) {
+	store, clock := newTestStore(0, 2)
+
+	store.Put("a", 1)
+	clock.Advance(time.Second)
+	store.Put("b", 2)
+	clock.Advance(time.Second)
+	store.Get("a") // a is now more recently active than b
+	clock.Advance(time.Second)
+	store.Put("c", 3)
+
+	if _, ok := store.Get("b"); ok {
+		t.Fatalf("expected least recently active session to be evicted")
+	}
+	if _, ok := store.Get("a"); !ok {
+		t.Fatalf("expected recently active session to be kept")
+	}
+	if stats := store.Stats(); stats.Size != 2 || stats.EvictedOverflow != 1 || stats.MaxSize != 2 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestStoreActivityQueries(t *testing.T) {
+	store, clock := newTestStore(24*time.Hour, 0)
+
+	store.Put("finished", 0)
+	store.Put("playing", 1)
+	clock.Advance(45 * time.Minute)
+	store.Put("idle-finished", 0)
+
+	isPlaying := func(v int) bool { return v == 1 }
+	if store.ActiveWithin(30*time.Minute, isPlaying) {
+		t.Fatalf("expected no unfinished session active in the last 30 minutes")
+	}
+	if !store.ActiveWithin(time.Hour, isPlaying) {
+		t.Fatalf("expected the unfinished session to count within an hour")
+	}
+	if !store.ActiveWithin(30*time.Minute, nil) {
+		t.Fatalf("expected some session active in the last 30 minutes")
+	}
+
+	if removed := store.DeleteFunc(func(_ string, v int) bool { return v == 0 }); removed != 2 || store.Len() != 1 {
+		t.Fatalf("expected both finished sessions removed, removed %d leaving %d", removed, store.Len())
+	}
+}
@@ -74,3 +74,16 @@ func ValidateChallengeTitle(title string) (string, error) {
 
 	return title, nil
 }
+
+// ValidateThemeSlug validates a challenge theme slug (lowercase letters, numbers and single hyphens)
+func ValidateThemeSlug(slug string) error {
+	if len(slug) < 3 || len(slug) > 50 {
+		return fmt.Errorf("theme slug must be between 3 and 50 characters")
+	}
+
+	if !regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`).MatchString(slug) {
+		return fmt.Errorf("theme slug can only contain lowercase letters, numbers and single hyphens")
+	}
+
+	return nil
+}
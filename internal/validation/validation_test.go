@@ -110,3 +110,32 @@ func TestValidateDisplayName(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateThemeSlug(t *testing.T) {
+	cases := []struct {
+		name    string
+		value   string
+		wantErr string
+	}{
+		{"tooShort", "ab", "theme slug must be between 3 and 50 characters"},
+		{"tooLong", strings.Repeat("a", 51), "theme slug must be between 3 and 50 characters"},
+		{"uppercase", "Porsche-1960s", "theme slug can only contain lowercase letters, numbers and single hyphens"},
+		{"doubleHyphen", "porsche--1960s", "theme slug can only contain lowercase letters, numbers and single hyphens"},
+		{"trailingHyphen", "porsche-", "theme slug can only contain lowercase letters, numbers and single hyphens"},
+		{"valid", "1960s-porsche", ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateThemeSlug(tc.value)
+			if tc.wantErr == "" && err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if tc.wantErr != "" {
+				if err == nil || err.Error() != tc.wantErr {
+					t.Fatalf("expected error %q, got %v", tc.wantErr, err)
+				}
+			}
+		})
+	}
+}